@@ -2,28 +2,72 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	grpcclient "github.com/corray333/backend-labs/consumer/internal/dal/grpc"
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditrepo"
 	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	fanoutrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/fanout"
 	auditrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/postgres"
 	inboxrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/inbox/postgres"
+	partitionrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/partition/postgres"
+	quarantinerepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/quarantine/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
 	"github.com/corray333/backend-labs/consumer/internal/otel"
-	"github.com/corray333/backend-labs/consumer/internal/rabbitmq"
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/auditsvc"
 	"github.com/corray333/backend-labs/consumer/internal/service/services/consumersvc"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/partitionsvc"
+	admintransport "github.com/corray333/backend-labs/consumer/internal/transport/admin"
 	"github.com/corray333/backend-labs/consumer/internal/transport/consumer"
+	grpctransport "github.com/corray333/backend-labs/consumer/internal/transport/grpc"
+	httptransport "github.com/corray333/backend-labs/consumer/internal/transport/http"
+	checkpointworker "github.com/corray333/backend-labs/consumer/internal/worker/checkpoint"
 	inboxworker "github.com/corray333/backend-labs/consumer/internal/worker/inbox"
+	partitionworker "github.com/corray333/backend-labs/consumer/internal/worker/partition"
+	processedworker "github.com/corray333/backend-labs/consumer/internal/worker/processed"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	brokerfactory "github.com/corray333/backend-labs/order/pkg/broker/factory"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+// Audit sinks consumed audit logs are written to.
+const (
+	// sinkPostgres writes audit logs to the audit database
+	sinkPostgres = "postgres"
+	// sinkGRPC sends audit logs to the SaveAuditLog call of the order service,
+	// the order service stores the audit trail and the audit database holds no audit logs
+	sinkGRPC = "grpc"
+	// sinkFanout writes audit logs to the order service and the audit database
+	sinkFanout = "fanout"
 )
 
 // App represents the application.
 type App struct {
 	consumerSvc    *consumersvc.ConsumerService
 	consumerTransp *consumer.Consumer
-	inboxWorker    *inboxworker.Worker
-	rabbitMqClient *rabbitmq.Client
+	// grpcTransp and httpTransp serve the audit API, they are nil unless the audit database holds the audit trail
+	grpcTransp  *grpctransport.GRPCTransport
+	httpTransp  *httptransport.HTTPTransport
+	adminTransp *admintransport.AdminTransport
+	inboxWorker *inboxworker.Worker
+	// checkpointWorker is nil without a checkpoint signing key or an audit trail in the audit database
+	checkpointWorker *checkpointworker.Worker
+	// partitionWorker is nil unless the audit database holds the audit trail
+	partitionWorker *partitionworker.Worker
+	// processedWorker is nil unless the audit database marks processed messages
+	processedWorker *processedworker.Worker
+	broker          broker.Broker
+	// orderClient is nil unless the audit sink sends audit logs to the order service
+	orderClient    *grpcclient.Client
 	postgresClient *postgres.Client
 	otelController *otel.OtelController
 }
@@ -31,31 +75,81 @@ type App struct {
 // MustNewApp creates a new application.
 func MustNewApp() *App {
 	otelController := otel.MustInitOtel()
-	rabbitMqClient := rabbitmq.MustNewClient()
+	messageBroker := brokerfactory.MustNew()
 	postgresClient := postgres.MustNewClient()
 
-	// Initialize PostgreSQL audit repository
-	auditRepository := auditrepo.NewAuditRepository(postgresClient)
+	// Initialize PostgreSQL audit repository, saved audit logs are linked into hash chains
+	auditRepository := auditrepo.NewAuditRepository(postgresClient, mustChainScope())
+	// Consumed audit logs are written to the configured sink, chains and partitions stay in the audit database
+	sink := auditSinkFromViper()
+	auditSink, orderClient := mustNewAuditSink(sink, auditRepository)
 
 	// Initialize inbox repository
 	inboxRepository := inboxrepo.NewInboxRepository(postgresClient)
+	quarantineRepository := quarantinerepo.NewQuarantineRepository(postgresClient)
 
 	consumerSvc := consumersvc.MustNewConsumerService(
-		consumersvc.WithAuditRepository(auditRepository),
+		consumersvc.WithAuditRepository(auditSink),
 	)
 
-	consumerTransp := consumer.NewConsumer(rabbitMqClient, consumerSvc, inboxRepository)
+	// Events are dispatched to their handlers by type, untyped ones by the queue they were received from
+	registry := handlers.NewOrderRegistry()
+
+	retrier := consumer.MustNewRetrier(messageBroker, inboxRepository)
+	consumerTransp := consumer.NewConsumer(
+		messageBroker,
+		consumerSvc,
+		registry,
+		retrier,
+		quarantineRepository,
+		consumer.ConfigFromViper(),
+	)
 
-	// Initialize inbox worker
-	inboxWorker := inboxworker.NewWorker(inboxRepository, consumerSvc)
+	// Initialize inbox worker, it also drains messages left in the inbox when broker retries are used
+	inboxWorker := inboxworker.NewWorker(
+		inboxRepository,
+		quarantineRepository,
+		registry,
+		consumerSvc,
+		inboxworker.ConfigFromViper(),
+	)
 
+	// The admin server serves health probes, metrics and inbox administration
+	adminTransp := admintransport.NewAdminTransport(
+		inboxRepository,
+		inboxWorker,
+		admintransport.ConfigFromViper(),
+		readinessChecks(messageBroker, postgresClient, consumerTransp)...,
+	)
+	adminTransp.RegisterRoutes()
+	if inspector, ok := messageBroker.(broker.QueueInspector); ok {
+		prometheus.MustRegister(metrics.NewQueueDepthCollector(inspector, consumerTransp.Queues(), consumerTransp.ConsumerTag()))
+	} else {
+		slog.Warn("Message broker reports no queue depth, it is left out of the metrics")
+	}
+
+	// The audit API, chains and partitions work on the audit database, which is empty with the grpc sink
+	var trail auditTrail
+	if storesAuditTrail(sink) {
+		trail = mustNewAuditTrail(auditRepository, postgresClient)
+	} else {
+		slog.Warn("The order service stores the audit trail, the audit API, chain checkpoints " +
+			"and partition maintenance are not started")
+	}
 	return &App{
-		consumerSvc:    consumerSvc,
-		consumerTransp: consumerTransp,
-		inboxWorker:    inboxWorker,
-		rabbitMqClient: rabbitMqClient,
-		postgresClient: postgresClient,
-		otelController: otelController,
+		consumerSvc:      consumerSvc,
+		consumerTransp:   consumerTransp,
+		grpcTransp:       trail.grpcTransp,
+		httpTransp:       trail.httpTransp,
+		adminTransp:      adminTransp,
+		inboxWorker:      inboxWorker,
+		checkpointWorker: trail.checkpointWorker,
+		partitionWorker:  trail.partitionWorker,
+		processedWorker:  trail.processedWorker,
+		broker:           messageBroker,
+		orderClient:      orderClient,
+		postgresClient:   postgresClient,
+		otelController:   otelController,
 	}
 }
 
@@ -76,11 +170,57 @@ func (a *App) Run() {
 		}
 	}()
 
+	if a.httpTransp != nil {
+		go func() {
+			slog.Info("Starting HTTP server")
+			if err := a.httpTransp.Run(); err != nil {
+				slog.Error("HTTP server error", "error", err)
+			}
+		}()
+	}
+
+	go func() {
+		slog.Info("Starting admin server")
+		if err := a.adminTransp.Run(); err != nil {
+			slog.Error("Admin server error", "error", err)
+		}
+	}()
+
+	if a.grpcTransp != nil {
+		go func() {
+			slog.Info("Starting gRPC server")
+			if err := a.grpcTransp.Run(); err != nil {
+				slog.Error("gRPC server error", "error", err)
+			}
+		}()
+	}
+
 	go func() {
 		slog.Info("Starting inbox worker")
 		a.inboxWorker.Start(ctx)
 	}()
 
+	if a.partitionWorker != nil {
+		go func() {
+			slog.Info("Starting partition worker")
+			a.partitionWorker.Start(ctx)
+		}()
+	}
+
+	if a.processedWorker != nil {
+		go func() {
+			slog.Info("Starting processed messages worker")
+			a.processedWorker.Start(ctx)
+		}()
+	}
+
+	if a.checkpointWorker != nil {
+		go func() {
+			slog.Info("Starting checkpoint worker")
+			a.checkpointWorker.Start(ctx)
+		}()
+	}
+
 	<-stop
 	slog.Info("Shutdown signal received")
 	cancel()
@@ -89,25 +229,72 @@ func (a *App) Run() {
 }
 
 // gracefulShutdown performs graceful shutdown of all application components.
-// It shuts down components sequentially: inbox worker, consumer, RabbitMQ, PostgreSQL, and OpenTelemetry.
+// It shuts down components sequentially: HTTP, gRPC and admin servers, inbox, checkpoint, partition
+// and processed messages workers, consumer, message broker, order service connection, PostgreSQL, and OpenTelemetry.
 func (a *App) gracefulShutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if a.httpTransp != nil {
+		if err := a.httpTransp.Shutdown(ctx); err != nil {
+			slog.Error("HTTP server shutdown error", "error", err)
+		} else {
+			slog.Info("HTTP server stopped gracefully")
+		}
+	}
+
+	if a.grpcTransp != nil {
+		if err := a.grpcTransp.Shutdown(ctx); err != nil {
+			slog.Error("gRPC server shutdown error", "error", err)
+		} else {
+			slog.Info("gRPC server stopped gracefully")
+		}
+	}
+
+	if err := a.adminTransp.Shutdown(ctx); err != nil {
+		slog.Error("Admin server shutdown error", "error", err)
+	} else {
+		slog.Info("Admin server stopped gracefully")
+	}
+
 	// Stop inbox worker
 	a.inboxWorker.Stop()
 	slog.Info("Inbox worker stopped gracefully")
 
+	if a.checkpointWorker != nil {
+		a.checkpointWorker.Stop()
+		slog.Info("Checkpoint worker stopped gracefully")
+	}
+
+	if a.partitionWorker != nil {
+		a.partitionWorker.Stop()
+		slog.Info("Partition worker stopped gracefully")
+	}
+
+	if a.processedWorker != nil {
+		a.processedWorker.Stop()
+		slog.Info("Processed messages worker stopped gracefully")
+	}
+
 	if err := a.consumerTransp.Shutdown(); err != nil {
 		slog.Error("Consumer shutdown error", "error", err)
 	} else {
 		slog.Info("Consumer stopped gracefully")
 	}
 
-	if err := a.rabbitMqClient.Close(); err != nil {
-		slog.Error("RabbitMQ connection close error", "error", err)
+	if err := a.broker.Close(); err != nil {
+		slog.Error("Message broker connection close error", "error", err)
 	} else {
-		slog.Info("RabbitMQ connection closed gracefully")
+		slog.Info("Message broker connection closed gracefully")
+	}
+
+	// In-flight audit logs are sent by the time the consumer stopped
+	if a.orderClient != nil {
+		if err := a.orderClient.Close(); err != nil {
+			slog.Error("Order service connection close error", "error", err)
+		} else {
+			slog.Info("Order service connection closed gracefully")
+		}
 	}
 
 	a.postgresClient.Close()
@@ -125,3 +312,141 @@ func (a *App) gracefulShutdown() {
 		slog.Info("Application shutdown complete")
 	}
 }
+
+// mustPartitionConfig reads the partition maintenance config from the audit.partitions section.
+func mustPartitionConfig() partitionsvc.Config {
+	action, err := partition.ParseRetentionAction(viper.GetString("audit.partitions.retention_action"))
+	if err != nil {
+		panic(err)
+	}
+
+	return partitionsvc.Config{
+		PremakeMonths:   viper.GetInt("audit.partitions.premake_months"),
+		RetentionMonths: viper.GetInt("audit.partitions.retention_months"),
+		RetentionAction: action,
+	}
+}
+
+// auditSinkFromViper returns the audit sink of audit.sink, the audit database when it is not set.
+func auditSinkFromViper() string {
+	if sink := viper.GetString("audit.sink"); sink != "" {
+		return sink
+	}
+
+	return sinkPostgres
+}
+
+// storesAuditTrail reports whether the audit database holds the audit trail with the sink,
+// so the audit API, chains and partitions of the audit database cover all consumed audit logs.
+func storesAuditTrail(sink string) bool {
+	return sink != sinkGRPC
+}
+
+// errAuditTrailInOrderService is returned by commands working on the audit trail with the grpc sink.
+var errAuditTrailInOrderService = errors.New("audit.sink is grpc, the audit trail is stored by the order service")
+
+// mustNewAuditSink creates the audit sink over the PostgreSQL audit repository.
+// The order service client is returned for sinks sending audit logs to it, nil otherwise.
+func mustNewAuditSink(
+	sink string,
+	auditRepository *auditrepo.AuditRepository,
+) (iauditrepo.IAuditRepository, *grpcclient.Client) {
+	switch sink {
+	case sinkPostgres:
+		return auditRepository, nil
+	case sinkGRPC:
+		orderClient := grpcclient.MustNewClient()
+
+		return orderClient, orderClient
+	case sinkFanout:
+		orderClient := grpcclient.MustNewClient()
+
+		return fanoutrepo.NewAuditRepository(auditRepository, orderClient), orderClient
+	default:
+		panic(fmt.Errorf("unknown audit sink %q", sink))
+	}
+}
+
+// auditTrail is the components working on the audit trail in the audit database.
+type auditTrail struct {
+	grpcTransp *grpctransport.GRPCTransport
+	httpTransp *httptransport.HTTPTransport
+	// checkpointWorker is nil without a checkpoint signing key
+	checkpointWorker *checkpointworker.Worker
+	partitionWorker  *partitionworker.Worker
+	processedWorker  *processedworker.Worker
+}
+
+// mustNewAuditTrail creates the audit API, the checkpoint worker, the partition worker
+// and the processed messages worker over the audit database.
+func mustNewAuditTrail(auditRepository *auditrepo.AuditRepository, postgresClient *postgres.Client) auditTrail {
+	auditSvc := auditsvc.MustNewAuditService(
+		auditsvc.WithAuditRepository(auditRepository),
+	)
+
+	chainSvc := mustNewChainService(auditRepository)
+
+	// The gRPC server serves audit log queries, the HTTP gateway maps them to REST
+	grpcTransp := grpctransport.NewGRPCTransport(auditSvc, chainSvc)
+	httpTransp := httptransport.NewHTTPTransport(grpcTransp.GetAuditServer())
+	httpTransp.RegisterRoutes()
+
+	// The partition worker creates upcoming monthly partitions and removes the expired ones,
+	// exporting them first when an archive store is set
+	var archiver partitionsvc.Archiver
+	if archiveStore := mustNewArchiveStore(context.Background()); archiveStore != nil {
+		archiver = newArchiveService(auditRepository, archiveStore)
+	} else {
+		slog.Warn("Audit archiving is disabled, expired audit logs are removed without an export")
+	}
+	partitionSvc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(partitionrepo.NewPartitionRepository(postgresClient)),
+		partitionsvc.WithConfig(mustPartitionConfig()),
+		partitionsvc.WithArchiver(archiver),
+	)
+
+	var checkpointWorker *checkpointworker.Worker
+	if chainSvc.CanSign() {
+		checkpointWorker = checkpointworker.NewWorker(chainSvc, checkpointworker.ConfigFromViper())
+	} else {
+		slog.Warn("Audit chain checkpoints are disabled, " + signingKeyEnv + " is not set")
+	}
+
+	return auditTrail{
+		grpcTransp:       grpcTransp,
+		httpTransp:       httpTransp,
+		checkpointWorker: checkpointWorker,
+		partitionWorker:  partitionworker.NewWorker(partitionSvc, partitionworker.ConfigFromViper()),
+		processedWorker:  processedworker.NewWorker(auditRepository, processedworker.ConfigFromViper()),
+	}
+}
+
+// errConsumerNotRunning fails the readiness of a consumer that receives no messages.
+var errConsumerNotRunning = errors.New("consumer is not running")
+
+// readinessChecks returns the checks of the readiness probe: the broker connection and channels,
+// the audit database and the consumer receiving messages.
+func readinessChecks(
+	messageBroker broker.Broker,
+	postgresClient *postgres.Client,
+	consumerTransp *consumer.Consumer,
+) []admintransport.Check {
+	checks := []admintransport.Check{
+		{Name: "postgres", Check: postgresClient.Ping},
+		{Name: "consumer", Check: func(context.Context) error {
+			if !consumerTransp.Running() {
+				return errConsumerNotRunning
+			}
+
+			return nil
+		}},
+	}
+
+	if healthChecker, ok := messageBroker.(broker.HealthChecker); ok {
+		checks = append(checks, admintransport.Check{Name: "broker", Check: healthChecker.CheckHealth})
+	} else {
+		slog.Warn("Message broker reports no health, it is left out of the readiness probe")
+	}
+
+	return checks
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iarchivestore"
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditarchiverepo"
+	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	localarchive "github.com/corray333/backend-labs/consumer/internal/dal/repositories/archive/local"
+	s3archive "github.com/corray333/backend-labs/consumer/internal/dal/repositories/archive/s3"
+	auditrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/archivesvc"
+	"github.com/spf13/viper"
+)
+
+// Environment variables with the credentials of the S3 archive store.
+const (
+	archiveAccessKeyEnv = "AUDIT_ARCHIVE_S3_ACCESS_KEY"
+	archiveSecretKeyEnv = "AUDIT_ARCHIVE_S3_SECRET_KEY"
+)
+
+var errArchiveUsage = errors.New("usage: archive export|verify|import [flags]")
+
+// errNoArchiveStore is returned by the archive command without a configured store.
+var errNoArchiveStore = errors.New("audit.archive.store is not set")
+
+// archiveActions are the subcommands of the archive command.
+var archiveActions = map[string]func(context.Context, *archivesvc.ArchiveService, []string) error{
+	"export": runArchiveExport,
+	"verify": runArchiveVerify,
+	"import": runArchiveImport,
+}
+
+// mustNewArchiveStore creates the archive store of the audit.archive section, nil when none is set.
+func mustNewArchiveStore(ctx context.Context) iarchivestore.IArchiveStore {
+	switch store := viper.GetString("audit.archive.store"); store {
+	case "":
+		return nil
+	case "local":
+		return localarchive.NewStore(viper.GetString("audit.archive.local.dir"))
+	case "s3":
+		s3Store, err := s3archive.NewStore(ctx, s3archive.Config{
+			Endpoint:  viper.GetString("audit.archive.s3.endpoint"),
+			Bucket:    viper.GetString("audit.archive.s3.bucket"),
+			Region:    viper.GetString("audit.archive.s3.region"),
+			UseSSL:    viper.GetBool("audit.archive.s3.use_ssl"),
+			AccessKey: os.Getenv(archiveAccessKeyEnv),
+			SecretKey: os.Getenv(archiveSecretKeyEnv),
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		return s3Store
+	default:
+		panic(fmt.Errorf("unknown archive store %q", store))
+	}
+}
+
+// newArchiveService creates the archive service writing to the store.
+func newArchiveService(
+	auditRepo iauditarchiverepo.IAuditArchiveRepository,
+	store iarchivestore.IArchiveStore,
+) *archivesvc.ArchiveService {
+	return archivesvc.MustNewArchiveService(
+		archivesvc.WithAuditArchiveRepository(auditRepo),
+		archivesvc.WithArchiveStore(store),
+		archivesvc.WithRowsPerFile(viper.GetInt("audit.archive.rows_per_file")),
+	)
+}
+
+// RunArchive runs the archive command with the given command line arguments.
+// Manifests and import reports are written to stdout as JSON.
+func RunArchive(args []string) error {
+	if len(args) == 0 {
+		return errArchiveUsage
+	}
+
+	action, ok := archiveActions[args[0]]
+	if !ok {
+		return errArchiveUsage
+	}
+
+	if !storesAuditTrail(auditSinkFromViper()) {
+		return errAuditTrailInOrderService
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store := mustNewArchiveStore(ctx)
+	if store == nil {
+		return errNoArchiveStore
+	}
+
+	postgresClient := postgres.MustNewClient()
+	defer postgresClient.Close()
+
+	archiveSvc := newArchiveService(auditrepo.NewAuditRepository(postgresClient, mustChainScope()), store)
+
+	return action(ctx, archiveSvc, args[1:])
+}
+
+// runArchiveExport exports the audit logs created in a range.
+func runArchiveExport(ctx context.Context, svc *archivesvc.ArchiveService, args []string) error {
+	var from, to string
+
+	fs := flag.NewFlagSet("archive export", flag.ContinueOnError)
+	fs.StringVar(&from, "from", "", "start of the range, inclusive, as 2006-01-02 or RFC 3339")
+	fs.StringVar(&to, "to", "", "end of the range, exclusive, as 2006-01-02 or RFC 3339")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fromTime, err := parseArchiveTime("from", from)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseArchiveTime("to", to)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := svc.Export(ctx, fromTime, toTime)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(manifest)
+}
+
+// runArchiveVerify checks the files of an archive against its manifest.
+func runArchiveVerify(ctx context.Context, svc *archivesvc.ArchiveService, args []string) error {
+	prefix, err := parseArchivePrefix("archive verify", args)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := svc.Verify(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(manifest)
+}
+
+// runArchiveImport restores the audit logs of an archive.
+// Restored audit logs older than the retention are pruned again by the next partition maintenance.
+func runArchiveImport(ctx context.Context, svc *archivesvc.ArchiveService, args []string) error {
+	prefix, err := parseArchivePrefix("archive import", args)
+	if err != nil {
+		return err
+	}
+
+	report, err := svc.Import(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(report)
+}
+
+// parseArchivePrefix parses the required -prefix flag.
+func parseArchivePrefix(name string, args []string) (string, error) {
+	var prefix string
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&prefix, "prefix", "", "prefix of the archive, as printed by archive export")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if prefix == "" {
+		return "", fmt.Errorf("%s: -prefix is required", name)
+	}
+
+	return prefix, nil
+}
+
+// parseArchiveTime parses a range bound given as a UTC date or an RFC 3339 time.
+func parseArchiveTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("archive export: -%s is required", name)
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("archive export: invalid -%s %q", name, value)
+	}
+
+	return t, nil
+}
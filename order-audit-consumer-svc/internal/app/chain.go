@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditchainrepo"
+	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	auditrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/chainsvc"
+	"github.com/spf13/viper"
+)
+
+// signingKeyEnv is the environment variable with the base64 encoded ed25519 seed checkpoints are signed with.
+const signingKeyEnv = "AUDIT_CHECKPOINT_SIGNING_KEY"
+
+var errChainUsage = errors.New("usage: chain verify|checkpoint|export [flags]")
+
+// errChainBroken is returned by chain verify when a chain does not verify.
+var errChainBroken = errors.New("audit chain is broken")
+
+// chainActions are the subcommands of the chain command.
+var chainActions = map[string]func(context.Context, *chainsvc.ChainService, []string) error{
+	"verify":     runChainVerify,
+	"checkpoint": runChainCheckpoint,
+	"export":     runChainExport,
+}
+
+// checkpointExport is the exported checkpoints with the key to verify their signatures.
+type checkpointExport struct {
+	PublicKey   []byte                  `json:"public_key"`
+	KeyID       string                  `json:"key_id"`
+	Checkpoints []auditchain.Checkpoint `json:"checkpoints"`
+}
+
+// mustChainScope reads the chain scope of audit logs from the audit.chain section.
+func mustChainScope() auditchain.Scope {
+	scope, err := auditchain.ParseScope(viper.GetString("audit.chain.scope"))
+	if err != nil {
+		panic(err)
+	}
+
+	return scope
+}
+
+// mustNewChainService creates the chain service with the signing key of the environment.
+func mustNewChainService(chainRepo iauditchainrepo.IAuditChainRepository) *chainsvc.ChainService {
+	signingKey, err := chainsvc.ParseSigningKey(os.Getenv(signingKeyEnv))
+	if err != nil {
+		panic(fmt.Errorf("invalid %s: %w", signingKeyEnv, err))
+	}
+
+	return chainsvc.MustNewChainService(
+		chainsvc.WithAuditChainRepository(chainRepo),
+		chainsvc.WithSigningKey(signingKey),
+	)
+}
+
+// RunChain runs the chain command with the given command line arguments.
+// Results are written to stdout as JSON.
+func RunChain(args []string) error {
+	if len(args) == 0 {
+		return errChainUsage
+	}
+
+	action, ok := chainActions[args[0]]
+	if !ok {
+		return errChainUsage
+	}
+
+	if !storesAuditTrail(auditSinkFromViper()) {
+		return errAuditTrailInOrderService
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	postgresClient := postgres.MustNewClient()
+	defer postgresClient.Close()
+
+	chainSvc := mustNewChainService(auditrepo.NewAuditRepository(postgresClient, mustChainScope()))
+
+	return action(ctx, chainSvc, args[1:])
+}
+
+// runChainVerify verifies chains and fails when one of them is broken.
+func runChainVerify(ctx context.Context, svc *chainsvc.ChainService, args []string) error {
+	chainID, err := parseChainID("chain verify", args)
+	if err != nil {
+		return err
+	}
+
+	verifications, err := svc.VerifyChains(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSON(verifications); err != nil {
+		return err
+	}
+	for _, verification := range verifications {
+		if verification.BrokenLink != nil {
+			return errChainBroken
+		}
+	}
+
+	return nil
+}
+
+// runChainCheckpoint signs checkpoints of the chains extended since their last checkpoint.
+func runChainCheckpoint(ctx context.Context, svc *chainsvc.ChainService, _ []string) error {
+	checkpoints, err := svc.CreateCheckpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(checkpoints)
+}
+
+// runChainExport writes the signed checkpoints and the public key to stdout or a file.
+func runChainExport(ctx context.Context, svc *chainsvc.ChainService, args []string) error {
+	var chainID, out string
+
+	fs := flag.NewFlagSet("chain export", flag.ContinueOnError)
+	fs.StringVar(&chainID, "chain", "", "chain to export, all chains by default")
+	fs.StringVar(&out, "out", "", "file to write, stdout by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	checkpoints, err := svc.ListCheckpoints(ctx, chainID)
+	if err != nil {
+		return err
+	}
+
+	export := checkpointExport{Checkpoints: checkpoints}
+	if publicKey := svc.PublicKey(); publicKey != nil {
+		export.PublicKey = publicKey
+		export.KeyID = auditchain.KeyID(publicKey)
+	}
+
+	if out == "" {
+		return writeJSON(export)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoints: %w", err)
+	}
+	if err := os.WriteFile(out, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+
+	return nil
+}
+
+// parseChainID parses the optional -chain flag.
+func parseChainID(name string, args []string) (string, error) {
+	var chainID string
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&chainID, "chain", "", "chain to verify, all chains by default")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	return chainID, nil
+}
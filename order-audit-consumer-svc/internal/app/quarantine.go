@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	quarantinerepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/quarantine/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/quarantinesvc"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	brokerfactory "github.com/corray333/backend-labs/order/pkg/broker/factory"
+)
+
+var errQuarantineUsage = errors.New("usage: quarantine list|show|edit|replay|discard [flags]")
+
+// quarantineActions are the subcommands of the quarantine command.
+var quarantineActions = map[string]func(context.Context, *quarantinesvc.QuarantineService, []string) error{
+	"list":    runQuarantineList,
+	"show":    runQuarantineShow,
+	"edit":    runQuarantineEdit,
+	"replay":  runQuarantineReplay,
+	"discard": runQuarantineDiscard,
+}
+
+// RunQuarantine runs the quarantine command with the given command line arguments.
+// Messages are written to stdout as JSON, payloads are base64 encoded.
+func RunQuarantine(args []string) error {
+	if len(args) == 0 {
+		return errQuarantineUsage
+	}
+
+	action, ok := quarantineActions[args[0]]
+	if !ok {
+		return errQuarantineUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	postgresClient := postgres.MustNewClient()
+	defer postgresClient.Close()
+
+	// Only replay publishes, the other actions work without a broker
+	var messageBroker broker.Broker
+	if args[0] == "replay" {
+		messageBroker = brokerfactory.MustNew()
+		defer func() { _ = messageBroker.Close() }()
+	}
+
+	quarantineSvc := quarantinesvc.MustNewQuarantineService(
+		quarantinesvc.WithQuarantineRepository(quarantinerepo.NewQuarantineRepository(postgresClient)),
+		quarantinesvc.WithPublisher(messageBroker),
+	)
+
+	return action(ctx, quarantineSvc, args[1:])
+}
+
+// runQuarantineList lists quarantined messages.
+func runQuarantineList(ctx context.Context, svc *quarantinesvc.QuarantineService, args []string) error {
+	var limit, offset int
+
+	fs := flag.NewFlagSet("quarantine list", flag.ContinueOnError)
+	fs.IntVar(&limit, "limit", 0, "maximum number of messages (default 100)")
+	fs.IntVar(&offset, "offset", 0, "number of messages to skip")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	messages, err := svc.ListMessages(ctx, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(messages)
+}
+
+// runQuarantineShow shows a single quarantined message.
+func runQuarantineShow(ctx context.Context, svc *quarantinesvc.QuarantineService, args []string) error {
+	id, err := parseQuarantineID("quarantine show", args)
+	if err != nil {
+		return err
+	}
+
+	msg, err := svc.GetMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(msg)
+}
+
+// runQuarantineEdit replaces the payload or content type of a quarantined message.
+func runQuarantineEdit(ctx context.Context, svc *quarantinesvc.QuarantineService, args []string) error {
+	var (
+		model       quarantine.EditQuarantinedMessageModel
+		payloadFile string
+	)
+
+	fs := flag.NewFlagSet("quarantine edit", flag.ContinueOnError)
+	fs.Int64Var(&model.ID, "id", 0, "quarantined message ID")
+	fs.StringVar(&payloadFile, "payload-file", "", "file with the new payload, - reads stdin")
+	fs.StringVar(&model.ContentType, "content-type", "", "new content type")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if model.ID <= 0 {
+		return errors.New("-id is required")
+	}
+
+	if payloadFile != "" {
+		payload, err := readPayload(payloadFile)
+		if err != nil {
+			return err
+		}
+		model.Payload = payload
+	}
+
+	msg, err := svc.EditMessage(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(msg)
+}
+
+// runQuarantineReplay publishes a quarantined message back to its queue.
+func runQuarantineReplay(ctx context.Context, svc *quarantinesvc.QuarantineService, args []string) error {
+	id, err := parseQuarantineID("quarantine replay", args)
+	if err != nil {
+		return err
+	}
+
+	return svc.ReplayMessage(ctx, id)
+}
+
+// runQuarantineDiscard deletes a quarantined message.
+func runQuarantineDiscard(ctx context.Context, svc *quarantinesvc.QuarantineService, args []string) error {
+	id, err := parseQuarantineID("quarantine discard", args)
+	if err != nil {
+		return err
+	}
+
+	return svc.DiscardMessage(ctx, id)
+}
+
+// parseQuarantineID parses the required -id flag.
+func parseQuarantineID(name string, args []string) (int64, error) {
+	var id int64
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Int64Var(&id, "id", 0, "quarantined message ID")
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, errors.New("-id is required")
+	}
+
+	return id, nil
+}
+
+// readPayload reads a payload from the file, - reads stdin.
+func readPayload(path string) ([]byte, error) {
+	if path == "-" {
+		payload, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload from stdin: %w", err)
+		}
+
+		return payload, nil
+	}
+
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload file: %w", err)
+	}
+
+	return payload, nil
+}
+
+// writeJSON writes the value to stdout as indented JSON.
+func writeJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
@@ -2,48 +2,120 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/corray333/backend-labs/consumer/internal/service/models"
 	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/corray333/backend-labs/order/pkg/events"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// Config configures the gRPC client.
+// Zero values are replaced with defaults.
+type Config struct {
+	Addr string
+	// Timeout bounds one attempt
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts of a call, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled for every following one up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Breaker        circuitbreaker.Config
+}
+
+// ConfigFromViper reads the gRPC client config from the grpc section.
+func ConfigFromViper() Config {
+	return Config{
+		Addr:           viper.GetString("grpc.order_service_addr"),
+		Timeout:        time.Duration(viper.GetInt("grpc.timeout_seconds")) * time.Second,
+		MaxAttempts:    viper.GetInt("grpc.retry.max_attempts"),
+		InitialBackoff: time.Duration(viper.GetInt("grpc.retry.initial_backoff_ms")) * time.Millisecond,
+		MaxBackoff:     time.Duration(viper.GetInt("grpc.retry.max_backoff_ms")) * time.Millisecond,
+		Breaker: circuitbreaker.Config{
+			FailureThreshold: viper.GetInt("grpc.circuit_breaker.failure_threshold"),
+			SuccessThreshold: viper.GetInt("grpc.circuit_breaker.success_threshold"),
+			OpenTimeout:      time.Duration(viper.GetInt("grpc.circuit_breaker.open_timeout_seconds")) * time.Second,
+		},
+	}
+}
+
 // Client represents a gRPC client for order service.
+// It is an audit sink: audit logs are saved through the SaveAuditLog call of the order service,
+// audit logs cannot be read back.
 type Client struct {
-	conn   *grpc.ClientConn
-	client pb.OrderServiceClient
+	conn    *grpc.ClientConn
+	client  pb.OrderServiceClient
+	cfg     Config
+	breaker *circuitbreaker.Breaker
 }
 
-// MustNewClient creates a new gRPC client.
-func MustNewClient() *Client {
-	addr := viper.GetString("grpc.order_service_addr")
-	if addr == "" {
-		panic("grpc.order_service_addr is not set in config")
+// NewClient creates a new gRPC client of the order service and starts connecting.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("order service address is not set")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = max(2*time.Second, cfg.InitialBackoff)
+	}
+	if cfg.Breaker.OnStateChange == nil {
+		cfg.Breaker.OnStateChange = func(name string, from circuitbreaker.State, to circuitbreaker.State) {
+			slog.Warn("Circuit breaker state changed", "name", name, "from", from, "to", to)
+		}
 	}
 
 	conn, err := grpc.NewClient(
-		addr,
+		cfg.Addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to order service: %v", err))
+		return nil, fmt.Errorf("failed to create order service connection: %w", err)
 	}
-
-	client := pb.NewOrderServiceClient(conn)
-
-	slog.Info("gRPC client connected to order service", "address", addr)
+	// Connections are lazy, the first audit logs would wait for the handshake
+	conn.Connect()
 
 	return &Client{
-		conn:   conn,
-		client: client,
+		conn:    conn,
+		client:  pb.NewOrderServiceClient(conn),
+		cfg:     cfg,
+		breaker: circuitbreaker.New("order_service_audit", cfg.Breaker),
+	}, nil
+}
+
+// MustNewClient creates a new gRPC client from the grpc section of the config.
+func MustNewClient() *Client {
+	client, err := NewClient(ConfigFromViper())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to connect to order service: %v", err))
 	}
+
+	slog.Info("gRPC client connecting to order service", "address", client.cfg.Addr)
+
+	return client
 }
 
 // Close closes the gRPC connection.
@@ -55,43 +127,230 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// SaveAuditLogs sends audit logs to the order service via gRPC.
-func (c *Client) SaveAuditLogs(ctx context.Context, auditLogs []models.AuditLogOrder) error {
+// SaveAuditLogs sends the audit logs of a message to the order service.
+// Redelivered messages are sent again, the order service drops them by the message ID
+// and models.ErrAlreadyProcessed is returned.
+func (c *Client) SaveAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error {
 	ctx, span := otel.Tracer("grpc-client").Start(ctx, "Client.SaveAuditLogs")
 	defer span.End()
 
-	timeout := viper.GetInt("grpc.timeout_seconds")
-	if timeout == 0 {
-		timeout = 30
+	duplicates, err := c.save(ctx, []models.AuditLogMessage{{MessageID: messageID, AuditLogs: auditLogs}})
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return models.ErrAlreadyProcessed
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
-	defer cancel()
+	return nil
+}
+
+// SaveAuditLogBatch sends the audit logs of several messages to the order service in one call.
+// Returns the number of messages not processed by the order service before.
+func (c *Client) SaveAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) (int, error) {
+	ctx, span := otel.Tracer("grpc-client").Start(ctx, "Client.SaveAuditLogBatch")
+	defer span.End()
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	duplicates, err := c.save(ctx, messages)
+	if err != nil {
+		return 0, err
+	}
 
-	pbAuditLogs := make([]*pb.AuditLogOrder, len(auditLogs))
-	for i, log := range auditLogs {
-		pbAuditLogs[i] = &pb.AuditLogOrder{
-			OrderId:     log.OrderID,
-			OrderItemId: log.OrderItemID,
-			CustomerId:  log.CustomerID,
-			OrderStatus: log.OrderStatus,
-			CreatedAt:   timestamppb.New(log.CreatedAt),
-			UpdatedAt:   timestamppb.New(log.UpdatedAt),
+	return len(messages) - len(duplicates), nil
+}
+
+// ListAuditLogs is not served by the order service.
+func (c *Client) ListAuditLogs(context.Context, models.ListAuditLogsModel) ([]models.AuditLogOrder, error) {
+	return nil, models.ErrAuditReadUnsupported
+}
+
+// GetOrderAuditLogs is not served by the order service.
+func (c *Client) GetOrderAuditLogs(context.Context, int64) ([]models.AuditLogOrder, error) {
+	return nil, models.ErrAuditReadUnsupported
+}
+
+// save calls SaveAuditLog, retrying transient failures with exponential backoff,
+// and returns the IDs of the messages the order service processed before.
+// Every audit log carries the ID of its message and the order service saves a message once,
+// so a retry of an attempt that saved the audit logs but lost the response does not duplicate them.
+// Attempts go through the circuit breaker, an open breaker fails the call right away.
+func (c *Client) save(ctx context.Context, messages []models.AuditLogMessage) ([]string, error) {
+	var (
+		messageIDs  = make([]string, 0, len(messages))
+		pbAuditLogs []*pb.AuditLogOrder
+	)
+	for _, msg := range messages {
+		messageIDs = append(messageIDs, msg.MessageID)
+		for _, log := range msg.AuditLogs {
+			pbAuditLogs = append(pbAuditLogs, auditLogToProto(msg.MessageID, log))
 		}
 	}
 
 	req := &pb.SaveAuditLogRequest{
 		AuditLogs: pbAuditLogs,
 	}
+	ctx = metadata.AppendToOutgoingContext(ctx, messageIDPairs(messageIDs)...)
 
-	resp, err := c.client.SaveAuditLog(ctx, req)
+	var (
+		resp *pb.SaveAuditLogResponse
+		err  error
+	)
+	for attempt := 1; attempt <= c.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := c.wait(ctx, attempt); err != nil {
+				return nil, fmt.Errorf("failed to save audit logs via gRPC: %w", err)
+			}
+		}
+
+		if err = c.breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("failed to save audit logs via gRPC: %w", err)
+		}
+
+		resp, err = c.attempt(ctx, req)
+		if ctx.Err() != nil {
+			// The caller gave up, the order service is not to blame
+			break
+		}
+		if !serviceFailure(err) {
+			// The order service answered, it is up even when it rejected the call
+			c.breaker.Success()
+
+			break
+		}
+		c.breaker.Failure()
+		if !retryable(err) {
+			break
+		}
+
+		slog.Warn("Failed to save audit logs via gRPC, retrying",
+			"attempt", attempt,
+			"max_attempts", c.cfg.MaxAttempts,
+			"error", err,
+		)
+	}
 	if err != nil {
 		slog.Error("Failed to save audit logs", "error", err)
 
-		return fmt.Errorf("failed to save audit logs via gRPC: %w", err)
+		return nil, fmt.Errorf("failed to save audit logs via gRPC: %w", err)
 	}
 
-	slog.Info("Audit logs saved via gRPC", "count", len(resp.AuditLogs))
+	slog.Info("Audit logs saved via gRPC",
+		"count", len(resp.GetAuditLogs()),
+		"duplicate_message_ids", resp.GetDuplicateMessageIds(),
+	)
 
-	return nil
+	return resp.GetDuplicateMessageIds(), nil
+}
+
+// attempt makes one SaveAuditLog call within the attempt timeout.
+func (c *Client) attempt(ctx context.Context, req *pb.SaveAuditLogRequest) (*pb.SaveAuditLogResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	return c.client.SaveAuditLog(ctx, req)
+}
+
+// wait sleeps the backoff before an attempt or until the context is done.
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	backoff := time.Duration(math.Pow(2, float64(attempt-2)) * float64(c.cfg.InitialBackoff))
+	timer := time.NewTimer(min(backoff, c.cfg.MaxBackoff))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// serviceFailure reports whether a call failed because the order service is down or broken,
+// as opposed to a rejection of the request. Service failures open the circuit breaker.
+func serviceFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.Unimplemented, codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryable reports whether a call failed for a reason a later attempt may not run into.
+// A DeadlineExceeded or Aborted attempt may have saved the audit logs, retrying it is safe
+// only because the order service drops messages it saved before.
+func retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// messageIDPairs returns the metadata key value pairs of the message IDs.
+func messageIDPairs(messageIDs []string) []string {
+	pairs := make([]string, 0, 2*len(messageIDs))
+	for _, id := range messageIDs {
+		pairs = append(pairs, events.MetadataMessageID, id)
+	}
+
+	return pairs
+}
+
+// auditLogToProto converts an audit log of the message to its protobuf representation.
+func auditLogToProto(messageID string, log models.AuditLogOrder) *pb.AuditLogOrder {
+	return &pb.AuditLogOrder{
+		OrderId:       log.OrderID,
+		OrderItemId:   log.OrderItemID,
+		CustomerId:    log.CustomerID,
+		OrderStatus:   log.OrderStatus,
+		CreatedAt:     timestamppb.New(log.CreatedAt),
+		UpdatedAt:     timestamppb.New(log.UpdatedAt),
+		EventType:     log.EventType,
+		ActorId:       log.ActorID,
+		ActorType:     log.ActorType,
+		SourceService: log.SourceService,
+		RequestId:     log.RequestID,
+		Changes:       changesToProto(log.Changes),
+		MessageId:     messageID,
+	}
+}
+
+// changesToProto converts field changes to their protobuf representation ordered by field.
+func changesToProto(changes map[string]models.FieldChange) []*pb.FieldChange {
+	fields := slices.Sorted(maps.Keys(changes))
+
+	converted := make([]*pb.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		converted = append(converted, &pb.FieldChange{
+			Field:  field,
+			Before: valueToProto(changes[field].Before),
+			After:  valueToProto(changes[field].After),
+		})
+	}
+
+	return converted
+}
+
+// valueToProto converts a changed value, values without a JSON representation are converted to strings.
+func valueToProto(v any) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+
+	return value
 }
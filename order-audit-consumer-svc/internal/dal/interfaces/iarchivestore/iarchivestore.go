@@ -0,0 +1,19 @@
+package iarchivestore
+
+import (
+	"context"
+	"io"
+)
+
+// IArchiveStore is interface for the storage archive files are written to.
+// Paths are slash separated and relative to the root of the store.
+type IArchiveStore interface {
+	// Create opens a file for writing, replacing an existing one.
+	// The file is stored once the writer is closed without error.
+	Create(ctx context.Context, path string) (io.WriteCloser, error)
+	// Open opens a file for reading.
+	// Returns archive.ErrNotFound for a file that does not exist.
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+	// Exists reports whether a file exists.
+	Exists(ctx context.Context, path string) (bool, error)
+}
@@ -0,0 +1,17 @@
+package iauditarchiverepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+)
+
+// IAuditArchiveRepository is interface for reading audit logs out to archives and restoring them.
+type IAuditArchiveRepository interface {
+	// ListArchiveAuditLogs returns at most limit audit logs created in [from, to) with IDs above afterID, in ID order.
+	ListArchiveAuditLogs(ctx context.Context, from, to time.Time, afterID int64, limit int) ([]models.AuditLogOrder, error)
+	// RestoreAuditLogs inserts archived audit logs with their IDs and chain links, skipping the ones still stored.
+	// Pruned runs whose audit logs are all stored again are removed. Returns the number of inserted audit logs.
+	RestoreAuditLogs(ctx context.Context, auditLogs []models.AuditLogOrder) (int64, error)
+}
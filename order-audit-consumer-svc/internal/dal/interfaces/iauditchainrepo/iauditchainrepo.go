@@ -0,0 +1,27 @@
+package iauditchainrepo
+
+import (
+	"context"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+)
+
+// IAuditChainRepository is interface for the hash chain of audit logs and its checkpoints.
+type IAuditChainRepository interface {
+	// ListChainHeads returns the heads of all chains ordered by chain.
+	ListChainHeads(ctx context.Context) ([]auditchain.Head, error)
+	// GetChainHead returns the head of a chain.
+	// Returns auditchain.ErrChainNotFound for a chain without audit logs.
+	GetChainHead(ctx context.Context, chainID string) (auditchain.Head, error)
+	// ListChainAuditLogs returns at most limit audit logs of a chain with IDs above afterID, in chain order.
+	ListChainAuditLogs(ctx context.Context, chainID string, afterID int64, limit int) ([]models.AuditLogOrder, error)
+	// ListUncheckpointedHeads returns the heads of chains extended since their last checkpoint.
+	ListUncheckpointedHeads(ctx context.Context) ([]auditchain.Head, error)
+	// SaveCheckpoints saves signed checkpoints and returns them with their IDs.
+	SaveCheckpoints(ctx context.Context, checkpoints []auditchain.Checkpoint) ([]auditchain.Checkpoint, error)
+	// ListCheckpoints returns the checkpoints of a chain, of all chains for an empty chain ID, oldest first.
+	ListCheckpoints(ctx context.Context, chainID string) ([]auditchain.Checkpoint, error)
+	// ListPrunedRuns returns the runs of audit logs of a chain removed by the retention, in chain order.
+	ListPrunedRuns(ctx context.Context, chainID string) ([]auditchain.PrunedRun, error)
+}
@@ -8,5 +8,14 @@ import (
 
 // IAuditRepository is interface for audit repository.
 type IAuditRepository interface {
-	SaveAuditLogs(ctx context.Context, auditLogs []models.AuditLogOrder) error
+	// SaveAuditLogs saves the audit logs of a message and marks the message processed atomically.
+	// Returns models.ErrAlreadyProcessed without saving anything for a message seen before.
+	SaveAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error
+	// SaveAuditLogBatch saves the audit logs of several messages in one write.
+	// Messages seen before are skipped, the number of newly processed messages is returned.
+	SaveAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) (int, error)
+	// ListAuditLogs returns at most model.Limit audit logs matching the filter, newest first.
+	ListAuditLogs(ctx context.Context, model models.ListAuditLogsModel) ([]models.AuditLogOrder, error)
+	// GetOrderAuditLogs returns all audit logs of an order, oldest first.
+	GetOrderAuditLogs(ctx context.Context, orderID int64) ([]models.AuditLogOrder, error)
 }
@@ -15,6 +15,12 @@ type IInboxRepository interface {
 	// GetPendingMessages retrieves messages that are ready for retry
 	GetPendingMessages(ctx context.Context, limit int) ([]inbox.InboxMessage, error)
 
+	// List retrieves messages in the order they are retried, including the ones out of retries
+	List(ctx context.Context, limit int, offset int) ([]inbox.InboxMessage, error)
+
+	// Get retrieves a message by ID
+	Get(ctx context.Context, id int64) (inbox.InboxMessage, error)
+
 	// Delete removes a message from the inbox after successful processing
 	Delete(ctx context.Context, id int64) error
 
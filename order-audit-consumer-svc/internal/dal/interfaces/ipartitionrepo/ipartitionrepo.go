@@ -0,0 +1,29 @@
+package ipartitionrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+)
+
+// IPartitionRepository is interface for the monthly partitions of the audit logs.
+type IPartitionRepository interface {
+	// ListPartitions returns the attached monthly partitions, oldest first.
+	ListPartitions(ctx context.Context) ([]partition.Partition, error)
+	// CreatePartition creates the partition of a month,
+	// moving audit logs of the month out of the default partition.
+	CreatePartition(ctx context.Context, p partition.Partition) error
+	// RemovePartition detaches or drops a partition, archiving its audit logs with archive first
+	// unless it is nil. The chain links of its audit logs are kept as pruned runs so the chains still verify.
+	RemovePartition(
+		ctx context.Context,
+		p partition.Partition,
+		action partition.RetentionAction,
+		archive partition.ArchiveFunc,
+	) error
+	// PruneDefaultPartition deletes audit logs created before the time from the default partition,
+	// archiving them with archive first unless it is nil or there is nothing to delete.
+	// Their chain links are kept as pruned runs. Returns the number of deleted audit logs.
+	PruneDefaultPartition(ctx context.Context, before time.Time, archive partition.ArchiveFunc) (int64, error)
+}
@@ -0,0 +1,25 @@
+package iquarantinerepo
+
+import (
+	"context"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+)
+
+// IQuarantineRepository defines the interface for quarantine operations.
+type IQuarantineRepository interface {
+	// Insert adds a poison message to the quarantine
+	Insert(ctx context.Context, msg quarantine.QuarantinedMessage) error
+
+	// List retrieves quarantined messages, most recent first
+	List(ctx context.Context, limit int, offset int) ([]quarantine.QuarantinedMessage, error)
+
+	// Get retrieves a quarantined message by ID
+	Get(ctx context.Context, id int64) (quarantine.QuarantinedMessage, error)
+
+	// Update replaces the payload and content type of a quarantined message
+	Update(ctx context.Context, model quarantine.EditQuarantinedMessageModel) error
+
+	// Delete removes a quarantined message
+	Delete(ctx context.Context, id int64) error
+}
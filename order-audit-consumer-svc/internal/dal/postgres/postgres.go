@@ -22,6 +22,11 @@ func (p *Client) Pool() *pgxpool.Pool {
 	return p.pool
 }
 
+// Ping checks that a connection to the database can be acquired and used.
+func (p *Client) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
+}
+
 // Close closes the database connection for graceful shutdown.
 func (p *Client) Close() {
 	p.pool.Close()
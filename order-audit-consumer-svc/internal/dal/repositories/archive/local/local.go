@@ -0,0 +1,91 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/archive"
+)
+
+// Store keeps archive files in a local directory.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a new store of archive files under the directory.
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Create opens a file for writing. It is written to a temporary file renamed into place on close,
+// so a partly written file never shows up under its name.
+func (s *Store) Create(_ context.Context, path string) (io.WriteCloser, error) {
+	name := filepath.Join(s.dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
+	}
+
+	return &fileWriter{File: file, name: name}, nil
+}
+
+// Open opens a file for reading.
+func (s *Store) Open(_ context.Context, path string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", archive.ErrNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive file: %w", err)
+	}
+
+	return file, nil
+}
+
+// Exists reports whether a file exists.
+func (s *Store) Exists(_ context.Context, path string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(path)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat archive file: %w", err)
+	}
+
+	return true, nil
+}
+
+// fileWriter renames the temporary file to its name once it is synced and closed.
+type fileWriter struct {
+	*os.File
+	name string
+}
+
+// Close syncs and closes the temporary file and moves it to its name.
+func (w *fileWriter) Close() error {
+	err := w.Sync()
+	if closeErr := w.File.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(w.File.Name(), w.name)
+	}
+	if err != nil {
+		_ = os.Remove(w.File.Name())
+
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	return nil
+}
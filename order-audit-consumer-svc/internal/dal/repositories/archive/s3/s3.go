@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/archive"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// partSize is the size of the parts files of unknown size are uploaded in.
+const partSize = 16 << 20
+
+// Config configures the S3 compatible store.
+type Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+	AccessKey string
+	SecretKey string
+}
+
+// Store keeps archive files as objects of an S3 compatible bucket, MinIO works locally.
+type Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewStore creates a new store of archive files in the bucket, creating the bucket if it does not exist.
+func NewStore(ctx context.Context, cfg Config) (*Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %s: %w", cfg.Bucket, err)
+		}
+	}
+
+	return &Store{
+		client: client,
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+// Create opens an object for writing, it is uploaded in parts while written.
+// The object is stored once the upload completes on close.
+func (s *Store) Create(ctx context.Context, path string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	w := &objectWriter{
+		PipeWriter: pw,
+		done:       make(chan error, 1),
+	}
+
+	go func() {
+		_, err := s.client.PutObject(ctx, s.bucket, path, pr, -1, minio.PutObjectOptions{
+			ContentType: "application/octet-stream",
+			PartSize:    partSize,
+		})
+		// Unblocks writes when the upload failed
+		_ = pr.CloseWithError(err)
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+// Open opens an object for reading.
+func (s *Store) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, path, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", path, err)
+	}
+
+	// GetObject is lazy, a missing object shows up on the first request
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%w: %s", archive.ErrNotFound, path)
+		}
+
+		return nil, fmt.Errorf("failed to stat object %s: %w", path, err)
+	}
+
+	return object, nil
+}
+
+// Exists reports whether an object exists.
+func (s *Store) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, path, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat object %s: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// objectWriter streams writes to an upload.
+type objectWriter struct {
+	*io.PipeWriter
+	done chan error
+}
+
+// Close ends the upload and waits for it to complete.
+func (w *objectWriter) Close() error {
+	_ = w.PipeWriter.Close()
+	if err := <-w.done; err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+)
+
+// AuditRepository writes audit logs to several audit sinks and reads them from the primary one.
+//
+// Secondary sinks are written first and the primary one last, a message fails when any sink fails.
+// The primary sink decides whether a message was processed before, so a message whose primary write
+// failed is redelivered to every sink and secondary sinks get audit logs at least once.
+type AuditRepository struct {
+	primary     iauditrepo.IAuditRepository
+	secondaries []iauditrepo.IAuditRepository
+}
+
+// NewAuditRepository creates a new fan-out audit repository.
+func NewAuditRepository(primary iauditrepo.IAuditRepository, secondaries ...iauditrepo.IAuditRepository) *AuditRepository {
+	return &AuditRepository{
+		primary:     primary,
+		secondaries: secondaries,
+	}
+}
+
+// SaveAuditLogs saves the audit logs of a message to every sink.
+// Returns models.ErrAlreadyProcessed when the primary sink saw the message before.
+func (r *AuditRepository) SaveAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error {
+	for i, sink := range r.secondaries {
+		err := sink.SaveAuditLogs(ctx, messageID, auditLogs)
+		if err != nil && !errors.Is(err, models.ErrAlreadyProcessed) {
+			return fmt.Errorf("failed to save audit logs to secondary sink %d: %w", i, err)
+		}
+	}
+
+	return r.primary.SaveAuditLogs(ctx, messageID, auditLogs)
+}
+
+// SaveAuditLogBatch saves the audit logs of several messages to every sink.
+// Returns the number of messages newly processed by the primary sink.
+func (r *AuditRepository) SaveAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) (int, error) {
+	for i, sink := range r.secondaries {
+		if _, err := sink.SaveAuditLogBatch(ctx, messages); err != nil {
+			return 0, fmt.Errorf("failed to save audit log batch to secondary sink %d: %w", i, err)
+		}
+	}
+
+	return r.primary.SaveAuditLogBatch(ctx, messages)
+}
+
+// ListAuditLogs lists audit logs of the primary sink.
+func (r *AuditRepository) ListAuditLogs(
+	ctx context.Context,
+	model models.ListAuditLogsModel,
+) ([]models.AuditLogOrder, error) {
+	return r.primary.ListAuditLogs(ctx, model)
+}
+
+// GetOrderAuditLogs returns the audit logs of an order from the primary sink.
+func (r *AuditRepository) GetOrderAuditLogs(ctx context.Context, orderID int64) ([]models.AuditLogOrder, error) {
+	return r.primary.GetOrderAuditLogs(ctx, orderID)
+}
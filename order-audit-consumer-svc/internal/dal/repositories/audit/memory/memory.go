@@ -0,0 +1,519 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+)
+
+// AuditRepository is an in-memory audit repository for tests.
+type AuditRepository struct {
+	mu        sync.Mutex
+	nextID    int64
+	logs      []models.AuditLogOrder
+	processed map[string]time.Time
+	writes    int
+	err       error
+	delay     time.Duration
+
+	chainScope       auditchain.Scope
+	heads            map[string]auditchain.Head
+	checkpoints      []auditchain.Checkpoint
+	nextCheckpointID int64
+	prunedRuns       []auditchain.PrunedRun
+}
+
+// auditKey identifies an audit log, at most one is stored per order item status change.
+type auditKey struct {
+	orderID     int64
+	orderItemID int64
+	status      string
+	createdAt   int64
+}
+
+// NewAuditRepository creates a new in-memory audit repository with a global chain.
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{
+		processed:  make(map[string]time.Time),
+		chainScope: auditchain.ScopeGlobal,
+		heads:      make(map[string]auditchain.Head),
+	}
+}
+
+// SaveAuditLogs stores audit logs with generated IDs and marks the message processed.
+func (r *AuditRepository) SaveAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error {
+	saved, err := r.SaveAuditLogBatch(ctx, []models.AuditLogMessage{
+		{MessageID: messageID, AuditLogs: auditLogs},
+	})
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return models.ErrAlreadyProcessed
+	}
+
+	return nil
+}
+
+// SaveAuditLogBatch stores audit logs of the messages not processed before
+// and appends them to their chains.
+func (r *AuditRepository) SaveAuditLogBatch(_ context.Context, messages []models.AuditLogMessage) (int, error) {
+	r.mu.Lock()
+	delay := r.delay
+	r.mu.Unlock()
+	time.Sleep(delay)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.writes++
+
+	existing := make(map[auditKey]struct{}, len(r.logs))
+	for _, log := range r.logs {
+		existing[auditKey{log.OrderID, log.OrderItemID, log.OrderStatus, log.CreatedAt.UnixNano()}] = struct{}{}
+	}
+
+	saved := 0
+	for _, msg := range messages {
+		if _, ok := r.processed[msg.MessageID]; ok {
+			continue
+		}
+		r.processed[msg.MessageID] = time.Now()
+		saved++
+
+		for _, log := range msg.AuditLogs {
+			key := auditKey{log.OrderID, log.OrderItemID, log.OrderStatus, log.CreatedAt.UnixNano()}
+			if _, ok := existing[key]; ok {
+				continue
+			}
+			existing[key] = struct{}{}
+
+			r.nextID++
+			log.ID = r.nextID
+			log.ChainID = r.chainScope.ChainID(log.CustomerID)
+
+			head := r.heads[log.ChainID]
+			linked, err := auditchain.Link(log, head.Hash)
+			if err != nil {
+				return 0, err
+			}
+			r.heads[log.ChainID] = auditchain.Head{ChainID: log.ChainID, LastAuditLogID: linked.ID, Hash: linked.Hash}
+			r.logs = append(r.logs, linked)
+		}
+	}
+
+	return saved, nil
+}
+
+// DeleteProcessedMessagesBefore forgets at most limit messages processed before the cutoff.
+func (r *AuditRepository) DeleteProcessedMessagesBefore(_ context.Context, cutoff time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, processedAt := range r.processed {
+		if deleted == int64(limit) {
+			break
+		}
+		if processedAt.Before(cutoff) {
+			delete(r.processed, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// IsProcessed reports whether the message is marked processed.
+func (r *AuditRepository) IsProcessed(messageID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.processed[messageID]
+
+	return ok
+}
+
+// SetProcessedAt backdates the processed mark of a message, simulating an old delivery.
+func (r *AuditRepository) SetProcessedAt(messageID string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processed[messageID] = at
+}
+
+// SetError makes every following save fail with err, nil restores saving.
+func (r *AuditRepository) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+}
+
+// SetDelay makes every following save take at least d, simulating a slow database.
+func (r *AuditRepository) SetDelay(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.delay = d
+}
+
+// SetChainScope makes the following saves append audit logs to chains of the scope.
+func (r *AuditRepository) SetChainScope(scope auditchain.Scope) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.chainScope = scope
+}
+
+// UpdateAuditLog changes a stored audit log in place, simulating an edit after the fact.
+func (r *AuditRepository) UpdateAuditLog(id int64, update func(*models.AuditLogOrder)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.logs {
+		if r.logs[i].ID == id {
+			update(&r.logs[i])
+		}
+	}
+}
+
+// Writes returns the number of successful save calls.
+func (r *AuditRepository) Writes() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.writes
+}
+
+// AuditLogs returns a snapshot of stored audit logs.
+func (r *AuditRepository) AuditLogs() []models.AuditLogOrder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.logs)
+}
+
+// ListAuditLogs returns stored audit logs matching the filter, newest first.
+func (r *AuditRepository) ListAuditLogs(
+	_ context.Context,
+	model models.ListAuditLogsModel,
+) ([]models.AuditLogOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var auditLogs []models.AuditLogOrder
+	for _, log := range r.logs {
+		if matches(log, model) {
+			auditLogs = append(auditLogs, log)
+		}
+	}
+
+	slices.SortFunc(auditLogs, func(a, b models.AuditLogOrder) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	if model.Limit > 0 && len(auditLogs) > model.Limit {
+		auditLogs = auditLogs[:model.Limit]
+	}
+
+	return auditLogs, nil
+}
+
+// GetOrderAuditLogs returns the stored audit logs of an order, oldest first.
+func (r *AuditRepository) GetOrderAuditLogs(_ context.Context, orderID int64) ([]models.AuditLogOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var auditLogs []models.AuditLogOrder
+	for _, log := range r.logs {
+		if log.OrderID == orderID {
+			auditLogs = append(auditLogs, log)
+		}
+	}
+
+	slices.SortFunc(auditLogs, func(a, b models.AuditLogOrder) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return auditLogs, nil
+}
+
+// matches reports whether an audit log passes the listing filter and lies after its cursor.
+func matches(log models.AuditLogOrder, model models.ListAuditLogsModel) bool {
+	switch {
+	case model.OrderID != 0 && log.OrderID != model.OrderID,
+		model.CustomerID != 0 && log.CustomerID != model.CustomerID,
+		model.OrderStatus != "" && log.OrderStatus != model.OrderStatus,
+		!model.CreatedFrom.IsZero() && log.CreatedAt.Before(model.CreatedFrom),
+		!model.CreatedTo.IsZero() && !log.CreatedAt.Before(model.CreatedTo):
+		return false
+	case model.After != nil:
+		if c := log.CreatedAt.Compare(model.After.CreatedAt); c != 0 {
+			return c < 0
+		}
+
+		return log.ID < model.After.ID
+	default:
+		return true
+	}
+}
+
+// ListChainHeads returns the heads of all chains ordered by chain.
+func (r *AuditRepository) ListChainHeads(_ context.Context) ([]auditchain.Head, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	heads := slices.Collect(maps.Values(r.heads))
+	slices.SortFunc(heads, func(a, b auditchain.Head) int {
+		return cmp.Compare(a.ChainID, b.ChainID)
+	})
+
+	return heads, nil
+}
+
+// GetChainHead returns the head of a chain.
+func (r *AuditRepository) GetChainHead(_ context.Context, chainID string) (auditchain.Head, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	head, ok := r.heads[chainID]
+	if !ok {
+		return auditchain.Head{}, auditchain.ErrChainNotFound
+	}
+
+	return head, nil
+}
+
+// ListChainAuditLogs returns at most limit stored audit logs of a chain with IDs above afterID, in ID order.
+func (r *AuditRepository) ListChainAuditLogs(
+	_ context.Context,
+	chainID string,
+	afterID int64,
+	limit int,
+) ([]models.AuditLogOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Audit logs are stored in ID order
+	var auditLogs []models.AuditLogOrder
+	for _, log := range r.logs {
+		if log.ChainID == chainID && log.ID > afterID && len(auditLogs) < limit {
+			auditLogs = append(auditLogs, log)
+		}
+	}
+
+	return auditLogs, nil
+}
+
+// ListUncheckpointedHeads returns the heads of chains extended since their last checkpoint.
+func (r *AuditRepository) ListUncheckpointedHeads(_ context.Context) ([]auditchain.Head, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checkpointed := make(map[string]int64)
+	for _, checkpoint := range r.checkpoints {
+		checkpointed[checkpoint.ChainID] = max(checkpointed[checkpoint.ChainID], checkpoint.LastAuditLogID)
+	}
+
+	var heads []auditchain.Head
+	for _, head := range r.heads {
+		if head.LastAuditLogID > checkpointed[head.ChainID] {
+			heads = append(heads, head)
+		}
+	}
+	slices.SortFunc(heads, func(a, b auditchain.Head) int {
+		return cmp.Compare(a.ChainID, b.ChainID)
+	})
+
+	return heads, nil
+}
+
+// SaveCheckpoints stores checkpoints with generated IDs.
+func (r *AuditRepository) SaveCheckpoints(
+	_ context.Context,
+	checkpoints []auditchain.Checkpoint,
+) ([]auditchain.Checkpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := slices.Clone(checkpoints)
+	for i := range saved {
+		r.nextCheckpointID++
+		saved[i].ID = r.nextCheckpointID
+	}
+	r.checkpoints = append(r.checkpoints, saved...)
+
+	return saved, nil
+}
+
+// ListCheckpoints returns the stored checkpoints of a chain, of all chains for an empty chain ID, oldest first.
+func (r *AuditRepository) ListCheckpoints(_ context.Context, chainID string) ([]auditchain.Checkpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var checkpoints []auditchain.Checkpoint
+	for _, checkpoint := range r.checkpoints {
+		if chainID == "" || checkpoint.ChainID == chainID {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+
+	return checkpoints, nil
+}
+
+// ListPrunedRuns returns the runs of pruned audit logs of a chain, in chain order.
+func (r *AuditRepository) ListPrunedRuns(_ context.Context, chainID string) ([]auditchain.PrunedRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var runs []auditchain.PrunedRun
+	for _, run := range r.prunedRuns {
+		if run.ChainID == chainID {
+			runs = append(runs, run)
+		}
+	}
+	slices.SortFunc(runs, func(a, b auditchain.PrunedRun) int {
+		return cmp.Compare(a.FirstAuditLogID, b.FirstAuditLogID)
+	})
+
+	return runs, nil
+}
+
+// PruneAuditLogs deletes audit logs created in [from, to) and keeps their chain links as pruned runs,
+// like removing a partition. Returns the number of deleted audit logs.
+func (r *AuditRepository) PruneAuditLogs(from, to time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Consecutive pruned audit logs of a chain collapse into one run,
+	// live audit logs and runs pruned before separate them
+	previous := slices.Clone(r.prunedRuns)
+	separated := func(run auditchain.PrunedRun, id int64) bool {
+		return slices.ContainsFunc(previous, func(p auditchain.PrunedRun) bool {
+			return p.ChainID == run.ChainID && p.FirstAuditLogID > run.LastAuditLogID && p.FirstAuditLogID < id
+		})
+	}
+	open := make(map[string]int)
+	var (
+		kept   []models.AuditLogOrder
+		pruned int64
+	)
+	for _, log := range r.logs {
+		if log.CreatedAt.Before(from) || !log.CreatedAt.Before(to) {
+			delete(open, log.ChainID)
+			kept = append(kept, log)
+
+			continue
+		}
+		pruned++
+
+		if i, ok := open[log.ChainID]; ok && !separated(r.prunedRuns[i], log.ID) {
+			r.prunedRuns[i].LastAuditLogID = log.ID
+			r.prunedRuns[i].Hash = log.Hash
+			r.prunedRuns[i].AuditLogs++
+
+			continue
+		}
+		open[log.ChainID] = len(r.prunedRuns)
+		r.prunedRuns = append(r.prunedRuns, auditchain.PrunedRun{
+			ChainID:         log.ChainID,
+			FirstAuditLogID: log.ID,
+			LastAuditLogID:  log.ID,
+			PrevHash:        log.PrevHash,
+			Hash:            log.Hash,
+			AuditLogs:       1,
+		})
+	}
+	r.logs = kept
+
+	return pruned
+}
+
+// ListArchiveAuditLogs returns at most limit stored audit logs created in [from, to) with IDs above afterID,
+// in ID order.
+func (r *AuditRepository) ListArchiveAuditLogs(
+	_ context.Context,
+	from, to time.Time,
+	afterID int64,
+	limit int,
+) ([]models.AuditLogOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var auditLogs []models.AuditLogOrder
+	for _, log := range r.logs {
+		if log.ID > afterID && !log.CreatedAt.Before(from) && log.CreatedAt.Before(to) && len(auditLogs) < limit {
+			auditLogs = append(auditLogs, log)
+		}
+	}
+
+	return auditLogs, nil
+}
+
+// RestoreAuditLogs stores archived audit logs with their IDs, skipping the ones still stored,
+// and removes the pruned runs whose audit logs are all stored again.
+func (r *AuditRepository) RestoreAuditLogs(_ context.Context, auditLogs []models.AuditLogOrder) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	existing := make(map[auditKey]struct{}, len(r.logs))
+	ids := make(map[int64]struct{}, len(r.logs))
+	for _, log := range r.logs {
+		existing[auditKey{log.OrderID, log.OrderItemID, log.OrderStatus, log.CreatedAt.UnixNano()}] = struct{}{}
+		ids[log.ID] = struct{}{}
+	}
+
+	var restored int64
+	for _, log := range auditLogs {
+		key := auditKey{log.OrderID, log.OrderItemID, log.OrderStatus, log.CreatedAt.UnixNano()}
+		if _, ok := existing[key]; ok {
+			continue
+		}
+		if _, ok := ids[log.ID]; ok {
+			continue
+		}
+		existing[key] = struct{}{}
+		ids[log.ID] = struct{}{}
+
+		r.logs = append(r.logs, log)
+		restored++
+	}
+	slices.SortFunc(r.logs, func(a, b models.AuditLogOrder) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	r.prunedRuns = slices.DeleteFunc(r.prunedRuns, func(run auditchain.PrunedRun) bool {
+		var stored int64
+		for _, log := range r.logs {
+			if log.ChainID == run.ChainID && log.ID >= run.FirstAuditLogID && log.ID <= run.LastAuditLogID {
+				stored++
+			}
+		}
+
+		return stored == run.AuditLogs
+	})
+
+	return restored, nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+)
+
+// deleteRestoredRunsQuery removes the pruned runs of the chains whose audit logs are all stored again.
+const deleteRestoredRunsQuery = `delete from audit_chain_pruned_runs r
+where r.chain_id = any($1)
+  and r.audit_logs = (select count(*)
+                      from audit_log_order a
+                      where a.chain_id = r.chain_id
+                        and a.id between r.first_audit_log_id and r.last_audit_log_id)`
+
+// ListArchiveAuditLogs returns at most limit audit logs created in [from, to) with IDs above afterID, in ID order.
+// The created_at bounds limit the scan to the partitions of the range.
+func (r *AuditRepository) ListArchiveAuditLogs(
+	ctx context.Context,
+	from, to time.Time,
+	afterID int64,
+	limit int,
+) ([]models.AuditLogOrder, error) {
+	query, args, err := sq.Select(auditLogColumns()...).
+		From("audit_log_order").
+		Where(sq.GtOrEq{"created_at": from}).
+		Where(sq.Lt{"created_at": to}).
+		Where(sq.Gt{"id": afterID}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryAuditLogs(ctx, query, args...)
+}
+
+// RestoreAuditLogs inserts archived audit logs as they were stored, in one transaction.
+// Audit logs of months without a partition land in the default partition.
+func (r *AuditRepository) RestoreAuditLogs(ctx context.Context, auditLogs []models.AuditLogOrder) (int64, error) {
+	if len(auditLogs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.pgClient.Pool().Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		restored int64
+		chainIDs []string
+	)
+	for chunk := range slices.Chunk(auditLogs, insertChunkSize) {
+		builder := sq.Insert("audit_log_order").
+			Columns(auditLogColumns()...).
+			Suffix("on conflict do nothing").
+			PlaceholderFormat(sq.Dollar)
+
+		for _, auditLog := range chunk {
+			changes := auditLog.Changes
+			if changes == nil {
+				changes = map[string]models.FieldChange{}
+			}
+			changesData, err := json.Marshal(changes)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal audit log changes: %w", err)
+			}
+
+			builder = builder.Values(
+				auditLog.ID,
+				auditLog.OrderID,
+				auditLog.OrderItemID,
+				auditLog.CustomerID,
+				auditLog.OrderStatus,
+				auditLog.EventType,
+				auditLog.ActorID,
+				auditLog.ActorType,
+				auditLog.SourceService,
+				auditLog.RequestID,
+				changesData,
+				auditLog.CreatedAt,
+				auditLog.UpdatedAt,
+				auditLog.ChainID,
+				auditLog.PrevHash,
+				auditLog.Hash,
+			)
+			if !slices.Contains(chainIDs, auditLog.ChainID) {
+				chainIDs = append(chainIDs, auditLog.ChainID)
+			}
+		}
+
+		query, args, err := builder.ToSql()
+		if err != nil {
+			return 0, fmt.Errorf("failed to build audit logs insert query: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to restore audit logs: %w", err)
+		}
+		restored += tag.RowsAffected()
+	}
+
+	if _, err := tx.Exec(ctx, deleteRestoredRunsQuery, chainIDs); err != nil {
+		return 0, fmt.Errorf("failed to delete restored pruned runs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return restored, nil
+}
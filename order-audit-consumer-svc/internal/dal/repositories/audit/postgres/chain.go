@@ -0,0 +1,375 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// createChainHeadsQuery creates missing chain heads, inserted in chain order like they are locked
+	createChainHeadsQuery = `insert into audit_chain_heads (chain_id)
+select chain_id from unnest($1::text[]) as chain_id
+order by chain_id
+on conflict (chain_id) do nothing`
+
+	// lockChainHeadsQuery locks chain heads in chain order so concurrent appends cannot deadlock
+	lockChainHeadsQuery = `select chain_id, last_audit_log_id, hash
+from audit_chain_heads
+where chain_id = any($1::text[])
+order by chain_id
+for update`
+
+	linkAuditLogsQuery = `update audit_log_order as a
+set prev_hash = v.prev_hash,
+    hash      = v.hash
+from unnest($1::bigint[], $2::text[], $3::text[]) as v(id, prev_hash, hash)
+where a.id = v.id`
+
+	updateChainHeadsQuery = `update audit_chain_heads as h
+set last_audit_log_id = v.last_audit_log_id,
+    hash              = v.hash,
+    updated_at        = now()
+from unnest($1::text[], $2::bigint[], $3::text[]) as v(chain_id, last_audit_log_id, hash)
+where h.chain_id = v.chain_id`
+)
+
+// appendAuditLogs inserts audit logs and appends them to their chains in ID order.
+// The heads of the chains stay locked until the transaction ends,
+// so audit logs of a chain are hashed in the order their IDs were assigned.
+func (r *AuditRepository) appendAuditLogs(ctx context.Context, tx pgx.Tx, auditLogs []models.AuditLogOrder) error {
+	if len(auditLogs) == 0 {
+		return nil
+	}
+
+	chained := make([]models.AuditLogOrder, 0, len(auditLogs))
+	chainIDs := make(map[string]struct{})
+	for _, auditLog := range auditLogs {
+		auditLog.ChainID = r.chainScope.ChainID(auditLog.CustomerID)
+		chainIDs[auditLog.ChainID] = struct{}{}
+		chained = append(chained, auditLog)
+	}
+
+	heads, err := lockChainHeads(ctx, tx, slices.Sorted(maps.Keys(chainIDs)))
+	if err != nil {
+		return err
+	}
+
+	inserted, err := r.insertAuditLogs(ctx, tx, chained)
+	if err != nil {
+		return err
+	}
+	if len(inserted) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(inserted, func(a, b models.AuditLogOrder) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	ids := make([]int64, 0, len(inserted))
+	prevHashes := make([]string, 0, len(inserted))
+	hashes := make([]string, 0, len(inserted))
+	for _, auditLog := range inserted {
+		head := heads[auditLog.ChainID]
+
+		linked, err := auditchain.Link(auditLog, head.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to link audit log %d: %w", auditLog.ID, err)
+		}
+		ids = append(ids, linked.ID)
+		prevHashes = append(prevHashes, linked.PrevHash)
+		hashes = append(hashes, linked.Hash)
+
+		head.LastAuditLogID = linked.ID
+		head.Hash = linked.Hash
+		heads[auditLog.ChainID] = head
+	}
+
+	if _, err := tx.Exec(ctx, linkAuditLogsQuery, ids, prevHashes, hashes); err != nil {
+		return fmt.Errorf("failed to link audit logs: %w", err)
+	}
+
+	var (
+		headChainIDs []string
+		headLastIDs  []int64
+		headHashes   []string
+	)
+	for _, head := range heads {
+		headChainIDs = append(headChainIDs, head.ChainID)
+		headLastIDs = append(headLastIDs, head.LastAuditLogID)
+		headHashes = append(headHashes, head.Hash)
+	}
+
+	if _, err := tx.Exec(ctx, updateChainHeadsQuery, headChainIDs, headLastIDs, headHashes); err != nil {
+		return fmt.Errorf("failed to update chain heads: %w", err)
+	}
+
+	return nil
+}
+
+// lockChainHeads creates the missing heads of the chains and locks all of them.
+func lockChainHeads(ctx context.Context, tx pgx.Tx, chainIDs []string) (map[string]auditchain.Head, error) {
+	if _, err := tx.Exec(ctx, createChainHeadsQuery, chainIDs); err != nil {
+		return nil, fmt.Errorf("failed to create chain heads: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, lockChainHeadsQuery, chainIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock chain heads: %w", err)
+	}
+	locked, err := pgx.CollectRows(rows, rowToChainHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan chain heads: %w", err)
+	}
+
+	heads := make(map[string]auditchain.Head, len(locked))
+	for _, head := range locked {
+		heads[head.ChainID] = head
+	}
+
+	return heads, nil
+}
+
+// ListChainHeads returns the heads of all chains ordered by chain.
+func (r *AuditRepository) ListChainHeads(ctx context.Context) ([]auditchain.Head, error) {
+	query, args, err := sq.Select("chain_id", "last_audit_log_id", "hash").
+		From("audit_chain_heads").
+		Where(sq.Gt{"last_audit_log_id": 0}).
+		OrderBy("chain_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryChainHeads(ctx, query, args...)
+}
+
+// GetChainHead returns the head of a chain.
+func (r *AuditRepository) GetChainHead(ctx context.Context, chainID string) (auditchain.Head, error) {
+	query, args, err := sq.Select("chain_id", "last_audit_log_id", "hash").
+		From("audit_chain_heads").
+		Where(sq.Eq{"chain_id": chainID}).
+		Where(sq.Gt{"last_audit_log_id": 0}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return auditchain.Head{}, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	head, err := scanChainHead(r.pgClient.Pool().QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auditchain.Head{}, auditchain.ErrChainNotFound
+	}
+	if err != nil {
+		return auditchain.Head{}, fmt.Errorf("failed to get chain head: %w", err)
+	}
+
+	return head, nil
+}
+
+// ListUncheckpointedHeads returns the heads of chains extended since their last checkpoint.
+func (r *AuditRepository) ListUncheckpointedHeads(ctx context.Context) ([]auditchain.Head, error) {
+	query, args, err := sq.Select("h.chain_id", "h.last_audit_log_id", "h.hash").
+		From("audit_chain_heads h").
+		Where("h.last_audit_log_id > coalesce((select max(c.last_audit_log_id) " +
+			"from audit_checkpoints c where c.chain_id = h.chain_id), 0)").
+		OrderBy("h.chain_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryChainHeads(ctx, query, args...)
+}
+
+// ListChainAuditLogs returns at most limit audit logs of a chain with IDs above afterID, in chain order.
+func (r *AuditRepository) ListChainAuditLogs(
+	ctx context.Context,
+	chainID string,
+	afterID int64,
+	limit int,
+) ([]models.AuditLogOrder, error) {
+	query, args, err := sq.Select(auditLogColumns()...).
+		From("audit_log_order").
+		Where(sq.Eq{"chain_id": chainID}).
+		Where(sq.Gt{"id": afterID}).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryAuditLogs(ctx, query, args...)
+}
+
+// SaveCheckpoints saves signed checkpoints and returns them with their IDs.
+func (r *AuditRepository) SaveCheckpoints(
+	ctx context.Context,
+	checkpoints []auditchain.Checkpoint,
+) ([]auditchain.Checkpoint, error) {
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+
+	builder := sq.Insert("audit_checkpoints").
+		Columns("chain_id", "last_audit_log_id", "hash", "key_id", "signature", "created_at").
+		Suffix("returning id").
+		PlaceholderFormat(sq.Dollar)
+	for _, checkpoint := range checkpoints {
+		builder = builder.Values(
+			checkpoint.ChainID,
+			checkpoint.LastAuditLogID,
+			checkpoint.Hash,
+			checkpoint.KeyID,
+			checkpoint.Signature,
+			checkpoint.CreatedAt,
+		)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build checkpoints insert query: %w", err)
+	}
+
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert checkpoints: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan checkpoint ids: %w", err)
+	}
+
+	saved := slices.Clone(checkpoints)
+	for i := range saved {
+		saved[i].ID = ids[i]
+	}
+
+	return saved, nil
+}
+
+// ListCheckpoints returns the checkpoints of a chain, of all chains for an empty chain ID, oldest first.
+func (r *AuditRepository) ListCheckpoints(ctx context.Context, chainID string) ([]auditchain.Checkpoint, error) {
+	builder := sq.Select("id", "chain_id", "last_audit_log_id", "hash", "key_id", "signature", "created_at").
+		From("audit_checkpoints").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+	if chainID != "" {
+		builder = builder.Where(sq.Eq{"chain_id": chainID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+
+	checkpoints, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (auditchain.Checkpoint, error) {
+		var checkpoint auditchain.Checkpoint
+		err := row.Scan(
+			&checkpoint.ID,
+			&checkpoint.ChainID,
+			&checkpoint.LastAuditLogID,
+			&checkpoint.Hash,
+			&checkpoint.KeyID,
+			&checkpoint.Signature,
+			&checkpoint.CreatedAt,
+		)
+
+		return checkpoint, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan checkpoints: %w", err)
+	}
+
+	return checkpoints, nil
+}
+
+// queryChainHeads runs a select of chain head columns and scans the rows.
+func (r *AuditRepository) queryChainHeads(ctx context.Context, query string, args ...any) ([]auditchain.Head, error) {
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chain heads: %w", err)
+	}
+
+	heads, err := pgx.CollectRows(rows, rowToChainHead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan chain heads: %w", err)
+	}
+
+	return heads, nil
+}
+
+// rowToChainHead scans a collected chain head row.
+func rowToChainHead(row pgx.CollectableRow) (auditchain.Head, error) {
+	return scanChainHead(row)
+}
+
+// scanChainHead scans a row of chain_id, last_audit_log_id and hash.
+func scanChainHead(row pgx.Row) (auditchain.Head, error) {
+	var head auditchain.Head
+	err := row.Scan(&head.ChainID, &head.LastAuditLogID, &head.Hash)
+
+	return head, err
+}
+
+// ListPrunedRuns returns the runs of audit logs of a chain removed by the retention, in chain order.
+func (r *AuditRepository) ListPrunedRuns(ctx context.Context, chainID string) ([]auditchain.PrunedRun, error) {
+	query, args, err := sq.Select(
+		"chain_id",
+		"first_audit_log_id",
+		"last_audit_log_id",
+		"prev_hash",
+		"hash",
+		"audit_logs",
+	).
+		From("audit_chain_pruned_runs").
+		Where(sq.Eq{"chain_id": chainID}).
+		OrderBy("first_audit_log_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pruned runs: %w", err)
+	}
+
+	runs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (auditchain.PrunedRun, error) {
+		var run auditchain.PrunedRun
+		err := row.Scan(
+			&run.ChainID,
+			&run.FirstAuditLogID,
+			&run.LastAuditLogID,
+			&run.PrevHash,
+			&run.Hash,
+			&run.AuditLogs,
+		)
+
+		return run, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pruned runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -2,65 +2,353 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
 	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/jackc/pgx/v5"
 )
 
+// insertChunkSize is the number of audit logs inserted by one statement.
+const insertChunkSize = 1000
+
 // AuditRepository implements the audit repository for PostgreSQL.
 type AuditRepository struct {
-	pgClient *postgres.Client
+	pgClient   *postgres.Client
+	chainScope auditchain.Scope
 }
 
-// NewAuditRepository creates a new audit repository.
-func NewAuditRepository(pgClient *postgres.Client) *AuditRepository {
+// NewAuditRepository creates a new audit repository linking saved audit logs into chains of the scope.
+func NewAuditRepository(pgClient *postgres.Client, chainScope auditchain.Scope) *AuditRepository {
 	return &AuditRepository{
-		pgClient: pgClient,
+		pgClient:   pgClient,
+		chainScope: chainScope,
 	}
 }
 
-// SaveAuditLogs saves audit log entries to the PostgreSQL database using squirrel bulk insert.
+// SaveAuditLogs saves audit log entries and the processed message in one transaction.
+// Audit logs that already exist for an order item status are skipped.
 func (r *AuditRepository) SaveAuditLogs(
 	ctx context.Context,
+	messageID string,
 	auditLogs []models.AuditLogOrder,
 ) error {
-	if len(auditLogs) == 0 {
-		return nil
+	saved, err := r.SaveAuditLogBatch(ctx, []models.AuditLogMessage{{MessageID: messageID, AuditLogs: auditLogs}})
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return models.ErrAlreadyProcessed
+	}
+
+	return nil
+}
+
+// SaveAuditLogBatch saves audit log entries of several messages and marks the messages processed
+// in one transaction. Audit logs of messages processed before are not inserted again.
+// Inserted audit logs are appended to their hash chains in the same transaction.
+func (r *AuditRepository) SaveAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.pgClient.Pool().Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	builder := sq.Insert("processed_messages").
+		Columns("message_id").
+		Suffix("on conflict (message_id) do nothing returning message_id").
+		PlaceholderFormat(sq.Dollar)
+	for _, msg := range messages {
+		builder = builder.Values(msg.MessageID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build processed messages insert query: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert processed messages: %w", err)
+	}
+	inserted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan processed messages: %w", err)
+	}
+
+	fresh := make(map[string]struct{}, len(inserted))
+	for _, id := range inserted {
+		fresh[id] = struct{}{}
+	}
+
+	var auditLogs []models.AuditLogOrder
+	for _, msg := range messages {
+		if _, ok := fresh[msg.MessageID]; !ok {
+			continue
+		}
+		// A message repeated within the batch is saved once
+		delete(fresh, msg.MessageID)
+		auditLogs = append(auditLogs, msg.AuditLogs...)
+	}
+
+	if err := r.appendAuditLogs(ctx, tx, auditLogs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(inserted), nil
+}
+
+// DeleteProcessedMessagesBefore forgets at most limit messages processed before the cutoff, oldest first.
+// Returns the number of forgotten messages.
+func (r *AuditRepository) DeleteProcessedMessagesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query, args, err := sq.Delete("processed_messages").
+		Where(sq.Expr(
+			"message_id in (select message_id from processed_messages where processed_at < ? order by processed_at limit ?)",
+			cutoff,
+			limit,
+		)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build processed messages delete query: %w", err)
+	}
+
+	tag, err := r.pgClient.Pool().Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed messages: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// insertAuditLogs bulk inserts audit log entries using squirrel,
+// in chunks that stay below the PostgreSQL bind parameter limit.
+// Returns the inserted audit logs, the ones already stored are skipped.
+func (r *AuditRepository) insertAuditLogs(
+	ctx context.Context,
+	tx pgx.Tx,
+	auditLogs []models.AuditLogOrder,
+) ([]models.AuditLogOrder, error) {
+	var inserted []models.AuditLogOrder
+	for chunk := range slices.Chunk(auditLogs, insertChunkSize) {
+		chunkInserted, err := r.insertAuditLogChunk(ctx, tx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, chunkInserted...)
 	}
 
+	return inserted, nil
+}
+
+// insertAuditLogChunk inserts audit log entries with a single multi-row insert.
+func (r *AuditRepository) insertAuditLogChunk(
+	ctx context.Context,
+	tx pgx.Tx,
+	auditLogs []models.AuditLogOrder,
+) ([]models.AuditLogOrder, error) {
 	builder := sq.Insert("audit_log_order").
 		Columns(
 			"order_id",
 			"order_item_id",
 			"customer_id",
 			"order_status",
+			"event_type",
+			"actor_id",
+			"actor_type",
+			"source_service",
+			"request_id",
+			"changes",
 			"created_at",
 			"updated_at",
+			"chain_id",
 		).
+		Suffix("on conflict (order_id, order_item_id, order_status, created_at) do nothing returning " +
+			strings.Join(auditLogColumns(), ", ")).
 		PlaceholderFormat(sq.Dollar)
 
 	for _, auditLog := range auditLogs {
+		changes := auditLog.Changes
+		if changes == nil {
+			changes = map[string]models.FieldChange{}
+		}
+		changesData, err := json.Marshal(changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal audit log changes: %w", err)
+		}
+
 		builder = builder.Values(
 			auditLog.OrderID,
 			auditLog.OrderItemID,
 			auditLog.CustomerID,
 			auditLog.OrderStatus,
+			auditLog.EventType,
+			auditLog.ActorID,
+			auditLog.ActorType,
+			auditLog.SourceService,
+			auditLog.RequestID,
+			changesData,
 			auditLog.CreatedAt,
 			auditLog.UpdatedAt,
+			auditLog.ChainID,
 		)
 	}
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to build audit logs insert query: %w", err)
+		return nil, fmt.Errorf("failed to build audit logs insert query: %w", err)
 	}
 
-	_, err = r.pgClient.Pool().Exec(ctx, query, args...)
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to bulk insert audit logs: %w", err)
+		return nil, fmt.Errorf("failed to bulk insert audit logs: %w", err)
 	}
 
-	return nil
+	return scanAuditLogs(rows)
+}
+
+// ListAuditLogs returns audit logs matching the filter newest first.
+// Pages continue after a cursor with a keyset condition, so the created_at index serves deep pages
+// and the order_id and customer_id indexes serve listings of one order or customer.
+func (r *AuditRepository) ListAuditLogs(
+	ctx context.Context,
+	model models.ListAuditLogsModel,
+) ([]models.AuditLogOrder, error) {
+	builder := sq.Select(auditLogColumns()...).
+		From("audit_log_order").
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if model.OrderID != 0 {
+		builder = builder.Where(sq.Eq{"order_id": model.OrderID})
+	}
+	if model.CustomerID != 0 {
+		builder = builder.Where(sq.Eq{"customer_id": model.CustomerID})
+	}
+	if model.OrderStatus != "" {
+		builder = builder.Where(sq.Eq{"order_status": model.OrderStatus})
+	}
+	if !model.CreatedFrom.IsZero() {
+		builder = builder.Where(sq.GtOrEq{"created_at": model.CreatedFrom})
+	}
+	if !model.CreatedTo.IsZero() {
+		builder = builder.Where(sq.Lt{"created_at": model.CreatedTo})
+	}
+	if model.After != nil {
+		builder = builder.Where(sq.Expr("(created_at, id) < (?, ?)", model.After.CreatedAt, model.After.ID))
+	}
+	if model.Limit > 0 {
+		builder = builder.Limit(uint64(model.Limit))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryAuditLogs(ctx, query, args...)
+}
+
+// GetOrderAuditLogs returns the audit logs of an order oldest first.
+func (r *AuditRepository) GetOrderAuditLogs(ctx context.Context, orderID int64) ([]models.AuditLogOrder, error) {
+	query, args, err := sq.Select(auditLogColumns()...).
+		From("audit_log_order").
+		Where(sq.Eq{"order_id": orderID}).
+		OrderBy("created_at", "id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return r.queryAuditLogs(ctx, query, args...)
+}
+
+// queryAuditLogs runs a select of audit log columns and scans the rows.
+func (r *AuditRepository) queryAuditLogs(ctx context.Context, query string, args ...any) ([]models.AuditLogOrder, error) {
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+
+	return scanAuditLogs(rows)
+}
+
+// scanAuditLogs scans and closes rows of audit log columns.
+func scanAuditLogs(rows pgx.Rows) ([]models.AuditLogOrder, error) {
+	defer rows.Close()
+
+	var auditLogs []models.AuditLogOrder
+	for rows.Next() {
+		var (
+			auditLog    models.AuditLogOrder
+			changesData []byte
+		)
+		if err := rows.Scan(
+			&auditLog.ID,
+			&auditLog.OrderID,
+			&auditLog.OrderItemID,
+			&auditLog.CustomerID,
+			&auditLog.OrderStatus,
+			&auditLog.EventType,
+			&auditLog.ActorID,
+			&auditLog.ActorType,
+			&auditLog.SourceService,
+			&auditLog.RequestID,
+			&changesData,
+			&auditLog.CreatedAt,
+			&auditLog.UpdatedAt,
+			&auditLog.ChainID,
+			&auditLog.PrevHash,
+			&auditLog.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := json.Unmarshal(changesData, &auditLog.Changes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit log changes: %w", err)
+		}
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return auditLogs, nil
+}
+
+// auditLogColumns returns the selected audit log columns in scan order.
+func auditLogColumns() []string {
+	return []string{
+		"id",
+		"order_id",
+		"order_item_id",
+		"customer_id",
+		"order_status",
+		"event_type",
+		"actor_id",
+		"actor_type",
+		"source_service",
+		"request_id",
+		"changes",
+		"created_at",
+		"updated_at",
+		"chain_id",
+		"prev_hash",
+		"hash",
+	}
 }
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+)
+
+// InboxRepository is an in-memory inbox repository for tests.
+type InboxRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages []inbox.InboxMessage
+}
+
+// NewInboxRepository creates a new in-memory inbox repository.
+func NewInboxRepository() *InboxRepository {
+	return &InboxRepository{}
+}
+
+// Insert adds a new message to the inbox.
+func (r *InboxRepository) Insert(_ context.Context, msg inbox.InboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	msg.ID = r.nextID
+	msg.Headers = maps.Clone(msg.Headers)
+	r.messages = append(r.messages, msg)
+
+	return nil
+}
+
+// GetPendingMessages returns messages that are ready for retry, oldest retry first.
+func (r *InboxRepository) GetPendingMessages(_ context.Context, limit int) ([]inbox.InboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var pending []inbox.InboxMessage
+	for _, msg := range r.messages {
+		if !msg.NextRetryAt.After(now) && msg.RetryCount < msg.MaxRetries {
+			pending = append(pending, msg)
+		}
+	}
+
+	slices.SortFunc(pending, compareNextRetry)
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+// List returns messages in the order they are retried, including the ones out of retries.
+func (r *InboxRepository) List(_ context.Context, limit int, offset int) ([]inbox.InboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := slices.Clone(r.messages)
+	slices.SortFunc(messages, compareNextRetry)
+
+	if offset >= len(messages) {
+		return nil, nil
+	}
+	messages = messages[offset:]
+	if limit > 0 && limit < len(messages) {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// Get returns a message by ID.
+func (r *InboxRepository) Get(_ context.Context, id int64) (inbox.InboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return inbox.InboxMessage{}, inbox.ErrInboxMessageNotFound
+	}
+
+	return r.messages[i], nil
+}
+
+// Delete removes a message from the inbox.
+func (r *InboxRepository) Delete(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return inbox.ErrInboxMessageNotFound
+	}
+	r.messages = slices.Delete(r.messages, i, i+1)
+
+	return nil
+}
+
+// UpdateRetry updates retry count and error information.
+func (r *InboxRepository) UpdateRetry(
+	_ context.Context,
+	id int64,
+	retryCount int,
+	lastError string,
+	nextRetryAt time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.messages {
+		if r.messages[i].ID == id {
+			r.messages[i].RetryCount = retryCount
+			r.messages[i].LastError = lastError
+			r.messages[i].NextRetryAt = nextRetryAt
+			r.messages[i].UpdatedAt = time.Now()
+		}
+	}
+
+	return nil
+}
+
+// Messages returns a snapshot of the inbox.
+func (r *InboxRepository) Messages() []inbox.InboxMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.messages)
+}
+
+// index returns the position of the message with the ID or -1. Must be called with the lock held.
+func (r *InboxRepository) index(id int64) int {
+	return slices.IndexFunc(r.messages, func(msg inbox.InboxMessage) bool {
+		return msg.ID == id
+	})
+}
+
+// compareNextRetry orders messages by their next retry, oldest first.
+func compareNextRetry(a, b inbox.InboxMessage) int {
+	if c := a.NextRetryAt.Compare(b.NextRetryAt); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(a.ID, b.ID)
+}
@@ -2,12 +2,15 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
 	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/jackc/pgx/v5"
 )
 
 // InboxRepository implements the inbox repository for PostgreSQL.
@@ -22,8 +25,61 @@ func NewInboxRepository(client *postgres.Client) *InboxRepository {
 	}
 }
 
+// inboxColumns returns the columns of the inbox table in scan order.
+func inboxColumns() []string {
+	return []string{
+		"id",
+		"message_id",
+		"queue_name",
+		"routing_key",
+		"payload",
+		"content_type",
+		"headers",
+		"retry_count",
+		"max_retries",
+		"last_error",
+		"created_at",
+		"updated_at",
+		"next_retry_at",
+		"delivery_tag",
+	}
+}
+
+// scanInboxMessage scans a row selected with inboxColumns.
+func scanInboxMessage(row pgx.Row) (inbox.InboxMessage, error) {
+	var msg inbox.InboxMessage
+	err := row.Scan(
+		&msg.ID,
+		&msg.MessageID,
+		&msg.QueueName,
+		&msg.RoutingKey,
+		&msg.Payload,
+		&msg.ContentType,
+		&msg.Headers,
+		&msg.RetryCount,
+		&msg.MaxRetries,
+		&msg.LastError,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+		&msg.NextRetryAt,
+		&msg.DeliveryTag,
+	)
+
+	return msg, err
+}
+
 // Insert adds a new message to the inbox.
 func (r *InboxRepository) Insert(ctx context.Context, msg inbox.InboxMessage) error {
+	headers := msg.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	headersData, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+
 	query, args, err := sq.Insert("inbox").
 		Columns(
 			"message_id",
@@ -31,6 +87,7 @@ func (r *InboxRepository) Insert(ctx context.Context, msg inbox.InboxMessage) er
 			"routing_key",
 			"payload",
 			"content_type",
+			"headers",
 			"retry_count",
 			"max_retries",
 			"last_error",
@@ -45,6 +102,7 @@ func (r *InboxRepository) Insert(ctx context.Context, msg inbox.InboxMessage) er
 			msg.RoutingKey,
 			msg.Payload,
 			msg.ContentType,
+			headersData,
 			msg.RetryCount,
 			msg.MaxRetries,
 			msg.LastError,
@@ -72,21 +130,7 @@ func (r *InboxRepository) GetPendingMessages(
 	ctx context.Context,
 	limit int,
 ) ([]inbox.InboxMessage, error) {
-	query, args, err := sq.Select(
-		"id",
-		"message_id",
-		"queue_name",
-		"routing_key",
-		"payload",
-		"content_type",
-		"retry_count",
-		"max_retries",
-		"last_error",
-		"created_at",
-		"updated_at",
-		"next_retry_at",
-		"delivery_tag",
-	).
+	query, args, err := sq.Select(inboxColumns()...).
 		From("inbox").
 		Where(sq.LtOrEq{"next_retry_at": time.Now()}).
 		Where(sq.Expr("retry_count < max_retries")).
@@ -106,22 +150,53 @@ func (r *InboxRepository) GetPendingMessages(
 
 	var messages []inbox.InboxMessage
 	for rows.Next() {
-		var msg inbox.InboxMessage
-		err := rows.Scan(
-			&msg.ID,
-			&msg.MessageID,
-			&msg.QueueName,
-			&msg.RoutingKey,
-			&msg.Payload,
-			&msg.ContentType,
-			&msg.RetryCount,
-			&msg.MaxRetries,
-			&msg.LastError,
-			&msg.CreatedAt,
-			&msg.UpdatedAt,
-			&msg.NextRetryAt,
-			&msg.DeliveryTag,
-		)
+		msg, err := scanInboxMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inbox message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inbox messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// List retrieves messages in the order they are retried, including the ones out of retries.
+func (r *InboxRepository) List(
+	ctx context.Context,
+	limit int,
+	offset int,
+) ([]inbox.InboxMessage, error) {
+	builder := sq.Select(inboxColumns()...).
+		From("inbox").
+		OrderBy("next_retry_at ASC", "id ASC").
+		PlaceholderFormat(sq.Dollar)
+
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+
+	if offset > 0 {
+		builder = builder.Offset(uint64(offset))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.client.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []inbox.InboxMessage
+	for rows.Next() {
+		msg, err := scanInboxMessage(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan inbox message: %w", err)
 		}
@@ -135,6 +210,28 @@ func (r *InboxRepository) GetPendingMessages(
 	return messages, nil
 }
 
+// Get retrieves a message by ID.
+func (r *InboxRepository) Get(ctx context.Context, id int64) (inbox.InboxMessage, error) {
+	query, args, err := sq.Select(inboxColumns()...).
+		From("inbox").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return inbox.InboxMessage{}, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	msg, err := scanInboxMessage(r.client.Pool().QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return inbox.InboxMessage{}, inbox.ErrInboxMessageNotFound
+	}
+	if err != nil {
+		return inbox.InboxMessage{}, fmt.Errorf("failed to get inbox message: %w", err)
+	}
+
+	return msg, nil
+}
+
 // Delete removes a message from the inbox after successful processing.
 func (r *InboxRepository) Delete(ctx context.Context, id int64) error {
 	query, args, err := sq.Delete("inbox").
@@ -145,10 +242,13 @@ func (r *InboxRepository) Delete(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to build delete query: %w", err)
 	}
 
-	_, err = r.client.Pool().Exec(ctx, query, args...)
+	tag, err := r.client.Pool().Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete inbox message: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return inbox.ErrInboxMessageNotFound
+	}
 
 	return nil
 }
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+)
+
+// PartitionRepository is an in-memory partition repository for tests.
+// Removing a partition prunes the audit logs of its month from the audit repository.
+type PartitionRepository struct {
+	mu         sync.Mutex
+	audit      *auditmemory.AuditRepository
+	partitions []partition.Partition
+	detached   []partition.Partition
+}
+
+// NewPartitionRepository creates a new in-memory partition repository over the audit logs of the repository.
+func NewPartitionRepository(audit *auditmemory.AuditRepository, partitions ...partition.Partition) *PartitionRepository {
+	return &PartitionRepository{
+		audit:      audit,
+		partitions: partitions,
+	}
+}
+
+// ListPartitions returns the attached partitions, oldest first.
+func (r *PartitionRepository) ListPartitions(_ context.Context) ([]partition.Partition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	partitions := slices.Clone(r.partitions)
+	slices.SortFunc(partitions, func(a, b partition.Partition) int {
+		return a.Month.Compare(b.Month)
+	})
+
+	return partitions, nil
+}
+
+// CreatePartition attaches the partition of a month.
+func (r *PartitionRepository) CreatePartition(_ context.Context, p partition.Partition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.ContainsFunc(r.partitions, p.Equal) {
+		return fmt.Errorf("partition %s already exists", p.Name())
+	}
+	r.partitions = append(r.partitions, p)
+
+	return nil
+}
+
+// RemovePartition removes the partition of a month and prunes its audit logs.
+func (r *PartitionRepository) RemovePartition(
+	ctx context.Context,
+	p partition.Partition,
+	action partition.RetentionAction,
+	archive partition.ArchiveFunc,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.partitions, p.Equal)
+	if i < 0 {
+		return fmt.Errorf("partition %s does not exist", p.Name())
+	}
+	if archive != nil {
+		if err := r.checkArchived(ctx, p.From(), p.To(), archive); err != nil {
+			return err
+		}
+	}
+
+	r.partitions = slices.Delete(r.partitions, i, i+1)
+	if action == partition.RetentionDetach {
+		r.detached = append(r.detached, p)
+	}
+
+	r.audit.PruneAuditLogs(p.From(), p.To())
+
+	return nil
+}
+
+// PruneDefaultPartition prunes audit logs created before the time outside of the attached partitions.
+func (r *PartitionRepository) PruneDefaultPartition(
+	ctx context.Context,
+	before time.Time,
+	archive partition.ArchiveFunc,
+) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Attached partitions older than the retention were removed first, older audit logs are in the default one
+	from := before
+	for _, p := range r.partitions {
+		if p.From().Before(from) {
+			from = p.From()
+		}
+	}
+
+	if r.extent(time.Time{}, from).Rows == 0 {
+		return 0, nil
+	}
+	if archive != nil {
+		if err := r.checkArchived(ctx, time.Time{}, from, archive); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.audit.PruneAuditLogs(time.Time{}, from), nil
+}
+
+// extent returns the extent of the audit logs created in [from, to).
+func (r *PartitionRepository) extent(from, to time.Time) partition.Extent {
+	var e partition.Extent
+	for _, auditLog := range r.audit.AuditLogs() {
+		if auditLog.CreatedAt.Before(from) || !auditLog.CreatedAt.Before(to) {
+			continue
+		}
+		e.Rows++
+		e.LastAuditLogID = max(e.LastAuditLogID, auditLog.ID)
+	}
+
+	return e
+}
+
+// checkArchived archives the audit logs created in [from, to) and checks the archive holds all of them.
+func (r *PartitionRepository) checkArchived(
+	ctx context.Context,
+	from, to time.Time,
+	archive partition.ArchiveFunc,
+) error {
+	archived, err := archive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to archive audit logs: %w", err)
+	}
+	if stored := r.extent(from, to); archived != stored {
+		return fmt.Errorf("%w: %d audit logs up to %d archived, %d up to %d stored",
+			partition.ErrNotArchived, archived.Rows, archived.LastAuditLogID, stored.Rows, stored.LastAuditLogID)
+	}
+
+	return nil
+}
+
+// Detached returns the partitions detached for archiving.
+func (r *PartitionRepository) Detached() []partition.Partition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.detached)
+}
@@ -0,0 +1,291 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultPartition is the partition of audit logs of months without their own partition.
+const defaultPartition = "audit_log_order_default"
+
+// pruneChainLinksQuery keeps the chain links of the audit logs of a partition created before $1
+// as pruned runs. Pruned audit logs of a chain form one run up to the next audit log that stays
+// or the next run pruned before, whichever comes first.
+const pruneChainLinksQuery = `insert into audit_chain_pruned_runs
+    (chain_id, first_audit_log_id, last_audit_log_id, prev_hash, hash, audit_logs)
+select chain_id,
+       min(id),
+       max(id),
+       (array_agg(prev_hash order by id))[1],
+       (array_agg(hash order by id desc))[1],
+       count(*)
+from (select p.chain_id,
+             p.id,
+             p.prev_hash,
+             p.hash,
+             least(
+                 (select min(a.id)
+                  from audit_log_order a
+                  where a.chain_id = p.chain_id
+                    and a.id > p.id
+                    and (a.tableoid <> p.tableoid or a.created_at >= $1)),
+                 (select min(r.first_audit_log_id)
+                  from audit_chain_pruned_runs r
+                  where r.chain_id = p.chain_id
+                    and r.first_audit_log_id > p.id)
+             ) as boundary_id
+      from %s p
+      where p.chain_id <> ''
+        and p.created_at < $1) pruned
+group by chain_id, boundary_id`
+
+// PartitionRepository implements the partition repository for PostgreSQL.
+type PartitionRepository struct {
+	pgClient *postgres.Client
+}
+
+// NewPartitionRepository creates a new partition repository.
+func NewPartitionRepository(pgClient *postgres.Client) *PartitionRepository {
+	return &PartitionRepository{
+		pgClient: pgClient,
+	}
+}
+
+// ListPartitions returns the attached monthly partitions of audit_log_order, oldest first.
+func (r *PartitionRepository) ListPartitions(ctx context.Context) ([]partition.Partition, error) {
+	query, args, err := sq.Select("c.relname").
+		From("pg_inherits i").
+		Join("pg_class c on c.oid = i.inhrelid").
+		Where("i.inhparent = 'audit_log_order'::regclass").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.pgClient.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query partitions: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan partitions: %w", err)
+	}
+
+	var partitions []partition.Partition
+	for _, name := range names {
+		// The default partition and partitions attached by hand are not monthly partitions
+		if p, ok := partition.ParseName(name); ok {
+			partitions = append(partitions, p)
+		}
+	}
+	slices.SortFunc(partitions, func(a, b partition.Partition) int {
+		return a.Month.Compare(b.Month)
+	})
+
+	return partitions, nil
+}
+
+// CreatePartition creates the partition of a month.
+// Audit logs of the month in the default partition are moved to the new partition before it is attached,
+// attaching fails while the default partition holds rows of its range.
+func (r *PartitionRepository) CreatePartition(ctx context.Context, p partition.Partition) error {
+	tx, err := r.pgClient.Pool().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	name := pgx.Identifier{p.Name()}.Sanitize()
+
+	createQuery := fmt.Sprintf(
+		"create table %s (like audit_log_order including defaults including constraints)",
+		name,
+	)
+	if _, err := tx.Exec(ctx, createQuery); err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", p.Name(), err)
+	}
+
+	moveQuery := fmt.Sprintf(
+		`with moved as (delete from %s where created_at >= $1 and created_at < $2 returning *)
+insert into %s select * from moved`,
+		defaultPartition,
+		name,
+	)
+	if _, err := tx.Exec(ctx, moveQuery, p.From(), p.To()); err != nil {
+		return fmt.Errorf("failed to move audit logs to partition %s: %w", p.Name(), err)
+	}
+
+	// Partition bounds cannot be bind parameters
+	attachQuery := fmt.Sprintf(
+		"alter table audit_log_order attach partition %s for values from ('%s') to ('%s')",
+		name,
+		p.From().Format(time.RFC3339),
+		p.To().Format(time.RFC3339),
+	)
+	if _, err := tx.Exec(ctx, attachQuery); err != nil {
+		return fmt.Errorf("failed to attach partition %s: %w", p.Name(), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemovePartition detaches or drops a partition, keeping the chain links of its audit logs.
+// A detached partition stays as a standalone table with the same name.
+// Audit log writes wait while the partition is archived, so no audit log is removed without being archived.
+//
+// Removing a partition needs an access exclusive lock on audit_log_order. Queries lock audit_log_order
+// before its partitions, so the partition is locked through audit_log_order in the same order.
+// Locking the partition first would deadlock with a query holding audit_log_order and waiting for it.
+func (r *PartitionRepository) RemovePartition(
+	ctx context.Context,
+	p partition.Partition,
+	action partition.RetentionAction,
+	archive partition.ArchiveFunc,
+) error {
+	tx, err := r.pgClient.Pool().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	name := pgx.Identifier{p.Name()}.Sanitize()
+
+	// Reads go on while the partition is archived, writes wait until it is removed.
+	// The lock is taken on all partitions, so it covers audit logs written to the partition
+	if _, err := tx.Exec(ctx, "lock table audit_log_order in exclusive mode"); err != nil {
+		return fmt.Errorf("failed to lock audit_log_order: %w", err)
+	}
+
+	if archive != nil {
+		stored, err := extent(ctx, tx, sq.Select("count(*)", "coalesce(max(id), 0)").From(name))
+		if err != nil {
+			return fmt.Errorf("failed to count audit logs of partition %s: %w", p.Name(), err)
+		}
+		if err := checkArchived(ctx, stored, archive); err != nil {
+			return err
+		}
+	}
+
+	// Reads started while the partition was archived finish before it is removed
+	if _, err := tx.Exec(ctx, "lock table audit_log_order in access exclusive mode"); err != nil {
+		return fmt.Errorf("failed to lock audit_log_order: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(pruneChainLinksQuery, name), p.To()); err != nil {
+		return fmt.Errorf("failed to prune chain links of partition %s: %w", p.Name(), err)
+	}
+
+	removeQuery := fmt.Sprintf("drop table %s", name)
+	if action == partition.RetentionDetach {
+		removeQuery = fmt.Sprintf("alter table audit_log_order detach partition %s", name)
+	}
+	if _, err := tx.Exec(ctx, removeQuery); err != nil {
+		return fmt.Errorf("failed to %s partition %s: %w", action, p.Name(), err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PruneDefaultPartition deletes audit logs created before the time from the default partition,
+// keeping their chain links. Returns the number of deleted audit logs.
+// Writes to the default partition wait while it is archived, a run finding nothing to delete archives nothing.
+func (r *PartitionRepository) PruneDefaultPartition(
+	ctx context.Context,
+	before time.Time,
+	archive partition.ArchiveFunc,
+) (int64, error) {
+	tx, err := r.pgClient.Pool().Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Reads go on, writes wait until the pruned audit logs are deleted
+	if _, err := tx.Exec(ctx, "lock table "+defaultPartition+" in exclusive mode"); err != nil {
+		return 0, fmt.Errorf("failed to lock default partition: %w", err)
+	}
+
+	stored, err := extent(ctx, tx, sq.Select("count(*)", "coalesce(max(id), 0)").
+		From(defaultPartition).
+		Where(sq.Lt{"created_at": before}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit logs of default partition: %w", err)
+	}
+	if stored.Rows == 0 {
+		// Pruned by an earlier run
+		return 0, nil
+	}
+	if archive != nil {
+		if err := checkArchived(ctx, stored, archive); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(pruneChainLinksQuery, defaultPartition), before); err != nil {
+		return 0, fmt.Errorf("failed to prune chain links of default partition: %w", err)
+	}
+
+	query, args, err := sq.Delete(defaultPartition).
+		Where(sq.Lt{"created_at": before}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs from default partition: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// extent returns the number of audit logs and the last audit log ID selected by the count query.
+func extent(ctx context.Context, tx pgx.Tx, countQuery sq.SelectBuilder) (partition.Extent, error) {
+	query, args, err := countQuery.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return partition.Extent{}, fmt.Errorf("failed to build count query: %w", err)
+	}
+
+	var e partition.Extent
+	if err := tx.QueryRow(ctx, query, args...).Scan(&e.Rows, &e.LastAuditLogID); err != nil {
+		return partition.Extent{}, err
+	}
+
+	return e, nil
+}
+
+// checkArchived archives the stored audit logs and checks the archive holds all of them.
+func checkArchived(ctx context.Context, stored partition.Extent, archive partition.ArchiveFunc) error {
+	archived, err := archive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to archive audit logs: %w", err)
+	}
+	if archived != stored {
+		return fmt.Errorf("%w: %d audit logs up to %d archived, %d up to %d stored",
+			partition.ErrNotArchived, archived.Rows, archived.LastAuditLogID, stored.Rows, stored.LastAuditLogID)
+	}
+
+	return nil
+}
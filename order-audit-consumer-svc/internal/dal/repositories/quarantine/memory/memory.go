@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+)
+
+// QuarantineRepository is an in-memory quarantine repository for tests.
+type QuarantineRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages []quarantine.QuarantinedMessage
+	err      error
+}
+
+// NewQuarantineRepository creates a new in-memory quarantine repository.
+func NewQuarantineRepository() *QuarantineRepository {
+	return &QuarantineRepository{}
+}
+
+// Insert adds a poison message to the quarantine.
+func (r *QuarantineRepository) Insert(_ context.Context, msg quarantine.QuarantinedMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.nextID++
+	msg.ID = r.nextID
+	msg.Payload = slices.Clone(msg.Payload)
+	msg.Headers = maps.Clone(msg.Headers)
+	r.messages = append(r.messages, msg)
+
+	return nil
+}
+
+// List returns quarantined messages, most recent first.
+func (r *QuarantineRepository) List(_ context.Context, limit int, offset int) ([]quarantine.QuarantinedMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := slices.Clone(r.messages)
+	slices.Reverse(messages)
+
+	if offset >= len(messages) {
+		return nil, nil
+	}
+	messages = messages[offset:]
+	if limit > 0 && limit < len(messages) {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// Get returns a quarantined message by ID.
+func (r *QuarantineRepository) Get(_ context.Context, id int64) (quarantine.QuarantinedMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return quarantine.QuarantinedMessage{}, quarantine.ErrQuarantinedMessageNotFound
+	}
+
+	return r.messages[i], nil
+}
+
+// Update replaces the payload and content type of a quarantined message, empty fields are kept.
+func (r *QuarantineRepository) Update(_ context.Context, model quarantine.EditQuarantinedMessageModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(model.ID)
+	if i < 0 {
+		return quarantine.ErrQuarantinedMessageNotFound
+	}
+
+	if model.Payload != nil {
+		r.messages[i].Payload = slices.Clone(model.Payload)
+	}
+	if model.ContentType != "" {
+		r.messages[i].ContentType = model.ContentType
+	}
+	r.messages[i].UpdatedAt = time.Now()
+
+	return nil
+}
+
+// Delete removes a quarantined message.
+func (r *QuarantineRepository) Delete(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return quarantine.ErrQuarantinedMessageNotFound
+	}
+	r.messages = slices.Delete(r.messages, i, i+1)
+
+	return nil
+}
+
+// SetError makes every following insert fail with err, nil restores inserting.
+func (r *QuarantineRepository) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+}
+
+// Messages returns a snapshot of quarantined messages in insertion order.
+func (r *QuarantineRepository) Messages() []quarantine.QuarantinedMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.messages)
+}
+
+// index returns the position of the message with the ID or -1. Must be called with the lock held.
+func (r *QuarantineRepository) index(id int64) int {
+	return slices.IndexFunc(r.messages, func(msg quarantine.QuarantinedMessage) bool {
+		return msg.ID == id
+	})
+}
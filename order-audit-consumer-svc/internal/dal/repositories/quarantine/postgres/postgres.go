@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/consumer/internal/dal/postgres"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/jackc/pgx/v5"
+)
+
+// QuarantineRepository implements the quarantine repository for PostgreSQL.
+type QuarantineRepository struct {
+	client *postgres.Client
+}
+
+// NewQuarantineRepository creates a new quarantine repository.
+func NewQuarantineRepository(client *postgres.Client) *QuarantineRepository {
+	return &QuarantineRepository{
+		client: client,
+	}
+}
+
+// quarantineColumns returns the columns of the quarantine table in scan order.
+func quarantineColumns() []string {
+	return []string{
+		"id",
+		"message_id",
+		"queue_name",
+		"routing_key",
+		"payload",
+		"content_type",
+		"headers",
+		"error",
+		"source",
+		"created_at",
+		"updated_at",
+	}
+}
+
+// scanQuarantinedMessage scans a row selected with quarantineColumns.
+func scanQuarantinedMessage(row pgx.Row) (quarantine.QuarantinedMessage, error) {
+	var msg quarantine.QuarantinedMessage
+	err := row.Scan(
+		&msg.ID,
+		&msg.MessageID,
+		&msg.QueueName,
+		&msg.RoutingKey,
+		&msg.Payload,
+		&msg.ContentType,
+		&msg.Headers,
+		&msg.Error,
+		&msg.Source,
+		&msg.CreatedAt,
+		&msg.UpdatedAt,
+	)
+
+	return msg, err
+}
+
+// Insert adds a poison message to the quarantine.
+func (r *QuarantineRepository) Insert(ctx context.Context, msg quarantine.QuarantinedMessage) error {
+	headers := msg.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	headersData, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+
+	payload := msg.Payload
+	if payload == nil {
+		payload = []byte{}
+	}
+
+	query, args, err := sq.Insert("quarantine").
+		Columns(
+			"message_id",
+			"queue_name",
+			"routing_key",
+			"payload",
+			"content_type",
+			"headers",
+			"error",
+			"source",
+			"created_at",
+			"updated_at",
+		).
+		Values(
+			msg.MessageID,
+			msg.QueueName,
+			msg.RoutingKey,
+			payload,
+			msg.ContentType,
+			headersData,
+			msg.Error,
+			msg.Source,
+			msg.CreatedAt,
+			msg.UpdatedAt,
+		).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	_, err = r.client.Pool().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert quarantined message: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves quarantined messages, most recent first.
+func (r *QuarantineRepository) List(
+	ctx context.Context,
+	limit int,
+	offset int,
+) ([]quarantine.QuarantinedMessage, error) {
+	builder := sq.Select(quarantineColumns()...).
+		From("quarantine").
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+
+	if offset > 0 {
+		builder = builder.Offset(uint64(offset))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.client.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []quarantine.QuarantinedMessage
+	for rows.Next() {
+		msg, err := scanQuarantinedMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating quarantined messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// Get retrieves a quarantined message by ID.
+func (r *QuarantineRepository) Get(ctx context.Context, id int64) (quarantine.QuarantinedMessage, error) {
+	query, args, err := sq.Select(quarantineColumns()...).
+		From("quarantine").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return quarantine.QuarantinedMessage{}, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	msg, err := scanQuarantinedMessage(r.client.Pool().QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return quarantine.QuarantinedMessage{}, quarantine.ErrQuarantinedMessageNotFound
+	}
+	if err != nil {
+		return quarantine.QuarantinedMessage{}, fmt.Errorf("failed to get quarantined message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// Update replaces the payload and content type of a quarantined message, empty fields are kept.
+func (r *QuarantineRepository) Update(ctx context.Context, model quarantine.EditQuarantinedMessageModel) error {
+	builder := sq.Update("quarantine").
+		Set("updated_at", time.Now()).
+		Where(sq.Eq{"id": model.ID}).
+		PlaceholderFormat(sq.Dollar)
+
+	if model.Payload != nil {
+		builder = builder.Set("payload", model.Payload)
+	}
+
+	if model.ContentType != "" {
+		builder = builder.Set("content_type", model.ContentType)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	tag, err := r.client.Pool().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update quarantined message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quarantine.ErrQuarantinedMessageNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a quarantined message.
+func (r *QuarantineRepository) Delete(ctx context.Context, id int64) error {
+	query, args, err := sq.Delete("quarantine").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	tag, err := r.client.Pool().Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete quarantined message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quarantine.ErrQuarantinedMessageNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "audit_consumer"
+
+// Outcomes of consumed messages.
+const (
+	OutcomeProcessed   = "processed"
+	OutcomeDuplicate   = "duplicate"
+	OutcomeRetried     = "retried"
+	OutcomeQuarantined = "quarantined"
+	OutcomeRequeued    = "requeued"
+)
+
+// ConsumedMessages counts messages received from the broker by queue and outcome.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var ConsumedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "messages_total",
+	Help:      "Number of messages received from the broker by outcome: processed, duplicate, retried, quarantined or requeued.",
+}, []string{"queue", "outcome"})
+
+// ConsumerRunning reports whether the consumer receives messages: 1 running, 0 stopped.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var ConsumerRunning = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "running",
+	Help:      "Whether the consumer receives messages: 1 running, 0 stopped.",
+})
+
+// UnackedMessages reports the deliveries the consumer received and has not settled yet, by queue.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var UnackedMessages = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "unacked_messages",
+	Help:      "Number of deliveries received by the consumer and not yet acknowledged or rejected.",
+}, []string{"queue"})
+
+// InboxRetries counts retries of inbox messages by outcome: processed, quarantined or rescheduled.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var InboxRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "inbox",
+	Name:      "retries_total",
+	Help:      "Number of inbox message retries by outcome: processed, quarantined or rescheduled.",
+}, []string{"outcome"})
+
+// queueDepthTimeout bounds inspecting the queues of a scrape.
+const queueDepthTimeout = 2 * time.Second
+
+// queueDepthCollector reports the ready messages of the subscribed queues, inspected at scrape time.
+type queueDepthCollector struct {
+	inspector broker.QueueInspector
+	queues    []string
+	group     string
+	desc      *prometheus.Desc
+}
+
+// NewQueueDepthCollector creates a collector of the messages waiting in the queues for the consumer group.
+// Queues failing to be inspected are left out of the scrape.
+func NewQueueDepthCollector(inspector broker.QueueInspector, queues []string, group string) prometheus.Collector {
+	return &queueDepthCollector{
+		inspector: inspector,
+		queues:    queues,
+		group:     group,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "queue", "ready_messages"),
+			"Number of messages waiting in the queue for delivery to the consumer.",
+			[]string{"queue"},
+			nil,
+		),
+	}
+}
+
+// Describe sends the descriptor of the queue depth metric.
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect inspects the queues and sends their depth.
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueDepthTimeout)
+	defer cancel()
+
+	for _, queue := range c.queues {
+		depth, err := c.inspector.QueueDepth(ctx, queue, c.group)
+		if err != nil {
+			slog.Warn("Failed to inspect queue depth", "queue", queue, "error", err)
+
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(depth), queue)
+	}
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Audit log statuses of events that do not carry a status.
+const (
+	StatusCreated     = "created"
+	StatusCancelled   = "cancelled"
+	StatusItemUpdated = "item_updated"
+)
+
+// ErrUnhandledEvent is returned for events no handler is registered for.
+var ErrUnhandledEvent = errors.New("no handler for event")
+
+// Event is a decoded event with the audit logs it produces.
+type Event struct {
+	// ID deduplicates redeliveries, empty for legacy events without an ID
+	ID        string
+	OrderID   int64
+	AuditLogs []models.AuditLogOrder
+}
+
+// Handler decodes a message body and converts the event into audit logs.
+type Handler func(contentType string, body []byte) (Event, error)
+
+// Registry dispatches messages to handlers by event type.
+// Messages without a type are dispatched by the queue they were received from.
+type Registry struct {
+	byType  map[string]Handler
+	byTopic map[string]Handler
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		byType:  make(map[string]Handler),
+		byTopic: make(map[string]Handler),
+	}
+}
+
+// NewOrderRegistry creates a Registry with handlers for all order events.
+func NewOrderRegistry() *Registry {
+	r := NewRegistry()
+	r.Handle(events.TypeOrderCreated, HandleOrderCreated)
+	r.Handle(events.TypeOrderStatusChanged, HandleOrderStatusChanged)
+	r.Handle(events.TypeOrderCancelled, HandleOrderCancelled)
+	r.Handle(events.TypeOrderItemUpdated, HandleOrderItemUpdated)
+
+	return r
+}
+
+// Handle registers the handler of an event type.
+func (r *Registry) Handle(eventType string, handler Handler) {
+	r.byType[eventType] = handler
+}
+
+// HandleTopic makes messages without a type received from the topic
+// be handled as events of the given type.
+func (r *Registry) HandleTopic(topic string, eventType string) error {
+	handler, ok := r.byType[eventType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnhandledEvent, eventType)
+	}
+	r.byTopic[topic] = handler
+
+	return nil
+}
+
+// Decode finds the handler of the message and decodes it.
+func (r *Registry) Decode(msg broker.Message) (Event, error) {
+	if msg.Type != "" {
+		handler, ok := r.byType[msg.Type]
+		if !ok {
+			return Event{}, fmt.Errorf("%w: type %s", ErrUnhandledEvent, msg.Type)
+		}
+
+		return handler(msg.ContentType, msg.Body)
+	}
+
+	handler, ok := r.byTopic[msg.Topic]
+	if !ok {
+		return Event{}, fmt.Errorf("%w: untyped message from %s", ErrUnhandledEvent, msg.Topic)
+	}
+
+	return handler(msg.ContentType, msg.Body)
+}
+
+// HandleOrderCreated produces a created audit log for every item of the order.
+// Every audit log records the order fields and the fields of its item set by the creation.
+func HandleOrderCreated(contentType string, body []byte) (Event, error) {
+	event, err := events.DecodeOrderCreated(contentType, body)
+	if err != nil {
+		return Event{}, err
+	}
+
+	ord := event.GetOrder()
+	base := newAuditLog(
+		events.TypeOrderCreated,
+		event.GetContext(),
+		ord.GetCustomerId(),
+		occurredAt(event.GetOccurredAt()),
+		changes(event.GetChanges()),
+	)
+
+	auditLogs := make([]models.AuditLogOrder, 0, len(ord.GetOrderItems()))
+	for _, item := range ord.GetOrderItems() {
+		entry := base.forItem(ord.GetId(), item.GetId(), StatusCreated)
+		maps.Copy(entry.Changes, createdItemChanges(item))
+		auditLogs = append(auditLogs, entry)
+	}
+
+	return Event{
+		ID:        event.GetEventId(),
+		OrderID:   ord.GetId(),
+		AuditLogs: auditLogs,
+	}, nil
+}
+
+// HandleOrderStatusChanged produces an audit log with the new status for every item of the order.
+func HandleOrderStatusChanged(contentType string, body []byte) (Event, error) {
+	event, err := events.DecodeOrderStatusChanged(contentType, body)
+	if err != nil {
+		return Event{}, err
+	}
+	if event.GetNewStatus() == "" {
+		return Event{}, errors.New("status changed event without new status")
+	}
+
+	fieldChanges := changes(event.GetChanges())
+	if _, ok := fieldChanges["status"]; !ok {
+		fieldChanges["status"] = models.FieldChange{Before: nullable(event.GetOldStatus()), After: event.GetNewStatus()}
+	}
+
+	base := newAuditLog(
+		events.TypeOrderStatusChanged,
+		event.GetContext(),
+		event.GetCustomerId(),
+		occurredAt(event.GetOccurredAt()),
+		fieldChanges,
+	)
+
+	return Event{
+		ID:        event.GetEventId(),
+		OrderID:   event.GetOrderId(),
+		AuditLogs: base.forItems(event.GetOrderId(), event.GetOrderItemIds(), event.GetNewStatus()),
+	}, nil
+}
+
+// HandleOrderCancelled produces a cancelled audit log for every item of the order.
+func HandleOrderCancelled(contentType string, body []byte) (Event, error) {
+	event, err := events.DecodeOrderCancelled(contentType, body)
+	if err != nil {
+		return Event{}, err
+	}
+
+	base := newAuditLog(
+		events.TypeOrderCancelled,
+		event.GetContext(),
+		event.GetCustomerId(),
+		occurredAt(event.GetOccurredAt()),
+		changes(event.GetChanges()),
+	)
+
+	return Event{
+		ID:        event.GetEventId(),
+		OrderID:   event.GetOrderId(),
+		AuditLogs: base.forItems(event.GetOrderId(), event.GetOrderItemIds(), StatusCancelled),
+	}, nil
+}
+
+// HandleOrderItemUpdated produces an item updated audit log for the changed item.
+func HandleOrderItemUpdated(contentType string, body []byte) (Event, error) {
+	event, err := events.DecodeOrderItemUpdated(contentType, body)
+	if err != nil {
+		return Event{}, err
+	}
+	if event.GetItem() == nil {
+		return Event{}, errors.New("item updated event without item")
+	}
+
+	base := newAuditLog(
+		events.TypeOrderItemUpdated,
+		event.GetContext(),
+		event.GetCustomerId(),
+		occurredAt(event.GetOccurredAt()),
+		changes(event.GetChanges()),
+	)
+
+	return Event{
+		ID:      event.GetEventId(),
+		OrderID: event.GetOrderId(),
+		AuditLogs: []models.AuditLogOrder{
+			base.forItem(event.GetOrderId(), event.GetItem().GetId(), StatusItemUpdated),
+		},
+	}, nil
+}
+
+// auditLog is the part of an audit log shared by all audit logs of an event.
+type auditLog models.AuditLogOrder
+
+// newAuditLog creates the shared part of the audit logs of an event.
+// Events of producers that do not send a context have empty actor and request fields.
+func newAuditLog(
+	eventType string,
+	eventContext *pb.EventContext,
+	customerID int64,
+	at time.Time,
+	changes map[string]models.FieldChange,
+) auditLog {
+	return auditLog{
+		CustomerID:    customerID,
+		EventType:     eventType,
+		ActorID:       eventContext.GetActorId(),
+		ActorType:     eventContext.GetActorType(),
+		SourceService: eventContext.GetSourceService(),
+		RequestID:     eventContext.GetRequestId(),
+		Changes:       changes,
+		CreatedAt:     at,
+		UpdatedAt:     time.Now(),
+	}
+}
+
+// forItem creates the audit log of an order item reaching the status.
+func (a auditLog) forItem(orderID int64, itemID int64, status string) models.AuditLogOrder {
+	entry := models.AuditLogOrder(a)
+	entry.OrderID = orderID
+	entry.OrderItemID = itemID
+	entry.OrderStatus = status
+	entry.Changes = maps.Clone(a.Changes)
+
+	return entry
+}
+
+// forItems creates an audit log with the status for every item.
+// Events of orders without items produce a single order level audit log with item ID 0.
+func (a auditLog) forItems(orderID int64, itemIDs []int64, status string) []models.AuditLogOrder {
+	if len(itemIDs) == 0 {
+		return []models.AuditLogOrder{a.forItem(orderID, 0, status)}
+	}
+
+	auditLogs := make([]models.AuditLogOrder, 0, len(itemIDs))
+	for _, itemID := range itemIDs {
+		auditLogs = append(auditLogs, a.forItem(orderID, itemID, status))
+	}
+
+	return auditLogs
+}
+
+// changes converts the field changes of an event, the map is never nil.
+func changes(fieldChanges []*pb.FieldChange) map[string]models.FieldChange {
+	converted := make(map[string]models.FieldChange, len(fieldChanges))
+	for _, change := range fieldChanges {
+		converted[change.GetField()] = models.FieldChange{
+			Before: value(change.GetBefore()),
+			After:  value(change.GetAfter()),
+		}
+	}
+
+	return converted
+}
+
+// createdItemChanges lists the item fields set by the creation of an order.
+func createdItemChanges(item *pb.OrderItemSnapshot) map[string]models.FieldChange {
+	return map[string]models.FieldChange{
+		"item.product_id":     {After: item.GetProductId()},
+		"item.quantity":       {After: item.GetQuantity()},
+		"item.price_cents":    {After: item.GetPriceCents()},
+		"item.price_currency": {After: item.GetPriceCurrency()},
+	}
+}
+
+// value converts a protobuf value, a missing value is nil.
+func value(v *structpb.Value) any {
+	if v == nil {
+		return nil
+	}
+
+	return v.AsInterface()
+}
+
+// nullable returns nil for an empty string.
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// occurredAt returns the time the event occurred at, events without it are stamped with the current time.
+func occurredAt(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Now()
+	}
+
+	return ts.AsTime()
+}
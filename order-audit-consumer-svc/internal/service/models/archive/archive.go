@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ManifestVersion is the version of the archive layout written by the export.
+const ManifestVersion = 1
+
+// FormatNDJSONGzip is gzip compressed newline delimited JSON, one audit log per line.
+const FormatNDJSONGzip = "ndjson+gzip"
+
+// manifestName is the name of the manifest within an archive.
+const manifestName = "manifest.json"
+
+// prefixTimeLayout formats the bounds of the archived range in archive prefixes.
+const prefixTimeLayout = "20060102T150405Z"
+
+var (
+	// ErrInvalidRange is returned for an export range that does not end after it starts.
+	ErrInvalidRange = errors.New("archive range must end after it starts")
+	// ErrArchiveExists is returned when exporting to an archive that was already written.
+	ErrArchiveExists = errors.New("archive already exists")
+	// ErrNotFound is returned for an archive or archive file that does not exist.
+	ErrNotFound = errors.New("archive not found")
+	// ErrUnsupportedManifest is returned for a manifest of an unknown version or format.
+	ErrUnsupportedManifest = errors.New("unsupported archive manifest")
+	// ErrCorrupted is returned for an archive file whose checksum or row count differs from its manifest.
+	ErrCorrupted = errors.New("archive file does not match its manifest")
+)
+
+// Prefix returns the prefix the files of the audit logs created in [from, to) are archived under.
+func Prefix(from, to time.Time) string {
+	return fmt.Sprintf("audit_log_order/%s-%s", from.UTC().Format(prefixTimeLayout), to.UTC().Format(prefixTimeLayout))
+}
+
+// ManifestPath returns the path of the manifest of an archive.
+func ManifestPath(prefix string) string {
+	return prefix + "/" + manifestName
+}
+
+// FileName returns the name of the nth file of an archive.
+func FileName(n int) string {
+	return fmt.Sprintf("part-%05d.ndjson.gz", n)
+}
+
+// File is an archive file listed in the manifest.
+type File struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+	// Bytes and SHA256 are of the compressed file as stored
+	Bytes           int64  `json:"bytes"`
+	SHA256          string `json:"sha256"`
+	FirstAuditLogID int64  `json:"first_audit_log_id"`
+	LastAuditLogID  int64  `json:"last_audit_log_id"`
+}
+
+// Manifest describes an archive of the audit logs created in [From, To).
+// It is written after the files, an archive without a manifest is incomplete.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Format    string    `json:"format"`
+	Prefix    string    `json:"prefix"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Rows      int64     `json:"rows"`
+	Files     []File    `json:"files"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the manifest can be read by this version.
+func (m Manifest) Validate() error {
+	if m.Version != ManifestVersion || m.Format != FormatNDJSONGzip {
+		return fmt.Errorf("%w: version %d, format %q", ErrUnsupportedManifest, m.Version, m.Format)
+	}
+
+	return nil
+}
+
+// LastAuditLogID returns the ID of the last archived audit log, zero for an empty archive.
+// Files are written in audit log ID order.
+func (m Manifest) LastAuditLogID() int64 {
+	if len(m.Files) == 0 {
+		return 0
+	}
+
+	return m.Files[len(m.Files)-1].LastAuditLogID
+}
+
+// ImportReport is the result of restoring an archive.
+type ImportReport struct {
+	Prefix string `json:"prefix"`
+	Files  int    `json:"files"`
+	Rows   int64  `json:"rows"`
+	// Restored is the number of audit logs inserted, the others were still stored
+	Restored int64 `json:"restored"`
+}
@@ -0,0 +1,236 @@
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+)
+
+// Scope selects which audit logs share a hash chain.
+type Scope string
+
+// Chain scopes.
+const (
+	// ScopeGlobal links every audit log into one chain
+	ScopeGlobal Scope = "global"
+	// ScopeCustomer keeps a chain per customer
+	ScopeCustomer Scope = "customer"
+)
+
+// ErrUnknownScope is returned for a chain scope other than global or customer.
+var ErrUnknownScope = errors.New("unknown chain scope")
+
+// ErrChainNotFound is returned for a chain without audit logs.
+var ErrChainNotFound = errors.New("chain not found")
+
+// ParseScope parses a chain scope, an empty scope is global.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case "", ScopeGlobal:
+		return ScopeGlobal, nil
+	case ScopeCustomer:
+		return ScopeCustomer, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownScope, s)
+	}
+}
+
+// ChainID returns the chain an audit log of the customer is appended to.
+func (s Scope) ChainID(customerID int64) string {
+	if s == ScopeCustomer {
+		return "customer:" + strconv.FormatInt(customerID, 10)
+	}
+
+	return string(ScopeGlobal)
+}
+
+// canonicalAuditLog is the hashed content of an audit log in a fixed field order.
+type canonicalAuditLog struct {
+	ID            int64           `json:"id"`
+	ChainID       string          `json:"chain_id"`
+	PrevHash      string          `json:"prev_hash"`
+	OrderID       int64           `json:"order_id"`
+	OrderItemID   int64           `json:"order_item_id"`
+	CustomerID    int64           `json:"customer_id"`
+	OrderStatus   string          `json:"order_status"`
+	EventType     string          `json:"event_type"`
+	ActorID       string          `json:"actor_id"`
+	ActorType     string          `json:"actor_type"`
+	SourceService string          `json:"source_service"`
+	RequestID     string          `json:"request_id"`
+	Changes       json.RawMessage `json:"changes"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
+}
+
+// Hash returns the hex encoded SHA-256 of the canonicalized audit log, including its previous hash.
+// Timestamps are hashed in UTC at the microsecond precision PostgreSQL stores, changes as the
+// sorted JSON they decode to, so an audit log read back from the database hashes the same.
+func Hash(auditLog models.AuditLogOrder) (string, error) {
+	changes, err := canonicalChanges(auditLog.Changes)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(canonicalAuditLog{
+		ID:            auditLog.ID,
+		ChainID:       auditLog.ChainID,
+		PrevHash:      auditLog.PrevHash,
+		OrderID:       auditLog.OrderID,
+		OrderItemID:   auditLog.OrderItemID,
+		CustomerID:    auditLog.CustomerID,
+		OrderStatus:   auditLog.OrderStatus,
+		EventType:     auditLog.EventType,
+		ActorID:       auditLog.ActorID,
+		ActorType:     auditLog.ActorType,
+		SourceService: auditLog.SourceService,
+		RequestID:     auditLog.RequestID,
+		Changes:       changes,
+		CreatedAt:     canonicalTime(auditLog.CreatedAt),
+		UpdatedAt:     canonicalTime(auditLog.UpdatedAt),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit log: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Link appends the audit log to a chain after the previous hash and sets its hash.
+func Link(auditLog models.AuditLogOrder, prevHash string) (models.AuditLogOrder, error) {
+	auditLog.PrevHash = prevHash
+
+	hash, err := Hash(auditLog)
+	if err != nil {
+		return models.AuditLogOrder{}, err
+	}
+	auditLog.Hash = hash
+
+	return auditLog, nil
+}
+
+// canonicalChanges round trips changes through JSON, numbers become floats as when read from jsonb.
+func canonicalChanges(changes map[string]models.FieldChange) (json.RawMessage, error) {
+	if changes == nil {
+		changes = map[string]models.FieldChange{}
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit log changes: %w", err)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit log changes: %w", err)
+	}
+
+	data, err = json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit log changes: %w", err)
+	}
+
+	return data, nil
+}
+
+// canonicalTime formats a timestamp in UTC at microsecond precision.
+func canonicalTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// Head is the last audit log of a chain.
+type Head struct {
+	ChainID        string `json:"chain_id"`
+	LastAuditLogID int64  `json:"last_audit_log_id"`
+	Hash           string `json:"hash"`
+}
+
+// Checkpoint is a signed chain head.
+// Anyone holding the public key can prove the chain up to the audit log was not changed since.
+type Checkpoint struct {
+	ID             int64     `json:"id"`
+	ChainID        string    `json:"chain_id"`
+	LastAuditLogID int64     `json:"last_audit_log_id"`
+	Hash           string    `json:"hash"`
+	KeyID          string    `json:"key_id"`
+	Signature      []byte    `json:"signature"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SigningPayload returns the signed content of the checkpoint.
+func (c Checkpoint) SigningPayload() []byte {
+	return fmt.Appendf(nil, "%s\n%d\n%s\n%s", c.ChainID, c.LastAuditLogID, c.Hash, canonicalTime(c.CreatedAt))
+}
+
+// Sign signs the checkpoint with the key.
+func (c Checkpoint) Sign(key ed25519.PrivateKey) Checkpoint {
+	c.KeyID = KeyID(key.Public().(ed25519.PublicKey))
+	c.Signature = ed25519.Sign(key, c.SigningPayload())
+
+	return c
+}
+
+// VerifySignature reports whether the checkpoint was signed by the key.
+func (c Checkpoint) VerifySignature(key ed25519.PublicKey) bool {
+	return c.KeyID == KeyID(key) && ed25519.Verify(key, c.SigningPayload(), c.Signature)
+}
+
+// KeyID identifies a public key by the first bytes of its SHA-256.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// PrunedRun stands in for consecutive audit logs of a chain removed by the retention.
+// It keeps the previous hash of the first audit log and the hash of the last one.
+type PrunedRun struct {
+	ChainID         string `json:"chain_id"`
+	FirstAuditLogID int64  `json:"first_audit_log_id"`
+	LastAuditLogID  int64  `json:"last_audit_log_id"`
+	PrevHash        string `json:"prev_hash"`
+	Hash            string `json:"hash"`
+	AuditLogs       int64  `json:"audit_logs"`
+}
+
+// Reasons of broken links.
+const (
+	// ReasonPrevHashMismatch is an audit log not linked to the previous one, one was removed or inserted
+	ReasonPrevHashMismatch = "prev_hash_mismatch"
+	// ReasonHashMismatch is an audit log whose content does not match its hash
+	ReasonHashMismatch = "hash_mismatch"
+	// ReasonHeadMismatch is a chain not ending at its head, audit logs were removed from its end
+	ReasonHeadMismatch = "head_mismatch"
+	// ReasonCheckpointMismatch is an audit log whose hash differs from the one of its checkpoint
+	ReasonCheckpointMismatch = "checkpoint_mismatch"
+	// ReasonCheckpointSignature is a checkpoint not signed by the checkpoint key
+	ReasonCheckpointSignature = "checkpoint_signature"
+)
+
+// BrokenLink is the first place a chain does not verify.
+type BrokenLink struct {
+	AuditLogID int64  `json:"audit_log_id"`
+	Reason     string `json:"reason"`
+	Expected   string `json:"expected"`
+	Actual     string `json:"actual"`
+}
+
+// Verification is the result of walking a chain.
+type Verification struct {
+	ChainID     string `json:"chain_id"`
+	AuditLogs   int    `json:"audit_logs"`
+	Checkpoints int    `json:"checkpoints"`
+	// PrunedAuditLogs is the number of audit logs removed by the retention, verified by their pruned runs
+	PrunedAuditLogs int64 `json:"pruned_audit_logs"`
+	// BrokenLink is nil for a chain that verified
+	BrokenLink *BrokenLink `json:"broken_link,omitempty"`
+}
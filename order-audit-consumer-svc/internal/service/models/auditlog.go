@@ -1,14 +1,88 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrAlreadyProcessed is returned when the audit logs of a message were already saved.
+var ErrAlreadyProcessed = errors.New("message already processed")
+
+// ErrAuditReadUnsupported is returned by audit sinks that only write audit logs.
+var ErrAuditReadUnsupported = errors.New("audit sink does not serve reads")
 
 // AuditLogOrder represents an audit log entry for order operations.
 type AuditLogOrder struct {
-	ID          int64     `json:"id"`
-	OrderID     int64     `json:"order_id"`
-	OrderItemID int64     `json:"order_item_id"`
-	CustomerID  int64     `json:"customer_id"`
-	OrderStatus string    `json:"order_status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            int64                  `json:"id"`
+	OrderID       int64                  `json:"order_id"`
+	OrderItemID   int64                  `json:"order_item_id"`
+	CustomerID    int64                  `json:"customer_id"`
+	OrderStatus   string                 `json:"order_status"`
+	EventType     string                 `json:"event_type"`
+	ActorID       string                 `json:"actor_id"`
+	ActorType     string                 `json:"actor_type"`
+	SourceService string                 `json:"source_service"`
+	RequestID     string                 `json:"request_id"`
+	Changes       map[string]FieldChange `json:"changes"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	// ChainID, PrevHash and Hash link the audit log to the previous one of its hash chain
+	ChainID  string `json:"chain_id"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// FieldChange is the value of a field before and after a change, nil for a field that was not set.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditLogMessage is the audit logs of one consumed message.
+type AuditLogMessage struct {
+	MessageID string
+	AuditLogs []AuditLogOrder
+}
+
+// ErrInvalidPageToken is returned for a page token not issued by a previous listing.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// ListAuditLogsModel filters audit logs, zero fields do not filter.
+type ListAuditLogsModel struct {
+	OrderID     int64
+	CustomerID  int64
+	OrderStatus string
+	// CreatedFrom is inclusive, CreatedTo is exclusive
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Limit       int
+	// After continues the listing after the last audit log of the previous page
+	After *AuditLogCursor
+}
+
+// AuditLogCursor is the position of an audit log in a listing ordered newest first.
+type AuditLogCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// AuditLogPage is a page of listed audit logs.
+type AuditLogPage struct {
+	AuditLogs []AuditLogOrder
+	// Next is nil on the last page
+	Next *AuditLogCursor
+}
+
+// OrderTimeline is the status history of an order.
+type OrderTimeline struct {
+	OrderID    int64
+	CustomerID int64
+	Entries    []OrderTimelineEntry
+}
+
+// OrderTimelineEntry is a status the items of an order reached at the same time.
+type OrderTimelineEntry struct {
+	OrderStatus  string
+	OrderItemIDs []int64
+	OccurredAt   time.Time
 }
@@ -1,22 +1,39 @@
 package inbox
 
 import (
+	"errors"
 	"time"
 )
 
+// Outcome is the result of retrying an inbox message.
+type Outcome string
+
+// Outcomes of retrying an inbox message.
+const (
+	// OutcomeProcessed means the message was processed, or had been already, and is removed
+	OutcomeProcessed Outcome = "processed"
+	// OutcomeQuarantined means the message could not be decoded and is moved to the quarantine
+	OutcomeQuarantined Outcome = "quarantined"
+	// OutcomeRescheduled means processing failed again and the next retry is scheduled
+	OutcomeRescheduled Outcome = "rescheduled"
+)
+
 // InboxMessage represents a message that failed to be processed.
 type InboxMessage struct {
-	ID          int64
-	MessageID   string
-	QueueName   string
-	RoutingKey  string
-	Payload     []byte
-	ContentType string
-	RetryCount  int
-	MaxRetries  int
-	LastError   string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	NextRetryAt time.Time
-	DeliveryTag uint64
+	ID          int64             `json:"id"`
+	MessageID   string            `json:"message_id"`
+	QueueName   string            `json:"queue_name"`
+	RoutingKey  string            `json:"routing_key"`
+	Payload     []byte            `json:"payload"`
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers"`
+	RetryCount  int               `json:"retry_count"`
+	MaxRetries  int               `json:"max_retries"`
+	LastError   string            `json:"last_error"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	NextRetryAt time.Time         `json:"next_retry_at"`
+	DeliveryTag uint64            `json:"delivery_tag"`
 }
+
+var ErrInboxMessageNotFound = errors.New("inbox message not found")
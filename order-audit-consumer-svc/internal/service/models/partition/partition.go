@@ -0,0 +1,115 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// namePrefix is the prefix of monthly audit log partitions, followed by the month as YYYYMM.
+const namePrefix = "audit_log_order_p"
+
+// RetentionAction is what happens to partitions older than the retention.
+type RetentionAction string
+
+// Retention actions.
+const (
+	// RetentionDetach detaches the partition and keeps it as a standalone table for archiving
+	RetentionDetach RetentionAction = "detach"
+	// RetentionDrop drops the partition with its audit logs
+	RetentionDrop RetentionAction = "drop"
+)
+
+// ErrUnknownRetentionAction is returned for a retention action other than detach or drop.
+var ErrUnknownRetentionAction = errors.New("unknown retention action")
+
+// ErrNotArchived is returned when the audit logs to remove are not the archived ones.
+var ErrNotArchived = errors.New("audit logs to remove differ from the archived ones")
+
+// ParseRetentionAction parses a retention action, an empty action is detach.
+func ParseRetentionAction(s string) (RetentionAction, error) {
+	switch RetentionAction(s) {
+	case "", RetentionDetach:
+		return RetentionDetach, nil
+	case RetentionDrop:
+		return RetentionDrop, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownRetentionAction, s)
+	}
+}
+
+// Partition is the partition of the audit logs created in a month.
+type Partition struct {
+	// Month is the first instant of the month in UTC
+	Month time.Time `json:"month"`
+}
+
+// ForMonth returns the partition of the month t falls in.
+func ForMonth(t time.Time) Partition {
+	t = t.UTC()
+
+	return Partition{Month: time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseName parses the name of a monthly partition.
+func ParseName(name string) (Partition, bool) {
+	month, ok := strings.CutPrefix(name, namePrefix)
+	if !ok {
+		return Partition{}, false
+	}
+
+	t, err := time.Parse("200601", month)
+	if err != nil {
+		return Partition{}, false
+	}
+
+	return Partition{Month: t}, true
+}
+
+// Name returns the table name of the partition.
+func (p Partition) Name() string {
+	return namePrefix + p.Month.Format("200601")
+}
+
+// From returns the inclusive lower bound of created_at in the partition.
+func (p Partition) From() time.Time {
+	return p.Month
+}
+
+// To returns the exclusive upper bound of created_at in the partition.
+func (p Partition) To() time.Time {
+	return p.Month.AddDate(0, 1, 0)
+}
+
+// Equal reports whether both are the partition of the same month.
+func (p Partition) Equal(other Partition) bool {
+	return p.Month.Equal(other.Month)
+}
+
+// Next returns the partition of the following month.
+func (p Partition) Next() Partition {
+	return Partition{Month: p.To()}
+}
+
+// MaintenanceReport is what a partition maintenance run changed.
+type MaintenanceReport struct {
+	Created []Partition `json:"created"`
+	Removed []Partition `json:"removed"`
+	// PrunedDefault is the number of audit logs older than the retention deleted from the default partition
+	PrunedDefault int64 `json:"pruned_default"`
+	// Archived is the prefixes of the archives expired audit logs were exported to before their removal
+	Archived []string `json:"archived"`
+}
+
+// Extent is the number of audit logs and the ID of the last one, audit log IDs only grow.
+type Extent struct {
+	Rows           int64
+	LastAuditLogID int64
+}
+
+// ArchiveFunc archives the audit logs about to be removed and returns the extent of the archive.
+// It is called while the audit logs cannot be written, removal fails with ErrNotArchived
+// when the audit logs differ from the archived ones.
+type ArchiveFunc func(ctx context.Context) (Extent, error)
@@ -0,0 +1,38 @@
+package quarantine
+
+import (
+	"errors"
+	"time"
+)
+
+// Sources of quarantined messages.
+const (
+	SourceConsumer = "consumer"
+	SourceInbox    = "inbox"
+)
+
+// QuarantinedMessage represents a poison message that could not be decoded.
+// Payload and headers are kept as received, headers include the message properties.
+type QuarantinedMessage struct {
+	ID          int64             `json:"id"`
+	MessageID   string            `json:"message_id"`
+	QueueName   string            `json:"queue_name"`
+	RoutingKey  string            `json:"routing_key"`
+	Payload     []byte            `json:"payload"`
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers"`
+	Error       string            `json:"error"`
+	Source      string            `json:"source"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// EditQuarantinedMessageModel represents changes to a quarantined message before replay.
+// Empty fields are left unchanged.
+type EditQuarantinedMessageModel struct {
+	ID          int64
+	Payload     []byte
+	ContentType string
+}
+
+var ErrQuarantinedMessageNotFound = errors.New("quarantined message not found")
@@ -0,0 +1,429 @@
+package archivesvc
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iarchivestore"
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditarchiverepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/archive"
+	"go.opentelemetry.io/otel"
+)
+
+// defaultRowsPerFile is the number of audit logs written to one archive file by default.
+const defaultRowsPerFile = 100_000
+
+// pageSize is the number of audit logs read or restored at once.
+const pageSize = 1000
+
+// ArchiveService exports audit logs to archive files and restores them.
+type ArchiveService struct {
+	auditRepo   iauditarchiverepo.IAuditArchiveRepository
+	store       iarchivestore.IArchiveStore
+	rowsPerFile int
+	now         func() time.Time
+}
+
+// option is a function that configures the ArchiveService.
+type option func(*ArchiveService)
+
+// MustNewArchiveService creates a new ArchiveService.
+func MustNewArchiveService(opts ...option) *ArchiveService {
+	s := &ArchiveService{
+		rowsPerFile: defaultRowsPerFile,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithAuditArchiveRepository sets the repository audit logs are read from and restored to.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithAuditArchiveRepository(auditRepo iauditarchiverepo.IAuditArchiveRepository) option {
+	return func(s *ArchiveService) {
+		s.auditRepo = auditRepo
+	}
+}
+
+// WithArchiveStore sets the store archive files are written to.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithArchiveStore(store iarchivestore.IArchiveStore) option {
+	return func(s *ArchiveService) {
+		s.store = store
+	}
+}
+
+// WithRowsPerFile sets the number of audit logs written to one archive file, non-positive keeps the default.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithRowsPerFile(rowsPerFile int) option {
+	return func(s *ArchiveService) {
+		if rowsPerFile > 0 {
+			s.rowsPerFile = rowsPerFile
+		}
+	}
+}
+
+// WithClock sets the clock manifests are dated with.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithClock(now func() time.Time) option {
+	return func(s *ArchiveService) {
+		s.now = now
+	}
+}
+
+// Export streams the audit logs created in [from, to) to gzip compressed NDJSON files under the prefix
+// of the range, in ID order, and writes the manifest last.
+// Returns archive.ErrArchiveExists when the range was archived before.
+func (s *ArchiveService) Export(ctx context.Context, from, to time.Time) (archive.Manifest, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ExportArchive")
+	defer span.End()
+
+	if !to.After(from) {
+		return archive.Manifest{}, archive.ErrInvalidRange
+	}
+
+	prefix := archive.Prefix(from, to)
+	exists, err := s.store.Exists(ctx, archive.ManifestPath(prefix))
+	if err != nil {
+		return archive.Manifest{}, fmt.Errorf("failed to check archive manifest: %w", err)
+	}
+	if exists {
+		return archive.Manifest{}, fmt.Errorf("%w: %s", archive.ErrArchiveExists, prefix)
+	}
+
+	manifest := archive.Manifest{
+		Version:   archive.ManifestVersion,
+		Format:    archive.FormatNDJSONGzip,
+		Prefix:    prefix,
+		From:      from.UTC(),
+		To:        to.UTC(),
+		Files:     []archive.File{},
+		CreatedAt: s.now().UTC(),
+	}
+
+	var w *fileWriter
+	defer func() {
+		// A file left open by a failed export is not listed in the manifest
+		if w != nil {
+			_ = w.out.Close()
+		}
+	}()
+
+	var afterID int64
+	for {
+		auditLogs, err := s.auditRepo.ListArchiveAuditLogs(ctx, from, to, afterID, pageSize)
+		if err != nil {
+			return archive.Manifest{}, fmt.Errorf("failed to list audit logs: %w", err)
+		}
+
+		for _, auditLog := range auditLogs {
+			if w == nil {
+				w, err = s.createFile(ctx, prefix, archive.FileName(len(manifest.Files)))
+				if err != nil {
+					return archive.Manifest{}, err
+				}
+			}
+			if err := w.write(auditLog); err != nil {
+				return archive.Manifest{}, err
+			}
+			afterID = auditLog.ID
+
+			if w.file.Rows >= int64(s.rowsPerFile) {
+				file, err := w.close()
+				w = nil
+				if err != nil {
+					return archive.Manifest{}, err
+				}
+				manifest.Files = append(manifest.Files, file)
+				manifest.Rows += file.Rows
+			}
+		}
+
+		if len(auditLogs) < pageSize {
+			break
+		}
+	}
+	if w != nil {
+		file, err := w.close()
+		w = nil
+		if err != nil {
+			return archive.Manifest{}, err
+		}
+		manifest.Files = append(manifest.Files, file)
+		manifest.Rows += file.Rows
+	}
+
+	if err := s.writeManifest(ctx, manifest); err != nil {
+		return archive.Manifest{}, err
+	}
+
+	slog.Info("Exported audit log archive", "prefix", prefix, "files", len(manifest.Files), "rows", manifest.Rows)
+
+	return manifest, nil
+}
+
+// ReadManifest returns the manifest of an archive.
+func (s *ArchiveService) ReadManifest(ctx context.Context, prefix string) (archive.Manifest, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ReadArchiveManifest")
+	defer span.End()
+
+	return s.readManifest(ctx, prefix)
+}
+
+// Verify reads every file of an archive and checks it against the manifest.
+// Returns archive.ErrCorrupted for a file whose checksum, size or row count differs.
+func (s *ArchiveService) Verify(ctx context.Context, prefix string) (archive.Manifest, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.VerifyArchive")
+	defer span.End()
+
+	manifest, err := s.readManifest(ctx, prefix)
+	if err != nil {
+		return archive.Manifest{}, err
+	}
+
+	for _, file := range manifest.Files {
+		if err := s.readFile(ctx, prefix, file, func([]models.AuditLogOrder) error { return nil }); err != nil {
+			return archive.Manifest{}, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// Import verifies an archive and restores its audit logs.
+// Audit logs still stored are skipped, so an interrupted import can be run again.
+func (s *ArchiveService) Import(ctx context.Context, prefix string) (archive.ImportReport, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ImportArchive")
+	defer span.End()
+
+	// Nothing is restored from an archive with a corrupted file
+	manifest, err := s.Verify(ctx, prefix)
+	if err != nil {
+		return archive.ImportReport{}, err
+	}
+
+	report := archive.ImportReport{Prefix: manifest.Prefix}
+	for _, file := range manifest.Files {
+		err := s.readFile(ctx, prefix, file, func(auditLogs []models.AuditLogOrder) error {
+			restored, err := s.auditRepo.RestoreAuditLogs(ctx, auditLogs)
+			if err != nil {
+				return fmt.Errorf("failed to restore audit logs: %w", err)
+			}
+			report.Rows += int64(len(auditLogs))
+			report.Restored += restored
+
+			return nil
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Files++
+	}
+
+	slog.Info("Imported audit log archive", "prefix", prefix, "rows", report.Rows, "restored", report.Restored)
+
+	return report, nil
+}
+
+// createFile opens an archive file for writing.
+func (s *ArchiveService) createFile(ctx context.Context, prefix, name string) (*fileWriter, error) {
+	out, err := s.store.Create(ctx, prefix+"/"+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file %s: %w", name, err)
+	}
+
+	return newFileWriter(out, name), nil
+}
+
+// writeManifest writes the manifest of an archive.
+func (s *ArchiveService) writeManifest(ctx context.Context, manifest archive.Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive manifest: %w", err)
+	}
+
+	out, err := s.store.Create(ctx, archive.ManifestPath(manifest.Prefix))
+	if err != nil {
+		return fmt.Errorf("failed to create archive manifest: %w", err)
+	}
+	if _, err := out.Write(data); err != nil {
+		_ = out.Close()
+
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+
+	return nil
+}
+
+// readManifest reads the manifest of an archive.
+func (s *ArchiveService) readManifest(ctx context.Context, prefix string) (archive.Manifest, error) {
+	in, err := s.store.Open(ctx, archive.ManifestPath(prefix))
+	if err != nil {
+		return archive.Manifest{}, fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer in.Close()
+
+	var manifest archive.Manifest
+	if err := json.NewDecoder(in).Decode(&manifest); err != nil {
+		return archive.Manifest{}, fmt.Errorf("failed to decode archive manifest: %w", err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return archive.Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// readFile streams the audit logs of an archive file to fn in pages
+// and checks the file against its manifest entry once it is read.
+func (s *ArchiveService) readFile(
+	ctx context.Context,
+	prefix string,
+	file archive.File,
+	fn func([]models.AuditLogOrder) error,
+) error {
+	in, err := s.store.Open(ctx, prefix+"/"+file.Name)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file %s: %w", file.Name, err)
+	}
+	defer in.Close()
+
+	counter := &countingWriter{hash: sha256.New()}
+	raw := io.TeeReader(in, counter)
+
+	gz, err := gzip.NewReader(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", archive.ErrCorrupted, file.Name, err)
+	}
+
+	var (
+		rows int64
+		page []models.AuditLogOrder
+	)
+	dec := json.NewDecoder(gz)
+	for {
+		var auditLog models.AuditLogOrder
+		if err := dec.Decode(&auditLog); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: %s: %w", archive.ErrCorrupted, file.Name, err)
+		}
+		rows++
+
+		page = append(page, auditLog)
+		if len(page) == pageSize {
+			if err := fn(page); err != nil {
+				return err
+			}
+			page = nil
+		}
+	}
+	if len(page) > 0 {
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+
+	// The checksum covers the whole stored file, bytes after the gzip stream included
+	if _, err := io.Copy(io.Discard, raw); err != nil {
+		return fmt.Errorf("failed to read archive file %s: %w", file.Name, err)
+	}
+
+	if sum := hex.EncodeToString(counter.hash.Sum(nil)); sum != file.SHA256 || counter.n != file.Bytes {
+		return fmt.Errorf("%w: %s: sha256 %s of %d bytes, expected %s of %d bytes",
+			archive.ErrCorrupted, file.Name, sum, counter.n, file.SHA256, file.Bytes)
+	}
+	if rows != file.Rows {
+		return fmt.Errorf("%w: %s: %d rows, expected %d", archive.ErrCorrupted, file.Name, rows, file.Rows)
+	}
+
+	return nil
+}
+
+// fileWriter writes audit logs as gzip compressed NDJSON and tracks the manifest entry of the file.
+type fileWriter struct {
+	out     io.WriteCloser
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	counter *countingWriter
+	file    archive.File
+}
+
+// newFileWriter creates a writer of the archive file.
+func newFileWriter(out io.WriteCloser, name string) *fileWriter {
+	counter := &countingWriter{hash: sha256.New()}
+	gz := gzip.NewWriter(io.MultiWriter(out, counter))
+
+	return &fileWriter{
+		out:     out,
+		gz:      gz,
+		enc:     json.NewEncoder(gz),
+		counter: counter,
+		file:    archive.File{Name: name},
+	}
+}
+
+// write appends an audit log to the file.
+func (w *fileWriter) write(auditLog models.AuditLogOrder) error {
+	if err := w.enc.Encode(auditLog); err != nil {
+		return fmt.Errorf("failed to write archive file %s: %w", w.file.Name, err)
+	}
+
+	if w.file.Rows == 0 {
+		w.file.FirstAuditLogID = auditLog.ID
+	}
+	w.file.LastAuditLogID = auditLog.ID
+	w.file.Rows++
+
+	return nil
+}
+
+// close flushes and stores the file and returns its manifest entry.
+func (w *fileWriter) close() (archive.File, error) {
+	if err := w.gz.Close(); err != nil {
+		_ = w.out.Close()
+
+		return archive.File{}, fmt.Errorf("failed to write archive file %s: %w", w.file.Name, err)
+	}
+	if err := w.out.Close(); err != nil {
+		return archive.File{}, fmt.Errorf("failed to store archive file %s: %w", w.file.Name, err)
+	}
+
+	w.file.Bytes = w.counter.n
+	w.file.SHA256 = hex.EncodeToString(w.counter.hash.Sum(nil))
+
+	return w.file, nil
+}
+
+// countingWriter hashes and counts the bytes written to it.
+type countingWriter struct {
+	hash hash.Hash
+	n    int64
+}
+
+// Write hashes and counts p.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+
+	return w.hash.Write(p)
+}
@@ -0,0 +1,152 @@
+package auditsvc
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// AuditService is a service for querying audit logs.
+type AuditService struct {
+	auditRepo iauditrepo.IAuditRepository
+}
+
+// option is a function that configures the AuditService.
+type option func(*AuditService)
+
+// MustNewAuditService creates a new AuditService.
+func MustNewAuditService(opts ...option) *AuditService {
+	s := &AuditService{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithAuditRepository sets the audit repository for the AuditService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithAuditRepository(auditRepo iauditrepo.IAuditRepository) option {
+	return func(s *AuditService) {
+		s.auditRepo = auditRepo
+	}
+}
+
+// ListAuditLogs retrieves a page of audit logs matching the filter, newest first.
+func (s *AuditService) ListAuditLogs(
+	ctx context.Context,
+	model models.ListAuditLogsModel,
+) (models.AuditLogPage, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ListAuditLogs")
+	defer span.End()
+
+	if model.Limit <= 0 {
+		model.Limit = defaultPageSize
+	}
+	model.Limit = min(model.Limit, maxPageSize)
+
+	// One extra audit log tells whether there is a next page
+	pageSize := model.Limit
+	model.Limit++
+
+	auditLogs, err := s.auditRepo.ListAuditLogs(ctx, model)
+	if err != nil {
+		return models.AuditLogPage{}, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	page := models.AuditLogPage{AuditLogs: auditLogs}
+	if len(auditLogs) > pageSize {
+		page.AuditLogs = auditLogs[:pageSize]
+		last := page.AuditLogs[pageSize-1]
+		page.Next = &models.AuditLogCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// GetOrderTimeline retrieves the status history of an order in the order it happened.
+// Audit logs of the items of an order reaching a status at the same time form one entry.
+func (s *AuditService) GetOrderTimeline(ctx context.Context, orderID int64) (models.OrderTimeline, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.GetOrderTimeline")
+	defer span.End()
+
+	auditLogs, err := s.auditRepo.GetOrderAuditLogs(ctx, orderID)
+	if err != nil {
+		return models.OrderTimeline{}, fmt.Errorf("failed to get order audit logs: %w", err)
+	}
+
+	timeline := models.OrderTimeline{OrderID: orderID}
+	for _, auditLog := range auditLogs {
+		timeline.CustomerID = auditLog.CustomerID
+
+		last := len(timeline.Entries) - 1
+		if last >= 0 &&
+			timeline.Entries[last].OrderStatus == auditLog.OrderStatus &&
+			timeline.Entries[last].OccurredAt.Equal(auditLog.CreatedAt) {
+			timeline.Entries[last].OrderItemIDs = append(timeline.Entries[last].OrderItemIDs, auditLog.OrderItemID)
+
+			continue
+		}
+
+		timeline.Entries = append(timeline.Entries, models.OrderTimelineEntry{
+			OrderStatus:  auditLog.OrderStatus,
+			OrderItemIDs: []int64{auditLog.OrderItemID},
+			OccurredAt:   auditLog.CreatedAt,
+		})
+	}
+
+	return timeline, nil
+}
+
+// EncodePageToken encodes a listing cursor into an opaque page token.
+func EncodePageToken(cursor *models.AuditLogCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	raw := strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatInt(cursor.ID, 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodePageToken decodes a page token issued by EncodePageToken, an empty token has no cursor.
+func DecodePageToken(token string) (*models.AuditLogCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	nanos, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	cursorID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, models.ErrInvalidPageToken
+	}
+
+	return &models.AuditLogCursor{CreatedAt: time.Unix(0, nanos), ID: cursorID}, nil
+}
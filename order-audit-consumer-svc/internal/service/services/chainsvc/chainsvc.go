@@ -0,0 +1,367 @@
+package chainsvc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditchainrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"go.opentelemetry.io/otel"
+)
+
+// verifyPageSize is the number of audit logs read at once while walking a chain.
+const verifyPageSize = 1000
+
+// ErrNoSigningKey is returned when checkpoints are created without a signing key.
+var ErrNoSigningKey = errors.New("checkpoint signing key is not configured")
+
+// ChainService verifies the hash chains of audit logs and signs their checkpoints.
+type ChainService struct {
+	chainRepo  iauditchainrepo.IAuditChainRepository
+	signingKey ed25519.PrivateKey
+	now        func() time.Time
+}
+
+// option is a function that configures the ChainService.
+type option func(*ChainService)
+
+// MustNewChainService creates a new ChainService.
+func MustNewChainService(opts ...option) *ChainService {
+	s := &ChainService{
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithAuditChainRepository sets the audit chain repository for the ChainService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithAuditChainRepository(chainRepo iauditchainrepo.IAuditChainRepository) option {
+	return func(s *ChainService) {
+		s.chainRepo = chainRepo
+	}
+}
+
+// WithSigningKey sets the key checkpoints are signed and verified with.
+// Without a key checkpoints are not created and their signatures are not verified.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithSigningKey(key ed25519.PrivateKey) option {
+	return func(s *ChainService) {
+		s.signingKey = key
+	}
+}
+
+// ParseSigningKey parses a base64 encoded ed25519 seed, an empty seed is no key.
+func ParseSigningKey(seed string) (ed25519.PrivateKey, error) {
+	if seed == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+	if len(decoded) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a %d byte seed, got %d bytes", ed25519.SeedSize, len(decoded))
+	}
+
+	return ed25519.NewKeyFromSeed(decoded), nil
+}
+
+// CanSign reports whether the service has a key to sign checkpoints with.
+func (s *ChainService) CanSign() bool {
+	return s.signingKey != nil
+}
+
+// PublicKey returns the key checkpoint signatures are verified with, nil without a signing key.
+func (s *ChainService) PublicKey() ed25519.PublicKey {
+	if s.signingKey == nil {
+		return nil
+	}
+
+	return s.signingKey.Public().(ed25519.PublicKey)
+}
+
+// VerifyChains walks a chain, all chains for an empty chain ID, and reports the first broken link of each.
+func (s *ChainService) VerifyChains(ctx context.Context, chainID string) ([]auditchain.Verification, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.VerifyChains")
+	defer span.End()
+
+	var heads []auditchain.Head
+	if chainID != "" {
+		head, err := s.chainRepo.GetChainHead(ctx, chainID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chain head: %w", err)
+		}
+		heads = []auditchain.Head{head}
+	} else {
+		var err error
+		heads, err = s.chainRepo.ListChainHeads(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list chain heads: %w", err)
+		}
+	}
+
+	verifications := make([]auditchain.Verification, 0, len(heads))
+	for _, head := range heads {
+		verification, err := s.verifyChain(ctx, head)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, verification)
+	}
+
+	return verifications, nil
+}
+
+// verifyChain recomputes the hashes of a chain from its first audit log to its head.
+// Every audit log has to link to the previous hash and hash to its own, audit logs removed by
+// the retention are linked by their pruned runs, and the hashes of checkpointed audit logs have
+// to match their signed checkpoints. Audit logs appended after the head was read are left to
+// the next verification.
+func (s *ChainService) verifyChain(ctx context.Context, head auditchain.Head) (auditchain.Verification, error) {
+	checkpoints, err := s.chainRepo.ListCheckpoints(ctx, head.ChainID)
+	if err != nil {
+		return auditchain.Verification{}, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	runs, err := s.chainRepo.ListPrunedRuns(ctx, head.ChainID)
+	if err != nil {
+		return auditchain.Verification{}, fmt.Errorf("failed to list pruned runs: %w", err)
+	}
+
+	w := &chainWalk{
+		verification: auditchain.Verification{ChainID: head.ChainID},
+		checkpointed: make(map[int64]auditchain.Checkpoint, len(checkpoints)),
+		runs:         runs,
+	}
+	for _, checkpoint := range checkpoints {
+		if publicKey := s.PublicKey(); publicKey != nil && !checkpoint.VerifySignature(publicKey) {
+			return w.broken(auditchain.BrokenLink{
+				AuditLogID: checkpoint.LastAuditLogID,
+				Reason:     auditchain.ReasonCheckpointSignature,
+				Expected:   auditchain.KeyID(publicKey),
+				Actual:     checkpoint.KeyID,
+			}), nil
+		}
+		w.checkpointed[checkpoint.LastAuditLogID] = checkpoint
+	}
+
+	var afterID int64
+walk:
+	for {
+		auditLogs, err := s.chainRepo.ListChainAuditLogs(ctx, head.ChainID, afterID, verifyPageSize)
+		if err != nil {
+			return auditchain.Verification{}, fmt.Errorf("failed to list chain audit logs: %w", err)
+		}
+
+		for _, auditLog := range auditLogs {
+			if auditLog.ID > head.LastAuditLogID {
+				break walk
+			}
+			if brokenLink := w.prune(auditLog.ID); brokenLink != nil {
+				return w.broken(*brokenLink), nil
+			}
+			// Audit logs restored into a pruned run that was not restored whole are covered by the run
+			if auditLog.ID <= w.lastID {
+				afterID = auditLog.ID
+
+				continue
+			}
+			if brokenLink := verifyLink(auditLog, w.prevHash); brokenLink != nil {
+				return w.broken(*brokenLink), nil
+			}
+			if brokenLink := w.checkpoint(auditLog.ID, auditLog.Hash); brokenLink != nil {
+				return w.broken(*brokenLink), nil
+			}
+
+			w.verification.AuditLogs++
+			w.prevHash = auditLog.Hash
+			w.lastID = auditLog.ID
+			afterID = auditLog.ID
+		}
+
+		if len(auditLogs) < verifyPageSize {
+			break
+		}
+	}
+	if brokenLink := w.prune(head.LastAuditLogID); brokenLink != nil {
+		return w.broken(*brokenLink), nil
+	}
+
+	// Audit logs removed from the end of the chain leave it short of its head or a checkpoint
+	if w.lastID != head.LastAuditLogID || w.prevHash != head.Hash {
+		return w.broken(auditchain.BrokenLink{
+			AuditLogID: head.LastAuditLogID,
+			Reason:     auditchain.ReasonHeadMismatch,
+			Expected:   head.Hash,
+			Actual:     w.prevHash,
+		}), nil
+	}
+	if len(w.checkpointed) > 0 {
+		checkpoint := w.checkpointed[slices.Min(slices.Collect(maps.Keys(w.checkpointed)))]
+
+		return w.broken(auditchain.BrokenLink{
+			AuditLogID: checkpoint.LastAuditLogID,
+			Reason:     auditchain.ReasonCheckpointMismatch,
+			Expected:   checkpoint.Hash,
+		}), nil
+	}
+
+	return w.verification, nil
+}
+
+// chainWalk is the state of a chain verification.
+type chainWalk struct {
+	verification auditchain.Verification
+	// checkpointed holds the checkpoints not reached yet by their audit log ID
+	checkpointed map[int64]auditchain.Checkpoint
+	// runs holds the pruned runs not reached yet in chain order
+	runs     []auditchain.PrunedRun
+	prevHash string
+	lastID   int64
+}
+
+// prune links the pruned runs starting up to the audit log ID into the chain.
+func (w *chainWalk) prune(throughID int64) *auditchain.BrokenLink {
+	for len(w.runs) > 0 && w.runs[0].FirstAuditLogID <= throughID {
+		run := w.runs[0]
+		w.runs = w.runs[1:]
+
+		if run.PrevHash != w.prevHash {
+			return &auditchain.BrokenLink{
+				AuditLogID: run.FirstAuditLogID,
+				Reason:     auditchain.ReasonPrevHashMismatch,
+				Expected:   w.prevHash,
+				Actual:     run.PrevHash,
+			}
+		}
+
+		// Only the hash of the last pruned audit log is kept, checkpoints within the run are passed
+		for id := range w.checkpointed {
+			if id >= run.FirstAuditLogID && id < run.LastAuditLogID {
+				delete(w.checkpointed, id)
+				w.verification.Checkpoints++
+			}
+		}
+		if brokenLink := w.checkpoint(run.LastAuditLogID, run.Hash); brokenLink != nil {
+			return brokenLink
+		}
+
+		w.verification.PrunedAuditLogs += run.AuditLogs
+		w.prevHash = run.Hash
+		w.lastID = run.LastAuditLogID
+	}
+
+	return nil
+}
+
+// checkpoint compares the hash of an audit log with its checkpoint, if it has one.
+func (w *chainWalk) checkpoint(auditLogID int64, hash string) *auditchain.BrokenLink {
+	checkpoint, ok := w.checkpointed[auditLogID]
+	if !ok {
+		return nil
+	}
+	if checkpoint.Hash != hash {
+		return &auditchain.BrokenLink{
+			AuditLogID: auditLogID,
+			Reason:     auditchain.ReasonCheckpointMismatch,
+			Expected:   checkpoint.Hash,
+			Actual:     hash,
+		}
+	}
+
+	delete(w.checkpointed, auditLogID)
+	w.verification.Checkpoints++
+
+	return nil
+}
+
+// broken returns the verification ending at the broken link.
+func (w *chainWalk) broken(brokenLink auditchain.BrokenLink) auditchain.Verification {
+	w.verification.BrokenLink = &brokenLink
+
+	return w.verification
+}
+
+// verifyLink checks that an audit log follows the previous hash and its content matches its hash.
+func verifyLink(auditLog models.AuditLogOrder, prevHash string) *auditchain.BrokenLink {
+	if auditLog.PrevHash != prevHash {
+		return &auditchain.BrokenLink{
+			AuditLogID: auditLog.ID,
+			Reason:     auditchain.ReasonPrevHashMismatch,
+			Expected:   prevHash,
+			Actual:     auditLog.PrevHash,
+		}
+	}
+
+	hash, err := auditchain.Hash(auditLog)
+	if err != nil || hash != auditLog.Hash {
+		return &auditchain.BrokenLink{
+			AuditLogID: auditLog.ID,
+			Reason:     auditchain.ReasonHashMismatch,
+			Expected:   hash,
+			Actual:     auditLog.Hash,
+		}
+	}
+
+	return nil
+}
+
+// CreateCheckpoints signs the heads of chains extended since their last checkpoint.
+func (s *ChainService) CreateCheckpoints(ctx context.Context) ([]auditchain.Checkpoint, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.CreateCheckpoints")
+	defer span.End()
+
+	if s.signingKey == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	heads, err := s.chainRepo.ListUncheckpointedHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uncheckpointed chain heads: %w", err)
+	}
+
+	createdAt := s.now().UTC().Truncate(time.Microsecond)
+	checkpoints := make([]auditchain.Checkpoint, 0, len(heads))
+	for _, head := range heads {
+		checkpoint := auditchain.Checkpoint{
+			ChainID:        head.ChainID,
+			LastAuditLogID: head.LastAuditLogID,
+			Hash:           head.Hash,
+			CreatedAt:      createdAt,
+		}
+		checkpoints = append(checkpoints, checkpoint.Sign(s.signingKey))
+	}
+
+	saved, err := s.chainRepo.SaveCheckpoints(ctx, checkpoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save checkpoints: %w", err)
+	}
+
+	return saved, nil
+}
+
+// ListCheckpoints returns the checkpoints of a chain, of all chains for an empty chain ID, oldest first.
+func (s *ChainService) ListCheckpoints(ctx context.Context, chainID string) ([]auditchain.Checkpoint, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ListCheckpoints")
+	defer span.End()
+
+	checkpoints, err := s.chainRepo.ListCheckpoints(ctx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	return checkpoints, nil
+}
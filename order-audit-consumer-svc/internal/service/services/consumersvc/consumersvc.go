@@ -2,6 +2,7 @@ package consumersvc
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iauditrepo"
@@ -36,27 +37,53 @@ func WithAuditRepository(auditRepo iauditrepo.IAuditRepository) option {
 	}
 }
 
-// ProcessAuditLog processes a single audit log by sending it to the repository.
-func (s *ConsumerService) ProcessAuditLog(
+// ProcessAuditLogs saves the audit logs of a message.
+// A message processed before is skipped and models.ErrAlreadyProcessed is returned,
+// so redeliveries can be acknowledged without side effects.
+func (s *ConsumerService) ProcessAuditLogs(
 	ctx context.Context,
-	auditLog models.AuditLogOrder,
+	messageID string,
+	auditLogs []models.AuditLogOrder,
 ) error {
-	ctx, span := otel.Tracer("service").Start(ctx, "Service.ProcessAuditLog")
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ProcessAuditLogs")
 	defer span.End()
 
-	slog.Info("Processing audit log",
-		"order_id", auditLog.OrderID,
-		"order_item_id", auditLog.OrderItemID,
-		"customer_id", auditLog.CustomerID)
+	slog.Info("Processing audit logs", "message_id", messageID, "count", len(auditLogs))
 
-	err := s.auditRepo.SaveAuditLogs(ctx, []models.AuditLogOrder{auditLog})
+	err := s.auditRepo.SaveAuditLogs(ctx, messageID, auditLogs)
+	if errors.Is(err, models.ErrAlreadyProcessed) {
+		slog.Info("Message already processed, skipping", "message_id", messageID)
+
+		return err
+	}
+	if err != nil {
+		slog.Error("Failed to save audit logs", "error", err, "message_id", messageID)
+
+		return err
+	}
+
+	slog.Info("Audit logs processed successfully", "message_id", messageID)
+
+	return nil
+}
+
+// ProcessAuditLogBatch saves the audit logs of several messages with a single write.
+// Messages processed before are skipped.
+func (s *ConsumerService) ProcessAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) error {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ProcessAuditLogBatch")
+	defer span.End()
+
+	saved, err := s.auditRepo.SaveAuditLogBatch(ctx, messages)
 	if err != nil {
-		slog.Error("Failed to save audit log", "error", err)
+		slog.Error("Failed to save audit log batch", "error", err, "messages", len(messages))
 
 		return err
 	}
 
-	slog.Info("Audit log processed successfully", "order_id", auditLog.OrderID)
+	slog.Info("Audit log batch processed successfully",
+		"messages", len(messages),
+		"duplicates", len(messages)-saved,
+	)
 
 	return nil
 }
@@ -0,0 +1,210 @@
+package partitionsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/ipartitionrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/archive"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"go.opentelemetry.io/otel"
+)
+
+// defaultPremakeMonths is the number of months ahead partitions are created for by default.
+const defaultPremakeMonths = 3
+
+// Config configures the partition maintenance.
+type Config struct {
+	// PremakeMonths is the number of months after the current one partitions are created ahead for
+	PremakeMonths int
+	// RetentionMonths is the number of whole months before the current one audit logs are kept for,
+	// zero keeps audit logs forever
+	RetentionMonths int
+	// RetentionAction is what happens to partitions older than the retention
+	RetentionAction partition.RetentionAction
+}
+
+// Archiver exports audit logs created in a range to an archive.
+type Archiver interface {
+	Export(ctx context.Context, from, to time.Time) (archive.Manifest, error)
+	ReadManifest(ctx context.Context, prefix string) (archive.Manifest, error)
+}
+
+// PartitionService maintains the monthly partitions of the audit logs.
+type PartitionService struct {
+	partitionRepo ipartitionrepo.IPartitionRepository
+	// archiver is nil when expired audit logs are removed without an archive
+	archiver Archiver
+	cfg      Config
+	now      func() time.Time
+}
+
+// option is a function that configures the PartitionService.
+type option func(*PartitionService)
+
+// MustNewPartitionService creates a new PartitionService.
+func MustNewPartitionService(opts ...option) *PartitionService {
+	s := &PartitionService{
+		cfg: Config{
+			PremakeMonths:   defaultPremakeMonths,
+			RetentionAction: partition.RetentionDetach,
+		},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithPartitionRepository sets the partition repository for the PartitionService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithPartitionRepository(partitionRepo ipartitionrepo.IPartitionRepository) option {
+	return func(s *PartitionService) {
+		s.partitionRepo = partitionRepo
+	}
+}
+
+// WithConfig sets the premake and retention config, a negative premake is replaced with the default.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithConfig(cfg Config) option {
+	return func(s *PartitionService) {
+		if cfg.PremakeMonths < 0 {
+			cfg.PremakeMonths = defaultPremakeMonths
+		}
+		if cfg.RetentionAction == "" {
+			cfg.RetentionAction = partition.RetentionDetach
+		}
+		s.cfg = cfg
+	}
+}
+
+// WithArchiver exports expired audit logs with the archiver before they are removed, nil removes them without.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithArchiver(archiver Archiver) option {
+	return func(s *PartitionService) {
+		s.archiver = archiver
+	}
+}
+
+// WithClock sets the clock the current month is read from.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithClock(now func() time.Time) option {
+	return func(s *PartitionService) {
+		s.now = now
+	}
+}
+
+// Maintain creates the partitions from the current month to the premade months ahead
+// and removes partitions of months older than the retention.
+// Audit logs older than the retention left in the default partition are pruned.
+// With an archiver expired audit logs are exported first while they cannot be written,
+// nothing is removed when the export fails or does not hold every one of them.
+func (s *PartitionService) Maintain(ctx context.Context) (partition.MaintenanceReport, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.MaintainPartitions")
+	defer span.End()
+
+	var report partition.MaintenanceReport
+
+	partitions, err := s.partitionRepo.ListPartitions(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	current := partition.ForMonth(s.now())
+	last := current
+	for range s.cfg.PremakeMonths {
+		last = last.Next()
+	}
+	for p := current; !p.Month.After(last.Month); p = p.Next() {
+		if slices.ContainsFunc(partitions, p.Equal) {
+			continue
+		}
+		if err := s.partitionRepo.CreatePartition(ctx, p); err != nil {
+			return report, fmt.Errorf("failed to create partition: %w", err)
+		}
+		slog.Info("Created audit log partition", "partition", p.Name())
+		report.Created = append(report.Created, p)
+	}
+
+	if s.cfg.RetentionMonths <= 0 {
+		return report, nil
+	}
+
+	// Audit logs created before the first retained month are removed
+	cutoff := current.Month.AddDate(0, -s.cfg.RetentionMonths, 0)
+	for _, p := range partitions {
+		if p.To().After(cutoff) {
+			continue
+		}
+		var prefix string
+		err := s.partitionRepo.RemovePartition(ctx, p, s.cfg.RetentionAction, s.archiveFunc(p.From(), p.To(), &prefix))
+		if err != nil {
+			return report, fmt.Errorf("failed to remove partition %s: %w", p.Name(), err)
+		}
+		if prefix != "" {
+			report.Archived = append(report.Archived, prefix)
+		}
+		slog.Info("Removed audit log partition", "partition", p.Name(), "action", s.cfg.RetentionAction)
+		report.Removed = append(report.Removed, p)
+	}
+
+	// The default partition is archived up to the cutoff once a month,
+	// audit logs expiring later in the month are pruned by the first run of the next one
+	var prefix string
+	pruned, err := s.partitionRepo.PruneDefaultPartition(ctx, cutoff, s.archiveFunc(time.Time{}, cutoff, &prefix))
+	if errors.Is(err, partition.ErrNotArchived) {
+		slog.Warn("Expired audit logs saved after the default partition was archived are left to the next month",
+			"error", err,
+		)
+
+		return report, nil
+	}
+	if err != nil {
+		return report, fmt.Errorf("failed to prune default partition: %w", err)
+	}
+	if prefix != "" {
+		report.Archived = append(report.Archived, prefix)
+	}
+	report.PrunedDefault = pruned
+
+	return report, nil
+}
+
+// archiveFunc returns the function exporting the audit logs created in [from, to) before they are removed,
+// nil without an archiver. The prefix of a newly written archive is stored to prefix.
+// An archive written by an earlier run that failed to remove the audit logs is not written again,
+// the audit logs are checked against its manifest instead.
+func (s *PartitionService) archiveFunc(from, to time.Time, prefix *string) partition.ArchiveFunc {
+	if s.archiver == nil {
+		return nil
+	}
+
+	return func(ctx context.Context) (partition.Extent, error) {
+		manifest, err := s.archiver.Export(ctx, from, to)
+		if errors.Is(err, archive.ErrArchiveExists) {
+			manifest, err = s.archiver.ReadManifest(ctx, archive.Prefix(from, to))
+			if err != nil {
+				return partition.Extent{}, fmt.Errorf("failed to read archive manifest: %w", err)
+			}
+
+			return partition.Extent{Rows: manifest.Rows, LastAuditLogID: manifest.LastAuditLogID()}, nil
+		}
+		if err != nil {
+			return partition.Extent{}, err
+		}
+		slog.Info("Archived expired audit logs", "prefix", manifest.Prefix, "rows", manifest.Rows)
+		*prefix = manifest.Prefix
+
+		return partition.Extent{Rows: manifest.Rows, LastAuditLogID: manifest.LastAuditLogID()}, nil
+	}
+}
@@ -0,0 +1,131 @@
+package quarantinesvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iquarantinerepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"go.opentelemetry.io/otel"
+)
+
+const defaultMessagesLimit = 100
+
+// QuarantineService is a service for administrating quarantined poison messages.
+type QuarantineService struct {
+	quarantineRepo iquarantinerepo.IQuarantineRepository
+	publisher      broker.Publisher
+}
+
+// option is a function that configures the QuarantineService.
+type option func(*QuarantineService)
+
+// MustNewQuarantineService creates a new QuarantineService.
+func MustNewQuarantineService(opts ...option) *QuarantineService {
+	s := &QuarantineService{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithQuarantineRepository sets the quarantine repository for the QuarantineService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithQuarantineRepository(quarantineRepo iquarantinerepo.IQuarantineRepository) option {
+	return func(s *QuarantineService) {
+		s.quarantineRepo = quarantineRepo
+	}
+}
+
+// WithPublisher sets the publisher replayed messages are sent with.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithPublisher(publisher broker.Publisher) option {
+	return func(s *QuarantineService) {
+		s.publisher = publisher
+	}
+}
+
+// ListMessages retrieves quarantined messages, most recent first.
+func (s *QuarantineService) ListMessages(
+	ctx context.Context,
+	limit int,
+	offset int,
+) ([]quarantine.QuarantinedMessage, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ListMessages")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultMessagesLimit
+	}
+
+	return s.quarantineRepo.List(ctx, limit, offset)
+}
+
+// GetMessage retrieves a single quarantined message.
+func (s *QuarantineService) GetMessage(ctx context.Context, id int64) (quarantine.QuarantinedMessage, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.GetMessage")
+	defer span.End()
+
+	return s.quarantineRepo.Get(ctx, id)
+}
+
+// EditMessage fixes the payload or content type of a quarantined message before replay.
+func (s *QuarantineService) EditMessage(
+	ctx context.Context,
+	model quarantine.EditQuarantinedMessageModel,
+) (quarantine.QuarantinedMessage, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.EditMessage")
+	defer span.End()
+
+	if err := s.quarantineRepo.Update(ctx, model); err != nil {
+		return quarantine.QuarantinedMessage{}, err
+	}
+
+	return s.quarantineRepo.Get(ctx, model.ID)
+}
+
+// ReplayMessage publishes a quarantined message back to its queue with its original properties
+// and removes it from the quarantine.
+func (s *QuarantineService) ReplayMessage(ctx context.Context, id int64) error {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ReplayMessage")
+	defer span.End()
+
+	msg, err := s.quarantineRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	replay := broker.MessageFromStringHeaders(msg.QueueName, "", msg.Headers, msg.Payload)
+	replay.ContentType = msg.ContentType
+
+	if err := s.publisher.Publish(ctx, replay); err != nil {
+		return fmt.Errorf("failed to publish quarantined message: %w", err)
+	}
+
+	if err := s.quarantineRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove replayed message from quarantine: %w", err)
+	}
+
+	slog.Info("Quarantined message replayed", "id", id, "message_id", msg.MessageID, "queue", msg.QueueName)
+
+	return nil
+}
+
+// DiscardMessage deletes a quarantined message.
+func (s *QuarantineService) DiscardMessage(ctx context.Context, id int64) error {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.DiscardMessage")
+	defer span.End()
+
+	if err := s.quarantineRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	slog.Info("Quarantined message discarded", "id", id)
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package admintransport
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iinboxrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/corray333/backend-labs/order/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+)
+
+// inboxRetrier retries inbox messages on request.
+type inboxRetrier interface {
+	RetryNow(ctx context.Context, id int64) (inbox.Outcome, error)
+}
+
+// AdminTransport represents the HTTP admin server of the consumer:
+// health probes, metrics and inbox administration.
+// It listens on a port of its own, apart from the audit API.
+type AdminTransport struct {
+	server       *http.Server
+	router       *chi.Mux
+	inboxRepo    iinboxrepo.IInboxRepository
+	inboxRetrier inboxRetrier
+	checks       []Check
+	checkTimeout time.Duration
+}
+
+// Config configures the admin server.
+// Zero values are replaced with defaults.
+type Config struct {
+	Port string
+	// CheckTimeout bounds the readiness checks of a probe
+	CheckTimeout time.Duration
+}
+
+// ConfigFromViper reads the admin server config from the server.admin section.
+func ConfigFromViper() Config {
+	return Config{
+		Port:         viper.GetString("server.admin.port"),
+		CheckTimeout: time.Duration(viper.GetInt("server.admin.check_timeout_seconds")) * time.Second,
+	}
+}
+
+// NewAdminTransport creates a new AdminTransport.
+// The service is ready when all the checks pass.
+func NewAdminTransport(
+	inboxRepo iinboxrepo.IInboxRepository,
+	inboxRetrier inboxRetrier,
+	cfg Config,
+	checks ...Check,
+) *AdminTransport {
+	if cfg.Port == "" {
+		cfg.Port = "3102"
+	}
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = 2 * time.Second
+	}
+
+	router := newRouter()
+
+	return &AdminTransport{
+		server:       newServer(cfg.Port, router),
+		router:       router,
+		inboxRepo:    inboxRepo,
+		inboxRetrier: inboxRetrier,
+		checks:       checks,
+		checkTimeout: cfg.CheckTimeout,
+	}
+}
+
+// Run starts the admin server.
+func (a *AdminTransport) Run() error {
+	return a.server.ListenAndServe()
+}
+
+// Handler returns the HTTP handler with all registered routes.
+func (a *AdminTransport) Handler() http.Handler {
+	return a.router
+}
+
+// Shutdown gracefully shuts down the admin server.
+func (a *AdminTransport) Shutdown(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
+// RegisterRoutes registers the routes for the AdminTransport.
+func (a *AdminTransport) RegisterRoutes() {
+	// Probes and scrapes are too frequent to be logged
+	a.router.Get("/healthz", a.healthz)
+	a.router.Get("/readyz", a.readyz)
+	a.router.Handle("/metrics", promhttp.Handler())
+
+	a.router.Route("/inbox", func(r chi.Router) {
+		r.Use(logger.NewLoggerMiddleware(slog.Default()))
+
+		r.Get("/", a.listInbox)
+		r.Post("/{id}/retry", a.retryInbox)
+		r.Delete("/{id}", a.deleteInbox)
+	})
+}
+
+// newRouter creates a new router for the AdminTransport.
+func newRouter() *chi.Mux {
+	router := chi.NewMux()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Recoverer)
+
+	return router
+}
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+)
+
+// newServer creates a new HTTP server.
+func newServer(port string, router http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              "0.0.0.0:" + port,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// errorResponse is the body of failed admin requests.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes the value as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write admin response", "error", err)
+	}
+}
+
+// writeError writes the error message as the JSON body of the response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,95 @@
+package admintransport
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Check is a named readiness check of a dependency.
+type Check struct {
+	Name string
+	// Check returns nil when the dependency is usable
+	Check func(ctx context.Context) error
+}
+
+// Check statuses.
+const (
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+// checkResult is the result of a readiness check.
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// readinessResponse is the body of the readiness probe.
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// healthz reports that the process is alive and serving requests.
+func (a *AdminTransport) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// readyz runs the readiness checks concurrently within the check timeout.
+// The service is not ready, with 503 Service Unavailable, when any check fails.
+func (a *AdminTransport) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), a.checkTimeout)
+	defer cancel()
+
+	results := make([]checkResult, len(a.checks))
+	var wg sync.WaitGroup
+	for i, check := range a.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	resp := readinessResponse{
+		Status: statusOK,
+		Checks: make(map[string]checkResult, len(a.checks)),
+	}
+	for i, check := range a.checks {
+		resp.Checks[check.Name] = results[i]
+		if results[i].Status != statusOK {
+			resp.Status = statusFailed
+		}
+	}
+
+	if resp.Status != statusOK {
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runCheck runs a check, a check outliving the context fails with the context error.
+func runCheck(ctx context.Context, check Check) checkResult {
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return checkResult{Status: statusFailed, Error: err.Error()}
+	}
+
+	return checkResult{Status: statusOK}
+}
@@ -0,0 +1,126 @@
+package admintransport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/go-chi/chi/v5"
+)
+
+// Page size limits of the inbox listing.
+const (
+	defaultInboxLimit = 100
+	maxInboxLimit     = 1000
+)
+
+// listInboxResponse is the body of the inbox listing.
+type listInboxResponse struct {
+	Messages []inbox.InboxMessage `json:"messages"`
+}
+
+// retryInboxResponse is the body of an inbox message retry.
+type retryInboxResponse struct {
+	ID      int64         `json:"id"`
+	Outcome inbox.Outcome `json:"outcome"`
+}
+
+// listInbox lists inbox messages in the order they are retried, paged by the limit and offset query parameters.
+func (a *AdminTransport) listInbox(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultInboxLimit)
+	if err != nil || limit <= 0 || limit > maxInboxLimit {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxInboxLimit))
+
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "offset must not be negative")
+
+		return
+	}
+
+	messages, err := a.inboxRepo.List(r.Context(), limit, offset)
+	if err != nil {
+		slog.Error("Failed to list inbox messages", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to list inbox messages")
+
+		return
+	}
+	if messages == nil {
+		messages = []inbox.InboxMessage{}
+	}
+
+	writeJSON(w, http.StatusOK, listInboxResponse{Messages: messages})
+}
+
+// retryInbox retries an inbox message right away and reports the outcome.
+func (a *AdminTransport) retryInbox(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	outcome, err := a.inboxRetrier.RetryNow(r.Context(), id)
+	if errors.Is(err, inbox.ErrInboxMessageNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to retry inbox message", "inbox_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to retry inbox message")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, retryInboxResponse{ID: id, Outcome: outcome})
+}
+
+// deleteInbox removes an inbox message without processing it.
+func (a *AdminTransport) deleteInbox(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.inboxRepo.Delete(r.Context(), id)
+	if errors.Is(err, inbox.ErrInboxMessageNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to delete inbox message", "inbox_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete inbox message")
+
+		return
+	}
+
+	slog.Warn("Inbox message deleted on request", "inbox_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathID parses the id path parameter, writing 400 Bad Request when it is invalid.
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid inbox message id")
+
+		return 0, false
+	}
+
+	return id, true
+}
+
+// queryInt parses an integer query parameter, returning the default when it is not set.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,169 @@
+package consumer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// batchItem is a decoded delivery waiting in a batch.
+type batchItem struct {
+	delivery broker.Delivery
+	message  models.AuditLogMessage
+}
+
+// batch accumulates audit logs of consecutive deliveries and writes them at once
+// when the batch size is reached or the batch timeout expires.
+// The deliveries are acknowledged together only after the write is committed.
+//
+// Deliveries arrive in delivery order and every delivery is settled before a later batch is
+// acknowledged, which makes cumulative acknowledgement of the last delivery safe.
+func (c *Consumer) batch(ctx context.Context, jobs <-chan broker.Delivery) {
+	var (
+		items []batchItem
+		size  int
+	)
+
+	timer := time.NewTimer(c.batchTimeout)
+	timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		c.flush(ctx, items)
+		items = nil
+		size = 0
+	}
+
+	for {
+		select {
+		case msg, ok := <-jobs:
+			if !ok {
+				flush()
+
+				return
+			}
+
+			item, ok := c.prepare(ctx, msg)
+			if !ok {
+				continue
+			}
+
+			if len(items) == 0 {
+				timer.Reset(c.batchTimeout)
+			}
+			items = append(items, item)
+			size += len(item.message.AuditLogs)
+
+			if size >= c.batchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// prepare decodes a delivery into a batch item. Malformed deliveries are quarantined immediately.
+func (c *Consumer) prepare(ctx context.Context, msg broker.Delivery) (batchItem, bool) {
+	event, err := c.registry.Decode(msg.Message)
+	if err != nil {
+		slog.Error("Failed to decode event",
+			"type", msg.Type,
+			"queue", msg.Topic,
+			"content_type", msg.ContentType,
+			"error", err,
+		)
+		_ = c.quarantine(ctx, msg, err)
+
+		return batchItem{}, false
+	}
+
+	return batchItem{
+		delivery: msg,
+		message: models.AuditLogMessage{
+			MessageID: processedID(event, msg.Body),
+			AuditLogs: event.AuditLogs,
+		},
+	}, true
+}
+
+// flush writes the batch and acknowledges its deliveries.
+// When the write fails every message is processed on its own,
+// so failed messages are retried as without batching.
+func (c *Consumer) flush(ctx context.Context, items []batchItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	// The batch span is linked to the traces of all contributing messages
+	links := make([]trace.Link, 0, len(items))
+	for _, item := range items {
+		links = append(links, trace.LinkFromContext(broker.ExtractTraceContext(ctx, item.delivery.Headers)))
+	}
+
+	ctx, span := otel.Tracer("consumer").Start(ctx, "Consumer.flush",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(items))),
+	)
+	defer span.End()
+
+	messages := make([]models.AuditLogMessage, 0, len(items))
+	deliveries := make([]broker.Delivery, 0, len(items))
+	for _, item := range items {
+		messages = append(messages, item.message)
+		deliveries = append(deliveries, item.delivery)
+	}
+
+	if err := c.service.ProcessAuditLogBatch(ctx, messages); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.Warn("Failed to write audit log batch, processing messages one by one",
+			"error", err,
+			"messages", len(items),
+		)
+
+		for _, item := range items {
+			c.handle(ctx, item.delivery)
+		}
+
+		return
+	}
+
+	// Cumulative acknowledgement covers a single subscription, deliveries are acknowledged per queue
+	for _, queued := range groupByTopic(deliveries) {
+		if err := broker.AckBatch(queued); err != nil {
+			slog.Error("Failed to ack message batch", "error", err, "queue", queued[0].Topic, "messages", len(queued))
+
+			continue
+		}
+		metrics.ConsumedMessages.WithLabelValues(queued[0].Topic, metrics.OutcomeProcessed).Add(float64(len(queued)))
+	}
+
+	slog.Info("Message batch processed successfully", "messages", len(items))
+}
+
+// groupByTopic groups deliveries by the queue they were received from, keeping their order.
+func groupByTopic(deliveries []broker.Delivery) [][]broker.Delivery {
+	index := make(map[string]int)
+	var groups [][]broker.Delivery
+	for _, d := range deliveries {
+		i, ok := index[d.Topic]
+		if !ok {
+			i = len(groups)
+			index[d.Topic] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], d)
+	}
+
+	return groups
+}
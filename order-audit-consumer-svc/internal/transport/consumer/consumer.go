@@ -4,239 +4,459 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iinboxrepo"
-	"github.com/corray333/backend-labs/consumer/internal/rabbitmq"
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iquarantinerepo"
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
 	"github.com/corray333/backend-labs/consumer/internal/service/models"
-	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
-	"github.com/corray333/backend-labs/consumer/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
 	"github.com/spf13/viper"
-	"github.com/streadway/amqp"
 	"go.opentelemetry.io/otel"
-	"golang.org/x/sync/errgroup"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // service represents the service layer interface.
 type service interface {
-	ProcessAuditLog(ctx context.Context, auditLog models.AuditLogOrder) error
+	ProcessAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error
+	ProcessAuditLogBatch(ctx context.Context, messages []models.AuditLogMessage) error
 }
 
-// Consumer represents the RabbitMQ consumer transport.
+// Consumer represents the message broker consumer transport.
 type Consumer struct {
-	client     *rabbitmq.Client
-	service    service
-	inboxRepo  iinboxrepo.IInboxRepository
-	queue      amqp.Queue
-	stop       chan struct{}
-	done       chan struct{}
-	maxRetries int
+	subscriber      broker.Subscriber
+	service         service
+	retrier         Retrier
+	quarantineRepo  iquarantinerepo.IQuarantineRepository
+	registry        *handlers.Registry
+	queues          []string
+	consumerTag     string
+	sequences       *sequenceTracker
+	workers         int
+	stop            chan struct{}
+	stopOnce        sync.Once
+	done            chan struct{}
+	running         atomic.Bool
+	shutdownTimeout time.Duration
+	batchSize       int
+	batchTimeout    time.Duration
 }
 
-// NewConsumer creates a new Consumer.
+// Subscription is a queue the consumer receives events from.
+type Subscription struct {
+	Queue string `mapstructure:"queue"`
+	// EventType is assumed for messages without a type, such as legacy and replayed events
+	EventType string `mapstructure:"event_type"`
+}
+
+// Config configures the consumer.
+// Zero values except the subscriptions are replaced with defaults.
+type Config struct {
+	Subscriptions []Subscription
+	ConsumerTag   string
+	// Workers is the number of messages processed concurrently
+	Workers int
+	// ShutdownTimeout bounds waiting for in-flight messages on shutdown
+	ShutdownTimeout time.Duration
+	// BatchSize is the number of audit logs written at once, batching is disabled below two
+	BatchSize int
+	// BatchTimeout bounds how long a started batch waits to fill up
+	BatchTimeout time.Duration
+	// SequenceAggregates is the number of recently seen aggregates whose event sequence is checked
+	SequenceAggregates int
+}
+
+// ConfigFromViper reads the consumer config from the rabbitmq section.
+func ConfigFromViper() Config {
+	return Config{
+		Subscriptions:      subscriptionsFromViper(),
+		ConsumerTag:        viper.GetString("rabbitmq.consumer.tag"),
+		Workers:            viper.GetInt("rabbitmq.consumer.workers"),
+		ShutdownTimeout:    time.Duration(viper.GetInt("rabbitmq.consumer.shutdown_timeout_seconds")) * time.Second,
+		BatchSize:          viper.GetInt("rabbitmq.consumer.batch.size"),
+		BatchTimeout:       time.Duration(viper.GetInt("rabbitmq.consumer.batch.timeout_ms")) * time.Millisecond,
+		SequenceAggregates: viper.GetInt("rabbitmq.consumer.sequence_aggregates"),
+	}
+}
+
+// subscriptionsFromViper reads rabbitmq.subscriptions.
+// Without subscriptions order created events are consumed from rabbitmq.queue.name.
+func subscriptionsFromViper() []Subscription {
+	var subscriptions []Subscription
+	if err := viper.UnmarshalKey("rabbitmq.subscriptions", &subscriptions); err != nil {
+		panic(fmt.Sprintf("invalid rabbitmq.subscriptions: %v", err))
+	}
+
+	if len(subscriptions) == 0 && viper.GetString("rabbitmq.queue.name") != "" {
+		subscriptions = []Subscription{{
+			Queue:     viper.GetString("rabbitmq.queue.name"),
+			EventType: events.TypeOrderCreated,
+		}}
+	}
+
+	return subscriptions
+}
+
+// subscribedQueues returns the queues of the subscriptions.
+func subscribedQueues(subscriptions []Subscription) []string {
+	queues := make([]string, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		queues = append(queues, subscription.Queue)
+	}
+
+	return queues
+}
+
+// NewConsumer creates a new Consumer and declares its queues.
+// Messages without a type received from a subscribed queue are handled
+// as the event type of the subscription, which is registered in the registry.
 func NewConsumer(
-	client *rabbitmq.Client,
+	subscriber broker.Subscriber,
 	service service,
-	inboxRepo iinboxrepo.IInboxRepository,
+	registry *handlers.Registry,
+	retrier Retrier,
+	quarantineRepo iquarantinerepo.IQuarantineRepository,
+	cfg Config,
 ) *Consumer {
-	queueName := viper.GetString("rabbitmq.queue.name")
-	if queueName == "" {
-		panic("rabbitmq.queue.name is not set in config")
-	}
-
-	queue, err := client.DeclareQueue(rabbitmq.DeclareQueueConfig{
-		Name:       queueName,
-		Durable:    viper.GetBool("rabbitmq.queue.durable"),
-		AutoDelete: viper.GetBool("rabbitmq.queue.auto_delete"),
-		Exclusive:  viper.GetBool("rabbitmq.queue.exclusive"),
-		NoWait:     viper.GetBool("rabbitmq.queue.no_wait"),
-	})
-	if err != nil {
-		panic(err)
+	if len(cfg.Subscriptions) == 0 {
+		panic("rabbitmq.subscriptions are not set in config")
+	}
+	if cfg.ConsumerTag == "" {
+		cfg.ConsumerTag = "consumer-svc"
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 10
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+	if cfg.BatchTimeout <= 0 {
+		cfg.BatchTimeout = 200 * time.Millisecond
+	}
+	if cfg.SequenceAggregates <= 0 {
+		cfg.SequenceAggregates = 100000
 	}
 
-	maxRetries := viper.GetInt("rabbitmq.inbox.max_retries")
-	if maxRetries == 0 {
-		maxRetries = 5
+	for _, subscription := range cfg.Subscriptions {
+		if err := subscriber.Declare(context.Background(), subscription.Queue); err != nil {
+			panic(err)
+		}
+		if subscription.EventType != "" {
+			if err := registry.HandleTopic(subscription.Queue, subscription.EventType); err != nil {
+				panic(err)
+			}
+		}
 	}
 
 	return &Consumer{
-		client:     client,
-		service:    service,
-		inboxRepo:  inboxRepo,
-		queue:      queue,
-		stop:       make(chan struct{}),
-		done:       make(chan struct{}),
-		maxRetries: maxRetries,
+		subscriber:      subscriber,
+		service:         service,
+		retrier:         retrier,
+		quarantineRepo:  quarantineRepo,
+		registry:        registry,
+		queues:          subscribedQueues(cfg.Subscriptions),
+		consumerTag:     cfg.ConsumerTag,
+		sequences:       newSequenceTracker(cfg.SequenceAggregates),
+		workers:         cfg.Workers,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+		shutdownTimeout: cfg.ShutdownTimeout,
+		batchSize:       cfg.BatchSize,
+		batchTimeout:    cfg.BatchTimeout,
 	}
 }
 
-// Run starts consuming messages from RabbitMQ.
+// Run starts consuming messages from the broker and blocks until the consumer is stopped.
+// Messages are dispatched in delivery order to a fixed pool of workers,
+// a failed message does not affect the others. With batching enabled
+// a single batcher takes the place of the workers.
+//
+// On stop the consumer takes no new deliveries, rejects the ones it already received
+// with requeue and waits for the in-flight messages to finish.
 func (c *Consumer) Run(ctx context.Context) error {
-	consumerTag := viper.GetString("rabbitmq.consumer.tag")
-	if consumerTag == "" {
-		consumerTag = "consumer-svc"
-	}
-
-	msgs, err := c.client.Consume(rabbitmq.ConsumeConfig{
-		Queue:     c.queue.Name,
-		Consumer:  consumerTag,
-		AutoAck:   viper.GetBool("rabbitmq.consumer.auto_ack"),
-		Exclusive: viper.GetBool("rabbitmq.consumer.exclusive"),
-		NoLocal:   viper.GetBool("rabbitmq.consumer.no_local"),
-		NoWait:    viper.GetBool("rabbitmq.consumer.no_wait"),
-	})
+	defer close(c.done)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs, err := c.subscribe(subCtx)
 	if err != nil {
 		return err
 	}
 
-	slog.Info("Consumer started", "queue", c.queue.Name, "consumer_tag", consumerTag)
+	c.setRunning(true)
+
+	slog.Info("Consumer started", "queues", c.queues, "consumer_tag", c.consumerTag, "workers", c.workers, "batch_size", c.batchSize)
+
+	// In-flight messages are finished even when ctx is canceled
+	processCtx := context.WithoutCancel(ctx)
+
+	jobs := make(chan broker.Delivery)
+	var wg sync.WaitGroup
+	if c.batchSize > 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c.batch(processCtx, jobs)
+		}()
+	} else {
+		for range c.workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for msg := range jobs {
+					c.handle(processCtx, msg)
+				}
+			}()
+		}
+	}
+
+	c.dispatch(ctx, msgs, jobs)
+	close(jobs)
+	// Draining in-flight messages, the consumer takes no more
+	c.setRunning(false)
+
+	// Deliveries the subscription still holds were never started
+	cancel()
+	requeued := 0
+	for msg := range msgs {
+		c.requeue(msg)
+		requeued++
+	}
 
-	g, gctx := errgroup.WithContext(ctx)
-	g.SetLimit(50)
+	wg.Wait()
+	slog.Info("Consumer stopped", "requeued", requeued)
+
+	return nil
+}
+
+// Queues returns the queues the consumer receives messages from.
+func (c *Consumer) Queues() []string {
+	return c.queues
+}
+
+// ConsumerTag returns the group the consumer subscribes as.
+func (c *Consumer) ConsumerTag() string {
+	return c.consumerTag
+}
+
+// Running reports whether the consumer receives messages.
+// It turns false once the consumer is stopped or its subscription ends.
+func (c *Consumer) Running() bool {
+	return c.running.Load()
+}
+
+// setRunning sets the running state and its metric.
+func (c *Consumer) setRunning(running bool) {
+	c.running.Store(running)
+	if running {
+		metrics.ConsumerRunning.Set(1)
+	} else {
+		metrics.ConsumerRunning.Set(0)
+	}
+}
+
+// subscribe subscribes to all queues and merges their deliveries.
+// Deliveries of a queue keep their order, a delivery held when the context is canceled is requeued.
+func (c *Consumer) subscribe(ctx context.Context) (<-chan broker.Delivery, error) {
+	out := make(chan broker.Delivery)
+
+	var wg sync.WaitGroup
+	for _, queue := range c.queues {
+		msgs, err := c.subscriber.Subscribe(ctx, queue, c.consumerTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", queue, err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for msg := range msgs {
+				unacked := metrics.UnackedMessages.WithLabelValues(queue)
+				unacked.Inc()
+				msg = msg.WithSettleHook(unacked.Dec)
+
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					c.requeue(msg)
+				}
+			}
+		}()
+	}
 
 	go func() {
-		for {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// dispatch hands deliveries to the workers until the consumer is stopped or the subscription ends.
+func (c *Consumer) dispatch(ctx context.Context, msgs <-chan broker.Delivery, jobs chan<- broker.Delivery) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				slog.Info("Message channel closed")
+
+				return
+			}
+
+			// Checked in delivery order, before messages are processed concurrently
+			c.sequences.observe(msg)
+
 			select {
+			case jobs <- msg:
 			case <-c.stop:
-				slog.Info("Stopping consumer")
-				close(c.done)
+				c.requeue(msg)
 
 				return
-			case msg, ok := <-msgs:
-				if !ok {
-					slog.Info("Message channel closed")
-					close(c.done)
+			case <-ctx.Done():
+				c.requeue(msg)
 
-					return
-				}
+				return
+			}
+		}
+	}
+}
 
-				g.Go(func() error {
-					return c.processMessage(gctx, msg)
-				})
+// handle processes a message in isolation: errors and panics are logged
+// and never stop the consumer or other in-flight messages.
+func (c *Consumer) handle(ctx context.Context, msg broker.Delivery) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Panic while processing message", "panic", r, "message_id", msg.ID)
+			// A message that panics again on redelivery is dropped instead of looping forever
+			if err := msg.Nack(!msg.Redelivered); err != nil {
+				slog.Error("Failed to nack message", "error", err)
 			}
 		}
 	}()
 
-	<-c.done
-	if err := g.Wait(); err != nil {
-		slog.Error("Error processing messages", "error", err)
+	if err := c.processMessage(ctx, msg); err != nil {
+		slog.Error("Error processing message", "error", err, "message_id", msg.ID)
 	}
+}
 
-	return nil
+// requeue rejects a delivery that was not processed so the broker delivers it again.
+func (c *Consumer) requeue(msg broker.Delivery) {
+	metrics.ConsumedMessages.WithLabelValues(msg.Topic, metrics.OutcomeRequeued).Inc()
+	if err := msg.Nack(true); err != nil {
+		slog.Error("Failed to requeue message", "error", err, "message_id", msg.ID)
+	}
 }
 
-// processMessage processes a single message from RabbitMQ.
-func (c *Consumer) processMessage(ctx context.Context, msg amqp.Delivery) error {
-	ctx, span := otel.Tracer("consumer").Start(ctx, "Consumer.processMessage")
+// processMessage processes a single message from the broker.
+func (c *Consumer) processMessage(ctx context.Context, msg broker.Delivery) error {
+	// Continue the trace of the publisher, the span links the order and audit flows
+	ctx, span := otel.Tracer("consumer").Start(
+		broker.ExtractTraceContext(ctx, msg.Headers),
+		"Consumer.processMessage",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(broker.ProcessAttributes(c.subscriber.System(), c.consumerTag, msg.Message)...),
+	)
 	defer span.End()
 
-	slog.Info("Received message", "delivery_tag", msg.DeliveryTag)
+	slog.Info("Received message", "message_id", msg.ID)
 
-	var ord order.Order
-	if err := json.Unmarshal(msg.Body, &ord); err != nil {
-		slog.Error("Failed to unmarshal order", "error", err)
-		if err := msg.Nack(false, false); err != nil {
-			slog.Error("Failed to nack message", "error", err)
-		}
-
-		return err
+	event, err := c.registry.Decode(msg.Message)
+	if err != nil {
+		slog.Error("Failed to decode event",
+			"type", msg.Type,
+			"queue", msg.Topic,
+			"content_type", msg.ContentType,
+			"error", err,
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return c.quarantine(ctx, msg, err)
 	}
 
-	auditLogs := c.convertOrderToAuditLogs(ord)
-
-	var processingErr error
-	for _, auditLog := range auditLogs {
-		if err := c.service.ProcessAuditLog(ctx, auditLog); err != nil {
-			processingErr = err
-			slog.Error("Failed to process audit log", "error", err, "order_id", ord.ID)
+	processedID := processedID(event, msg.Body)
 
-			break
-		}
+	outcome := metrics.OutcomeProcessed
+	processingErr := c.service.ProcessAuditLogs(ctx, processedID, event.AuditLogs)
+	if errors.Is(processingErr, models.ErrAlreadyProcessed) {
+		slog.Info("Duplicate message acknowledged", "order_id", event.OrderID, "event_id", processedID)
+		outcome = metrics.OutcomeDuplicate
+		processingErr = nil
 	}
 
 	if processingErr != nil {
-		messageID := c.generateMessageID(msg.Body)
-		inboxMsg := inbox.InboxMessage{
-			MessageID:   messageID,
-			QueueName:   c.queue.Name,
-			RoutingKey:  msg.RoutingKey,
-			Payload:     msg.Body,
-			ContentType: msg.ContentType,
-			RetryCount:  0,
-			MaxRetries:  c.maxRetries,
-			LastError:   processingErr.Error(),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-			NextRetryAt: time.Now().Add(30 * time.Second),
-			DeliveryTag: msg.DeliveryTag,
-		}
+		slog.Error("Failed to process audit logs", "error", processingErr, "order_id", event.OrderID)
+		span.RecordError(processingErr)
+		span.SetStatus(codes.Error, processingErr.Error())
 
-		if err := c.inboxRepo.Insert(ctx, inboxMsg); err != nil {
-			slog.Error("Failed to save message to inbox", "error", err, "order_id", ord.ID)
-			if err := msg.Nack(false, true); err != nil {
-				slog.Error("Failed to nack message", "error", err)
-			}
+		if err := c.retrier.Retry(ctx, msg, processingErr); err != nil {
+			slog.Error("Failed to schedule message retry", "error", err, "order_id", event.OrderID)
+			c.requeue(msg)
 
-			return fmt.Errorf("failed to save to inbox: %w", err)
+			return err
 		}
-
-		slog.Info("Message saved to inbox for retry", "order_id", ord.ID, "message_id", messageID)
+		outcome = metrics.OutcomeRetried
 	}
 
-	if err := msg.Ack(false); err != nil {
+	if err := msg.Ack(); err != nil {
 		slog.Error("Failed to ack message", "error", err)
 
 		return err
 	}
 
+	metrics.ConsumedMessages.WithLabelValues(msg.Topic, outcome).Inc()
 	if processingErr == nil {
-		slog.Info("Message processed successfully", "order_id", ord.ID)
+		slog.Info("Message processed successfully", "order_id", event.OrderID)
 	}
 
 	return nil
 }
 
-// generateMessageID generates a unique message ID based on message content.
-func (c *Consumer) generateMessageID(payload []byte) string {
-	hash := sha256.Sum256(payload)
+// processedID returns the ID a message is deduplicated by.
+// The event ID survives redelivery and republishing, the payload hash is a fallback for old events.
+func processedID(event handlers.Event, body []byte) string {
+	if event.ID != "" {
+		return event.ID
+	}
 
-	return hex.EncodeToString(hash[:])
+	return generateMessageID(body)
 }
 
-// convertOrderToAuditLogs converts an order to audit log entries.
-func (c *Consumer) convertOrderToAuditLogs(ord order.Order) []models.AuditLogOrder {
-	auditLogs := make([]models.AuditLogOrder, 0, len(ord.OrderItems))
-
-	for _, item := range ord.OrderItems {
-		auditLog := models.AuditLogOrder{
-			OrderID:     ord.ID,
-			OrderItemID: item.ID,
-			CustomerID:  ord.CustomerID,
-			OrderStatus: "created",
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-		auditLogs = append(auditLogs, auditLog)
-	}
+// generateMessageID generates a unique message ID based on message content.
+func generateMessageID(payload []byte) string {
+	hash := sha256.Sum256(payload)
 
-	return auditLogs
+	return hex.EncodeToString(hash[:])
 }
 
-// Shutdown gracefully shuts down the consumer.
+// Shutdown stops taking new messages and waits for the in-flight ones
+// at most for the shutdown timeout.
 func (c *Consumer) Shutdown() error {
 	slog.Info("Shutting down consumer")
-	close(c.stop)
+	c.stopOnce.Do(func() { close(c.stop) })
 
-	// Wait for processing to finish with timeout
 	select {
 	case <-c.done:
 		slog.Info("Consumer stopped successfully")
-	case <-time.After(10 * time.Second):
+	case <-time.After(c.shutdownTimeout):
 		slog.Warn("Consumer shutdown timeout")
+
+		return fmt.Errorf("failed to drain in-flight messages within %s", c.shutdownTimeout)
 	}
 
 	return nil
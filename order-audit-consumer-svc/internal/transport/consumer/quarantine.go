@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+)
+
+// quarantine saves a poison message with its raw body, headers and the decoding error
+// and acknowledges it. A message that can not be saved is handed to the retrier instead of
+// being requeued, so an unavailable quarantine does not make the broker redeliver it in a hot loop.
+func (c *Consumer) quarantine(ctx context.Context, msg broker.Delivery, cause error) error {
+	quarantined := quarantine.QuarantinedMessage{
+		MessageID:   msg.ID,
+		QueueName:   msg.Topic,
+		RoutingKey:  msg.Topic,
+		Payload:     msg.Body,
+		ContentType: msg.ContentType,
+		Headers:     broker.StringHeaders(msg.Message),
+		Error:       cause.Error(),
+		Source:      quarantine.SourceConsumer,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	outcome := metrics.OutcomeQuarantined
+	if err := c.quarantineRepo.Insert(ctx, quarantined); err != nil {
+		slog.Error("Failed to quarantine message", "error", err, "message_id", msg.ID)
+
+		// The retried message fails decoding again and is quarantined after the retry delay
+		if err := c.retrier.Retry(ctx, msg, cause); err != nil {
+			slog.Error("Failed to schedule message retry", "error", err, "message_id", msg.ID)
+			c.requeue(msg)
+
+			return fmt.Errorf("failed to quarantine message: %w", err)
+		}
+		outcome = metrics.OutcomeRetried
+	}
+
+	if err := msg.Ack(); err != nil {
+		slog.Error("Failed to ack message", "error", err)
+
+		return err
+	}
+
+	metrics.ConsumedMessages.WithLabelValues(msg.Topic, outcome).Inc()
+	if outcome == metrics.OutcomeQuarantined {
+		slog.Warn("Poison message quarantined", "message_id", msg.ID, "error", cause)
+	}
+
+	return nil
+}
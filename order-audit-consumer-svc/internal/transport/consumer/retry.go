@@ -0,0 +1,243 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iinboxrepo"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/spf13/viper"
+)
+
+// Retry strategies selectable in config.
+const (
+	RetryStrategyInbox  = "inbox"
+	RetryStrategyBroker = "broker"
+)
+
+// Headers of messages retried through the broker.
+const (
+	HeaderRetryCount = "x-retry-count"
+	HeaderLastError  = "x-last-error"
+)
+
+// Retrier schedules another attempt for a delivery that failed processing.
+// The consumer acknowledges the delivery once Retry succeeds and requeues it otherwise.
+type Retrier interface {
+	Retry(ctx context.Context, msg broker.Delivery, cause error) error
+}
+
+// MustNewRetrier creates the retry strategy selected by rabbitmq.retry.strategy.
+// The inbox table is used when the strategy is not set.
+func MustNewRetrier(publisher broker.Publisher, inboxRepo iinboxrepo.IInboxRepository) Retrier {
+	strategy := viper.GetString("rabbitmq.retry.strategy")
+
+	switch strategy {
+	case "", RetryStrategyInbox:
+		return NewInboxRetrier(inboxRepo, InboxRetryConfigFromViper())
+	case RetryStrategyBroker:
+		return MustNewBrokerRetrier(publisher, BrokerRetryConfigFromViper())
+	default:
+		panic(fmt.Sprintf("unknown retry strategy: %s", strategy))
+	}
+}
+
+// InboxRetrier saves failed messages to the inbox table, the inbox worker retries them.
+type InboxRetrier struct {
+	inboxRepo     iinboxrepo.IInboxRepository
+	maxRetries    int
+	retryInterval time.Duration
+}
+
+// InboxRetryConfig configures the inbox retry strategy.
+// Zero values are replaced with defaults.
+type InboxRetryConfig struct {
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// InboxRetryConfigFromViper reads the inbox retry config from the rabbitmq section.
+func InboxRetryConfigFromViper() InboxRetryConfig {
+	return InboxRetryConfig{
+		MaxRetries:    viper.GetInt("rabbitmq.inbox.max_retries"),
+		RetryInterval: time.Duration(viper.GetInt("rabbitmq.inbox.retry_interval_seconds")) * time.Second,
+	}
+}
+
+// NewInboxRetrier creates a new InboxRetrier.
+func NewInboxRetrier(inboxRepo iinboxrepo.IInboxRepository, cfg InboxRetryConfig) *InboxRetrier {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
+	}
+
+	return &InboxRetrier{
+		inboxRepo:     inboxRepo,
+		maxRetries:    cfg.MaxRetries,
+		retryInterval: cfg.RetryInterval,
+	}
+}
+
+// Retry saves the message to the inbox.
+// The message headers are kept, so the inbox worker dispatches it by its event type.
+func (r *InboxRetrier) Retry(ctx context.Context, msg broker.Delivery, cause error) error {
+	headers := broker.StringHeaders(msg.Message)
+	maps.Copy(headers, broker.TraceHeaders(ctx))
+
+	messageID := generateMessageID(msg.Body)
+	inboxMsg := inbox.InboxMessage{
+		MessageID:   messageID,
+		QueueName:   msg.Topic,
+		RoutingKey:  msg.Topic,
+		Payload:     msg.Body,
+		ContentType: msg.ContentType,
+		Headers:     headers,
+		RetryCount:  0,
+		MaxRetries:  r.maxRetries,
+		LastError:   cause.Error(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		NextRetryAt: time.Now().Add(r.retryInterval),
+	}
+
+	if err := r.inboxRepo.Insert(ctx, inboxMsg); err != nil {
+		return fmt.Errorf("failed to save to inbox: %w", err)
+	}
+
+	slog.Info("Message saved to inbox for retry", "message_id", messageID)
+
+	return nil
+}
+
+// BrokerRetrier retries failed messages through a ladder of delayed broker queues.
+// Every retry queue holds messages for its delay and then moves them back to the queue
+// they were received from, the retry count is carried in the message headers.
+// Messages failing after the last retry are moved to the parking lot queue for manual inspection.
+type BrokerRetrier struct {
+	publisher   broker.Publisher
+	retryQueues map[string][]string
+	parkingLots map[string]string
+}
+
+// BrokerRetryConfig configures the broker retry strategy.
+// Every queue gets a retry queue for every delay. Without a parking lot
+// every queue parks its messages in its own parking lot queue.
+// Empty delays are replaced with defaults.
+type BrokerRetryConfig struct {
+	Queues     []string
+	Delays     []time.Duration
+	ParkingLot string
+}
+
+// BrokerRetryConfigFromViper reads the broker retry config from the rabbitmq section.
+func BrokerRetryConfigFromViper() BrokerRetryConfig {
+	var delays []time.Duration
+	for _, seconds := range viper.GetIntSlice("rabbitmq.retry.delays_seconds") {
+		delays = append(delays, time.Duration(seconds)*time.Second)
+	}
+
+	return BrokerRetryConfig{
+		Queues:     subscribedQueues(subscriptionsFromViper()),
+		Delays:     delays,
+		ParkingLot: viper.GetString("rabbitmq.retry.parking_lot"),
+	}
+}
+
+// MustNewBrokerRetrier creates a new BrokerRetrier and declares its queues.
+// The broker has to support delayed messages.
+func MustNewBrokerRetrier(publisher broker.Publisher, cfg BrokerRetryConfig) *BrokerRetrier {
+	if len(cfg.Queues) == 0 {
+		panic("rabbitmq.subscriptions are not set in config")
+	}
+	if len(cfg.Delays) == 0 {
+		cfg.Delays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute}
+	}
+
+	declarer, ok := publisher.(broker.DelayDeclarer)
+	if !ok {
+		panic(fmt.Errorf("%w: delayed messages on %s", broker.ErrNotImplemented, publisher.System()))
+	}
+
+	ctx := context.Background()
+	retrier := &BrokerRetrier{
+		publisher:   publisher,
+		retryQueues: make(map[string][]string, len(cfg.Queues)),
+		parkingLots: make(map[string]string, len(cfg.Queues)),
+	}
+	for _, queueName := range cfg.Queues {
+		retryQueues := make([]string, 0, len(cfg.Delays))
+		for _, delay := range cfg.Delays {
+			// The delay is part of the name, a declared queue can not change its TTL
+			queue := fmt.Sprintf("%s.retry.%dms", queueName, delay.Milliseconds())
+			if err := declarer.DeclareDelayed(ctx, queue, delay, queueName); err != nil {
+				panic(err)
+			}
+			retryQueues = append(retryQueues, queue)
+		}
+
+		parkingLot := cfg.ParkingLot
+		if parkingLot == "" {
+			parkingLot = queueName + ".parking-lot"
+		}
+		if err := publisher.Declare(ctx, parkingLot); err != nil {
+			panic(err)
+		}
+
+		retrier.retryQueues[queueName] = retryQueues
+		retrier.parkingLots[queueName] = parkingLot
+	}
+
+	return retrier
+}
+
+// Retry publishes a copy of the message to the next retry queue,
+// or to the parking lot when all retries are used up.
+// Publish returns once the broker stored the copy, a copy that was not stored fails the retry
+// and the consumer requeues the message instead of acknowledging it.
+func (r *BrokerRetrier) Retry(ctx context.Context, msg broker.Delivery, cause error) error {
+	retryQueues, ok := r.retryQueues[msg.Topic]
+	if !ok {
+		return fmt.Errorf("no retry queues for %s", msg.Topic)
+	}
+	parkingLot := r.parkingLots[msg.Topic]
+
+	retries, _ := broker.HeaderInt64(msg.Headers[HeaderRetryCount])
+
+	retry := msg.Message
+	retry.Headers = make(map[string]any, len(msg.Headers)+4)
+	maps.Copy(retry.Headers, msg.Headers)
+	for k, v := range broker.TraceHeaders(ctx) {
+		retry.Headers[k] = v
+	}
+	retry.Headers[HeaderLastError] = cause.Error()
+
+	if retries >= int64(len(retryQueues)) {
+		retry.Topic = parkingLot
+		retry.Headers[HeaderRetryCount] = retries
+	} else {
+		retry.Topic = retryQueues[retries]
+		retry.Headers[HeaderRetryCount] = retries + 1
+	}
+
+	if err := r.publisher.Publish(ctx, retry); err != nil {
+		return fmt.Errorf("failed to publish message to %s: %w", retry.Topic, err)
+	}
+
+	if retry.Topic == parkingLot {
+		slog.Warn("Retries exhausted, message moved to parking lot",
+			"message_id", msg.ID,
+			"retries", retries,
+			"parking_lot", parkingLot,
+		)
+	} else {
+		slog.Info("Message scheduled for retry", "message_id", msg.ID, "retry", retries+1, "queue", retry.Topic)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package consumer
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+)
+
+// sequenceStatus is the result of checking a delivery against the aggregate sequence.
+type sequenceStatus int
+
+const (
+	// sequenceUnchecked is a delivery without ordering headers or the first one seen of its aggregate
+	sequenceUnchecked sequenceStatus = iota
+	// sequenceInOrder is a delivery directly following the last seen one
+	sequenceInOrder
+	// sequenceGap is a delivery skipping sequence numbers
+	sequenceGap
+	// sequenceStale is a duplicate or a delivery older than the last seen one
+	sequenceStale
+)
+
+// sequenceTracker remembers the last sequence number seen for every aggregate
+// and reports gaps and reordering in the event stream.
+// State is kept in memory for the most recently seen aggregates, the first event of an aggregate
+// after a restart or after it was evicted is not checked.
+type sequenceTracker struct {
+	mu       sync.Mutex
+	capacity int
+	// recent holds the aggregates from the most recently seen one, last indexes its elements
+	recent *list.List
+	last   map[string]*list.Element
+}
+
+// sequenceEntry is the last sequence seen of an aggregate.
+type sequenceEntry struct {
+	aggregateID string
+	sequence    int64
+}
+
+// newSequenceTracker creates a new sequenceTracker remembering at most capacity aggregates.
+func newSequenceTracker(capacity int) *sequenceTracker {
+	return &sequenceTracker{
+		capacity: capacity,
+		recent:   list.New(),
+		last:     make(map[string]*list.Element),
+	}
+}
+
+// observe checks the ordering headers of a delivery against the previously seen sequence.
+// Messages without ordering headers are not checked.
+func (t *sequenceTracker) observe(msg broker.Delivery) sequenceStatus {
+	aggregateID, ok := msg.Headers[events.HeaderAggregateID].(string)
+	if !ok || aggregateID == "" {
+		return sequenceUnchecked
+	}
+
+	sequence, ok := broker.HeaderInt64(msg.Headers[events.HeaderSequence])
+	if !ok {
+		slog.Warn("Message has aggregate id but no sequence", "aggregate_id", aggregateID)
+
+		return sequenceUnchecked
+	}
+
+	last, seen := t.remember(aggregateID, sequence)
+
+	switch {
+	case !seen:
+		return sequenceUnchecked
+	case sequence == last+1:
+		return sequenceInOrder
+	case sequence > last+1:
+		slog.Warn("Gap detected in aggregate event sequence",
+			"aggregate_id", aggregateID,
+			"expected_sequence", last+1,
+			"sequence", sequence,
+			"missing", sequence-last-1,
+		)
+
+		return sequenceGap
+	default:
+		slog.Warn("Out of order or duplicate event for aggregate",
+			"aggregate_id", aggregateID,
+			"last_sequence", last,
+			"sequence", sequence,
+		)
+
+		return sequenceStale
+	}
+}
+
+// remember returns the last sequence seen of the aggregate and records the sequence if it is newer.
+// The aggregate becomes the most recent one, the least recent aggregate is evicted over capacity.
+func (t *sequenceTracker) remember(aggregateID string, sequence int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if elem, ok := t.last[aggregateID]; ok {
+		entry := elem.Value.(*sequenceEntry)
+		last := entry.sequence
+		if sequence > last {
+			entry.sequence = sequence
+		}
+		t.recent.MoveToFront(elem)
+
+		return last, true
+	}
+
+	t.last[aggregateID] = t.recent.PushFront(&sequenceEntry{aggregateID: aggregateID, sequence: sequence})
+	if t.recent.Len() > t.capacity {
+		oldest := t.recent.Back()
+		t.recent.Remove(oldest)
+		delete(t.last, oldest.Value.(*sequenceEntry).aggregateID)
+	}
+
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+)
+
+// sequenced builds a delivery of the aggregate with the sequence header value.
+func sequenced(aggregateID string, sequence any) broker.Delivery {
+	headers := map[string]any{events.HeaderAggregateID: aggregateID}
+	if sequence != nil {
+		headers[events.HeaderSequence] = sequence
+	}
+
+	return broker.NewDelivery(broker.Message{Headers: headers}, false, nil, nil)
+}
+
+func TestSequenceTracker(t *testing.T) {
+	type observation struct {
+		msg  broker.Delivery
+		want sequenceStatus
+	}
+
+	tests := []struct {
+		name         string
+		observations []observation
+	}{
+		{
+			name: "consecutive sequences are in order",
+			observations: []observation{
+				{sequenced("a", int64(1)), sequenceUnchecked},
+				{sequenced("a", int64(2)), sequenceInOrder},
+				{sequenced("a", int64(3)), sequenceInOrder},
+			},
+		},
+		{
+			name: "string headers of brokers without typed headers",
+			observations: []observation{
+				{sequenced("a", "4"), sequenceUnchecked},
+				{sequenced("a", "5"), sequenceInOrder},
+			},
+		},
+		{
+			name: "skipped sequence is a gap",
+			observations: []observation{
+				{sequenced("a", int64(1)), sequenceUnchecked},
+				{sequenced("a", int64(4)), sequenceGap},
+				{sequenced("a", int64(5)), sequenceInOrder},
+			},
+		},
+		{
+			name: "duplicate and older sequences are stale",
+			observations: []observation{
+				{sequenced("a", int64(2)), sequenceUnchecked},
+				{sequenced("a", int64(3)), sequenceInOrder},
+				{sequenced("a", int64(3)), sequenceStale},
+				{sequenced("a", int64(1)), sequenceStale},
+				{sequenced("a", int64(4)), sequenceInOrder},
+			},
+		},
+		{
+			name: "aggregates are tracked independently",
+			observations: []observation{
+				{sequenced("a", int64(1)), sequenceUnchecked},
+				{sequenced("b", int64(7)), sequenceUnchecked},
+				{sequenced("a", int64(2)), sequenceInOrder},
+				{sequenced("b", int64(9)), sequenceGap},
+			},
+		},
+		{
+			name: "messages without ordering headers are not checked",
+			observations: []observation{
+				{broker.NewDelivery(broker.Message{}, false, nil, nil), sequenceUnchecked},
+				{sequenced("", int64(1)), sequenceUnchecked},
+				{sequenced("a", nil), sequenceUnchecked},
+				{sequenced("a", "not a number"), sequenceUnchecked},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newSequenceTracker(10)
+			for i, o := range tt.observations {
+				if got := tracker.observe(o.msg); got != o.want {
+					t.Fatalf("observation %d: expected status %d, got %d", i, o.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceTrackerEvictsLeastRecentAggregate(t *testing.T) {
+	tracker := newSequenceTracker(2)
+
+	tracker.observe(sequenced("a", int64(1)))
+	tracker.observe(sequenced("b", int64(1)))
+	// Seeing a again makes b the least recent aggregate
+	if got := tracker.observe(sequenced("a", int64(2))); got != sequenceInOrder {
+		t.Fatalf("expected a in order, got %d", got)
+	}
+	tracker.observe(sequenced("c", int64(1)))
+
+	if n := len(tracker.last); n != 2 {
+		t.Fatalf("expected 2 tracked aggregates, got %d", n)
+	}
+	if got := tracker.observe(sequenced("a", int64(3))); got != sequenceInOrder {
+		t.Fatalf("expected a still tracked, got %d", got)
+	}
+	// b was evicted, its next event starts over unchecked
+	if got := tracker.observe(sequenced("b", int64(5))); got != sequenceUnchecked {
+		t.Fatalf("expected evicted b unchecked, got %d", got)
+	}
+}
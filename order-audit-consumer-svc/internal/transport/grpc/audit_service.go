@@ -0,0 +1,258 @@
+package grpctransport
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/auditsvc"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// auditService is an interface for the audit query service layer.
+type auditService interface {
+	ListAuditLogs(ctx context.Context, model models.ListAuditLogsModel) (models.AuditLogPage, error)
+	GetOrderTimeline(ctx context.Context, orderID int64) (models.OrderTimeline, error)
+}
+
+// chainService is an interface for the audit chain service layer.
+type chainService interface {
+	VerifyChains(ctx context.Context, chainID string) ([]auditchain.Verification, error)
+	ListCheckpoints(ctx context.Context, chainID string) ([]auditchain.Checkpoint, error)
+	PublicKey() ed25519.PublicKey
+}
+
+// AuditServer implements the gRPC AuditService.
+type AuditServer struct {
+	pb.UnimplementedAuditServiceServer
+
+	auditService auditService
+	chainService chainService
+}
+
+// NewAuditServer creates a new AuditServer.
+func NewAuditServer(auditService auditService, chainService chainService) *AuditServer {
+	return &AuditServer{
+		auditService: auditService,
+		chainService: chainService,
+	}
+}
+
+// ListAuditLogs handles the list audit logs gRPC request.
+func (s *AuditServer) ListAuditLogs(
+	ctx context.Context,
+	req *pb.ListAuditLogsRequest,
+) (*pb.ListAuditLogsResponse, error) {
+	after, err := auditsvc.DecodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	model := models.ListAuditLogsModel{
+		OrderID:     req.OrderId,
+		CustomerID:  req.CustomerId,
+		OrderStatus: req.OrderStatus,
+		Limit:       int(req.PageSize),
+		After:       after,
+	}
+	if req.CreatedFrom != nil {
+		model.CreatedFrom = req.CreatedFrom.AsTime()
+	}
+	if req.CreatedTo != nil {
+		model.CreatedTo = req.CreatedTo.AsTime()
+	}
+
+	page, err := s.auditService.ListAuditLogs(ctx, model)
+	if errors.Is(err, models.ErrInvalidPageToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if errors.Is(err, models.ErrAuditReadUnsupported) {
+		return nil, status.Errorf(codes.Unimplemented, "%v", err)
+	}
+	if err != nil {
+		slog.Error("Error listing audit logs", "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to list audit logs: %v", err)
+	}
+
+	auditLogs := make([]*pb.AuditLog, 0, len(page.AuditLogs))
+	for _, auditLog := range page.AuditLogs {
+		auditLogs = append(auditLogs, auditLogToProto(auditLog))
+	}
+
+	return &pb.ListAuditLogsResponse{
+		AuditLogs:     auditLogs,
+		NextPageToken: auditsvc.EncodePageToken(page.Next),
+	}, nil
+}
+
+// GetOrderTimeline handles the get order timeline gRPC request.
+func (s *AuditServer) GetOrderTimeline(
+	ctx context.Context,
+	req *pb.GetOrderTimelineRequest,
+) (*pb.GetOrderTimelineResponse, error) {
+	if req.OrderId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "order_id must be positive")
+	}
+
+	timeline, err := s.auditService.GetOrderTimeline(ctx, req.OrderId)
+	if errors.Is(err, models.ErrAuditReadUnsupported) {
+		return nil, status.Errorf(codes.Unimplemented, "%v", err)
+	}
+	if err != nil {
+		slog.Error("Error getting order timeline", "order_id", req.OrderId, "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to get order timeline: %v", err)
+	}
+	if len(timeline.Entries) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no audit logs of order %d", req.OrderId)
+	}
+
+	entries := make([]*pb.OrderTimelineEntry, 0, len(timeline.Entries))
+	for _, entry := range timeline.Entries {
+		entries = append(entries, &pb.OrderTimelineEntry{
+			OrderStatus:  entry.OrderStatus,
+			OrderItemIds: entry.OrderItemIDs,
+			OccurredAt:   timestamppb.New(entry.OccurredAt),
+		})
+	}
+
+	return &pb.GetOrderTimelineResponse{
+		OrderId:    timeline.OrderID,
+		CustomerId: timeline.CustomerID,
+		Entries:    entries,
+	}, nil
+}
+
+// VerifyAuditChain handles the verify audit chain gRPC request.
+func (s *AuditServer) VerifyAuditChain(
+	ctx context.Context,
+	req *pb.VerifyAuditChainRequest,
+) (*pb.VerifyAuditChainResponse, error) {
+	verifications, err := s.chainService.VerifyChains(ctx, req.ChainId)
+	if errors.Is(err, auditchain.ErrChainNotFound) {
+		return nil, status.Errorf(codes.NotFound, "no audit logs in chain %q", req.ChainId)
+	}
+	if err != nil {
+		slog.Error("Error verifying audit chains", "chain_id", req.ChainId, "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to verify audit chains: %v", err)
+	}
+
+	resp := &pb.VerifyAuditChainResponse{
+		Verified:      true,
+		Verifications: make([]*pb.AuditChainVerification, 0, len(verifications)),
+	}
+	for _, verification := range verifications {
+		converted := &pb.AuditChainVerification{
+			ChainId:         verification.ChainID,
+			AuditLogs:       int64(verification.AuditLogs),
+			Checkpoints:     int64(verification.Checkpoints),
+			PrunedAuditLogs: verification.PrunedAuditLogs,
+		}
+		if link := verification.BrokenLink; link != nil {
+			resp.Verified = false
+			converted.BrokenLink = &pb.AuditChainBrokenLink{
+				AuditLogId: link.AuditLogID,
+				Reason:     link.Reason,
+				Expected:   link.Expected,
+				Actual:     link.Actual,
+			}
+		}
+		resp.Verifications = append(resp.Verifications, converted)
+	}
+
+	return resp, nil
+}
+
+// ListAuditCheckpoints handles the list audit checkpoints gRPC request.
+func (s *AuditServer) ListAuditCheckpoints(
+	ctx context.Context,
+	req *pb.ListAuditCheckpointsRequest,
+) (*pb.ListAuditCheckpointsResponse, error) {
+	checkpoints, err := s.chainService.ListCheckpoints(ctx, req.ChainId)
+	if err != nil {
+		slog.Error("Error listing audit checkpoints", "chain_id", req.ChainId, "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to list audit checkpoints: %v", err)
+	}
+
+	resp := &pb.ListAuditCheckpointsResponse{
+		Checkpoints: make([]*pb.AuditCheckpoint, 0, len(checkpoints)),
+		PublicKey:   s.chainService.PublicKey(),
+	}
+	for _, checkpoint := range checkpoints {
+		resp.Checkpoints = append(resp.Checkpoints, &pb.AuditCheckpoint{
+			Id:             checkpoint.ID,
+			ChainId:        checkpoint.ChainID,
+			LastAuditLogId: checkpoint.LastAuditLogID,
+			Hash:           checkpoint.Hash,
+			KeyId:          checkpoint.KeyID,
+			Signature:      checkpoint.Signature,
+			CreatedAt:      timestamppb.New(checkpoint.CreatedAt),
+		})
+	}
+
+	return resp, nil
+}
+
+// auditLogToProto converts an audit log to its protobuf representation.
+func auditLogToProto(auditLog models.AuditLogOrder) *pb.AuditLog {
+	return &pb.AuditLog{
+		Id:            auditLog.ID,
+		OrderId:       auditLog.OrderID,
+		OrderItemId:   auditLog.OrderItemID,
+		CustomerId:    auditLog.CustomerID,
+		OrderStatus:   auditLog.OrderStatus,
+		CreatedAt:     timestamppb.New(auditLog.CreatedAt),
+		UpdatedAt:     timestamppb.New(auditLog.UpdatedAt),
+		EventType:     auditLog.EventType,
+		ActorId:       auditLog.ActorID,
+		ActorType:     auditLog.ActorType,
+		SourceService: auditLog.SourceService,
+		RequestId:     auditLog.RequestID,
+		Changes:       changesToProto(auditLog.Changes),
+		ChainId:       auditLog.ChainID,
+		PrevHash:      auditLog.PrevHash,
+		Hash:          auditLog.Hash,
+	}
+}
+
+// changesToProto converts field changes to their protobuf representation ordered by field.
+func changesToProto(changes map[string]models.FieldChange) []*pb.FieldChange {
+	fields := slices.Sorted(maps.Keys(changes))
+
+	converted := make([]*pb.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		converted = append(converted, &pb.FieldChange{
+			Field:  field,
+			Before: valueToProto(changes[field].Before),
+			After:  valueToProto(changes[field].After),
+		})
+	}
+
+	return converted
+}
+
+// valueToProto converts a changed value, values without a JSON representation are converted to strings.
+func valueToProto(v any) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+
+	return value
+}
@@ -0,0 +1,106 @@
+package httptransport
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/http/middleware/trace"
+	"github.com/corray333/backend-labs/order/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/spf13/viper"
+)
+
+// HTTPTransport represents the HTTP transport layer.
+type HTTPTransport struct {
+	server      *http.Server
+	router      *chi.Mux
+	auditServer v1.AuditServiceServer
+	gatewayMux  *runtime.ServeMux
+}
+
+// NewHTTPTransport creates a new HTTPTransport.
+func NewHTTPTransport(auditServer v1.AuditServiceServer) *HTTPTransport {
+	router := newRouter()
+	gatewayMux := runtime.NewServeMux()
+	server := newServer(router)
+
+	return &HTTPTransport{
+		server:      server,
+		router:      router,
+		auditServer: auditServer,
+		gatewayMux:  gatewayMux,
+	}
+}
+
+// Run starts the HTTP server.
+func (h *HTTPTransport) Run() error {
+	return h.server.ListenAndServe()
+}
+
+// Handler returns the HTTP handler with all registered routes.
+func (h *HTTPTransport) Handler() http.Handler {
+	return h.router
+}
+
+// Shutdown gracefully shuts down the HTTP server.
+func (h *HTTPTransport) Shutdown(ctx context.Context) error {
+	return h.server.Shutdown(ctx)
+}
+
+// RegisterRoutes registers the routes for the HTTPTransport.
+func (h *HTTPTransport) RegisterRoutes() {
+	// Register grpc-gateway handlers
+	if err := v1.RegisterAuditServiceHandlerServer(context.Background(), h.gatewayMux, h.auditServer); err != nil {
+		slog.Error("Failed to register audit grpc-gateway handler", "error", err)
+		panic(err)
+	}
+
+	// Mount the gateway mux to the router
+	h.router.Mount("/", h.gatewayMux)
+}
+
+// newRouter creates a new router for the HTTPTransport.
+func newRouter() *chi.Mux {
+	router := chi.NewMux()
+	router.Use(middleware.RequestID)
+	router.Use(logger.NewLoggerMiddleware(slog.Default()))
+	router.Use(trace.NewTraceMiddleware)
+
+	c := cors.New(cors.Options{
+		AllowedOrigins:   viper.GetStringSlice("server.http.cors.allowed_origins"),
+		AllowedMethods:   viper.GetStringSlice("server.http.cors.allowed_methods"),
+		AllowedHeaders:   viper.GetStringSlice("server.http.cors.allowed_headers"),
+		ExposedHeaders:   viper.GetStringSlice("server.http.cors.exposed_headers"),
+		AllowCredentials: viper.GetBool("server.http.cors.allow_credentials"),
+		MaxAge:           viper.GetInt("server.http.cors.max_age"),
+	})
+
+	router.Use(c.Handler)
+
+	return router
+}
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+)
+
+// newServer creates a new HTTP server.
+func newServer(router http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              "0.0.0.0:" + viper.GetString("server.http.port"),
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
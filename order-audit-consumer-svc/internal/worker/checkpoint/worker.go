@@ -0,0 +1,90 @@
+package checkpoint
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/spf13/viper"
+)
+
+// service represents the service layer interface.
+type service interface {
+	CreateCheckpoints(ctx context.Context) ([]auditchain.Checkpoint, error)
+}
+
+// Worker periodically signs checkpoints of the audit chains.
+type Worker struct {
+	service  service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// Config configures the checkpoint worker.
+// Zero values are replaced with defaults.
+type Config struct {
+	Interval time.Duration
+}
+
+// ConfigFromViper reads the checkpoint worker config from the audit.checkpoints section.
+func ConfigFromViper() Config {
+	return Config{
+		Interval: time.Duration(viper.GetInt("audit.checkpoints.interval_minutes")) * time.Minute,
+	}
+}
+
+// NewWorker creates a new checkpoint worker.
+func NewWorker(service service, cfg Config) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+
+	return &Worker{
+		service:  service,
+		interval: cfg.Interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins creating checkpoints every interval.
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Checkpoint worker started", "interval", w.interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Checkpoint worker shutting down")
+
+			return
+		case <-w.stopCh:
+			slog.Info("Checkpoint worker stopped")
+
+			return
+		case <-ticker.C:
+			w.createCheckpoints(ctx)
+		}
+	}
+}
+
+// Stop stops the worker.
+func (w *Worker) Stop() {
+	close(w.stopCh)
+}
+
+// createCheckpoints signs the heads of the chains extended since the last run.
+func (w *Worker) createCheckpoints(ctx context.Context) {
+	checkpoints, err := w.service.CreateCheckpoints(ctx)
+	if err != nil {
+		slog.Error("Failed to create audit chain checkpoints", "error", err)
+
+		return
+	}
+
+	if len(checkpoints) > 0 {
+		slog.Info("Created audit chain checkpoints", "count", len(checkpoints))
+	}
+}
@@ -2,52 +2,89 @@ package inbox
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math"
 	"time"
 
 	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iinboxrepo"
+	"github.com/corray333/backend-labs/consumer/internal/dal/interfaces/iquarantinerepo"
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
 	"github.com/corray333/backend-labs/consumer/internal/service/models"
-	"github.com/corray333/backend-labs/consumer/internal/service/models/order"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/corray333/backend-labs/order/pkg/broker"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // service represents the service layer interface.
 type service interface {
-	ProcessAuditLog(ctx context.Context, auditLog models.AuditLogOrder) error
+	ProcessAuditLogs(ctx context.Context, messageID string, auditLogs []models.AuditLogOrder) error
 }
 
 // Worker processes messages from the inbox table.
 type Worker struct {
-	inboxRepo    iinboxrepo.IInboxRepository
-	service      service
-	pollInterval time.Duration
-	batchSize    int
-	stopCh       chan struct{}
+	inboxRepo      iinboxrepo.IInboxRepository
+	quarantineRepo iquarantinerepo.IQuarantineRepository
+	registry       *handlers.Registry
+	service        service
+	pollInterval   time.Duration
+	batchSize      int
+	retryInterval  time.Duration
+	stopCh         chan struct{}
+}
+
+// Config configures the inbox worker.
+// Zero values are replaced with defaults.
+type Config struct {
+	PollInterval  time.Duration
+	BatchSize     int
+	RetryInterval time.Duration
+}
+
+// ConfigFromViper reads the inbox worker config from the rabbitmq.inbox section.
+func ConfigFromViper() Config {
+	return Config{
+		PollInterval:  time.Duration(viper.GetInt("rabbitmq.inbox.poll_interval_seconds")) * time.Second,
+		BatchSize:     viper.GetInt("rabbitmq.inbox.batch_size"),
+		RetryInterval: time.Duration(viper.GetInt("rabbitmq.inbox.retry_interval_seconds")) * time.Second,
+	}
 }
 
 // NewWorker creates a new inbox worker.
 func NewWorker(
 	inboxRepo iinboxrepo.IInboxRepository,
+	quarantineRepo iquarantinerepo.IQuarantineRepository,
+	registry *handlers.Registry,
 	service service,
+	cfg Config,
 ) *Worker {
-	pollIntervalSeconds := viper.GetInt("rabbitmq.inbox.poll_interval_seconds")
-	if pollIntervalSeconds == 0 {
-		pollIntervalSeconds = 10
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 10 * time.Second
 	}
-
-	batchSize := viper.GetInt("rabbitmq.inbox.batch_size")
-	if batchSize == 0 {
-		batchSize = 100
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
 	}
 
 	return &Worker{
-		inboxRepo:    inboxRepo,
-		service:      service,
-		pollInterval: time.Duration(pollIntervalSeconds) * time.Second,
-		batchSize:    batchSize,
-		stopCh:       make(chan struct{}),
+		inboxRepo:      inboxRepo,
+		quarantineRepo: quarantineRepo,
+		registry:       registry,
+		service:        service,
+		pollInterval:   cfg.PollInterval,
+		batchSize:      cfg.BatchSize,
+		retryInterval:  cfg.RetryInterval,
+		stopCh:         make(chan struct{}),
 	}
 }
 
@@ -95,109 +132,154 @@ func (w *Worker) processMessages(ctx context.Context) {
 	slog.Info("Processing inbox messages", "count", len(messages))
 
 	for _, msg := range messages {
-		// Unmarshal the order
-		var ord order.Order
-		if err := json.Unmarshal(msg.Payload, &ord); err != nil {
-			slog.Error("Failed to unmarshal order from inbox", "error", err, "inbox_id", msg.ID)
-
-			// If unmarshal fails, increment retry or delete if max retries reached
-			newRetryCount := msg.RetryCount + 1
-			if newRetryCount >= msg.MaxRetries {
-				slog.Warn("Max retries reached for malformed message, deleting",
-					"inbox_id", msg.ID,
-					"message_id", msg.MessageID,
-				)
-				if err := w.inboxRepo.Delete(ctx, msg.ID); err != nil {
-					slog.Error(
-						"Failed to delete message from inbox",
-						"inbox_id",
-						msg.ID,
-						"error",
-						err,
-					)
-				}
-			} else {
-				backoffSeconds := math.Pow(2, float64(newRetryCount)) * 30
-				nextRetryAt := time.Now().Add(time.Duration(backoffSeconds) * time.Second)
-				if err := w.inboxRepo.UpdateRetry(ctx, msg.ID, newRetryCount, err.Error(), nextRetryAt); err != nil {
-					slog.Error("Failed to update retry information", "inbox_id", msg.ID, "error", err)
-				}
-			}
-
-			continue
-		}
+		w.processMessage(ctx, msg)
+	}
+}
 
-		// Convert order to audit logs
-		auditLogs := w.convertOrderToAuditLogs(ord)
-
-		// Try to process each audit log
-		var processingErr error
-		for _, auditLog := range auditLogs {
-			if err := w.service.ProcessAuditLog(ctx, auditLog); err != nil {
-				processingErr = err
-				slog.Error(
-					"Failed to process audit log from inbox",
-					"error",
-					err,
-					"inbox_id",
-					msg.ID,
-					"order_id",
-					ord.ID,
-				)
-
-				break
-			}
-		}
+// RetryNow retries the inbox message right away, whether its retry is due or its retries are used up.
+// Returns inbox.ErrInboxMessageNotFound when the message is not in the inbox.
+func (w *Worker) RetryNow(ctx context.Context, id int64) (inbox.Outcome, error) {
+	msg, err := w.inboxRepo.Get(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get inbox message: %w", err)
+	}
+
+	slog.Info("Retrying inbox message on request", "inbox_id", msg.ID, "retry_count", msg.RetryCount)
+
+	return w.processMessage(ctx, msg), nil
+}
+
+// processMessage retries a single inbox message, continuing the trace of the original delivery.
+func (w *Worker) processMessage(ctx context.Context, msg inbox.InboxMessage) inbox.Outcome {
+	outcome := w.retry(ctx, msg)
+	metrics.InboxRetries.WithLabelValues(string(outcome)).Inc()
+
+	return outcome
+}
+
+// retry processes the message and removes it from the inbox or schedules its next retry.
+func (w *Worker) retry(ctx context.Context, msg inbox.InboxMessage) inbox.Outcome {
+	ctx, span := otel.Tracer("worker").Start(
+		broker.ExtractTraceHeaders(ctx, msg.Headers),
+		"InboxWorker.processMessage",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingDestinationName(msg.QueueName),
+			semconv.MessagingMessageID(msg.MessageID),
+			semconv.MessagingOperationTypeProcess,
+			attribute.Int("inbox.retry_count", msg.RetryCount),
+		),
+	)
+	defer span.End()
+
+	// Decode the event with the handler of its type, untyped messages by the queue they came from
+	delivered := broker.MessageFromStringHeaders(msg.RoutingKey, "", msg.Headers, msg.Payload)
+	delivered.ContentType = msg.ContentType
+	event, err := w.registry.Decode(delivered)
+	if err != nil {
+		slog.Error("Failed to decode event from inbox", "error", err, "inbox_id", msg.ID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 
-		if processingErr != nil {
-			// Update retry count and schedule next retry with exponential backoff
-			newRetryCount := msg.RetryCount + 1
-			backoffSeconds := math.Pow(2, float64(newRetryCount)) * 30 // 30s, 60s, 120s, 240s, etc.
-			nextRetryAt := time.Now().Add(time.Duration(backoffSeconds) * time.Second)
-
-			slog.Warn("Failed to process message from inbox, will retry",
-				"inbox_id", msg.ID,
-				"retry_count", newRetryCount,
-				"next_retry", nextRetryAt,
-				"error", processingErr,
-			)
-
-			if err := w.inboxRepo.UpdateRetry(ctx, msg.ID, newRetryCount, processingErr.Error(), nextRetryAt); err != nil {
-				slog.Error("Failed to update retry information", "inbox_id", msg.ID, "error", err)
-			}
-		} else {
-			// Successfully processed, delete from inbox
-			if err := w.inboxRepo.Delete(ctx, msg.ID); err != nil {
-				slog.Error("Failed to delete message from inbox after successful processing",
-					"inbox_id", msg.ID,
-					"error", err,
-				)
-			} else {
-				slog.Info("Message successfully processed and removed from inbox",
-					"inbox_id", msg.ID,
-					"message_id", msg.MessageID,
-					"order_id", ord.ID,
-				)
-			}
+		// Decoding fails the same way on every retry, the message is moved to the quarantine
+		return w.quarantine(ctx, msg, err)
+	}
+
+	processedID := event.ID
+	if processedID == "" {
+		processedID = msg.MessageID
+	}
+
+	// A message processed since it was saved to the inbox only has to be removed
+	processingErr := w.service.ProcessAuditLogs(ctx, processedID, event.AuditLogs)
+	if errors.Is(processingErr, models.ErrAlreadyProcessed) {
+		slog.Info("Inbox message already processed", "inbox_id", msg.ID, "event_id", processedID)
+		processingErr = nil
+	}
+	if processingErr != nil {
+		slog.Error("Failed to process audit logs from inbox",
+			"error", processingErr,
+			"inbox_id", msg.ID,
+			"order_id", event.OrderID,
+		)
+	}
+
+	if processingErr != nil {
+		span.RecordError(processingErr)
+		span.SetStatus(codes.Error, processingErr.Error())
+
+		// Update retry count and schedule next retry with exponential backoff of the retry interval
+		newRetryCount := msg.RetryCount + 1
+		backoff := math.Pow(2, float64(newRetryCount)) * float64(w.retryInterval)
+		nextRetryAt := time.Now().Add(time.Duration(backoff))
+
+		slog.Warn("Failed to process message from inbox, will retry",
+			"inbox_id", msg.ID,
+			"retry_count", newRetryCount,
+			"next_retry", nextRetryAt,
+			"error", processingErr,
+		)
+
+		if err := w.inboxRepo.UpdateRetry(ctx, msg.ID, newRetryCount, processingErr.Error(), nextRetryAt); err != nil {
+			slog.Error("Failed to update retry information", "inbox_id", msg.ID, "error", err)
 		}
+
+		return inbox.OutcomeRescheduled
 	}
+
+	// Successfully processed, delete from inbox
+	if err := w.inboxRepo.Delete(ctx, msg.ID); err != nil {
+		slog.Error("Failed to delete message from inbox after successful processing",
+			"inbox_id", msg.ID,
+			"error", err,
+		)
+	} else {
+		slog.Info("Message successfully processed and removed from inbox",
+			"inbox_id", msg.ID,
+			"message_id", msg.MessageID,
+			"order_id", event.OrderID,
+		)
+	}
+
+	return inbox.OutcomeProcessed
 }
 
-// convertOrderToAuditLogs converts an order to audit log entries.
-func (w *Worker) convertOrderToAuditLogs(ord order.Order) []models.AuditLogOrder {
-	auditLogs := make([]models.AuditLogOrder, 0, len(ord.OrderItems))
-
-	for _, item := range ord.OrderItems {
-		auditLog := models.AuditLogOrder{
-			OrderID:     ord.ID,
-			OrderItemID: item.ID,
-			CustomerID:  ord.CustomerID,
-			OrderStatus: "created",
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+// quarantine moves a malformed inbox message to the quarantine.
+// The message stays in the inbox and is retried when it can not be quarantined.
+func (w *Worker) quarantine(ctx context.Context, msg inbox.InboxMessage, cause error) inbox.Outcome {
+	quarantined := quarantine.QuarantinedMessage{
+		MessageID:   msg.MessageID,
+		QueueName:   msg.QueueName,
+		RoutingKey:  msg.RoutingKey,
+		Payload:     msg.Payload,
+		ContentType: msg.ContentType,
+		Headers:     msg.Headers,
+		Error:       cause.Error(),
+		Source:      quarantine.SourceInbox,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := w.quarantineRepo.Insert(ctx, quarantined); err != nil {
+		slog.Error("Failed to quarantine inbox message", "inbox_id", msg.ID, "error", err)
+
+		newRetryCount := msg.RetryCount + 1
+		backoff := math.Pow(2, float64(newRetryCount)) * float64(w.retryInterval)
+		nextRetryAt := time.Now().Add(time.Duration(backoff))
+		if err := w.inboxRepo.UpdateRetry(ctx, msg.ID, newRetryCount, cause.Error(), nextRetryAt); err != nil {
+			slog.Error("Failed to update retry information", "inbox_id", msg.ID, "error", err)
 		}
-		auditLogs = append(auditLogs, auditLog)
+
+		return inbox.OutcomeRescheduled
 	}
 
-	return auditLogs
+	if err := w.inboxRepo.Delete(ctx, msg.ID); err != nil {
+		slog.Error("Failed to delete quarantined message from inbox", "inbox_id", msg.ID, "error", err)
+
+		return inbox.OutcomeQuarantined
+	}
+
+	slog.Warn("Malformed inbox message quarantined", "inbox_id", msg.ID, "message_id", msg.MessageID)
+
+	return inbox.OutcomeQuarantined
 }
@@ -0,0 +1,97 @@
+package partition
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"github.com/spf13/viper"
+)
+
+// service represents the service layer interface.
+type service interface {
+	Maintain(ctx context.Context) (partition.MaintenanceReport, error)
+}
+
+// Worker periodically creates upcoming audit log partitions and removes expired ones.
+type Worker struct {
+	service  service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// Config configures the partition worker.
+// Zero values are replaced with defaults.
+type Config struct {
+	Interval time.Duration
+}
+
+// ConfigFromViper reads the partition worker config from the audit.partitions section.
+func ConfigFromViper() Config {
+	return Config{
+		Interval: time.Duration(viper.GetInt("audit.partitions.interval_minutes")) * time.Minute,
+	}
+}
+
+// NewWorker creates a new partition worker.
+func NewWorker(service service, cfg Config) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 6 * time.Hour
+	}
+
+	return &Worker{
+		service:  service,
+		interval: cfg.Interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start maintains the partitions right away and then every interval.
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Partition worker started", "interval", w.interval)
+
+	w.maintain(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Partition worker shutting down")
+
+			return
+		case <-w.stopCh:
+			slog.Info("Partition worker stopped")
+
+			return
+		case <-ticker.C:
+			w.maintain(ctx)
+		}
+	}
+}
+
+// Stop stops the worker.
+func (w *Worker) Stop() {
+	close(w.stopCh)
+}
+
+// maintain runs one partition maintenance.
+func (w *Worker) maintain(ctx context.Context) {
+	report, err := w.service.Maintain(ctx)
+	if err != nil {
+		slog.Error("Failed to maintain audit log partitions", "error", err)
+
+		return
+	}
+
+	if len(report.Created) > 0 || len(report.Removed) > 0 || report.PrunedDefault > 0 || len(report.Archived) > 0 {
+		slog.Info("Maintained audit log partitions",
+			"created", len(report.Created),
+			"removed", len(report.Removed),
+			"pruned_default", report.PrunedDefault,
+			"archived", len(report.Archived),
+		)
+	}
+}
@@ -0,0 +1,119 @@
+package processed
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// repository represents the processed messages store.
+type repository interface {
+	DeleteProcessedMessagesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// Worker periodically forgets messages processed longer ago than the retention,
+// so the processed_messages table does not grow forever.
+// A message delivered again after it was forgotten is not recognized as a duplicate,
+// the retention has to be longer than the broker can redeliver a message.
+type Worker struct {
+	repo      repository
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+	stopCh    chan struct{}
+}
+
+// Config configures the processed messages worker.
+// Zero values are replaced with defaults.
+type Config struct {
+	Interval  time.Duration
+	Retention time.Duration
+	BatchSize int
+}
+
+// ConfigFromViper reads the processed messages worker config from the audit.processed_messages section.
+func ConfigFromViper() Config {
+	return Config{
+		Interval:  time.Duration(viper.GetInt("audit.processed_messages.interval_minutes")) * time.Minute,
+		Retention: time.Duration(viper.GetInt("audit.processed_messages.retention_days")) * 24 * time.Hour,
+		BatchSize: viper.GetInt("audit.processed_messages.batch_size"),
+	}
+}
+
+// NewWorker creates a new processed messages worker.
+func NewWorker(repo repository, cfg Config) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Retention <= 0 {
+		// Twice the default Kafka log retention
+		cfg.Retention = 14 * 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+
+	return &Worker{
+		repo:      repo,
+		interval:  cfg.Interval,
+		retention: cfg.Retention,
+		batchSize: cfg.BatchSize,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start forgets expired processed messages right away and then every interval.
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Processed messages worker started", "interval", w.interval, "retention", w.retention)
+
+	w.expire(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Processed messages worker shutting down")
+
+			return
+		case <-w.stopCh:
+			slog.Info("Processed messages worker stopped")
+
+			return
+		case <-ticker.C:
+			w.expire(ctx)
+		}
+	}
+}
+
+// Stop stops the worker.
+func (w *Worker) Stop() {
+	close(w.stopCh)
+}
+
+// expire deletes messages processed before the retention in batches.
+func (w *Worker) expire(ctx context.Context) {
+	cutoff := time.Now().Add(-w.retention)
+
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := w.repo.DeleteProcessedMessagesBefore(ctx, cutoff, w.batchSize)
+		if err != nil {
+			slog.Error("Failed to delete expired processed messages", "error", err)
+
+			break
+		}
+		total += deleted
+
+		if deleted < int64(w.batchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("Deleted expired processed messages", "count", total, "cutoff", cutoff)
+	}
+}
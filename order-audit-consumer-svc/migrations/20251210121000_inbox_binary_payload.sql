@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table inbox
+    alter column payload type bytea using convert_to(payload::text, 'UTF8'),
+    alter column content_type set default 'application/x-protobuf';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table inbox
+    alter column content_type set default 'application/json',
+    alter column payload type jsonb using convert_from(payload, 'UTF8')::jsonb;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Message headers such as the W3C trace context, kept to continue the trace on retries
+alter table inbox
+    add column if not exists headers jsonb not null default '{}'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table inbox
+    drop column if exists headers;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists processed_messages
+(
+    message_id   text                     not null primary key,
+    processed_at timestamp with time zone not null default now()
+);
+
+create index if not exists idx_processed_messages_processed_at on processed_messages (processed_at);
+
+-- Audit logs are compliance records and are never deleted here. Duplicates of an order item status
+-- stop the migration, they are listed with
+--   select order_id, order_item_id, order_status, count(*) from audit_log_order
+--   group by order_id, order_item_id, order_status having count(*) > 1;
+-- and have to be resolved by hand before the migration is run again
+do
+$$
+    declare
+        duplicates bigint;
+    begin
+        select count(*)
+        into duplicates
+        from (select 1
+              from audit_log_order
+              group by order_id, order_item_id, order_status
+              having count(*) > 1) d;
+
+        if duplicates > 0 then
+            raise exception '% order item statuses have duplicate audit logs in audit_log_order, resolve them before enforcing uniqueness', duplicates;
+        end if;
+    end
+$$;
+
+alter table audit_log_order
+    add constraint uq_audit_log_order_item_status unique (order_id, order_item_id, order_status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table audit_log_order
+    drop constraint if exists uq_audit_log_order_item_status;
+
+drop table if exists processed_messages;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Poison messages that can not be decoded, kept as received for inspection, editing and replay
+create table if not exists quarantine
+(
+    id           bigserial                not null primary key,
+    message_id   text                     not null,
+    queue_name   text                     not null,
+    routing_key  text                     not null,
+    payload      bytea                    not null,
+    content_type text                     not null default '',
+    headers      jsonb                    not null default '{}'::jsonb,
+    error        text                     not null,
+    source       text                     not null,
+    created_at   timestamp with time zone not null,
+    updated_at   timestamp with time zone not null
+);
+
+create index if not exists idx_quarantine_created_at on quarantine (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists quarantine;
+-- +goose StatementEnd
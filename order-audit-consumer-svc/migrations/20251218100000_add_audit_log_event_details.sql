@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Who changed what: the event, its actor and request, and field level before/after values
+alter table audit_log_order
+    add column if not exists event_type     text  not null default '',
+    add column if not exists actor_id       text  not null default '',
+    add column if not exists actor_type     text  not null default '',
+    add column if not exists source_service text  not null default '',
+    add column if not exists request_id     text  not null default '',
+    add column if not exists changes        jsonb not null default '{}'::jsonb;
+
+-- An item can reach the same status several times, e.g. repeated item updates,
+-- only the audit log of the same change is a duplicate
+alter table audit_log_order
+    drop constraint if exists uq_audit_log_order_item_status;
+
+alter table audit_log_order
+    add constraint uq_audit_log_order_item_status_time unique (order_id, order_item_id, order_status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table audit_log_order
+    drop constraint if exists uq_audit_log_order_item_status_time;
+
+delete
+from audit_log_order a
+    using audit_log_order b
+where a.order_id = b.order_id
+  and a.order_item_id = b.order_item_id
+  and a.order_status = b.order_status
+  and a.id > b.id;
+
+alter table audit_log_order
+    add constraint uq_audit_log_order_item_status unique (order_id, order_item_id, order_status);
+
+alter table audit_log_order
+    drop column if exists changes,
+    drop column if exists request_id,
+    drop column if exists source_service,
+    drop column if exists actor_type,
+    drop column if exists actor_id,
+    drop column if exists event_type;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every audit log is linked to the previous audit log of its chain by hash.
+-- Audit logs saved before the chain was introduced keep an empty chain and are not verified.
+alter table audit_log_order
+    add column if not exists chain_id  text not null default '',
+    add column if not exists prev_hash text not null default '',
+    add column if not exists hash      text not null default '';
+
+create index if not exists idx_audit_log_order_chain_id on audit_log_order (chain_id, id);
+
+-- The last audit log of every chain, its row is locked while audit logs are appended
+create table if not exists audit_chain_heads
+(
+    chain_id          text                     not null primary key,
+    last_audit_log_id bigint                   not null default 0,
+    hash              text                     not null default '',
+    updated_at        timestamp with time zone not null default now()
+);
+
+-- Signed chain heads, exported to auditors
+create table if not exists audit_checkpoints
+(
+    id                bigserial                not null primary key,
+    chain_id          text                     not null,
+    last_audit_log_id bigint                   not null,
+    hash              text                     not null,
+    key_id            text                     not null,
+    signature         bytea                    not null,
+    created_at        timestamp with time zone not null
+);
+
+create index if not exists idx_audit_checkpoints_chain_id on audit_checkpoints (chain_id, last_audit_log_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists audit_checkpoints;
+drop table if exists audit_chain_heads;
+
+drop index if exists idx_audit_log_order_chain_id;
+
+alter table audit_log_order
+    drop column if exists hash,
+    drop column if exists prev_hash,
+    drop column if exists chain_id;
+-- +goose StatementEnd
@@ -0,0 +1,183 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit_log_order is rebuilt as a table partitioned by created_at month.
+-- Existing audit logs are copied into monthly partitions, IDs and the ID sequence are kept
+-- so hash chains still verify. The copy runs in the migration transaction.
+alter table audit_log_order rename to audit_log_order_legacy;
+
+alter table audit_log_order_legacy
+    drop constraint if exists audit_log_order_pkey,
+    drop constraint if exists uq_audit_log_order_item_status_time,
+    alter column id drop default;
+
+drop index if exists idx_audit_log_order_order_id;
+drop index if exists idx_audit_log_order_customer_id;
+drop index if exists idx_audit_log_order_created_at;
+drop index if exists idx_audit_log_order_chain_id;
+
+alter sequence audit_log_order_id_seq owned by none;
+
+-- Unique constraints of a partitioned table include the partition key
+create table audit_log_order
+(
+    id             bigint                   not null default nextval('audit_log_order_id_seq'),
+    order_id       bigint                   not null,
+    order_item_id  bigint                   not null,
+    customer_id    bigint                   not null,
+    order_status   text                     not null,
+    created_at     timestamp with time zone not null,
+    updated_at     timestamp with time zone not null,
+    event_type     text                     not null default '',
+    actor_id       text                     not null default '',
+    actor_type     text                     not null default '',
+    source_service text                     not null default '',
+    request_id     text                     not null default '',
+    changes        jsonb                    not null default '{}'::jsonb,
+    chain_id       text                     not null default '',
+    prev_hash      text                     not null default '',
+    hash           text                     not null default '',
+    constraint audit_log_order_pkey primary key (id, created_at),
+    constraint uq_audit_log_order_item_status_time unique (order_id, order_item_id, order_status, created_at)
+) partition by range (created_at);
+
+alter sequence audit_log_order_id_seq owned by audit_log_order.id;
+
+create index idx_audit_log_order_order_id on audit_log_order (order_id);
+create index idx_audit_log_order_customer_id on audit_log_order (customer_id);
+create index idx_audit_log_order_created_at on audit_log_order (created_at);
+create index idx_audit_log_order_chain_id on audit_log_order (chain_id, id);
+
+-- Audit logs of months without a partition, e.g. replayed events older than the retention
+create table audit_log_order_default partition of audit_log_order default;
+
+-- Monthly partitions audit_log_order_pYYYYMM from the oldest audit log to three months ahead,
+-- the partition worker keeps creating them afterwards
+do
+$$
+    declare
+        month timestamp with time zone := date_trunc('month', coalesce(
+                (select min(created_at) from audit_log_order_legacy), now()) at time zone 'UTC') at time zone 'UTC';
+        last  timestamp with time zone := date_trunc('month', now() at time zone 'UTC') at time zone 'UTC' + interval '3 months';
+    begin
+        while month <= last
+            loop
+                execute format(
+                        'create table if not exists %I partition of audit_log_order for values from (%L) to (%L)',
+                        'audit_log_order_p' || to_char(month at time zone 'UTC', 'YYYYMM'),
+                        month,
+                        month + interval '1 month'
+                        );
+                month := month + interval '1 month';
+            end loop;
+    end
+$$;
+
+insert into audit_log_order (id, order_id, order_item_id, customer_id, order_status, created_at, updated_at,
+                             event_type, actor_id, actor_type, source_service, request_id, changes,
+                             chain_id, prev_hash, hash)
+select id,
+       order_id,
+       order_item_id,
+       customer_id,
+       order_status,
+       created_at,
+       updated_at,
+       event_type,
+       actor_id,
+       actor_type,
+       source_service,
+       request_id,
+       changes,
+       chain_id,
+       prev_hash,
+       hash
+from audit_log_order_legacy;
+
+drop table audit_log_order_legacy;
+
+-- Chain links of audit logs removed by the retention, collapsed into runs of consecutive audit logs of a chain.
+-- A run stands in for its audit logs when the chain is verified.
+create table if not exists audit_chain_pruned_runs
+(
+    id                 bigserial                not null primary key,
+    chain_id           text                     not null,
+    first_audit_log_id bigint                   not null,
+    last_audit_log_id  bigint                   not null,
+    prev_hash          text                     not null,
+    hash               text                     not null,
+    audit_logs         bigint                   not null,
+    pruned_at          timestamp with time zone not null default now()
+);
+
+create index if not exists idx_audit_chain_pruned_runs_chain_id on audit_chain_pruned_runs (chain_id, first_audit_log_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists audit_chain_pruned_runs;
+
+alter table audit_log_order rename to audit_log_order_partitioned;
+
+alter table audit_log_order_partitioned
+    alter column id drop default;
+
+alter sequence audit_log_order_id_seq owned by none;
+
+drop index if exists idx_audit_log_order_order_id;
+drop index if exists idx_audit_log_order_customer_id;
+drop index if exists idx_audit_log_order_created_at;
+drop index if exists idx_audit_log_order_chain_id;
+
+alter table audit_log_order_partitioned
+    drop constraint if exists audit_log_order_pkey,
+    drop constraint if exists uq_audit_log_order_item_status_time;
+
+create table audit_log_order
+(
+    id             bigint                   not null default nextval('audit_log_order_id_seq') primary key,
+    order_id       bigint                   not null,
+    order_item_id  bigint                   not null,
+    customer_id    bigint                   not null,
+    order_status   text                     not null,
+    created_at     timestamp with time zone not null,
+    updated_at     timestamp with time zone not null,
+    event_type     text                     not null default '',
+    actor_id       text                     not null default '',
+    actor_type     text                     not null default '',
+    source_service text                     not null default '',
+    request_id     text                     not null default '',
+    changes        jsonb                    not null default '{}'::jsonb,
+    chain_id       text                     not null default '',
+    prev_hash      text                     not null default '',
+    hash           text                     not null default '',
+    constraint uq_audit_log_order_item_status_time unique (order_id, order_item_id, order_status, created_at)
+);
+
+alter sequence audit_log_order_id_seq owned by audit_log_order.id;
+
+insert into audit_log_order
+select id,
+       order_id,
+       order_item_id,
+       customer_id,
+       order_status,
+       created_at,
+       updated_at,
+       event_type,
+       actor_id,
+       actor_type,
+       source_service,
+       request_id,
+       changes,
+       chain_id,
+       prev_hash,
+       hash
+from audit_log_order_partitioned;
+
+drop table audit_log_order_partitioned;
+
+create index if not exists idx_audit_log_order_order_id on audit_log_order (order_id);
+create index if not exists idx_audit_log_order_customer_id on audit_log_order (customer_id);
+create index if not exists idx_audit_log_order_created_at on audit_log_order (created_at);
+create index if not exists idx_audit_log_order_chain_id on audit_log_order (chain_id, id);
+-- +goose StatementEnd
@@ -0,0 +1,360 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/inbox"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/consumersvc"
+	"github.com/corray333/backend-labs/consumer/internal/metrics"
+	admintransport "github.com/corray333/backend-labs/consumer/internal/transport/admin"
+	inboxworker "github.com/corray333/backend-labs/consumer/internal/worker/inbox"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// readinessResponse is the body of the readiness probe.
+type readinessResponse struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+// retryInboxResponse is the body of an inbox message retry.
+type retryInboxResponse struct {
+	ID      int64         `json:"id"`
+	Outcome inbox.Outcome `json:"outcome"`
+}
+
+// listInboxResponse is the body of the inbox listing.
+type listInboxResponse struct {
+	Messages []inbox.InboxMessage `json:"messages"`
+}
+
+// fakeCheck is a readiness check failing with the error it is set to.
+type fakeCheck struct {
+	mu  sync.Mutex
+	err error
+}
+
+// set makes the check fail with err, nil makes it pass.
+func (c *fakeCheck) set(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.err = err
+}
+
+// check returns the error the check is set to.
+func (c *fakeCheck) check(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// serveAdmin serves the admin API over the env, the database check is replaced with the fake one.
+// Inbox messages are retried on request only, the inbox worker reschedules failed retries an hour ahead.
+func serveAdmin(t *testing.T, e *env, database *fakeCheck) *httptest.Server {
+	t.Helper()
+
+	svc := consumersvc.MustNewConsumerService(consumersvc.WithAuditRepository(e.audit))
+	worker := inboxworker.NewWorker(e.inbox, e.quarantine, handlers.NewOrderRegistry(), svc, inboxworker.Config{
+		RetryInterval: time.Hour,
+	})
+
+	transport := admintransport.NewAdminTransport(
+		e.inbox,
+		worker,
+		admintransport.Config{CheckTimeout: 100 * time.Millisecond},
+		admintransport.Check{Name: "postgres", Check: database.check},
+		admintransport.Check{Name: "consumer", Check: func(context.Context) error {
+			if !e.consumer.Running() {
+				return errors.New("consumer is not running")
+			}
+
+			return nil
+		}},
+		admintransport.Check{Name: "broker", Check: e.broker.CheckHealth},
+	)
+	transport.RegisterRoutes()
+
+	server := httptest.NewServer(transport.Handler())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// adminRequest sends a request to the admin server and decodes a JSON response into out, when given.
+func adminRequest(t *testing.T, server *httptest.Server, method string, path string, out any) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("new request %s %s: %v", method, path, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+// insertInbox saves a message to the inbox as the inbox retrier does, due for its next retry after the delay.
+func insertInbox(t *testing.T, e *env, msg broker.Message, delay time.Duration) {
+	t.Helper()
+
+	err := e.inbox.Insert(context.Background(), inbox.InboxMessage{
+		MessageID:   msg.ID,
+		QueueName:   msg.Topic,
+		RoutingKey:  msg.Topic,
+		Payload:     msg.Body,
+		ContentType: msg.ContentType,
+		Headers:     broker.StringHeaders(msg),
+		MaxRetries:  3,
+		LastError:   "audit database is down",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		NextRetryAt: time.Now().Add(delay),
+	})
+	if err != nil {
+		t.Fatalf("insert inbox message: %v", err)
+	}
+}
+
+func TestAdminProbesReportDependencies(t *testing.T) {
+	e := newEnv(t)
+	database := &fakeCheck{}
+	server := serveAdmin(t, e, database)
+
+	var readiness readinessResponse
+	eventually(t, func() bool {
+		readiness = readinessResponse{}
+
+		return adminRequest(t, server, http.MethodGet, "/readyz", &readiness) == http.StatusOK
+	}, "service did not become ready")
+	if readiness.Status != "ok" || len(readiness.Checks) != 3 {
+		t.Fatalf("expected 3 passing checks, got %+v", readiness)
+	}
+
+	database.set(errors.New("connection refused"))
+	readiness = readinessResponse{}
+	if status := adminRequest(t, server, http.MethodGet, "/readyz", &readiness); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with the database down, got %d", status)
+	}
+	if check := readiness.Checks["postgres"]; check.Status != "failed" || check.Error != "connection refused" {
+		t.Fatalf("expected the postgres check to fail, got %+v", readiness)
+	}
+	if readiness.Checks["broker"].Status != "ok" || readiness.Checks["consumer"].Status != "ok" {
+		t.Fatalf("expected the other checks to pass, got %+v", readiness)
+	}
+	database.set(nil)
+
+	// A closed broker ends the subscription, the consumer stops receiving messages
+	_ = e.broker.Close()
+	eventually(t, func() bool {
+		return !e.consumer.Running()
+	}, "consumer did not stop with the broker")
+
+	readiness = readinessResponse{}
+	if status := adminRequest(t, server, http.MethodGet, "/readyz", &readiness); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with the broker closed, got %d", status)
+	}
+	if readiness.Checks["broker"].Status != "failed" || readiness.Checks["consumer"].Status != "failed" {
+		t.Fatalf("expected the broker and consumer checks to fail, got %+v", readiness)
+	}
+
+	// The process is still alive
+	var health map[string]string
+	if status := adminRequest(t, server, http.MethodGet, "/healthz", &health); status != http.StatusOK || health["status"] != "ok" {
+		t.Fatalf("expected the liveness probe to pass, got %d %v", status, health)
+	}
+}
+
+func TestAdminReadinessCheckTimesOut(t *testing.T) {
+	transport := admintransport.NewAdminTransport(nil, nil,
+		admintransport.Config{CheckTimeout: 20 * time.Millisecond},
+		admintransport.Check{Name: "postgres", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(time.Second)
+
+			return nil
+		}},
+	)
+	transport.RegisterRoutes()
+	server := httptest.NewServer(transport.Handler())
+	t.Cleanup(server.Close)
+
+	started := time.Now()
+	var readiness readinessResponse
+	if status := adminRequest(t, server, http.MethodGet, "/readyz", &readiness); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for a hanging check, got %d", status)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the probe to answer within the check timeout, took %s", elapsed)
+	}
+	if check := readiness.Checks["postgres"]; check.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the check to fail with the deadline, got %+v", check)
+	}
+}
+
+func TestAdminMetricsAreServed(t *testing.T) {
+	e := newEnv(t)
+	server := serveAdmin(t, e, &fakeCheck{})
+
+	e.publishOrder(t, 1, 1)
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 1
+	}, "message was not processed")
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	for _, series := range []string{
+		`audit_consumer_consumer_messages_total{outcome="processed",queue="oms.order.created"}`,
+		"audit_consumer_consumer_running",
+	} {
+		if !strings.Contains(string(body), series) {
+			t.Fatalf("expected %s in the metrics", series)
+		}
+	}
+}
+
+func TestQueueLagIsReported(t *testing.T) {
+	e := newEnv(t)
+	e.audit.SetDelay(100 * time.Millisecond)
+	ready := metrics.NewQueueDepthCollector(e.broker, []string{queueName}, "consumer-svc")
+	unacked := metrics.UnackedMessages.WithLabelValues(queueName)
+
+	// More messages than workers, the rest waits in the queue
+	for i := range 15 {
+		e.publishOrder(t, int64(i+1), 1)
+	}
+
+	eventually(t, func() bool {
+		return testutil.ToFloat64(unacked) > 0 && testutil.ToFloat64(ready) > 0
+	}, "in-flight and waiting messages were not reported")
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 15
+	}, "messages were not processed")
+	eventually(t, func() bool {
+		return testutil.ToFloat64(unacked) == 0 && testutil.ToFloat64(ready) == 0
+	}, "settled messages are still reported")
+}
+
+func TestAdminInboxIsListedRetriedAndDeleted(t *testing.T) {
+	e := newEnv(t)
+	server := serveAdmin(t, e, &fakeCheck{})
+
+	// Due in an hour or later, the running inbox worker leaves them alone
+	order := orderMessage(t, 1, 2)
+	insertInbox(t, e, orderMessage(t, 2, 1), 3*time.Hour)
+	insertInbox(t, e, order, time.Hour)
+	insertInbox(t, e, broker.Message{
+		Topic:       queueName,
+		ID:          "malformed",
+		ContentType: events.ContentTypeProtobuf,
+		Body:        []byte("not a protobuf message"),
+	}, 2*time.Hour)
+
+	var page listInboxResponse
+	if status := adminRequest(t, server, http.MethodGet, "/inbox?limit=2", &page); status != http.StatusOK {
+		t.Fatalf("expected 200 listing the inbox, got %d", status)
+	}
+	if len(page.Messages) != 2 || page.Messages[0].MessageID != order.ID || page.Messages[1].MessageID != "malformed" {
+		t.Fatalf("expected the first page in retry order, got %+v", page.Messages)
+	}
+	orderID, malformedID := page.Messages[0].ID, page.Messages[1].ID
+
+	page = listInboxResponse{}
+	adminRequest(t, server, http.MethodGet, "/inbox?limit=2&offset=2", &page)
+	if len(page.Messages) != 1 || page.Messages[0].ID != 1 {
+		t.Fatalf("expected the last message on the second page, got %+v", page.Messages)
+	}
+
+	// A failed retry is rescheduled
+	e.audit.SetError(errors.New("audit database is still down"))
+	var retry retryInboxResponse
+	if status := adminRequest(t, server, http.MethodPost, "/inbox/"+strconv.FormatInt(orderID, 10)+"/retry", &retry); status != http.StatusOK {
+		t.Fatalf("expected 200 retrying, got %d", status)
+	}
+	if retry.Outcome != inbox.OutcomeRescheduled {
+		t.Fatalf("expected the failed retry rescheduled, got %+v", retry)
+	}
+	msg, err := e.inbox.Get(context.Background(), orderID)
+	if err != nil || msg.RetryCount != 1 || msg.LastError != "audit database is still down" || !msg.NextRetryAt.After(time.Now()) {
+		t.Fatalf("expected the retry recorded on the inbox message, got %+v: %v", msg, err)
+	}
+
+	e.audit.SetError(nil)
+	retry = retryInboxResponse{}
+	adminRequest(t, server, http.MethodPost, "/inbox/"+strconv.FormatInt(orderID, 10)+"/retry", &retry)
+	if retry.Outcome != inbox.OutcomeProcessed {
+		t.Fatalf("expected the retry processed, got %+v", retry)
+	}
+	if n := len(e.audit.AuditLogs()); n != 2 {
+		t.Fatalf("expected 2 audit logs, got %d", n)
+	}
+
+	retry = retryInboxResponse{}
+	adminRequest(t, server, http.MethodPost, "/inbox/"+strconv.FormatInt(malformedID, 10)+"/retry", &retry)
+	if retry.Outcome != inbox.OutcomeQuarantined || len(e.quarantine.Messages()) != 1 {
+		t.Fatalf("expected the malformed message quarantined, got %+v", retry)
+	}
+
+	if status := adminRequest(t, server, http.MethodDelete, "/inbox/1", nil); status != http.StatusNoContent {
+		t.Fatalf("expected 204 deleting, got %d", status)
+	}
+	if n := len(e.inbox.Messages()); n != 0 {
+		t.Fatalf("expected empty inbox, got %d messages", n)
+	}
+
+	for _, req := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodDelete, "/inbox/1", http.StatusNotFound},
+		{http.MethodPost, "/inbox/1/retry", http.StatusNotFound},
+		{http.MethodPost, "/inbox/abc/retry", http.StatusBadRequest},
+		{http.MethodGet, "/inbox?limit=0", http.StatusBadRequest},
+		{http.MethodGet, "/inbox?offset=-1", http.StatusBadRequest},
+	} {
+		var resp struct {
+			Error string `json:"error"`
+		}
+		if status := adminRequest(t, server, req.method, req.path, &resp); status != req.status || resp.Error == "" {
+			t.Fatalf("expected %d with an error for %s %s, got %d %+v", req.status, req.method, req.path, status, resp)
+		}
+	}
+}
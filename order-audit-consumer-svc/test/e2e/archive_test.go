@@ -0,0 +1,372 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	localarchive "github.com/corray333/backend-labs/consumer/internal/dal/repositories/archive/local"
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	partitionmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/partition/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/archive"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/archivesvc"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/partitionsvc"
+)
+
+// newArchiveService creates an archive service writing files of at most rowsPerFile audit logs to dir.
+func newArchiveService(repo *auditmemory.AuditRepository, dir string, rowsPerFile int) *archivesvc.ArchiveService {
+	return archivesvc.MustNewArchiveService(
+		archivesvc.WithAuditArchiveRepository(repo),
+		archivesvc.WithArchiveStore(localarchive.NewStore(dir)),
+		archivesvc.WithRowsPerFile(rowsPerFile),
+	)
+}
+
+func TestArchiveExportAndImport(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2026, time.January).Add(time.Hour),
+		month(2026, time.January).Add(2*time.Hour),
+		month(2026, time.February).Add(time.Hour),
+		month(2026, time.January).Add(3*time.Hour),
+		month(2026, time.January).Add(4*time.Hour),
+		month(2026, time.January).Add(5*time.Hour),
+	)
+	archived := audit.AuditLogs()
+
+	dir := t.TempDir()
+	svc := newArchiveService(audit, dir, 2)
+
+	manifest, err := svc.Export(ctx, month(2026, time.January), month(2026, time.February))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if manifest.Rows != 5 || len(manifest.Files) != 3 {
+		t.Fatalf("expected 5 rows in 3 files, got %+v", manifest)
+	}
+	if file := manifest.Files[1]; file.Rows != 2 || file.FirstAuditLogID != 4 || file.LastAuditLogID != 5 {
+		t.Fatalf("expected the second file to hold audit logs 4 and 5, got %+v", file)
+	}
+	for _, file := range manifest.Files {
+		info, err := os.Stat(filepath.Join(dir, manifest.Prefix, file.Name))
+		if err != nil || info.Size() != file.Bytes || file.SHA256 == "" {
+			t.Fatalf("archive file %s does not match its manifest entry %+v: %v", file.Name, file, err)
+		}
+	}
+
+	if _, err := svc.Export(ctx, month(2026, time.January), month(2026, time.February)); !errors.Is(err, archive.ErrArchiveExists) {
+		t.Fatalf("expected exporting the range again to fail with ErrArchiveExists, got %v", err)
+	}
+	if _, err := svc.Verify(ctx, manifest.Prefix); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if pruned := audit.PruneAuditLogs(month(2026, time.January), month(2026, time.February)); pruned != 5 {
+		t.Fatalf("expected 5 audit logs pruned, got %d", pruned)
+	}
+
+	report, err := svc.Import(ctx, manifest.Prefix)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Files != 3 || report.Rows != 5 || report.Restored != 5 {
+		t.Fatalf("expected 5 audit logs restored from 3 files, got %+v", report)
+	}
+
+	restored := audit.AuditLogs()
+	if len(restored) != len(archived) {
+		t.Fatalf("expected %d audit logs after the import, got %d", len(archived), len(restored))
+	}
+	for i := range archived {
+		if restored[i].ID != archived[i].ID || restored[i].Hash != archived[i].Hash ||
+			!restored[i].CreatedAt.Equal(archived[i].CreatedAt) {
+			t.Fatalf("restored audit log %+v differs from the archived %+v", restored[i], archived[i])
+		}
+	}
+
+	// Restored audit logs take the place of their pruned runs
+	verification := verifyChain(t, audit)
+	if verification.BrokenLink != nil || verification.AuditLogs != 6 || verification.PrunedAuditLogs != 0 {
+		t.Fatalf("unexpected verification after the import: %+v", verification)
+	}
+
+	report, err = svc.Import(ctx, manifest.Prefix)
+	if err != nil {
+		t.Fatalf("import again: %v", err)
+	}
+	if report.Rows != 5 || report.Restored != 0 {
+		t.Fatalf("expected nothing restored by a repeated import, got %+v", report)
+	}
+}
+
+func TestArchiveImportRejectsCorruptedFiles(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2026, time.January).Add(time.Hour),
+		month(2026, time.January).Add(2*time.Hour),
+		month(2026, time.January).Add(3*time.Hour),
+	)
+
+	dir := t.TempDir()
+	svc := newArchiveService(audit, dir, 2)
+
+	manifest, err := svc.Export(ctx, month(2026, time.January), month(2026, time.February))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	audit.PruneAuditLogs(month(2026, time.January), month(2026, time.February))
+
+	// The second file is replaced with a valid archive file of other audit logs
+	other := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, other, month(2026, time.January).Add(time.Hour))
+	otherManifest, err := newArchiveService(other, dir, 2).Export(ctx, month(2026, time.January), month(2026, time.March))
+	if err != nil {
+		t.Fatalf("export other: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, otherManifest.Prefix, otherManifest.Files[0].Name))
+	if err != nil {
+		t.Fatalf("read archive file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.Prefix, manifest.Files[1].Name), data, 0o600); err != nil {
+		t.Fatalf("write archive file: %v", err)
+	}
+
+	if _, err := svc.Verify(ctx, manifest.Prefix); !errors.Is(err, archive.ErrCorrupted) {
+		t.Fatalf("expected verify to fail with ErrCorrupted, got %v", err)
+	}
+	if _, err := svc.Import(ctx, manifest.Prefix); !errors.Is(err, archive.ErrCorrupted) {
+		t.Fatalf("expected import to fail with ErrCorrupted, got %v", err)
+	}
+	if auditLogs := audit.AuditLogs(); len(auditLogs) != 0 {
+		t.Fatalf("expected nothing restored from a corrupted archive, got %+v", auditLogs)
+	}
+
+	if _, err := svc.Import(ctx, archive.Prefix(month(2025, time.January), month(2025, time.February))); !errors.Is(err, archive.ErrNotFound) {
+		t.Fatalf("expected importing a missing archive to fail with ErrNotFound, got %v", err)
+	}
+}
+
+func TestRetentionArchivesExpiredAuditLogs(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2026, time.January).Add(time.Hour),
+		month(2025, time.June).Add(time.Hour), // 2 and 3 are in the default partition
+		month(2025, time.August).Add(time.Hour),
+		month(2026, time.March).Add(time.Hour),
+	)
+	repo := partitionmemory.NewPartitionRepository(audit,
+		partition.ForMonth(month(2026, time.January)),
+		partition.ForMonth(month(2026, time.March)),
+	)
+
+	dir := t.TempDir()
+	archiveSvc := newArchiveService(audit, dir, 100)
+
+	// An archive of a part of the default partition, restored after the retention pruned it
+	partial, err := archiveSvc.Export(ctx, month(2025, time.June), month(2025, time.July))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{RetentionMonths: 1}),
+		partitionsvc.WithArchiver(archiveSvc),
+		partitionsvc.WithClock(func() time.Time { return time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC) }),
+	)
+
+	report, err := svc.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	januaryPrefix := archive.Prefix(month(2026, time.January), month(2026, time.February))
+	defaultPrefix := archive.Prefix(time.Time{}, month(2026, time.February))
+	if len(report.Archived) != 2 || report.Archived[0] != januaryPrefix || report.Archived[1] != defaultPrefix {
+		t.Fatalf("expected January and the default partition archived, got %+v", report.Archived)
+	}
+	if len(report.Removed) != 1 || report.PrunedDefault != 2 {
+		t.Fatalf("expected January and 2 default partition audit logs removed, got %+v", report)
+	}
+
+	for prefix, rows := range map[string]int64{januaryPrefix: 1, defaultPrefix: 2} {
+		manifest, err := archiveSvc.Verify(ctx, prefix)
+		if err != nil {
+			t.Fatalf("verify %s: %v", prefix, err)
+		}
+		if manifest.Rows != rows {
+			t.Fatalf("expected %d rows archived under %s, got %d", rows, prefix, manifest.Rows)
+		}
+	}
+
+	// The default partition is archived once a month, later runs leave it to the next month
+	report, err = svc.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("maintain again: %v", err)
+	}
+	if len(report.Archived) != 0 || report.PrunedDefault != 0 {
+		t.Fatalf("expected nothing archived or pruned by a second run, got %+v", report)
+	}
+
+	// A run restored in part stays pruned, the restored audit logs within it are not walked
+	if _, err := archiveSvc.Import(ctx, partial.Prefix); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	verification := verifyChain(t, audit)
+	if verification.BrokenLink != nil || verification.AuditLogs != 1 || verification.PrunedAuditLogs != 3 {
+		t.Fatalf("unexpected verification after a partial import: %+v", verification)
+	}
+
+	restored := audit.AuditLogs()
+	if len(restored) != 2 || restored[0].ID != 2 {
+		t.Fatalf("expected audit log 2 restored, got %+v", restored)
+	}
+}
+
+// racingArchiver saves audit logs created at the times right after an export,
+// like audit logs saved between an export and a removal that did not block writes.
+type racingArchiver struct {
+	*archivesvc.ArchiveService
+
+	t     *testing.T
+	audit *auditmemory.AuditRepository
+	times []time.Time
+}
+
+// Export exports the range and saves the racing audit logs once.
+func (a *racingArchiver) Export(ctx context.Context, from, to time.Time) (archive.Manifest, error) {
+	manifest, err := a.ArchiveService.Export(ctx, from, to)
+	saveAuditLogsAt(a.t, a.audit, a.times...)
+	a.times = nil
+
+	return manifest, err
+}
+
+func TestRetentionRetriesRemovalOfArchivedPartition(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2026, time.January).Add(time.Hour),
+		month(2026, time.March).Add(time.Hour),
+	)
+	repo := partitionmemory.NewPartitionRepository(audit,
+		partition.ForMonth(month(2026, time.January)),
+		partition.ForMonth(month(2026, time.March)),
+	)
+	archiveSvc := newArchiveService(audit, t.TempDir(), 100)
+
+	// An earlier run archived January and failed to remove it
+	januaryPrefix := archive.Prefix(month(2026, time.January), month(2026, time.February))
+	if _, err := archiveSvc.Export(ctx, month(2026, time.January), month(2026, time.February)); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{RetentionMonths: 1}),
+		partitionsvc.WithArchiver(archiveSvc),
+		partitionsvc.WithClock(func() time.Time { return time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC) }),
+	)
+
+	report, err := svc.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if len(report.Archived) != 0 || len(report.Removed) != 1 {
+		t.Fatalf("expected January removed without a new archive, got %+v", report)
+	}
+	if _, err := archiveSvc.Verify(ctx, januaryPrefix); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if auditLogs := audit.AuditLogs(); len(auditLogs) != 1 {
+		t.Fatalf("expected the March audit log to be kept, got %+v", auditLogs)
+	}
+}
+
+func TestRetentionRetriesPruneOfArchivedDefaultPartition(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2025, time.June).Add(time.Hour), // in the default partition
+		month(2026, time.March).Add(time.Hour),
+	)
+	repo := partitionmemory.NewPartitionRepository(audit, partition.ForMonth(month(2026, time.March)))
+	archiveSvc := newArchiveService(audit, t.TempDir(), 100)
+
+	// An earlier run archived the default partition and failed to prune it
+	if _, err := archiveSvc.Export(ctx, time.Time{}, month(2026, time.February)); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{RetentionMonths: 1}),
+		partitionsvc.WithArchiver(archiveSvc),
+		partitionsvc.WithClock(func() time.Time { return time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC) }),
+	)
+
+	report, err := svc.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if len(report.Archived) != 0 || report.PrunedDefault != 1 {
+		t.Fatalf("expected the default partition pruned without a new archive, got %+v", report)
+	}
+
+	// An expired audit log saved after the default partition was archived waits for the next month
+	saveAuditLogsAt(t, audit, month(2025, time.July).Add(time.Hour))
+	report, err = svc.Maintain(ctx)
+	if err != nil {
+		t.Fatalf("maintain again: %v", err)
+	}
+	if report.PrunedDefault != 0 {
+		t.Fatalf("expected an audit log missing from the archive not to be pruned, got %+v", report)
+	}
+	if auditLogs := audit.AuditLogs(); len(auditLogs) != 2 {
+		t.Fatalf("expected the March and the late audit log to be kept, got %+v", auditLogs)
+	}
+}
+
+func TestRetentionKeepsAuditLogsMissingFromTheArchive(t *testing.T) {
+	ctx := context.Background()
+	audit := auditmemory.NewAuditRepository()
+	saveAuditLogsAt(t, audit,
+		month(2026, time.January).Add(time.Hour),
+		month(2026, time.March).Add(time.Hour),
+	)
+	repo := partitionmemory.NewPartitionRepository(audit,
+		partition.ForMonth(month(2026, time.January)),
+		partition.ForMonth(month(2026, time.March)),
+	)
+	archiver := &racingArchiver{
+		ArchiveService: newArchiveService(audit, t.TempDir(), 100),
+		t:              t,
+		audit:          audit,
+		times:          []time.Time{month(2026, time.January).Add(2 * time.Hour)},
+	}
+
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{RetentionMonths: 1}),
+		partitionsvc.WithArchiver(archiver),
+		partitionsvc.WithClock(func() time.Time { return time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC) }),
+	)
+
+	if _, err := svc.Maintain(ctx); !errors.Is(err, partition.ErrNotArchived) {
+		t.Fatalf("expected ErrNotArchived for an audit log saved after the export, got %v", err)
+	}
+
+	partitions, err := repo.ListPartitions(ctx)
+	if err != nil {
+		t.Fatalf("list partitions: %v", err)
+	}
+	if len(partitions) != 2 || len(audit.AuditLogs()) != 3 {
+		t.Fatalf("expected nothing removed, got partitions %+v and audit logs %+v", partitions, audit.AuditLogs())
+	}
+}
@@ -0,0 +1,198 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/auditsvc"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/chainsvc"
+	grpctransport "github.com/corray333/backend-labs/consumer/internal/transport/grpc"
+	httptransport "github.com/corray333/backend-labs/consumer/internal/transport/http"
+)
+
+// auditLogsResponse is the HTTP gateway response of ListAuditLogs.
+type auditLogsResponse struct {
+	AuditLogs []struct {
+		ID          string `json:"id"`
+		OrderID     string `json:"orderId"`
+		OrderItemID string `json:"orderItemId"`
+		OrderStatus string `json:"orderStatus"`
+	} `json:"auditLogs"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// timelineResponse is the HTTP gateway response of GetOrderTimeline.
+type timelineResponse struct {
+	OrderID    string `json:"orderId"`
+	CustomerID string `json:"customerId"`
+	Entries    []struct {
+		OrderStatus  string   `json:"orderStatus"`
+		OrderItemIDs []string `json:"orderItemIds"`
+	} `json:"entries"`
+}
+
+// newAuditServer serves the audit query API over the HTTP gateway for the stored audit logs.
+func newAuditServer(t *testing.T, auditLogs ...models.AuditLogOrder) *httptest.Server {
+	t.Helper()
+
+	repo := auditmemory.NewAuditRepository()
+	for i, auditLog := range auditLogs {
+		if err := repo.SaveAuditLogs(context.Background(), fmt.Sprint(i), []models.AuditLogOrder{auditLog}); err != nil {
+			t.Fatalf("save audit log: %v", err)
+		}
+	}
+
+	return serveAudit(t, repo, chainsvc.MustNewChainService(chainsvc.WithAuditChainRepository(repo)))
+}
+
+// serveAudit serves the audit query API of the repository over the HTTP gateway.
+func serveAudit(t *testing.T, repo *auditmemory.AuditRepository, chainSvc *chainsvc.ChainService) *httptest.Server {
+	t.Helper()
+
+	svc := auditsvc.MustNewAuditService(auditsvc.WithAuditRepository(repo))
+	grpcTransport := grpctransport.NewGRPCTransport(svc, chainSvc)
+	transport := httptransport.NewHTTPTransport(grpcTransport.GetAuditServer())
+	transport.RegisterRoutes()
+
+	server := httptest.NewServer(transport.Handler())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// getJSON requests a path of the server and decodes the JSON response.
+func getJSON(t *testing.T, server *httptest.Server, path string, out any) int {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func TestAuditLogsAreListedByFilterAcrossPages(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	var auditLogs []models.AuditLogOrder
+	for i := range 5 {
+		auditLogs = append(auditLogs, models.AuditLogOrder{
+			OrderID:     1,
+			OrderItemID: int64(100 + i),
+			CustomerID:  10,
+			OrderStatus: "created",
+			CreatedAt:   start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	auditLogs = append(auditLogs, models.AuditLogOrder{
+		OrderID:     2,
+		OrderItemID: 200,
+		CustomerID:  20,
+		OrderStatus: "created",
+		CreatedAt:   start,
+	})
+	server := newAuditServer(t, auditLogs...)
+
+	var itemIDs []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not end")
+		}
+
+		query := url.Values{"customer_id": {"10"}, "page_size": {"2"}, "page_token": {token}}
+		var page auditLogsResponse
+		if code := getJSON(t, server, "/api/audit-service/v1/audit-logs?"+query.Encode(), &page); code != http.StatusOK {
+			t.Fatalf("list audit logs: status %d", code)
+		}
+		for _, auditLog := range page.AuditLogs {
+			itemIDs = append(itemIDs, auditLog.OrderItemID)
+		}
+
+		token = page.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+
+	want := []string{"104", "103", "102", "101", "100"}
+	if fmt.Sprint(itemIDs) != fmt.Sprint(want) {
+		t.Fatalf("expected items %v newest first, got %v", want, itemIDs)
+	}
+
+	query := url.Values{
+		"order_id":     {"1"},
+		"created_from": {start.Add(time.Minute).Format(time.RFC3339)},
+		"created_to":   {start.Add(3 * time.Minute).Format(time.RFC3339)},
+	}
+	var ranged auditLogsResponse
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-logs?"+query.Encode(), &ranged); code != http.StatusOK {
+		t.Fatalf("list audit logs: status %d", code)
+	}
+	if len(ranged.AuditLogs) != 2 || ranged.AuditLogs[0].OrderItemID != "102" || ranged.NextPageToken != "" {
+		t.Fatalf("unexpected audit logs in time range: %+v", ranged)
+	}
+
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-logs?page_token=broken", &ranged); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid page token, got %d", code)
+	}
+}
+
+func TestOrderTimelineListsStatusesInOrder(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	auditLog := func(itemID int64, status string, at time.Duration) models.AuditLogOrder {
+		return models.AuditLogOrder{
+			OrderID:     1,
+			OrderItemID: itemID,
+			CustomerID:  10,
+			OrderStatus: status,
+			CreatedAt:   start.Add(at),
+		}
+	}
+
+	// Saved out of order, the timeline is ordered by the time statuses were reached
+	server := newAuditServer(t,
+		auditLog(100, "cancelled", 2*time.Hour),
+		auditLog(100, "created", 0),
+		auditLog(101, "created", 0),
+		auditLog(100, "paid", time.Hour),
+		auditLog(101, "paid", time.Hour),
+	)
+
+	var timeline timelineResponse
+	if code := getJSON(t, server, "/api/audit-service/v1/orders/1/timeline", &timeline); code != http.StatusOK {
+		t.Fatalf("get timeline: status %d", code)
+	}
+
+	if timeline.CustomerID != "10" || len(timeline.Entries) != 3 {
+		t.Fatalf("unexpected timeline: %+v", timeline)
+	}
+	for i, status := range []string{"created", "paid", "cancelled"} {
+		if timeline.Entries[i].OrderStatus != status {
+			t.Fatalf("expected status %s at %d, got %+v", status, i, timeline.Entries)
+		}
+	}
+	if len(timeline.Entries[0].OrderItemIDs) != 2 {
+		t.Fatalf("expected both items in the created entry, got %+v", timeline.Entries[0])
+	}
+
+	if code := getJSON(t, server, "/api/audit-service/v1/orders/2/timeline", &timeline); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an order without audit logs, got %d", code)
+	}
+}
@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/transport/consumer"
+)
+
+func TestBatchedEventsAreWrittenTogether(t *testing.T) {
+	e := newEnvWithConfig(t, consumer.Config{
+		BatchSize:    10,
+		BatchTimeout: time.Second,
+	})
+
+	// Five orders of two items fill exactly one batch
+	for i := range 5 {
+		e.publishOrder(t, int64(i+1), 2)
+	}
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 10
+	}, "batched audit logs were not saved")
+
+	if n := e.audit.Writes(); n != 1 {
+		t.Fatalf("expected a single write, got %d", n)
+	}
+	if n := e.broker.Pending(queueName); n != 0 {
+		t.Fatalf("expected no pending messages, got %d", n)
+	}
+}
+
+func TestPartialBatchIsFlushedAfterTimeout(t *testing.T) {
+	e := newEnvWithConfig(t, consumer.Config{
+		BatchSize:    100,
+		BatchTimeout: 20 * time.Millisecond,
+	})
+
+	e.publishOrder(t, 1, 3)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 3
+	}, "partial batch was not flushed")
+}
+
+func TestDuplicateInBatchIsSavedOnce(t *testing.T) {
+	e := newEnvWithConfig(t, consumer.Config{
+		BatchSize:    100,
+		BatchTimeout: 20 * time.Millisecond,
+	})
+
+	msg := orderMessage(t, 1, 2)
+	e.publish(t, msg)
+	e.publish(t, msg)
+	e.publishOrder(t, 2, 1)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 3
+	}, "audit logs were not saved")
+
+	time.Sleep(40 * time.Millisecond)
+	if n := len(e.audit.AuditLogs()); n != 3 {
+		t.Fatalf("expected 3 audit logs, got %d", n)
+	}
+}
+
+func TestFailedBatchFallsBackToInbox(t *testing.T) {
+	e := newEnvWithConfig(t, consumer.Config{
+		BatchSize:    100,
+		BatchTimeout: 10 * time.Millisecond,
+	})
+	e.audit.SetError(errors.New("audit database is down"))
+
+	e.publishOrder(t, 1, 1)
+	e.publishOrder(t, 2, 1)
+
+	eventually(t, func() bool {
+		return len(e.inbox.Messages()) == 2
+	}, "messages of the failed batch were not saved to the inbox")
+
+	e.audit.SetError(nil)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2 && len(e.inbox.Messages()) == 0
+	}, "inbox worker did not process the messages")
+}
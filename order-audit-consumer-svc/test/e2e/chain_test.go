@@ -0,0 +1,238 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/chainsvc"
+)
+
+// verifyResponse is the HTTP gateway response of VerifyAuditChain.
+type verifyResponse struct {
+	Verified      bool `json:"verified"`
+	Verifications []struct {
+		ChainID    string `json:"chainId"`
+		AuditLogs  string `json:"auditLogs"`
+		BrokenLink *struct {
+			AuditLogID string `json:"auditLogId"`
+			Reason     string `json:"reason"`
+		} `json:"brokenLink"`
+	} `json:"verifications"`
+}
+
+// checkpointsResponse is the HTTP gateway response of ListAuditCheckpoints.
+type checkpointsResponse struct {
+	Checkpoints []struct {
+		ChainID        string    `json:"chainId"`
+		LastAuditLogID string    `json:"lastAuditLogId"`
+		Hash           string    `json:"hash"`
+		KeyID          string    `json:"keyId"`
+		Signature      []byte    `json:"signature"`
+		CreatedAt      time.Time `json:"createdAt"`
+	} `json:"checkpoints"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+// saveChainedAuditLogs saves an audit log per message for each customer.
+func saveChainedAuditLogs(t *testing.T, repo *auditmemory.AuditRepository, customerIDs ...int64) {
+	t.Helper()
+
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	for i, customerID := range customerIDs {
+		auditLog := models.AuditLogOrder{
+			OrderID:     customerID,
+			OrderItemID: int64(100 + i),
+			CustomerID:  customerID,
+			OrderStatus: "created",
+			Changes:     map[string]models.FieldChange{"item.quantity": {After: int64(i + 1)}},
+			CreatedAt:   start.Add(time.Duration(i) * time.Minute),
+			UpdatedAt:   start.Add(time.Duration(i) * time.Minute),
+		}
+		messageID := fmt.Sprint(len(repo.AuditLogs()), "-", i)
+		if err := repo.SaveAuditLogs(context.Background(), messageID, []models.AuditLogOrder{auditLog}); err != nil {
+			t.Fatalf("save audit log: %v", err)
+		}
+	}
+}
+
+func TestConsumedAuditLogsAreChained(t *testing.T) {
+	e := newEnv(t)
+
+	e.publish(t, orderMessage(t, 1, 2))
+	e.publish(t, orderMessage(t, 2, 3))
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 5
+	}, "audit logs were not saved")
+
+	prevHash := ""
+	for _, auditLog := range e.audit.AuditLogs() {
+		if auditLog.ChainID != string(auditchain.ScopeGlobal) || auditLog.PrevHash != prevHash || auditLog.Hash == "" {
+			t.Fatalf("audit log not linked to the previous one: %+v", auditLog)
+		}
+		prevHash = auditLog.Hash
+	}
+
+	svc := chainsvc.MustNewChainService(chainsvc.WithAuditChainRepository(e.audit))
+	verifications, err := svc.VerifyChains(context.Background(), "")
+	if err != nil {
+		t.Fatalf("verify chains: %v", err)
+	}
+	if len(verifications) != 1 || verifications[0].AuditLogs != 5 || verifications[0].BrokenLink != nil {
+		t.Fatalf("unexpected verification: %+v", verifications)
+	}
+}
+
+func TestEditedAuditLogBreaksItsChain(t *testing.T) {
+	repo := auditmemory.NewAuditRepository()
+	repo.SetChainScope(auditchain.ScopeCustomer)
+	saveChainedAuditLogs(t, repo, 10, 10, 20, 10, 20)
+	server := serveAudit(t, repo, chainsvc.MustNewChainService(chainsvc.WithAuditChainRepository(repo)))
+
+	var verified verifyResponse
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-chains/verify", &verified); code != http.StatusOK {
+		t.Fatalf("verify chains: status %d", code)
+	}
+	if !verified.Verified || len(verified.Verifications) != 2 {
+		t.Fatalf("expected two verified chains, got %+v", verified)
+	}
+
+	// The second audit log of customer 10 is edited after the fact
+	repo.UpdateAuditLog(2, func(auditLog *models.AuditLogOrder) {
+		auditLog.OrderStatus = "cancelled"
+	})
+
+	var broken verifyResponse
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-chains/verify", &broken); code != http.StatusOK {
+		t.Fatalf("verify chains: status %d", code)
+	}
+	if broken.Verified {
+		t.Fatal("expected the edited chain not to verify")
+	}
+	for _, verification := range broken.Verifications {
+		switch verification.ChainID {
+		case "customer:10":
+			link := verification.BrokenLink
+			if link == nil || link.AuditLogID != "2" || link.Reason != auditchain.ReasonHashMismatch {
+				t.Errorf("expected a hash mismatch at audit log 2, got %+v", link)
+			}
+		case "customer:20":
+			if verification.BrokenLink != nil || verification.AuditLogs != "2" {
+				t.Errorf("expected the chain of customer 20 to verify, got %+v", verification)
+			}
+		default:
+			t.Errorf("unexpected chain %q", verification.ChainID)
+		}
+	}
+
+	// Relinking the edited audit log moves the break to the next one of the chain
+	repo.UpdateAuditLog(2, func(auditLog *models.AuditLogOrder) {
+		hash, err := auditchain.Hash(*auditLog)
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		auditLog.Hash = hash
+	})
+
+	svc := chainsvc.MustNewChainService(chainsvc.WithAuditChainRepository(repo))
+	verifications, err := svc.VerifyChains(context.Background(), "customer:10")
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	link := verifications[0].BrokenLink
+	if link == nil || link.AuditLogID != 4 || link.Reason != auditchain.ReasonPrevHashMismatch {
+		t.Fatalf("expected a prev hash mismatch at audit log 4, got %+v", link)
+	}
+
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-chains/verify?chain_id=customer:99", &broken); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown chain, got %d", code)
+	}
+}
+
+func TestCheckpointsAreSignedAndExported(t *testing.T) {
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	repo := auditmemory.NewAuditRepository()
+	svc := chainsvc.MustNewChainService(
+		chainsvc.WithAuditChainRepository(repo),
+		chainsvc.WithSigningKey(signingKey),
+	)
+	server := serveAudit(t, repo, svc)
+
+	saveChainedAuditLogs(t, repo, 10, 20)
+	if checkpoints, err := svc.CreateCheckpoints(context.Background()); err != nil || len(checkpoints) != 1 {
+		t.Fatalf("expected a checkpoint of the global chain, got %+v, %v", checkpoints, err)
+	}
+	// The chain did not change since its checkpoint
+	if checkpoints, err := svc.CreateCheckpoints(context.Background()); err != nil || len(checkpoints) != 0 {
+		t.Fatalf("expected no checkpoints, got %+v, %v", checkpoints, err)
+	}
+	saveChainedAuditLogs(t, repo, 30)
+	if checkpoints, err := svc.CreateCheckpoints(context.Background()); err != nil || len(checkpoints) != 1 {
+		t.Fatalf("expected a checkpoint of the extended chain, got %+v, %v", checkpoints, err)
+	}
+
+	var exported checkpointsResponse
+	if code := getJSON(t, server, "/api/audit-service/v1/audit-checkpoints", &exported); code != http.StatusOK {
+		t.Fatalf("export checkpoints: status %d", code)
+	}
+	if len(exported.Checkpoints) != 2 {
+		t.Fatalf("expected 2 checkpoints, got %+v", exported)
+	}
+
+	// Auditors verify the exported checkpoints with the exported public key alone
+	publicKey := ed25519.PublicKey(exported.PublicKey)
+	for _, c := range exported.Checkpoints {
+		lastID, err := strconv.ParseInt(c.LastAuditLogID, 10, 64)
+		if err != nil {
+			t.Fatalf("parse last audit log id: %v", err)
+		}
+		checkpoint := auditchain.Checkpoint{
+			ChainID:        c.ChainID,
+			LastAuditLogID: lastID,
+			Hash:           c.Hash,
+			KeyID:          c.KeyID,
+			Signature:      c.Signature,
+			CreatedAt:      c.CreatedAt,
+		}
+		if !checkpoint.VerifySignature(publicKey) {
+			t.Errorf("checkpoint signature does not verify: %+v", c)
+		}
+	}
+
+	verifications, err := svc.VerifyChains(context.Background(), "")
+	if err != nil {
+		t.Fatalf("verify chains: %v", err)
+	}
+	if verifications[0].BrokenLink != nil || verifications[0].Checkpoints != 2 {
+		t.Fatalf("unexpected verification: %+v", verifications)
+	}
+
+	// A checkpoint signed with another key is reported
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	other := chainsvc.MustNewChainService(
+		chainsvc.WithAuditChainRepository(repo),
+		chainsvc.WithSigningKey(otherKey),
+	)
+	verifications, err = other.VerifyChains(context.Background(), "")
+	if err != nil {
+		t.Fatalf("verify chains: %v", err)
+	}
+	if link := verifications[0].BrokenLink; link == nil || link.Reason != auditchain.ReasonCheckpointSignature {
+		t.Fatalf("expected a checkpoint signature failure, got %+v", link)
+	}
+}
@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+)
+
+func TestOrderEventIsAudited(t *testing.T) {
+	e := newEnv(t)
+
+	e.publishOrder(t, 1, 3)
+	e.publishOrder(t, 2, 1)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 4
+	}, "audit logs were not saved")
+
+	for _, log := range e.audit.AuditLogs() {
+		if log.CustomerID != log.OrderID*10 || log.OrderStatus != "created" {
+			t.Errorf("unexpected audit log: %+v", log)
+		}
+	}
+
+	eventually(t, func() bool {
+		return e.broker.Pending(queueName) == 0
+	}, "messages were not acknowledged")
+	if n := len(e.inbox.Messages()); n != 0 {
+		t.Fatalf("expected empty inbox, got %d messages", n)
+	}
+}
+
+func TestRedeliveredEventIsAcknowledgedWithoutSideEffects(t *testing.T) {
+	e := newEnv(t)
+
+	msg := orderMessage(t, 1, 2)
+	e.publish(t, msg)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "audit logs were not saved")
+
+	// Redelivery after a crash between saving and acknowledging
+	e.publish(t, msg)
+
+	eventually(t, func() bool {
+		return e.broker.Pending(queueName) == 0
+	}, "redelivered message was not acknowledged")
+
+	time.Sleep(20 * time.Millisecond)
+	if n := len(e.audit.AuditLogs()); n != 2 {
+		t.Fatalf("expected 2 audit logs, got %d", n)
+	}
+	if n := len(e.inbox.Messages()); n != 0 {
+		t.Fatalf("expected empty inbox, got %d messages", n)
+	}
+}
+
+func TestFailedEventIsRetriedFromInbox(t *testing.T) {
+	e := newEnv(t)
+	e.audit.SetError(errors.New("audit database is down"))
+
+	e.publishOrder(t, 1, 2)
+
+	eventually(t, func() bool {
+		return len(e.inbox.Messages()) == 1
+	}, "failed message was not saved to the inbox")
+
+	e.audit.SetError(nil)
+
+	eventually(t, func() bool {
+		return len(e.inbox.Messages()) == 0
+	}, "inbox worker did not process the message")
+
+	if n := len(e.audit.AuditLogs()); n != 2 {
+		t.Fatalf("expected 2 audit logs, got %d", n)
+	}
+}
+
+func TestMalformedEventIsQuarantined(t *testing.T) {
+	e := newEnv(t)
+
+	e.publish(t, broker.Message{
+		Topic:       queueName,
+		ID:          "malformed",
+		ContentType: events.ContentTypeProtobuf,
+		Headers:     map[string]any{"custom": "kept"},
+		Body:        []byte("not a protobuf message"),
+	})
+	e.publishOrder(t, 1, 1)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 1
+	}, "valid message after a malformed one was not processed")
+
+	eventually(t, func() bool {
+		return len(e.quarantine.Messages()) == 1
+	}, "malformed message was not quarantined")
+
+	quarantined := e.quarantine.Messages()[0]
+	if quarantined.MessageID != "malformed" || string(quarantined.Payload) != "not a protobuf message" {
+		t.Errorf("raw message was not kept: %+v", quarantined)
+	}
+	if quarantined.Headers["custom"] != "kept" || quarantined.Error == "" {
+		t.Errorf("headers or parse error were not kept: %+v", quarantined)
+	}
+
+	if n := e.broker.Pending(queueName); n != 0 {
+		t.Fatalf("expected no pending messages, got %d", n)
+	}
+	if n := len(e.inbox.Messages()); n != 0 {
+		t.Fatalf("expected empty inbox, got %d messages", n)
+	}
+}
+
+func TestMalformedEventIsRetriedWhenQuarantineFails(t *testing.T) {
+	e := newEnv(t)
+	e.quarantine.SetError(errors.New("quarantine database is down"))
+
+	e.publish(t, broker.Message{
+		Topic:       queueName,
+		ID:          "malformed",
+		ContentType: events.ContentTypeProtobuf,
+		Body:        []byte("not a protobuf message"),
+	})
+
+	// The message is retried with a delay instead of being requeued right away
+	eventually(t, func() bool {
+		return len(e.inbox.Messages()) == 1
+	}, "malformed message was not saved to the inbox")
+	if n := e.broker.Pending(queueName); n != 0 {
+		t.Fatalf("expected the message acknowledged, got %d pending", n)
+	}
+
+	e.quarantine.SetError(nil)
+
+	eventually(t, func() bool {
+		return len(e.quarantine.Messages()) == 1 && len(e.inbox.Messages()) == 0
+	}, "inbox worker did not quarantine the message")
+
+	if quarantined := e.quarantine.Messages()[0]; string(quarantined.Payload) != "not a protobuf message" {
+		t.Errorf("raw message was not kept: %+v", quarantined)
+	}
+}
+
+func TestFailuresDoNotCancelInFlightMessages(t *testing.T) {
+	e := newEnv(t)
+	e.audit.SetDelay(20 * time.Millisecond)
+
+	for i := range 5 {
+		e.publishOrder(t, int64(i+1), 1)
+		e.publish(t, broker.Message{
+			Topic:       queueName,
+			ID:          "malformed",
+			ContentType: events.ContentTypeProtobuf,
+			Body:        []byte("not a protobuf message"),
+		})
+	}
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 5
+	}, "messages processed next to failing ones were not audited")
+}
+
+func TestShutdownDrainsInFlightAndRequeuesTheRest(t *testing.T) {
+	const orders = 30
+
+	e := newEnv(t)
+	e.audit.SetDelay(50 * time.Millisecond)
+
+	for i := range orders {
+		e.publishOrder(t, int64(i+1), 1)
+	}
+
+	// Let the workers pick up messages
+	time.Sleep(20 * time.Millisecond)
+
+	if err := e.consumer.Shutdown(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	audited := len(e.audit.AuditLogs())
+	pending := e.broker.Pending(queueName)
+	if audited == 0 {
+		t.Fatal("in-flight messages were not finished")
+	}
+	if pending == 0 {
+		t.Fatal("expected unstarted messages to stay in the queue")
+	}
+	if audited+pending != orders {
+		t.Fatalf("lost messages: %d audited, %d pending, %d published", audited, pending, orders)
+	}
+}
@@ -0,0 +1,194 @@
+package e2e
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	inboxmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/inbox/memory"
+	quarantinememory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/quarantine/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/consumersvc"
+	"github.com/corray333/backend-labs/consumer/internal/transport/consumer"
+	inboxworker "github.com/corray333/backend-labs/consumer/internal/worker/inbox"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/broker/memory"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	queueName          = "oms.order.created"
+	replayQueue        = "oms.order.replay"
+	statusChangedQueue = "oms.order.status_changed"
+	cancelledQueue     = "oms.order.cancelled"
+	itemUpdatedQueue   = "oms.order.item_updated"
+	parkingLot         = queueName + ".parking-lot"
+)
+
+// subscriptions are the queues the consumer receives events from in tests.
+var subscriptions = []consumer.Subscription{
+	{Queue: queueName, EventType: events.TypeOrderCreated},
+	{Queue: replayQueue, EventType: events.TypeOrderCreated},
+	{Queue: statusChangedQueue, EventType: events.TypeOrderStatusChanged},
+	{Queue: cancelledQueue, EventType: events.TypeOrderCancelled},
+	{Queue: itemUpdatedQueue, EventType: events.TypeOrderItemUpdated},
+}
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	os.Exit(m.Run())
+}
+
+// env is the consumer service wired with in-memory dependencies.
+type env struct {
+	consumer   *consumer.Consumer
+	broker     *memory.Broker
+	audit      *auditmemory.AuditRepository
+	inbox      *inboxmemory.InboxRepository
+	quarantine *quarantinememory.QuarantineRepository
+}
+
+// newEnv wires the consumer and the inbox worker and starts both.
+func newEnv(t *testing.T) *env {
+	t.Helper()
+
+	return newEnvWithConfig(t, consumer.Config{})
+}
+
+// newEnvWithConfig is newEnv with a custom consumer config, the subscriptions are set for tests.
+func newEnvWithConfig(t *testing.T, cfg consumer.Config) *env {
+	t.Helper()
+
+	return newEnvWithRetrier(t, cfg, func(e *env) consumer.Retrier {
+		return consumer.NewInboxRetrier(e.inbox, consumer.InboxRetryConfig{
+			RetryInterval: time.Millisecond,
+		})
+	})
+}
+
+// newBrokerRetryEnv is newEnv retrying failed messages through delayed broker queues.
+func newBrokerRetryEnv(t *testing.T, delays ...time.Duration) *env {
+	t.Helper()
+
+	return newEnvWithRetrier(t, consumer.Config{}, func(e *env) consumer.Retrier {
+		return consumer.MustNewBrokerRetrier(e.broker, consumer.BrokerRetryConfig{
+			Queues:     []string{queueName},
+			Delays:     delays,
+			ParkingLot: parkingLot,
+		})
+	})
+}
+
+// newEnvWithRetrier wires the consumer with the retry strategy built by newRetrier.
+func newEnvWithRetrier(t *testing.T, cfg consumer.Config, newRetrier func(e *env) consumer.Retrier) *env {
+	t.Helper()
+
+	cfg.Subscriptions = subscriptions
+
+	e := &env{
+		broker:     memory.New(),
+		audit:      auditmemory.NewAuditRepository(),
+		inbox:      inboxmemory.NewInboxRepository(),
+		quarantine: quarantinememory.NewQuarantineRepository(),
+	}
+
+	svc := consumersvc.MustNewConsumerService(consumersvc.WithAuditRepository(e.audit))
+	registry := handlers.NewOrderRegistry()
+
+	e.consumer = consumer.NewConsumer(e.broker, svc, registry, newRetrier(e), e.quarantine, cfg)
+	worker := inboxworker.NewWorker(e.inbox, e.quarantine, registry, svc, inboxworker.Config{
+		PollInterval:  10 * time.Millisecond,
+		RetryInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = e.consumer.Run(ctx)
+	}()
+	go worker.Start(ctx)
+
+	t.Cleanup(func() {
+		cancel()
+		_ = e.broker.Close()
+	})
+
+	return e
+}
+
+// publishOrder publishes an order created event for an order with the given number of items.
+func (e *env) publishOrder(t *testing.T, orderID int64, items int) {
+	t.Helper()
+
+	e.publish(t, orderMessage(t, orderID, items))
+}
+
+// orderMessage builds an order created event message for an order with the given number of items.
+func orderMessage(t *testing.T, orderID int64, items int) broker.Message {
+	t.Helper()
+
+	ord := &pb.OrderSnapshot{
+		Id:                 orderID,
+		CustomerId:         orderID * 10,
+		TotalPriceCurrency: "RUB",
+	}
+	for i := range items {
+		ord.OrderItems = append(ord.OrderItems, &pb.OrderItemSnapshot{
+			Id:        orderID*100 + int64(i),
+			OrderId:   orderID,
+			ProductId: int64(i + 1),
+			Quantity:  1,
+		})
+	}
+
+	eventID := uuid.NewString()
+
+	return eventMessage(t, queueName, events.TypeOrderCreated, eventID, &pb.OrderCreated{EventId: eventID, Order: ord})
+}
+
+// eventMessage builds a protobuf event message of the given type for a queue.
+func eventMessage(t *testing.T, queue string, eventType string, eventID string, event proto.Message) broker.Message {
+	t.Helper()
+
+	body, err := events.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+
+	return broker.Message{
+		Topic:       queue,
+		ID:          eventID,
+		Type:        eventType,
+		ContentType: events.ContentTypeProtobuf,
+		Timestamp:   time.Now(),
+		Body:        body,
+	}
+}
+
+// publish publishes a raw message to the consumer queue.
+func (e *env) publish(t *testing.T, msg broker.Message) {
+	t.Helper()
+
+	if err := e.broker.Publish(context.Background(), msg); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+// eventually polls cond until it holds or the timeout expires.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
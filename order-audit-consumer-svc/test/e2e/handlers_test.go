@@ -0,0 +1,185 @@
+package e2e
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/handlers"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/google/uuid"
+)
+
+func TestOrderEventTypesProduceTheirAuditLogs(t *testing.T) {
+	e := newEnv(t)
+
+	statusChangedID := uuid.NewString()
+	e.publish(t, eventMessage(t, statusChangedQueue, events.TypeOrderStatusChanged, statusChangedID,
+		&pb.OrderStatusChanged{
+			EventId:      statusChangedID,
+			OrderId:      1,
+			CustomerId:   10,
+			OrderItemIds: []int64{100, 101},
+			OldStatus:    "created",
+			NewStatus:    "paid",
+		}))
+
+	cancelledID := uuid.NewString()
+	e.publish(t, eventMessage(t, cancelledQueue, events.TypeOrderCancelled, cancelledID,
+		&pb.OrderCancelled{
+			EventId:      cancelledID,
+			OrderId:      2,
+			CustomerId:   20,
+			OrderItemIds: []int64{200},
+			Reason:       "customer request",
+		}))
+
+	itemUpdatedID := uuid.NewString()
+	e.publish(t, eventMessage(t, itemUpdatedQueue, events.TypeOrderItemUpdated, itemUpdatedID,
+		&pb.OrderItemUpdated{
+			EventId:    itemUpdatedID,
+			OrderId:    3,
+			CustomerId: 30,
+			Item:       &pb.OrderItemSnapshot{Id: 300, OrderId: 3, ProductId: 1, Quantity: 2},
+		}))
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 4
+	}, "audit logs were not saved")
+
+	want := []models.AuditLogOrder{
+		{OrderID: 1, OrderItemID: 100, CustomerID: 10, OrderStatus: "paid"},
+		{OrderID: 1, OrderItemID: 101, CustomerID: 10, OrderStatus: "paid"},
+		{OrderID: 2, OrderItemID: 200, CustomerID: 20, OrderStatus: handlers.StatusCancelled},
+		{OrderID: 3, OrderItemID: 300, CustomerID: 30, OrderStatus: handlers.StatusItemUpdated},
+	}
+	for _, w := range want {
+		found := slices.ContainsFunc(e.audit.AuditLogs(), func(log models.AuditLogOrder) bool {
+			return log.OrderID == w.OrderID &&
+				log.OrderItemID == w.OrderItemID &&
+				log.CustomerID == w.CustomerID &&
+				log.OrderStatus == w.OrderStatus
+		})
+		if !found {
+			t.Errorf("missing audit log %+v", w)
+		}
+	}
+
+	for _, queue := range []string{statusChangedQueue, cancelledQueue, itemUpdatedQueue} {
+		eventually(t, func() bool {
+			return e.broker.Pending(queue) == 0
+		}, "messages of "+queue+" were not acknowledged")
+	}
+}
+
+func TestUntypedEventIsHandledByItsQueue(t *testing.T) {
+	e := newEnv(t)
+
+	// Replayed and legacy events do not carry a type
+	msg := orderMessage(t, 1, 2)
+	msg.Topic = replayQueue
+	msg.Type = ""
+	e.publish(t, msg)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "untyped event from the replay queue was not audited")
+
+	for _, log := range e.audit.AuditLogs() {
+		if log.OrderStatus != handlers.StatusCreated {
+			t.Errorf("unexpected audit log: %+v", log)
+		}
+	}
+}
+
+func TestEventWithUnknownTypeIsQuarantined(t *testing.T) {
+	e := newEnv(t)
+
+	msg := orderMessage(t, 1, 2)
+	msg.Type = "order.refunded"
+	e.publish(t, msg)
+
+	eventually(t, func() bool {
+		return len(e.quarantine.Messages()) == 1
+	}, "event with unknown type was not quarantined")
+
+	if n := len(e.audit.AuditLogs()); n != 0 {
+		t.Fatalf("expected no audit logs, got %d", n)
+	}
+}
+
+func TestRegistryRejectsSubscriptionOfUnknownType(t *testing.T) {
+	registry := handlers.NewOrderRegistry()
+
+	err := registry.HandleTopic("oms.order.refunded", "order.refunded")
+	if !errors.Is(err, handlers.ErrUnhandledEvent) {
+		t.Fatalf("expected ErrUnhandledEvent, got %v", err)
+	}
+}
+
+func TestAuditLogsRecordActorAndChanges(t *testing.T) {
+	e := newEnv(t)
+
+	eventContext := &pb.EventContext{
+		ActorId:       "42",
+		ActorType:     "user",
+		SourceService: "order-svc",
+		RequestId:     "req-1",
+	}
+
+	statusChangedID := uuid.NewString()
+	e.publish(t, eventMessage(t, statusChangedQueue, events.TypeOrderStatusChanged, statusChangedID,
+		&pb.OrderStatusChanged{
+			EventId:      statusChangedID,
+			OrderId:      1,
+			CustomerId:   10,
+			OrderItemIds: []int64{100},
+			OldStatus:    "created",
+			NewStatus:    "paid",
+			Context:      eventContext,
+		}))
+
+	quantity, err := events.Change("quantity", 1, 3)
+	if err != nil {
+		t.Fatalf("change: %v", err)
+	}
+	itemUpdatedID := uuid.NewString()
+	e.publish(t, eventMessage(t, itemUpdatedQueue, events.TypeOrderItemUpdated, itemUpdatedID,
+		&pb.OrderItemUpdated{
+			EventId:    itemUpdatedID,
+			OrderId:    1,
+			CustomerId: 10,
+			Item:       &pb.OrderItemSnapshot{Id: 100, OrderId: 1, Quantity: 3},
+			Context:    eventContext,
+			Changes:    []*pb.FieldChange{quantity},
+		}))
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "audit logs were not saved")
+
+	for _, log := range e.audit.AuditLogs() {
+		if log.ActorID != "42" || log.ActorType != "user" ||
+			log.SourceService != "order-svc" || log.RequestID != "req-1" {
+			t.Errorf("unexpected actor of audit log: %+v", log)
+		}
+
+		switch log.EventType {
+		case events.TypeOrderStatusChanged:
+			// The status change is derived from the event when the producer does not list it
+			want := models.FieldChange{Before: "created", After: "paid"}
+			if log.Changes["status"] != want {
+				t.Errorf("unexpected status change: %+v", log.Changes)
+			}
+		case events.TypeOrderItemUpdated:
+			want := models.FieldChange{Before: float64(1), After: float64(3)}
+			if log.Changes["quantity"] != want {
+				t.Errorf("unexpected quantity change: %+v", log.Changes)
+			}
+		default:
+			t.Errorf("unexpected event type %q", log.EventType)
+		}
+	}
+}
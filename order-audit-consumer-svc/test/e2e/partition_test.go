@@ -0,0 +1,159 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	partitionmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/partition/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/auditchain"
+	"github.com/corray333/backend-labs/consumer/internal/service/models/partition"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/chainsvc"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/partitionsvc"
+)
+
+// month returns the first instant of a month in UTC.
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// saveAuditLogsAt saves an audit log created at each of the times, in order.
+func saveAuditLogsAt(t *testing.T, repo *auditmemory.AuditRepository, times ...time.Time) {
+	t.Helper()
+
+	for i, at := range times {
+		auditLog := models.AuditLogOrder{
+			OrderID:     1,
+			OrderItemID: int64(100 + i),
+			CustomerID:  10,
+			OrderStatus: "created",
+			CreatedAt:   at,
+			UpdatedAt:   at,
+		}
+		if err := repo.SaveAuditLogs(context.Background(), fmt.Sprint(at.UnixNano(), "-", i), []models.AuditLogOrder{auditLog}); err != nil {
+			t.Fatalf("save audit log: %v", err)
+		}
+	}
+}
+
+// verifyChain verifies the global chain of the repository.
+func verifyChain(t *testing.T, repo *auditmemory.AuditRepository) auditchain.Verification {
+	t.Helper()
+
+	svc := chainsvc.MustNewChainService(chainsvc.WithAuditChainRepository(repo))
+	verifications, err := svc.VerifyChains(context.Background(), string(auditchain.ScopeGlobal))
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+
+	return verifications[0]
+}
+
+func TestMaintenanceCreatesUpcomingPartitions(t *testing.T) {
+	repo := partitionmemory.NewPartitionRepository(
+		auditmemory.NewAuditRepository(),
+		partition.ForMonth(month(2026, time.January)),
+		partition.ForMonth(month(2026, time.March)),
+	)
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{PremakeMonths: 2}),
+		partitionsvc.WithClock(func() time.Time { return time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC) }),
+	)
+
+	report, err := svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+
+	var created []string
+	for _, p := range report.Created {
+		created = append(created, p.Name())
+	}
+	want := []string{"audit_log_order_p202604", "audit_log_order_p202605"}
+	if fmt.Sprint(created) != fmt.Sprint(want) {
+		t.Fatalf("expected partitions %v to be created, got %v", want, created)
+	}
+	if len(report.Removed) != 0 {
+		t.Fatalf("expected no partitions removed without a retention, got %+v", report.Removed)
+	}
+
+	report, err = svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if len(report.Created) != 0 {
+		t.Fatalf("expected the partitions to exist, got %+v", report.Created)
+	}
+}
+
+func TestRetentionKeepsChainsVerifiable(t *testing.T) {
+	audit := auditmemory.NewAuditRepository()
+	// Late events land in months older than the ones of audit logs saved before them
+	saveAuditLogsAt(t, audit,
+		month(2026, time.February).Add(time.Hour), // 1
+		month(2026, time.January).Add(time.Hour),  // 2
+		month(2026, time.February).Add(2*time.Hour),
+		month(2026, time.March).Add(time.Hour),
+		month(2025, time.June).Add(time.Hour), // 5, in the default partition
+		month(2026, time.March).Add(2*time.Hour),
+	)
+
+	repo := partitionmemory.NewPartitionRepository(audit,
+		partition.ForMonth(month(2026, time.January)),
+		partition.ForMonth(month(2026, time.February)),
+		partition.ForMonth(month(2026, time.March)),
+	)
+	now := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+	svc := partitionsvc.MustNewPartitionService(
+		partitionsvc.WithPartitionRepository(repo),
+		partitionsvc.WithConfig(partitionsvc.Config{
+			RetentionMonths: 1,
+			RetentionAction: partition.RetentionDetach,
+		}),
+		partitionsvc.WithClock(func() time.Time { return now }),
+	)
+
+	report, err := svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name() != "audit_log_order_p202601" || report.PrunedDefault != 1 {
+		t.Fatalf("expected January and the default partition audit log to expire, got %+v", report)
+	}
+	if detached := repo.Detached(); len(detached) != 1 {
+		t.Fatalf("expected January to be detached, got %+v", detached)
+	}
+
+	verification := verifyChain(t, audit)
+	if verification.BrokenLink != nil || verification.AuditLogs != 4 || verification.PrunedAuditLogs != 2 {
+		t.Fatalf("unexpected verification after pruning January: %+v", verification)
+	}
+
+	// The February audit logs around the pruned January one are pruned as separate runs
+	now = now.AddDate(0, 1, 0)
+	report, err = svc.Maintain(context.Background())
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name() != "audit_log_order_p202602" {
+		t.Fatalf("expected February to expire, got %+v", report)
+	}
+
+	verification = verifyChain(t, audit)
+	if verification.BrokenLink != nil || verification.AuditLogs != 2 || verification.PrunedAuditLogs != 4 {
+		t.Fatalf("unexpected verification after pruning February: %+v", verification)
+	}
+
+	// Retained audit logs are still verified
+	audit.UpdateAuditLog(6, func(auditLog *models.AuditLogOrder) {
+		auditLog.OrderStatus = "cancelled"
+	})
+	verification = verifyChain(t, audit)
+	if link := verification.BrokenLink; link == nil || link.AuditLogID != 6 || link.Reason != auditchain.ReasonHashMismatch {
+		t.Fatalf("expected a hash mismatch at audit log 6, got %+v", link)
+	}
+}
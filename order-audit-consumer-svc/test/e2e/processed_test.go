@@ -0,0 +1,55 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	processedworker "github.com/corray333/backend-labs/consumer/internal/worker/processed"
+)
+
+func TestProcessedMessagesExpireAfterRetention(t *testing.T) {
+	repo := auditmemory.NewAuditRepository()
+	now := time.Now()
+
+	// Two messages were processed before the retention, one within it
+	oldIDs := []string{"old-1", "old-2"}
+	for _, id := range append(oldIDs, "recent") {
+		if err := repo.SaveAuditLogs(context.Background(), id, nil); err != nil {
+			t.Fatalf("save message %s: %v", id, err)
+		}
+	}
+	for _, id := range oldIDs {
+		repo.SetProcessedAt(id, now.Add(-15*24*time.Hour))
+	}
+
+	worker := processedworker.NewWorker(repo, processedworker.Config{
+		Retention: 14 * 24 * time.Hour,
+		BatchSize: 1,
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Start(context.Background())
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.IsProcessed(oldIDs[0]) || repo.IsProcessed(oldIDs[1]) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected messages processed before the retention to be forgotten")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	worker.Stop()
+	<-done
+
+	// The recent message is still recognized when it is delivered again
+	err := repo.SaveAuditLogs(context.Background(), "recent", nil)
+	if !errors.Is(err, models.ErrAlreadyProcessed) {
+		t.Fatalf("expected the recent message to stay processed, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/corray333/backend-labs/consumer/internal/service/models/quarantine"
+	"github.com/corray333/backend-labs/consumer/internal/service/services/quarantinesvc"
+	"github.com/corray333/backend-labs/order/pkg/events"
+)
+
+func TestQuarantinedMessageIsEditedAndReplayed(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	valid := orderMessage(t, 1, 2)
+	broken := valid
+	broken.ContentType = "application/unknown"
+	e.publish(t, broken)
+
+	eventually(t, func() bool {
+		return len(e.quarantine.Messages()) == 1
+	}, "message with unknown content type was not quarantined")
+
+	svc := quarantinesvc.MustNewQuarantineService(
+		quarantinesvc.WithQuarantineRepository(e.quarantine),
+		quarantinesvc.WithPublisher(e.broker),
+	)
+
+	listed, err := svc.ListMessages(ctx, 0, 0)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("list: %v, %d messages", err, len(listed))
+	}
+	id := listed[0].ID
+
+	edited, err := svc.EditMessage(ctx, quarantine.EditQuarantinedMessageModel{
+		ID:          id,
+		ContentType: events.ContentTypeProtobuf,
+	})
+	if err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if edited.ContentType != events.ContentTypeProtobuf {
+		t.Fatalf("content type was not changed: %q", edited.ContentType)
+	}
+
+	if err := svc.ReplayMessage(ctx, id); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "replayed message was not processed")
+
+	if _, err := svc.GetMessage(ctx, id); !errors.Is(err, quarantine.ErrQuarantinedMessageNotFound) {
+		t.Fatalf("expected replayed message to leave the quarantine, got %v", err)
+	}
+
+	replayed := e.broker.Published(queueName)
+	if last := replayed[len(replayed)-1]; last.ID != valid.ID || last.Type != valid.Type {
+		t.Errorf("original properties were not restored: %+v", last)
+	}
+}
+
+func TestQuarantinedMessageIsDiscarded(t *testing.T) {
+	e := newEnv(t)
+	ctx := context.Background()
+
+	broken := orderMessage(t, 1, 1)
+	broken.Body = []byte("garbage")
+	e.publish(t, broken)
+
+	eventually(t, func() bool {
+		return len(e.quarantine.Messages()) == 1
+	}, "malformed message was not quarantined")
+
+	svc := quarantinesvc.MustNewQuarantineService(quarantinesvc.WithQuarantineRepository(e.quarantine))
+
+	id := e.quarantine.Messages()[0].ID
+	if err := svc.DiscardMessage(ctx, id); err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+	if n := len(e.quarantine.Messages()); n != 0 {
+		t.Fatalf("expected empty quarantine, got %d messages", n)
+	}
+	if err := svc.DiscardMessage(ctx, id); !errors.Is(err, quarantine.ErrQuarantinedMessageNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
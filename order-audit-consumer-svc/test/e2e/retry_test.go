@@ -0,0 +1,116 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/consumer/internal/transport/consumer"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/broker/memory"
+)
+
+// unconfirmedBroker fails publishes of the retrier while failing is set,
+// like a broker that did not confirm the retry copy.
+type unconfirmedBroker struct {
+	*memory.Broker
+
+	failing atomic.Bool
+	failed  atomic.Int64
+}
+
+// Publish fails while failing is set and publishes to the memory broker otherwise.
+func (b *unconfirmedBroker) Publish(ctx context.Context, msg broker.Message) error {
+	if b.failing.Load() {
+		b.failed.Add(1)
+
+		return errors.New("message was not confirmed")
+	}
+
+	return b.Broker.Publish(ctx, msg)
+}
+
+func TestBrokerRetryDeliversAgainAfterDelay(t *testing.T) {
+	e := newBrokerRetryEnv(t, 10*time.Millisecond, 20*time.Millisecond)
+	e.audit.SetError(errors.New("audit database is down"))
+
+	e.publishOrder(t, 1, 2)
+
+	eventually(t, func() bool {
+		return len(e.broker.Published(queueName+".retry.10ms")) == 1
+	}, "failed message was not published to the first retry queue")
+
+	e.audit.SetError(nil)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "retried message was not processed")
+
+	if n := len(e.inbox.Messages()); n != 0 {
+		t.Fatalf("expected the inbox to be unused, got %d messages", n)
+	}
+	if n := len(e.broker.Published(parkingLot)); n != 0 {
+		t.Fatalf("expected empty parking lot, got %d messages", n)
+	}
+}
+
+func TestBrokerRetryParksMessageAfterLastAttempt(t *testing.T) {
+	e := newBrokerRetryEnv(t, time.Millisecond, 2*time.Millisecond)
+	e.audit.SetError(errors.New("audit database is down"))
+
+	msg := orderMessage(t, 1, 1)
+	msg.Headers = map[string]any{"custom": "kept"}
+	e.publish(t, msg)
+
+	eventually(t, func() bool {
+		return len(e.broker.Published(parkingLot)) == 1
+	}, "message was not parked after the last retry")
+
+	parked := e.broker.Published(parkingLot)[0]
+	if retries, _ := broker.HeaderInt64(parked.Headers[consumer.HeaderRetryCount]); retries != 2 {
+		t.Errorf("expected 2 retries, got %d", retries)
+	}
+	if parked.Headers[consumer.HeaderLastError] != "audit database is down" {
+		t.Errorf("unexpected last error header: %v", parked.Headers[consumer.HeaderLastError])
+	}
+	if parked.ID != msg.ID || parked.ContentType != msg.ContentType || parked.Headers["custom"] != "kept" {
+		t.Errorf("message properties were not preserved: %+v", parked)
+	}
+	if n := len(e.broker.Published(queueName + ".retry.1ms")); n != 1 {
+		t.Errorf("expected one message through the first retry queue, got %d", n)
+	}
+	if n := len(e.broker.Published(queueName + ".retry.2ms")); n != 1 {
+		t.Errorf("expected one message through the second retry queue, got %d", n)
+	}
+}
+
+func TestBrokerRetryKeepsMessageWhenRetryIsNotConfirmed(t *testing.T) {
+	var publisher *unconfirmedBroker
+	e := newEnvWithRetrier(t, consumer.Config{}, func(e *env) consumer.Retrier {
+		publisher = &unconfirmedBroker{Broker: e.broker}
+		publisher.failing.Store(true)
+
+		return consumer.MustNewBrokerRetrier(publisher, consumer.BrokerRetryConfig{
+			Queues:     []string{queueName},
+			Delays:     []time.Duration{time.Millisecond},
+			ParkingLot: parkingLot,
+		})
+	})
+	e.audit.SetError(errors.New("audit database is down"))
+
+	e.publishOrder(t, 1, 2)
+
+	// The message is requeued and fails again instead of being acknowledged without a retry copy
+	eventually(t, func() bool {
+		return publisher.failed.Load() >= 2
+	}, "message was not delivered again after an unconfirmed retry")
+
+	publisher.failing.Store(false)
+	e.audit.SetError(nil)
+
+	eventually(t, func() bool {
+		return len(e.audit.AuditLogs()) == 2
+	}, "message was lost after an unconfirmed retry")
+}
@@ -0,0 +1,302 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	grpcclient "github.com/corray333/backend-labs/consumer/internal/dal/grpc"
+	fanoutrepo "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/fanout"
+	auditmemory "github.com/corray333/backend-labs/consumer/internal/dal/repositories/audit/memory"
+	"github.com/corray333/backend-labs/consumer/internal/service/models"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOrderService records SaveAuditLog calls and fails them with the errors of fail.
+// Like the order service it saves the audit logs of a message once.
+type fakeOrderService struct {
+	pb.UnimplementedOrderServiceServer
+
+	mu         sync.Mutex
+	calls      int
+	auditLogs  []*pb.AuditLogOrder
+	messageIDs []string
+	processed  map[string]bool
+	// fail returns the error of the nth call, nil saves the audit logs
+	fail func(call int) error
+}
+
+// SaveAuditLog records the call and the audit logs it saved.
+func (s *fakeOrderService) SaveAuditLog(
+	ctx context.Context,
+	req *pb.SaveAuditLogRequest,
+) (*pb.SaveAuditLogResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.fail != nil {
+		if err := s.fail(s.calls); err != nil {
+			return nil, err
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.messageIDs = append(s.messageIDs, md.Get(events.MetadataMessageID)...)
+
+	if s.processed == nil {
+		s.processed = make(map[string]bool)
+	}
+	resp := &pb.SaveAuditLogResponse{}
+	claimed := make(map[string]bool)
+	for _, l := range req.AuditLogs {
+		id := l.GetMessageId()
+		if s.processed[id] && !claimed[id] {
+			if !slices.Contains(resp.DuplicateMessageIds, id) {
+				resp.DuplicateMessageIds = append(resp.DuplicateMessageIds, id)
+			}
+
+			continue
+		}
+		s.processed[id] = true
+		claimed[id] = true
+		s.auditLogs = append(s.auditLogs, l)
+		resp.AuditLogs = append(resp.AuditLogs, l)
+	}
+
+	return resp, nil
+}
+
+// state returns the number of calls and the saved audit logs and message IDs.
+func (s *fakeOrderService) state() (int, []*pb.AuditLogOrder, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls, s.auditLogs, s.messageIDs
+}
+
+// serveOrderService serves the fake order service and returns a client sink configured by cfg.
+func serveOrderService(t *testing.T, svc *fakeOrderService, cfg grpcclient.Config) *grpcclient.Client {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterOrderServiceServer(server, svc)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	cfg.Addr = lis.Addr().String()
+	client, err := grpcclient.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+// sinkAuditLogs returns audit logs of an order item.
+func sinkAuditLogs(orderItemID int64) []models.AuditLogOrder {
+	createdAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	return []models.AuditLogOrder{{
+		OrderID:     1,
+		OrderItemID: orderItemID,
+		CustomerID:  10,
+		OrderStatus: "created",
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}}
+}
+
+func TestGRPCSinkRetriesUnavailableCalls(t *testing.T) {
+	svc := &fakeOrderService{fail: func(call int) error {
+		if call < 3 {
+			return status.Error(codes.Unavailable, "order service is restarting")
+		}
+
+		return nil
+	}}
+	client := serveOrderService(t, svc, grpcclient.Config{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	if err := client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100)); err != nil {
+		t.Fatalf("save audit logs: %v", err)
+	}
+
+	calls, auditLogs, messageIDs := svc.state()
+	if calls != 3 || len(auditLogs) != 1 || auditLogs[0].OrderItemId != 100 {
+		t.Fatalf("expected the audit log saved by the third call, got %d calls and %+v", calls, auditLogs)
+	}
+	if len(messageIDs) != 1 || messageIDs[0] != "m1" {
+		t.Fatalf("expected the message ID sent as metadata, got %v", messageIDs)
+	}
+
+	saved, err := client.SaveAuditLogBatch(context.Background(), []models.AuditLogMessage{
+		{MessageID: "m2", AuditLogs: sinkAuditLogs(101)},
+		{MessageID: "m3", AuditLogs: sinkAuditLogs(102)},
+	})
+	if err != nil || saved != 2 {
+		t.Fatalf("expected a batch of 2 messages saved, got %d: %v", saved, err)
+	}
+	if calls, _, messageIDs := svc.state(); calls != 4 || len(messageIDs) != 3 {
+		t.Fatalf("expected the batch sent in one call with both message IDs, got %d calls and %v", calls, messageIDs)
+	}
+}
+
+func TestGRPCSinkDoesNotRetryRejectedCalls(t *testing.T) {
+	svc := &fakeOrderService{fail: func(int) error {
+		return status.Error(codes.InvalidArgument, "invalid audit log")
+	}}
+	client := serveOrderService(t, svc, grpcclient.Config{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	err := client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected the rejection to be returned, got %v", err)
+	}
+	if calls, _, _ := svc.state(); calls != 1 {
+		t.Fatalf("expected a rejected call not to be retried, got %d calls", calls)
+	}
+
+	if _, err := client.ListAuditLogs(context.Background(), models.ListAuditLogsModel{}); !errors.Is(err, models.ErrAuditReadUnsupported) {
+		t.Fatalf("expected reads to fail with ErrAuditReadUnsupported, got %v", err)
+	}
+}
+
+func TestGRPCSinkCircuitBreakerOpens(t *testing.T) {
+	svc := &fakeOrderService{fail: func(int) error {
+		return status.Error(codes.Unavailable, "order service is down")
+	}}
+	client := serveOrderService(t, svc, grpcclient.Config{
+		MaxAttempts: 1,
+		Breaker: circuitbreaker.Config{
+			FailureThreshold: 2,
+			OpenTimeout:      time.Hour,
+		},
+	})
+
+	for i := range 2 {
+		if err := client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100)); err == nil {
+			t.Fatalf("expected call %d to fail", i+1)
+		}
+	}
+
+	err := client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100))
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected the open breaker to fail the call, got %v", err)
+	}
+	if calls, _, _ := svc.state(); calls != 2 {
+		t.Fatalf("expected no call through the open breaker, got %d calls", calls)
+	}
+}
+
+func TestGRPCSinkOpensOnBrokenOrderService(t *testing.T) {
+	svc := &fakeOrderService{fail: func(int) error {
+		return status.Error(codes.Unimplemented, "unknown method SaveAuditLog")
+	}}
+	client := serveOrderService(t, svc, grpcclient.Config{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Breaker: circuitbreaker.Config{
+			FailureThreshold: 1,
+			OpenTimeout:      time.Hour,
+		},
+	})
+
+	err := client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100))
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected the failure to be returned, got %v", err)
+	}
+	if calls, _, _ := svc.state(); calls != 1 {
+		t.Fatalf("expected a broken order service not to be retried, got %d calls", calls)
+	}
+
+	err = client.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100))
+	if !errors.Is(err, circuitbreaker.ErrOpen) {
+		t.Fatalf("expected the failure to open the breaker, got %v", err)
+	}
+}
+
+func TestGRPCSinkReportsRedeliveredMessages(t *testing.T) {
+	svc := &fakeOrderService{}
+	client := serveOrderService(t, svc, grpcclient.Config{MaxAttempts: 1})
+
+	auditLogs := sinkAuditLogs(100)
+	auditLogs[0].ActorID = "user-1"
+	auditLogs[0].Changes = map[string]models.FieldChange{"order_status": {After: "created"}}
+	if err := client.SaveAuditLogs(context.Background(), "m1", auditLogs); err != nil {
+		t.Fatalf("save audit logs: %v", err)
+	}
+	if err := client.SaveAuditLogs(context.Background(), "m1", auditLogs); !errors.Is(err, models.ErrAlreadyProcessed) {
+		t.Fatalf("expected ErrAlreadyProcessed for a redelivery, got %v", err)
+	}
+
+	saved, err := client.SaveAuditLogBatch(context.Background(), []models.AuditLogMessage{
+		{MessageID: "m1", AuditLogs: sinkAuditLogs(100)},
+		{MessageID: "m2", AuditLogs: sinkAuditLogs(101)},
+	})
+	if err != nil || saved != 1 {
+		t.Fatalf("expected only the new message of the batch saved, got %d: %v", saved, err)
+	}
+
+	_, sent, _ := svc.state()
+	if len(sent) != 2 || sent[0].GetMessageId() != "m1" || sent[1].GetMessageId() != "m2" {
+		t.Fatalf("expected every audit log sent with its message ID, got %+v", sent)
+	}
+	if sent[0].GetActorId() != "user-1" || len(sent[0].GetChanges()) != 1 ||
+		sent[0].GetChanges()[0].GetAfter().GetStringValue() != "created" {
+		t.Fatalf("expected the audit log context sent, got %+v", sent[0])
+	}
+}
+
+func TestFanoutSinkWritesEverySink(t *testing.T) {
+	var failing atomic.Bool
+	svc := &fakeOrderService{fail: func(int) error {
+		if failing.Load() {
+			return status.Error(codes.InvalidArgument, "invalid audit log")
+		}
+
+		return nil
+	}}
+	client := serveOrderService(t, svc, grpcclient.Config{MaxAttempts: 1})
+	primary := auditmemory.NewAuditRepository()
+	sink := fanoutrepo.NewAuditRepository(primary, client)
+
+	if err := sink.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100)); err != nil {
+		t.Fatalf("save audit logs: %v", err)
+	}
+	// Both sinks drop the redelivery
+	if err := sink.SaveAuditLogs(context.Background(), "m1", sinkAuditLogs(100)); !errors.Is(err, models.ErrAlreadyProcessed) {
+		t.Fatalf("expected ErrAlreadyProcessed for a redelivery, got %v", err)
+	}
+
+	failing.Store(true)
+	if err := sink.SaveAuditLogs(context.Background(), "m2", sinkAuditLogs(101)); err == nil {
+		t.Fatal("expected a failed secondary sink to fail the message")
+	}
+
+	if calls, auditLogs, _ := svc.state(); calls != 3 || len(auditLogs) != 1 {
+		t.Fatalf("expected the order service to save the message once in 3 calls, got %d calls and %+v", calls, auditLogs)
+	}
+
+	auditLogs, err := sink.ListAuditLogs(context.Background(), models.ListAuditLogsModel{})
+	if err != nil {
+		t.Fatalf("list audit logs: %v", err)
+	}
+	if len(auditLogs) != 1 || auditLogs[0].OrderItemID != 100 {
+		t.Fatalf("expected the primary sink to hold only the first message, got %+v", auditLogs)
+	}
+}
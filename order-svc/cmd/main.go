@@ -1,11 +1,37 @@
 package main
 
 import (
+	"fmt"
+	"log/slog"
+	"os"
+
 	"github.com/corray333/backend-labs/order/internal/app"
 	"github.com/corray333/backend-labs/order/internal/config"
 )
 
 func main() {
 	config.MustInit()
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			slog.Error("Command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	app.MustNewApp().Run()
 }
+
+// runCommand runs a one-off command instead of the service.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "replay":
+		return app.RunReplay(args)
+	case "reconcile":
+		return app.RunReconcile(args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
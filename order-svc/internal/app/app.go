@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -9,71 +10,159 @@ import (
 	"syscall"
 	"time"
 
+	brokerclient "github.com/corray333/backend-labs/order/internal/dal/broker"
 	"github.com/corray333/backend-labs/order/internal/dal/postgres"
-	"github.com/corray333/backend-labs/order/internal/dal/rabbitmq"
 	"github.com/corray333/backend-labs/order/internal/dal/repositories/audit"
+	auditlogrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/auditlog/postgres"
+	auditreaderrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/auditreader/postgres"
 	outboxrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/outbox/postgres"
 	"github.com/corray333/backend-labs/order/internal/otel"
+	outboxmodel "github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/internal/service/services/auditlogsvc"
 	"github.com/corray333/backend-labs/order/internal/service/services/ordersvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/outboxsvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/reconciliationsvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/replaysvc"
+	admintransport "github.com/corray333/backend-labs/order/internal/transport/admin"
 	grpctransport "github.com/corray333/backend-labs/order/internal/transport/grpc"
 	httptransport "github.com/corray333/backend-labs/order/internal/transport/http"
 	"github.com/corray333/backend-labs/order/internal/worker/outbox"
+	"github.com/corray333/backend-labs/order/internal/worker/processed"
+	"github.com/corray333/backend-labs/order/internal/worker/reconciliation"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	brokerfactory "github.com/corray333/backend-labs/order/pkg/broker/factory"
+	"github.com/spf13/viper"
+)
+
+// Stores of the audit trail reconciliation reads.
+const (
+	// auditStoreAudit is the audit database the audit consumer writes to
+	auditStoreAudit = "audit"
+	// auditStoreOrder is the audit_log_order table of the order database,
+	// written through SaveAuditLog when the audit consumer uses the grpc sink
+	auditStoreOrder = "order"
 )
 
 // App represents the application.
 type App struct {
-	orderSvc       *ordersvc.OrderService
-	transport      *httptransport.HTTPTransport
-	postgresClient *postgres.Client
-	rabbitMqClient *rabbitmq.Client
-	grpcTransport  *grpctransport.GRPCTransport
-	otelController *otel.OtelController
-	outboxWorker   *outbox.Worker
-	workerCtx      context.Context
-	workerCancel   context.CancelFunc
+	orderSvc             *ordersvc.OrderService
+	transport            *httptransport.HTTPTransport
+	adminTransp          *admintransport.AdminTransport
+	postgresClient       *postgres.Client
+	pgListener           *postgres.Listener
+	broker               broker.Broker
+	grpcTransport        *grpctransport.GRPCTransport
+	otelController       *otel.OtelController
+	outboxWorker         *outbox.Worker
+	retentionWorker      *outbox.RetentionWorker
+	processedWorker      *processed.Worker
+	reconciliationWorker *reconciliation.Worker
+	auditClient          *postgres.Client
+	workerCtx            context.Context
+	workerCancel         context.CancelFunc
 }
 
 // MustNewApp creates a new application.
 func MustNewApp() *App {
 	otelController := otel.MustInitOtel()
-	rabbitMqClient := rabbitmq.MustNewClient()
+	messageBroker := brokerfactory.MustNew()
 	postgresClient := postgres.MustNewClient()
+	pgListener := postgres.MustNewListener()
 
 	outboxRepository := outboxrepo.NewOutboxRepository(postgresClient)
+	deadLetterRepository := outboxrepo.NewDeadLetterRepository(postgresClient)
+	archiveRepository := outboxrepo.NewArchiveRepository(postgresClient)
+
+	publishBreaker := brokerclient.NewPublishBreaker()
 
-	auditRabbitMQRepository := audit.NewAuditRabbitMQRepository(
-		rabbitMqClient,
-		postgresClient,
+	auditBrokerRepository := audit.NewAuditBrokerRepository(
+		messageBroker,
 		outboxRepository,
+		publishBreaker,
+		audit.ConfigFromViper(),
 	)
 
 	orderSvc := ordersvc.MustNewOrderService(
 		ordersvc.WithPostgresClient(postgresClient),
-		ordersvc.WithAuditor(auditRabbitMQRepository),
+		ordersvc.WithAuditor(auditBrokerRepository),
 	)
 
-	grpcTransport := grpctransport.NewGRPCTransport(orderSvc)
+	auditLogRepository := auditlogrepo.NewAuditLogRepository(postgresClient)
+	auditLogSvc := auditlogsvc.MustNewAuditLogService(
+		auditlogsvc.WithAuditLogRepository(auditLogRepository),
+	)
+
+	outboxSvc := outboxsvc.MustNewOutboxService(
+		outboxsvc.WithDeadLetterRepository(deadLetterRepository),
+	)
+
+	replaySvc := replaysvc.MustNewReplayService(
+		replaysvc.WithPostgresClient(postgresClient),
+		replaysvc.WithOutboxRepository(outboxRepository),
+	)
+
+	// Replayed events are routed to their own queue so live consumers are not flooded
+	if err := messageBroker.Declare(context.Background(), replaySvc.RoutingKey()); err != nil {
+		panic(err)
+	}
+
+	// Reconciliation reads the audit database, which is only required when the job is enabled
+	var (
+		auditClient          *postgres.Client
+		reconciliationWorker *reconciliation.Worker
+	)
+	if viper.GetBool("reconciliation.enabled") {
+		auditReaderClient := postgresClient
+		if mustReconciliationAuditStore() == auditStoreAudit {
+			auditClient = postgres.MustNewAuditClient()
+			auditReaderClient = auditClient
+		}
+		reconciliationWorker = reconciliation.NewWorker(reconciliationsvc.MustNewReconciliationService(
+			reconciliationsvc.WithPostgresClient(postgresClient),
+			reconciliationsvc.WithAuditReader(auditreaderrepo.NewAuditReaderRepository(auditReaderClient)),
+			reconciliationsvc.WithReplayer(replaySvc),
+		), postgresClient)
+	}
+
+	grpcTransport := grpctransport.NewGRPCTransport(orderSvc, auditLogSvc, outboxSvc, replaySvc)
 
 	transport := httptransport.NewHTTPTransport(grpcTransport.GetOrderServer())
 	transport.RegisterRoutes()
 
+	// The admin server serves metrics and outbox administration on the internal port
+	adminTransp := admintransport.NewAdminTransport(grpcTransport.GetAdminServer(), admintransport.ConfigFromViper())
+	adminTransp.RegisterRoutes()
+
 	outboxWorker := outbox.NewWorker(
 		outboxRepository,
-		rabbitMqClient,
+		messageBroker,
+		publishBreaker,
+		outbox.ConfigFromViper(),
 	)
 
+	retentionWorker := outbox.NewRetentionWorker(archiveRepository)
+
+	// Messages of audit logs saved through SaveAuditLog are forgotten after the retention
+	processedWorker := processed.NewWorker(auditLogRepository)
+
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 
 	return &App{
-		orderSvc:       orderSvc,
-		transport:      transport,
-		postgresClient: postgresClient,
-		rabbitMqClient: rabbitMqClient,
-		grpcTransport:  grpcTransport,
-		otelController: otelController,
-		outboxWorker:   outboxWorker,
-		workerCtx:      workerCtx,
-		workerCancel:   workerCancel,
+		orderSvc:             orderSvc,
+		transport:            transport,
+		adminTransp:          adminTransp,
+		postgresClient:       postgresClient,
+		pgListener:           pgListener,
+		broker:               messageBroker,
+		grpcTransport:        grpcTransport,
+		otelController:       otelController,
+		outboxWorker:         outboxWorker,
+		retentionWorker:      retentionWorker,
+		processedWorker:      processedWorker,
+		reconciliationWorker: reconciliationWorker,
+		auditClient:          auditClient,
+		workerCtx:            workerCtx,
+		workerCancel:         workerCancel,
 	}
 }
 
@@ -91,6 +180,13 @@ func (a *App) Run() {
 		}
 	}()
 
+	go func() {
+		slog.Info("Starting admin server")
+		if err := a.adminTransp.Run(); err != nil {
+			slog.Error("Admin server error", "error", err)
+		}
+	}()
+
 	go func() {
 		slog.Info("Starting gRPC server")
 		if err := a.grpcTransport.Run(); err != nil {
@@ -104,6 +200,32 @@ func (a *App) Run() {
 		a.outboxWorker.Start(a.workerCtx)
 	}()
 
+	// Start outbox retention worker
+	go func() {
+		slog.Info("Starting outbox retention worker")
+		a.retentionWorker.Start(a.workerCtx)
+	}()
+
+	// Start processed messages worker
+	go func() {
+		slog.Info("Starting processed messages worker")
+		a.processedWorker.Start(a.workerCtx)
+	}()
+
+	// Start reconciliation worker
+	if a.reconciliationWorker != nil {
+		go func() {
+			slog.Info("Starting reconciliation worker")
+			a.reconciliationWorker.Start(a.workerCtx)
+		}()
+	}
+
+	// Wake outbox worker on inserts
+	go func() {
+		slog.Info("Starting outbox listener")
+		a.pgListener.Listen(a.workerCtx, outboxmodel.NotifyChannel, a.outboxWorker.Wake)
+	}()
+
 	<-stop
 	slog.Info("Shutdown signal received")
 
@@ -111,7 +233,7 @@ func (a *App) Run() {
 }
 
 // gracefulShutdown performs graceful shutdown of all application components.
-// It shuts down HTTP server, RabbitMQ and PostgreSQL connections in parallel.
+// It shuts down HTTP server, message broker and PostgreSQL connections in parallel.
 func (a *App) gracefulShutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -127,13 +249,19 @@ func (a *App) gracefulShutdown() {
 		slog.Info("HTTP server stopped gracefully")
 	}
 
+	if err := a.adminTransp.Shutdown(ctx); err != nil {
+		slog.Error("Admin server shutdown error", "error", err)
+	} else {
+		slog.Info("Admin server stopped gracefully")
+	}
+
 	var wg sync.WaitGroup
 
 	wg.Go(func() {
-		if err := a.rabbitMqClient.Close(); err != nil {
-			slog.Error("RabbitMQ connection close error", "error", err)
+		if err := a.broker.Close(); err != nil {
+			slog.Error("Message broker connection close error", "error", err)
 		} else {
-			slog.Info("RabbitMQ connection closed gracefully")
+			slog.Info("Message broker connection closed gracefully")
 		}
 	})
 	wg.Go(func() {
@@ -149,6 +277,13 @@ func (a *App) gracefulShutdown() {
 		slog.Info("Database connection closed gracefully")
 	})
 
+	if a.auditClient != nil {
+		wg.Go(func() {
+			a.auditClient.Close()
+			slog.Info("Audit database connection closed gracefully")
+		})
+	}
+
 	wg.Go(func() {
 		if err := a.grpcTransport.Shutdown(ctx); err != nil {
 			slog.Error("gRPC server shutdown error", "error", err)
@@ -160,3 +295,16 @@ func (a *App) gracefulShutdown() {
 	wg.Wait()
 	slog.Info("Application shutdown complete")
 }
+
+// mustReconciliationAuditStore reads the audit trail store reconciliation compares the outbox with.
+// It has to be where the audit consumer writes the audit trail, the audit database when not set.
+func mustReconciliationAuditStore() string {
+	switch store := viper.GetString("reconciliation.audit_store"); store {
+	case "", auditStoreAudit:
+		return auditStoreAudit
+	case auditStoreOrder:
+		return auditStoreOrder
+	default:
+		panic(fmt.Errorf("unknown reconciliation audit store %q", store))
+	}
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	auditreaderrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/auditreader/postgres"
+	outboxrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/outbox/postgres"
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+	"github.com/corray333/backend-labs/order/internal/service/services/reconciliationsvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/replaysvc"
+)
+
+var errReconciliationMismatch = errors.New("reconciliation found mismatches")
+
+// RunReconcile runs the reconcile command with the given command line arguments.
+// The report is written to stdout as JSON, mismatches result in an error.
+func RunReconcile(args []string) error {
+	model, err := parseReconcileFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	postgresClient := postgres.MustNewClient()
+	defer postgresClient.Close()
+
+	auditClient := postgres.MustNewAuditClient()
+	defer auditClient.Close()
+
+	reconciliationSvc := reconciliationsvc.MustNewReconciliationService(
+		reconciliationsvc.WithPostgresClient(postgresClient),
+		reconciliationsvc.WithAuditReader(auditreaderrepo.NewAuditReaderRepository(auditClient)),
+		reconciliationsvc.WithReplayer(replaysvc.MustNewReplayService(
+			replaysvc.WithPostgresClient(postgresClient),
+			replaysvc.WithOutboxRepository(outboxrepo.NewOutboxRepository(postgresClient)),
+		)),
+	)
+
+	report, err := reconciliationSvc.Reconcile(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if len(report.Missing) > 0 || len(report.Extra) > 0 {
+		return errReconciliationMismatch
+	}
+
+	return nil
+}
+
+// parseReconcileFlags parses reconcile command line flags.
+func parseReconcileFlags(args []string) (reconciliation.ReconcileModel, error) {
+	var (
+		model reconciliation.ReconcileModel
+		from  string
+		to    string
+	)
+
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	fs.StringVar(&from, "from", "", "check orders created at or after this RFC3339 time (default 24h ago)")
+	fs.StringVar(&to, "to", "", "check orders created before this RFC3339 time (default now)")
+	fs.BoolVar(&model.Republish, "republish", false, "republish events of orders with missing audit logs")
+
+	if err := fs.Parse(args); err != nil {
+		return model, err
+	}
+
+	var err error
+	if model.To, err = parseTime(to); err != nil {
+		return model, fmt.Errorf("invalid -to: %w", err)
+	}
+	if model.To.IsZero() {
+		model.To = time.Now()
+	}
+
+	if model.From, err = parseTime(from); err != nil {
+		return model, fmt.Errorf("invalid -from: %w", err)
+	}
+	if model.From.IsZero() {
+		model.From = model.To.Add(-24 * time.Hour)
+	}
+
+	return model, nil
+}
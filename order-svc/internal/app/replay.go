@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	outboxrepo "github.com/corray333/backend-labs/order/internal/dal/repositories/outbox/postgres"
+	"github.com/corray333/backend-labs/order/internal/service/models/replay"
+	"github.com/corray333/backend-labs/order/internal/service/services/replaysvc"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+)
+
+// RunReplay runs the replay command with the given command line arguments.
+// Events are written to the outbox only, the running service publishes them.
+func RunReplay(args []string) error {
+	model, err := parseReplayFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Events replayed from the command line are attributed to the replay tool
+	ctx = requestmeta.NewContext(ctx, requestmeta.Metadata{
+		ActorID:   "replay",
+		ActorType: requestmeta.ActorTypeSystem,
+	})
+
+	postgresClient := postgres.MustNewClient()
+	defer postgresClient.Close()
+
+	replaySvc := replaysvc.MustNewReplayService(
+		replaysvc.WithPostgresClient(postgresClient),
+		replaysvc.WithOutboxRepository(outboxrepo.NewOutboxRepository(postgresClient)),
+	)
+
+	result, err := replaySvc.ReplayOrders(ctx, model)
+	if err != nil {
+		slog.Error("Replay failed",
+			"orders_scanned", result.OrdersScanned,
+			"events_enqueued", result.EventsEnqueued,
+			"last_order_id", result.LastOrderID,
+			"error", err,
+		)
+
+		return err
+	}
+
+	slog.Info("Replay completed",
+		"orders_scanned", result.OrdersScanned,
+		"events_enqueued", result.EventsEnqueued,
+		"last_order_id", result.LastOrderID,
+		"dry_run", result.DryRun,
+	)
+
+	return nil
+}
+
+// parseReplayFlags parses replay command line flags.
+func parseReplayFlags(args []string) (replay.ReplayOrdersModel, error) {
+	var (
+		model       replay.ReplayOrdersModel
+		createdFrom string
+		createdTo   string
+	)
+
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.Int64Var(&model.FromID, "from-id", 0, "first order ID to replay (inclusive)")
+	fs.Int64Var(&model.ToID, "to-id", 0, "last order ID to replay (inclusive)")
+	fs.StringVar(&createdFrom, "from", "", "replay orders created at or after this RFC3339 time")
+	fs.StringVar(&createdTo, "to", "", "replay orders created before this RFC3339 time")
+	fs.StringVar(&model.RoutingKey, "routing-key", "", "routing key of replayed events (default from config)")
+	fs.Float64Var(&model.RatePerSecond, "rate", 0, "maximum events per second (default from config)")
+	fs.IntVar(&model.BatchSize, "batch-size", 0, "orders read per query (default from config)")
+	fs.BoolVar(&model.DryRun, "dry-run", false, "only count orders without writing events")
+
+	if err := fs.Parse(args); err != nil {
+		return model, err
+	}
+
+	var err error
+	if model.CreatedFrom, err = parseTime(createdFrom); err != nil {
+		return model, fmt.Errorf("invalid -from: %w", err)
+	}
+	if model.CreatedTo, err = parseTime(createdTo); err != nil {
+		return model, fmt.Errorf("invalid -to: %w", err)
+	}
+
+	return model, nil
+}
+
+// parseTime parses an optional RFC3339 time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
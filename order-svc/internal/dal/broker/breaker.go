@@ -0,0 +1,27 @@
+package broker
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/metrics"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/spf13/viper"
+)
+
+// NewPublishBreaker creates the circuit breaker guarding synchronous publishing.
+// Its state is exported as a metric and state changes are logged.
+func NewPublishBreaker() *circuitbreaker.Breaker {
+	return circuitbreaker.New("broker_publish", circuitbreaker.Config{
+		FailureThreshold: viper.GetInt("rabbitmq.circuit_breaker.failure_threshold"),
+		SuccessThreshold: viper.GetInt("rabbitmq.circuit_breaker.success_threshold"),
+		OpenTimeout:      time.Duration(viper.GetInt("rabbitmq.circuit_breaker.open_timeout_seconds")) * time.Second,
+		OnStateChange: func(name string, from circuitbreaker.State, to circuitbreaker.State) {
+			slog.Warn("Circuit breaker state changed", "name", name, "from", from, "to", to)
+		},
+		// Set under the breaker lock, a gauge set after it could be overwritten by an earlier transition
+		OnState: func(name string, state circuitbreaker.State) {
+			metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(state))
+		},
+	})
+}
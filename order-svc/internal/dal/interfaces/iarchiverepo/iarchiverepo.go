@@ -0,0 +1,21 @@
+package iarchiverepo
+
+import (
+	"context"
+	"time"
+)
+
+// IArchiveRepository defines the interface for outbox archive operations.
+type IArchiveRepository interface {
+	// PublishedMonths returns the months of published messages older than the given time
+	PublishedMonths(ctx context.Context, before time.Time) ([]time.Time, error)
+
+	// EnsurePartition creates the monthly archive partition that contains the given time
+	EnsurePartition(ctx context.Context, month time.Time) error
+
+	// ArchivePublished moves published messages older than the given time to the archive
+	ArchivePublished(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	// DropPartitionsBefore drops archive partitions older than the given time
+	DropPartitionsBefore(ctx context.Context, before time.Time) ([]string, error)
+}
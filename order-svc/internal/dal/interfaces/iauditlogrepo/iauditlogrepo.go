@@ -0,0 +1,19 @@
+package iauditlogrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
+)
+
+// IAuditLogRepository defines the interface for audit logs saved through the order service.
+type IAuditLogRepository interface {
+	// SaveAuditLogs saves the audit logs of messages not seen before and marks the messages processed
+	// atomically. Every audit log must carry its message ID.
+	SaveAuditLogs(ctx context.Context, auditLogs []auditlog.AuditLogOrder) (auditlog.SaveAuditLogsResult, error)
+
+	// DeleteProcessedMessagesBefore forgets up to limit messages processed before the given time
+	// and returns the number of forgotten messages. A forgotten message is saved again when it is redelivered.
+	DeleteProcessedMessagesBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
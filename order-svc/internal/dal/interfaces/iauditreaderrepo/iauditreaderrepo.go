@@ -0,0 +1,13 @@
+package iauditreaderrepo
+
+import (
+	"context"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+)
+
+// IAuditReaderRepository defines read access to audit logs of the audit database.
+type IAuditReaderRepository interface {
+	// OrderItemRefs returns distinct order items that have audit rows for orders in the ID range
+	OrderItemRefs(ctx context.Context, fromOrderID, toOrderID int64) ([]reconciliation.OrderItemRef, error)
+}
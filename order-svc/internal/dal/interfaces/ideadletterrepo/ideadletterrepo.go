@@ -0,0 +1,22 @@
+package ideadletterrepo
+
+import (
+	"context"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+)
+
+// IDeadLetterRepository defines the interface for outbox dead letter operations.
+type IDeadLetterRepository interface {
+	// List retrieves dead letters, most recent first
+	List(ctx context.Context, limit int, offset int) ([]outbox.DeadLetter, error)
+
+	// Get retrieves a single dead letter by ID
+	Get(ctx context.Context, id int64) (outbox.DeadLetter, error)
+
+	// Requeue moves a dead letter back to the outbox and returns the new outbox ID
+	Requeue(ctx context.Context, id int64) (int64, error)
+
+	// Purge deletes dead letters matching the filter
+	Purge(ctx context.Context, filter outbox.PurgeDeadLettersModel) (int64, error)
+}
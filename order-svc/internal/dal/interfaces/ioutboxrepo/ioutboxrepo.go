@@ -12,18 +12,27 @@ type IOutboxRepository interface {
 	// Insert adds a new message to the outbox
 	Insert(ctx context.Context, msg outbox.OutboxMessage) error
 
-	// GetPendingMessages retrieves messages that are ready for retry
-	GetPendingMessages(ctx context.Context, limit int) ([]outbox.OutboxMessage, error)
+	// NextSequence allocates the next sequence number of the aggregate
+	NextSequence(ctx context.Context, aggregateID string) (int64, error)
 
-	// Delete removes a message from the outbox after successful delivery
-	Delete(ctx context.Context, id int64) error
+	// HasPending reports whether the aggregate has messages waiting in the outbox
+	HasPending(ctx context.Context, aggregateID string) (bool, error)
 
-	// UpdateRetry updates retry count and error information
-	UpdateRetry(
+	// ClaimPendingMessages leases a batch of messages that are ready for retry to the worker
+	ClaimPendingMessages(
 		ctx context.Context,
-		id int64,
-		retryCount int,
-		lastError string,
-		nextRetryAt time.Time,
-	) error
+		workerID string,
+		limit int,
+		lease time.Duration,
+	) ([]outbox.OutboxMessage, error)
+
+	// MarkPublished marks messages leased by the worker as published after successful delivery
+	MarkPublished(ctx context.Context, workerID string, ids []int64) error
+
+	// UpdateRetries updates retry information of messages leased by the worker and releases them
+	UpdateRetries(ctx context.Context, workerID string, updates []outbox.RetryUpdate) error
+
+	// MoveExhaustedToDeadLetter moves unleased messages that exhausted their retries
+	// to the dead letter table and returns the number of moved messages per queue
+	MoveExhaustedToDeadLetter(ctx context.Context, limit int) (map[string]int, error)
 }
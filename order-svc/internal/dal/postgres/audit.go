@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MustNewAuditClient creates a Postgres client for the audit database.
+// The audit database is owned by the audit consumer, so no migrations are run.
+func MustNewAuditClient() *Client {
+	connStr := fmt.Sprintf(
+		"host=%s port=5432 user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv("AUDIT_PGBOUNCER_HOST"),
+		os.Getenv("AUDIT_PG_USER"),
+		os.Getenv("AUDIT_PG_PASSWORD"),
+		os.Getenv("AUDIT_PG_DB"),
+	)
+
+	pool, err := pgxpool.New(context.Background(), connStr)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := pool.Ping(context.Background()); err != nil {
+		panic(err)
+	}
+
+	return &Client{
+		pool: pool,
+	}
+}
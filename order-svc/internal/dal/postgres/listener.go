@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/viper"
+)
+
+// Listener receives Postgres notifications on a dedicated connection.
+// LISTEN does not work through pgbouncer in transaction mode, so the listener
+// connects to Postgres directly instead of going through the pool.
+type Listener struct {
+	connStr    string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// MustNewListener creates a new Postgres notification listener.
+func MustNewListener() *Listener {
+	connStr := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv("ORDER_PG_HOST"),
+		os.Getenv("ORDER_PG_PORT"),
+		os.Getenv("ORDER_PG_USER"),
+		os.Getenv("ORDER_PG_PASSWORD"),
+		os.Getenv("ORDER_PG_DB"),
+	)
+
+	if _, err := pgx.ParseConfig(connStr); err != nil {
+		panic(err)
+	}
+
+	minBackoffSeconds := viper.GetInt("postgres.listener.min_reconnect_seconds")
+	if minBackoffSeconds == 0 {
+		minBackoffSeconds = 1
+	}
+
+	maxBackoffSeconds := viper.GetInt("postgres.listener.max_reconnect_seconds")
+	if maxBackoffSeconds == 0 {
+		maxBackoffSeconds = 30
+	}
+
+	return &Listener{
+		connStr:    connStr,
+		minBackoff: time.Duration(minBackoffSeconds) * time.Second,
+		maxBackoff: time.Duration(maxBackoffSeconds) * time.Second,
+	}
+}
+
+// Listen subscribes to the channel and calls onNotify for every notification
+// until the context is canceled. Lost connections are re-established with backoff,
+// onNotify is also called after every reconnect since notifications may have been missed.
+func (l *Listener) Listen(ctx context.Context, channel string, onNotify func()) {
+	backoff := l.minBackoff
+
+	for ctx.Err() == nil {
+		err := l.listen(ctx, channel, onNotify, func() { backoff = l.minBackoff })
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("Postgres listener disconnected, reconnecting",
+			"channel", channel,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// listen holds a single listening connection until it fails or the context is canceled.
+func (l *Listener) listen(ctx context.Context, channel string, onNotify func(), onConnect func()) error {
+	conn, err := pgx.Connect(ctx, l.connStr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = conn.Close(closeCtx)
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on channel: %w", err)
+	}
+
+	slog.Info("Postgres listener connected", "channel", channel)
+	onConnect()
+	onNotify()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		onNotify()
+	}
+}
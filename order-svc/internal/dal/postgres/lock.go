@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// TryWithLock runs fn while holding the advisory lock with the key and reports whether it ran.
+// It does not wait when another session holds the lock.
+// The lock is taken by a transaction kept open while fn runs, so it survives PgBouncer
+// transaction pooling and is released when the transaction ends or its connection is lost.
+func (p *Client) TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	var acquired bool
+	if err := tx.QueryRow(ctx, "select pg_try_advisory_xact_lock($1)", key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	return true, fn(ctx)
+}
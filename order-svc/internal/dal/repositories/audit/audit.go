@@ -2,129 +2,239 @@ package audit
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/corray333/backend-labs/order/internal/dal/interfaces/ioutboxrepo"
-	"github.com/corray333/backend-labs/order/internal/dal/postgres"
-	"github.com/corray333/backend-labs/order/internal/dal/rabbitmq"
 	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderevent"
 	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
-	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type AuditRabbitMQRepository struct {
-	client        *rabbitmq.Client
-	queue         amqp.Queue
-	pgClient      *postgres.Client
+// AuditBrokerRepository publishes order events to the broker and falls back to the outbox.
+type AuditBrokerRepository struct {
+	publisher     broker.Publisher
+	queueName     string
 	outboxRepo    ioutboxrepo.IOutboxRepository
+	breaker       *circuitbreaker.Breaker
 	maxRetries    int
 	retryInterval time.Duration
 }
 
-func NewAuditRabbitMQRepository(
-	client *rabbitmq.Client,
-	pgClient *postgres.Client,
-	outboxRepo ioutboxrepo.IOutboxRepository,
-) *AuditRabbitMQRepository {
-	queueName := viper.GetString("rabbitmq.queue.name")
-	if queueName == "" {
-		queueName = "oms.order.created"
-	}
-
-	queue, err := client.DeclareQueue(rabbitmq.DeclareQueueConfig{
-		Name:       queueName,
-		Durable:    viper.GetBool("rabbitmq.queue.durable"),
-		Exclusive:  viper.GetBool("rabbitmq.queue.exclusive"),
-		AutoDelete: viper.GetBool("rabbitmq.queue.auto_delete"),
-		NoWait:     viper.GetBool("rabbitmq.queue.no_wait"),
-	})
-	if err != nil {
-		panic(err)
+// Config configures the audit repository.
+// Zero values are replaced with defaults.
+type Config struct {
+	QueueName     string
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+// ConfigFromViper reads the audit repository config from the rabbitmq section.
+func ConfigFromViper() Config {
+	return Config{
+		QueueName:     viper.GetString("rabbitmq.queue.name"),
+		MaxRetries:    viper.GetInt("rabbitmq.outbox.max_retries"),
+		RetryInterval: time.Duration(viper.GetInt("rabbitmq.outbox.retry_interval_seconds")) * time.Second,
 	}
+}
 
-	maxRetries := viper.GetInt("rabbitmq.outbox.max_retries")
-	if maxRetries == 0 {
-		maxRetries = 5
+// NewAuditBrokerRepository creates a new audit repository and declares its queue.
+func NewAuditBrokerRepository(
+	publisher broker.Publisher,
+	outboxRepo ioutboxrepo.IOutboxRepository,
+	breaker *circuitbreaker.Breaker,
+	cfg Config,
+) *AuditBrokerRepository {
+	if cfg.QueueName == "" {
+		cfg.QueueName = "oms.order.created"
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
 	}
 
-	retryIntervalSeconds := viper.GetInt("rabbitmq.outbox.retry_interval_seconds")
-	if retryIntervalSeconds == 0 {
-		retryIntervalSeconds = 30
+	if err := publisher.Declare(context.Background(), cfg.QueueName); err != nil {
+		panic(err)
 	}
 
-	return &AuditRabbitMQRepository{
-		client:        client,
-		queue:         queue,
-		pgClient:      pgClient,
+	return &AuditBrokerRepository{
+		publisher:     publisher,
+		queueName:     cfg.QueueName,
 		outboxRepo:    outboxRepo,
-		maxRetries:    maxRetries,
-		retryInterval: time.Duration(retryIntervalSeconds) * time.Second,
+		breaker:       breaker,
+		maxRetries:    cfg.MaxRetries,
+		retryInterval: cfg.RetryInterval,
 	}
 }
 
-func (r *AuditRabbitMQRepository) LogBatchInsert(ctx context.Context, orders []order.Order) error {
+func (r *AuditBrokerRepository) LogBatchInsert(ctx context.Context, orders []order.Order) error {
 	now := time.Now()
 
 	for _, ord := range orders {
-		orderData, err := json.Marshal(ord)
-		if err != nil {
-			slog.Error("Failed to marshal order", "order_id", ord.ID, "error", err)
+		if err := r.logInsert(ctx, ord, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// logInsert publishes the order created event of a single order under its own producer span.
+// The span context travels in the message headers, deferred messages keep it in the outbox.
+func (r *AuditBrokerRepository) logInsert(ctx context.Context, ord order.Order, now time.Time) error {
+	eventID := uuid.NewString()
+	aggregateID := strconv.FormatInt(ord.ID, 10)
+
+	ctx, span := otel.Tracer("dal").Start(ctx, "publish "+r.queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(broker.PublishAttributes(r.publisher.System(), broker.Message{
+			Topic: r.queueName,
+			ID:    eventID,
+		})...),
+	)
+	defer span.End()
+
+	event, err := orderevent.OrderCreated(eventID, now, ord, requestmeta.FromContext(ctx))
+	if err != nil {
+		slog.Error("Failed to build order event", "order_id", ord.ID, "error", err)
+
+		return fmt.Errorf("failed to build order event: %w", err)
+	}
+
+	orderData, err := events.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to marshal order", "order_id", ord.ID, "error", err)
+
+		return fmt.Errorf("failed to marshal order: %w", err)
+	}
+
+	sequence, err := r.outboxRepo.NextSequence(ctx, aggregateID)
+	if err != nil {
+		slog.Error("Failed to allocate event sequence", "order_id", ord.ID, "error", err)
 
-			return fmt.Errorf("failed to marshal order: %w", err)
+		return fmt.Errorf("failed to allocate event sequence: %w", err)
+	}
+
+	traceHeaders := broker.TraceHeaders(ctx)
+
+	outboxMsg := outbox.OutboxMessage{
+		QueueName:    r.queueName,
+		ExchangeName: "",
+		RoutingKey:   r.queueName,
+		MessageID:    eventID,
+		MessageType:  events.TypeOrderCreated,
+		AggregateID:  aggregateID,
+		Sequence:     sequence,
+		Payload:      orderData,
+		ContentType:  events.ContentTypeProtobuf,
+		Headers:      traceHeaders,
+		RetryCount:   0,
+		MaxRetries:   r.maxRetries,
+		ErrorHistory: []outbox.ErrorRecord{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		NextRetryAt:  now,
+	}
+
+	// Earlier events of the order are still waiting for retry, publishing now would overtake them
+	pending, err := r.outboxRepo.HasPending(ctx, aggregateID)
+	if err != nil {
+		slog.Warn("Failed to check pending outbox messages, saving to outbox",
+			"order_id", ord.ID,
+			"error", err,
+		)
+		pending = true
+	}
+
+	if pending {
+		if err := r.outboxRepo.Insert(ctx, outboxMsg); err != nil {
+			slog.Error("Failed to insert message to outbox", "order_id", ord.ID, "error", err)
+
+			return fmt.Errorf("failed to insert message to outbox: %w", err)
 		}
 
-		// Try to publish to RabbitMQ synchronously
-		err = r.client.Channel().Publish(
-			"",
-			r.queue.Name,
-			false,
-			false,
-			amqp.Publishing{
-				ContentType: "application/json",
-				Body:        orderData,
-			},
+		slog.Info("Message queued behind pending outbox messages", "order_id", ord.ID, "sequence", sequence)
+
+		return nil
+	}
+
+	// Try to publish to the broker synchronously, while the breaker is open
+	// the message goes straight to the outbox and the worker probes recovery
+	err = r.breaker.Execute(func() error {
+		return r.publisher.Publish(ctx, broker.Message{
+			Topic:       r.queueName,
+			Key:         aggregateID,
+			ID:          eventID,
+			Type:        events.TypeOrderCreated,
+			Headers:     messageHeaders(aggregateID, sequence, traceHeaders),
+			ContentType: events.ContentTypeProtobuf,
+			Timestamp:   now,
+			Body:        orderData,
+		})
+	})
+
+	// If publish fails, save to outbox
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		slog.Warn(
+			"Failed to publish to broker, saving to outbox",
+			"order_id",
+			ord.ID,
+			"error",
+			err,
 		)
 
-		// If publish fails, save to outbox
-		if err != nil {
-			slog.Warn(
-				"Failed to publish to RabbitMQ, saving to outbox",
-				"order_id",
-				ord.ID,
-				"error",
-				err,
-			)
-
-			outboxMsg := outbox.OutboxMessage{
-				QueueName:    r.queue.Name,
-				ExchangeName: "",
-				RoutingKey:   r.queue.Name,
-				Payload:      orderData,
-				ContentType:  "application/json",
-				RetryCount:   0,
-				MaxRetries:   r.maxRetries,
-				LastError:    err.Error(),
-				CreatedAt:    now,
-				UpdatedAt:    now,
-				NextRetryAt:  now.Add(r.retryInterval),
-			}
-
-			if err := r.outboxRepo.Insert(ctx, outboxMsg); err != nil {
-				slog.Error("Failed to insert message to outbox", "order_id", ord.ID, "error", err)
-
-				return fmt.Errorf("failed to insert message to outbox: %w", err)
-			}
-
-			slog.Info("Message saved to outbox", "order_id", ord.ID)
-		} else {
-			slog.Info("Message published to RabbitMQ", "order_id", ord.ID)
+		outboxMsg.LastError = err.Error()
+		outboxMsg.ErrorHistory = []outbox.ErrorRecord{{Attempt: 0, Error: err.Error(), OccurredAt: now}}
+		if !errors.Is(err, circuitbreaker.ErrOpen) {
+			outboxMsg.NextRetryAt = now.Add(r.retryInterval)
+		}
+
+		if err := r.outboxRepo.Insert(ctx, outboxMsg); err != nil {
+			slog.Error("Failed to insert message to outbox", "order_id", ord.ID, "error", err)
+
+			return fmt.Errorf("failed to insert message to outbox: %w", err)
+		}
+
+		slog.Info("Message saved to outbox", "order_id", ord.ID)
+	} else {
+		slog.Info("Message published to broker", "order_id", ord.ID)
+
+		// Keep published events in the outbox history, the event is already delivered
+		outboxMsg.PublishedAt = &now
+		if err := r.outboxRepo.Insert(ctx, outboxMsg); err != nil {
+			slog.Warn("Failed to record published message in outbox", "order_id", ord.ID, "error", err)
 		}
 	}
 
 	return nil
 }
 
+// messageHeaders builds the headers of an order event: ordering headers and the trace context.
+func messageHeaders(aggregateID string, sequence int64, traceHeaders map[string]string) map[string]any {
+	headers := map[string]any{
+		events.HeaderAggregateID: aggregateID,
+		events.HeaderSequence:    sequence,
+	}
+	for k, v := range traceHeaders {
+		headers[k] = v
+	}
+
+	return headers
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
+)
+
+// AuditLogRepository is an in-memory audit log repository for tests.
+// It follows the message deduplication rules of the Postgres repository.
+type AuditLogRepository struct {
+	mu        sync.Mutex
+	nextID    int64
+	auditLogs []auditlog.AuditLogOrder
+	processed map[string]time.Time
+}
+
+// NewAuditLogRepository creates a new in-memory audit log repository.
+func NewAuditLogRepository() *AuditLogRepository {
+	return &AuditLogRepository{
+		processed: make(map[string]time.Time),
+	}
+}
+
+// SaveAuditLogs saves the audit logs of messages not seen before and marks the messages processed.
+func (r *AuditLogRepository) SaveAuditLogs(
+	_ context.Context,
+	auditLogs []auditlog.AuditLogOrder,
+) (auditlog.SaveAuditLogsResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var messageIDs []string
+	for _, l := range auditLogs {
+		if l.MessageID == "" {
+			return auditlog.SaveAuditLogsResult{}, auditlog.ErrMissingMessageID
+		}
+		if !slices.Contains(messageIDs, l.MessageID) {
+			messageIDs = append(messageIDs, l.MessageID)
+		}
+	}
+
+	var result auditlog.SaveAuditLogsResult
+	claimed := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		if _, ok := r.processed[id]; ok {
+			result.DuplicateMessageIDs = append(result.DuplicateMessageIDs, id)
+
+			continue
+		}
+		r.processed[id] = time.Now()
+		claimed[id] = true
+	}
+
+	for _, l := range auditLogs {
+		if !claimed[l.MessageID] {
+			continue
+		}
+
+		r.nextID++
+		l.ID = r.nextID
+		r.auditLogs = append(r.auditLogs, l)
+		result.Saved = append(result.Saved, l)
+	}
+
+	return result, nil
+}
+
+// DeleteProcessedMessagesBefore forgets up to limit messages processed before the given time.
+func (r *AuditLogRepository) DeleteProcessedMessagesBefore(_ context.Context, before time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, processedAt := range r.processed {
+		if deleted == int64(limit) {
+			break
+		}
+		if processedAt.Before(before) {
+			delete(r.processed, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// SetProcessedAt backdates the processed mark of a message, simulating an old delivery.
+func (r *AuditLogRepository) SetProcessedAt(messageID string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processed[messageID] = at
+}
+
+// AuditLogs returns a snapshot of the saved audit logs.
+func (r *AuditLogRepository) AuditLogs() []auditlog.AuditLogOrder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.auditLogs)
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
+	"go.opentelemetry.io/otel"
+)
+
+// AuditLogRepository implements the audit log repository for PostgreSQL.
+// It stores the audit trail when the audit consumer uses the grpc sink, as plain rows:
+// hash chains, partitions and archives are kept by the audit database only.
+type AuditLogRepository struct {
+	client *postgres.Client
+}
+
+// NewAuditLogRepository creates a new audit log repository.
+func NewAuditLogRepository(client *postgres.Client) *AuditLogRepository {
+	return &AuditLogRepository{
+		client: client,
+	}
+}
+
+// SaveAuditLogs saves the audit logs of messages not seen before and marks the messages processed.
+// Both happen in a single statement, so a message is never marked processed without its audit logs.
+// A concurrent call saving the same message waits for the first one and skips the message.
+func (r *AuditLogRepository) SaveAuditLogs(
+	ctx context.Context,
+	auditLogs []auditlog.AuditLogOrder,
+) (auditlog.SaveAuditLogsResult, error) {
+	ctx, span := otel.Tracer("dal").Start(ctx, "DAL.SaveAuditLogs")
+	defer span.End()
+
+	if len(auditLogs) == 0 {
+		return auditlog.SaveAuditLogsResult{}, nil
+	}
+
+	var (
+		messageIDs     []string
+		orderIDs       = make([]int64, len(auditLogs))
+		orderItemIDs   = make([]int64, len(auditLogs))
+		customerIDs    = make([]int64, len(auditLogs))
+		orderStatuses  = make([]string, len(auditLogs))
+		eventTypes     = make([]string, len(auditLogs))
+		actorIDs       = make([]string, len(auditLogs))
+		actorTypes     = make([]string, len(auditLogs))
+		sourceServices = make([]string, len(auditLogs))
+		requestIDs     = make([]string, len(auditLogs))
+		changes        = make([]string, len(auditLogs))
+		logMessageIDs  = make([]string, len(auditLogs))
+		createdAts     = make([]time.Time, len(auditLogs))
+		updatedAts     = make([]time.Time, len(auditLogs))
+	)
+	for i, l := range auditLogs {
+		if l.MessageID == "" {
+			return auditlog.SaveAuditLogsResult{}, auditlog.ErrMissingMessageID
+		}
+		if !slices.Contains(messageIDs, l.MessageID) {
+			messageIDs = append(messageIDs, l.MessageID)
+		}
+
+		changesData, err := marshalChanges(l.Changes)
+		if err != nil {
+			return auditlog.SaveAuditLogsResult{}, err
+		}
+
+		orderIDs[i] = l.OrderID
+		orderItemIDs[i] = l.OrderItemID
+		customerIDs[i] = l.CustomerID
+		orderStatuses[i] = l.OrderStatus
+		eventTypes[i] = l.EventType
+		actorIDs[i] = l.ActorID
+		actorTypes[i] = l.ActorType
+		sourceServices[i] = l.SourceService
+		requestIDs[i] = l.RequestID
+		changes[i] = string(changesData)
+		logMessageIDs[i] = l.MessageID
+		createdAts[i] = l.CreatedAt
+		updatedAts[i] = l.UpdatedAt
+	}
+
+	query := `
+		WITH claimed AS (
+			INSERT INTO audit_log_processed_message (message_id, processed_at)
+			SELECT unnest($1::text[]), now()
+			ON CONFLICT (message_id) DO NOTHING
+			RETURNING message_id
+		)
+		INSERT INTO audit_log_order (
+			order_id, order_item_id, customer_id, order_status, event_type, actor_id, actor_type,
+			source_service, request_id, changes, message_id, created_at, updated_at
+		)
+		SELECT l.order_id, l.order_item_id, l.customer_id, l.order_status, l.event_type, l.actor_id, l.actor_type,
+		       l.source_service, l.request_id, l.changes::jsonb, l.message_id, l.created_at, l.updated_at
+		FROM unnest(
+			$2::bigint[], $3::bigint[], $4::bigint[], $5::text[], $6::text[], $7::text[], $8::text[],
+			$9::text[], $10::text[], $11::text[], $12::text[], $13::timestamptz[], $14::timestamptz[]
+		) AS l(
+			order_id, order_item_id, customer_id, order_status, event_type, actor_id, actor_type,
+			source_service, request_id, changes, message_id, created_at, updated_at
+		)
+		WHERE l.message_id IN (SELECT message_id FROM claimed)
+		RETURNING id, order_id, order_item_id, customer_id, order_status, event_type, actor_id, actor_type,
+		          source_service, request_id, changes, message_id, created_at, updated_at
+	`
+
+	rows, err := r.client.Pool().Query(ctx, query,
+		messageIDs,
+		orderIDs,
+		orderItemIDs,
+		customerIDs,
+		orderStatuses,
+		eventTypes,
+		actorIDs,
+		actorTypes,
+		sourceServices,
+		requestIDs,
+		changes,
+		logMessageIDs,
+		createdAts,
+		updatedAts,
+	)
+	if err != nil {
+		return auditlog.SaveAuditLogsResult{}, fmt.Errorf("failed to save audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var result auditlog.SaveAuditLogsResult
+	saved := make(map[string]bool, len(messageIDs))
+	for rows.Next() {
+		var (
+			l           auditlog.AuditLogOrder
+			changesData []byte
+		)
+		err := rows.Scan(
+			&l.ID,
+			&l.OrderID,
+			&l.OrderItemID,
+			&l.CustomerID,
+			&l.OrderStatus,
+			&l.EventType,
+			&l.ActorID,
+			&l.ActorType,
+			&l.SourceService,
+			&l.RequestID,
+			&changesData,
+			&l.MessageID,
+			&l.CreatedAt,
+			&l.UpdatedAt,
+		)
+		if err != nil {
+			return auditlog.SaveAuditLogsResult{}, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := json.Unmarshal(changesData, &l.Changes); err != nil {
+			return auditlog.SaveAuditLogsResult{}, fmt.Errorf("failed to unmarshal audit log changes: %w", err)
+		}
+
+		result.Saved = append(result.Saved, l)
+		saved[l.MessageID] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return auditlog.SaveAuditLogsResult{}, fmt.Errorf("error iterating saved audit logs: %w", err)
+	}
+
+	// Every message has audit logs, a message without saved ones was processed before
+	for _, id := range messageIDs {
+		if !saved[id] {
+			result.DuplicateMessageIDs = append(result.DuplicateMessageIDs, id)
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteProcessedMessagesBefore forgets up to limit messages processed before the given time, oldest first,
+// and returns the number of forgotten messages.
+func (r *AuditLogRepository) DeleteProcessedMessagesBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ctx, span := otel.Tracer("dal").Start(ctx, "DAL.DeleteProcessedMessagesBefore")
+	defer span.End()
+
+	query := `
+		DELETE FROM audit_log_processed_message
+		WHERE message_id IN (
+			SELECT message_id
+			FROM audit_log_processed_message
+			WHERE processed_at < $1
+			ORDER BY processed_at ASC
+			LIMIT $2
+		)
+	`
+
+	tag, err := r.client.Pool().Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete processed messages: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// marshalChanges encodes field changes for the jsonb column.
+func marshalChanges(changes map[string]auditlog.FieldChange) ([]byte, error) {
+	if changes == nil {
+		changes = map[string]auditlog.FieldChange{}
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit log changes: %w", err)
+	}
+
+	return data, nil
+}
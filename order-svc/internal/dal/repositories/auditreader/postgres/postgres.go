@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+)
+
+// AuditReaderRepository implements read access to the audit database.
+type AuditReaderRepository struct {
+	client *postgres.Client
+}
+
+// NewAuditReaderRepository creates a new audit reader repository.
+func NewAuditReaderRepository(client *postgres.Client) *AuditReaderRepository {
+	return &AuditReaderRepository{
+		client: client,
+	}
+}
+
+// OrderItemRefs returns distinct order items that have audit rows for orders in the ID range.
+func (r *AuditReaderRepository) OrderItemRefs(
+	ctx context.Context,
+	fromOrderID int64,
+	toOrderID int64,
+) ([]reconciliation.OrderItemRef, error) {
+	query, args, err := sq.Select("order_id", "order_item_id").
+		Distinct().
+		From("audit_log_order").
+		Where(sq.GtOrEq{"order_id": fromOrderID}).
+		Where(sq.LtOrEq{"order_id": toOrderID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.client.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []reconciliation.OrderItemRef
+	for rows.Next() {
+		var ref reconciliation.OrderItemRef
+		if err := rows.Scan(&ref.OrderID, &ref.OrderItemID); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return refs, nil
+}
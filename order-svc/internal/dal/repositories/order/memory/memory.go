@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+)
+
+// OrderRepository is an in-memory order repository for tests.
+type OrderRepository struct {
+	mu     sync.Mutex
+	nextID int64
+	orders []order.Order
+}
+
+// NewOrderRepository creates a new in-memory order repository.
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{}
+}
+
+// BulkInsert stores the orders and returns them with generated IDs.
+func (r *OrderRepository) BulkInsert(_ context.Context, orders []order.Order) ([]order.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]order.Order, 0, len(orders))
+	for _, o := range orders {
+		r.nextID++
+		o.ID = r.nextID
+
+		stored := o
+		stored.OrderItems = []orderitem.OrderItem{}
+		r.orders = append(r.orders, stored)
+
+		o.OrderItems = slices.Clone(o.OrderItems)
+		result = append(result, o)
+	}
+
+	return result, nil
+}
+
+// Query returns orders matching the filter ordered by ID.
+func (r *OrderRepository) Query(_ context.Context, filter *order.QueryOrdersModel) ([]order.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []order.Order
+	for _, o := range r.orders {
+		if matches(o, filter) {
+			o.OrderItems = []orderitem.OrderItem{}
+			result = append(result, o)
+		}
+	}
+
+	if filter.Offset > 0 {
+		result = result[min(filter.Offset, len(result)):]
+	}
+	if filter.Limit > 0 {
+		result = result[:min(filter.Limit, len(result))]
+	}
+
+	return result, nil
+}
+
+// Delete removes orders by ID, used to roll back unit of work transactions.
+func (r *OrderRepository) Delete(ids []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders = slices.DeleteFunc(r.orders, func(o order.Order) bool {
+		return slices.Contains(ids, o.ID)
+	})
+}
+
+// matches reports whether the order matches the filter.
+func matches(o order.Order, filter *order.QueryOrdersModel) bool {
+	switch {
+	case len(filter.Ids) > 0 && !slices.Contains(filter.Ids, o.ID):
+		return false
+	case len(filter.CustomerIds) > 0 && !slices.Contains(filter.CustomerIds, o.CustomerID):
+		return false
+	case filter.FromID > 0 && o.ID < filter.FromID:
+		return false
+	case filter.ToID > 0 && o.ID > filter.ToID:
+		return false
+	case !filter.CreatedFrom.IsZero() && o.CreatedAt.Before(filter.CreatedFrom):
+		return false
+	case !filter.CreatedTo.IsZero() && !o.CreatedAt.Before(filter.CreatedTo):
+		return false
+	default:
+		return true
+	}
+}
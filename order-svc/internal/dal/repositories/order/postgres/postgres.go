@@ -190,6 +190,24 @@ func (r *PostgresOrderRepository) Query(
 		query = query.Where(sq.Eq{"customer_id": filter.CustomerIds})
 	}
 
+	if filter.FromID > 0 {
+		query = query.Where(sq.GtOrEq{"id": filter.FromID})
+	}
+
+	if filter.ToID > 0 {
+		query = query.Where(sq.LtOrEq{"id": filter.ToID})
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where(sq.GtOrEq{"created_at": filter.CreatedFrom})
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where(sq.Lt{"created_at": filter.CreatedTo})
+	}
+
+	query = query.OrderBy("id")
+
 	if filter.Limit > 0 {
 		query = query.Limit(uint64(filter.Limit))
 	}
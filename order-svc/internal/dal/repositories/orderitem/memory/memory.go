@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+)
+
+// OrderItemRepository is an in-memory order item repository for tests.
+type OrderItemRepository struct {
+	mu     sync.Mutex
+	nextID int64
+	items  []orderitem.OrderItem
+}
+
+// NewOrderItemRepository creates a new in-memory order item repository.
+func NewOrderItemRepository() *OrderItemRepository {
+	return &OrderItemRepository{}
+}
+
+// BulkInsert stores the items and returns them with generated IDs.
+func (r *OrderItemRepository) BulkInsert(
+	_ context.Context,
+	orderItems []orderitem.OrderItem,
+) ([]orderitem.OrderItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]orderitem.OrderItem, 0, len(orderItems))
+	for _, item := range orderItems {
+		r.nextID++
+		item.ID = r.nextID
+		r.items = append(r.items, item)
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// Query returns items matching the filter in insertion order.
+func (r *OrderItemRepository) Query(
+	_ context.Context,
+	filter *orderitem.QueryOrderItemsModel,
+) ([]orderitem.OrderItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []orderitem.OrderItem
+	for _, item := range r.items {
+		if matches(item, filter) {
+			result = append(result, item)
+		}
+	}
+
+	if filter.Offset > 0 {
+		result = result[min(filter.Offset, len(result)):]
+	}
+	if filter.Limit > 0 {
+		result = result[:min(filter.Limit, len(result))]
+	}
+
+	return result, nil
+}
+
+// Delete removes items by ID, used to roll back unit of work transactions.
+func (r *OrderItemRepository) Delete(ids []int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = slices.DeleteFunc(r.items, func(item orderitem.OrderItem) bool {
+		return slices.Contains(ids, item.ID)
+	})
+}
+
+// matches reports whether the item matches the filter.
+func matches(item orderitem.OrderItem, filter *orderitem.QueryOrderItemsModel) bool {
+	switch {
+	case len(filter.Ids) > 0 && !slices.Contains(filter.Ids, item.ID):
+		return false
+	case len(filter.OrderIds) > 0 && !slices.Contains(filter.OrderIds, item.OrderID):
+		return false
+	case len(filter.ProductIds) > 0 && !slices.Contains(filter.ProductIds, item.ProductID):
+		return false
+	default:
+		return true
+	}
+}
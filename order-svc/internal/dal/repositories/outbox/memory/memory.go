@@ -0,0 +1,258 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+)
+
+// OutboxRepository is an in-memory outbox repository for tests.
+// It follows the leasing and per-aggregate ordering rules of the Postgres repository.
+type OutboxRepository struct {
+	mu          sync.Mutex
+	nextID      int64
+	nextDLID    int64
+	messages    []*entry
+	deadLetters []outbox.DeadLetter
+	sequences   map[string]int64
+}
+
+// entry is an outbox message with its lease.
+type entry struct {
+	msg         outbox.OutboxMessage
+	lockedBy    string
+	lockedUntil time.Time
+}
+
+// NewOutboxRepository creates a new in-memory outbox repository.
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{
+		sequences: make(map[string]int64),
+	}
+}
+
+// Insert adds a new message to the outbox.
+func (r *OutboxRepository) Insert(_ context.Context, msg outbox.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	msg.ID = r.nextID
+	msg.ErrorHistory = slices.Clone(msg.ErrorHistory)
+	msg.Headers = maps.Clone(msg.Headers)
+	r.messages = append(r.messages, &entry{msg: msg})
+
+	return nil
+}
+
+// NextSequence allocates the next sequence number of the aggregate.
+func (r *OutboxRepository) NextSequence(_ context.Context, aggregateID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sequences[aggregateID]++
+
+	return r.sequences[aggregateID], nil
+}
+
+// HasPending reports whether the aggregate has unpublished messages that did not exhaust their retries.
+func (r *OutboxRepository) HasPending(_ context.Context, aggregateID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.messages {
+		if e.msg.AggregateID == aggregateID && e.msg.PublishedAt == nil && e.msg.RetryCount < e.msg.MaxRetries {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ClaimPendingMessages leases messages that are ready for retry to the worker.
+func (r *OutboxRepository) ClaimPendingMessages(
+	_ context.Context,
+	workerID string,
+	limit int,
+	lease time.Duration,
+) ([]outbox.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var ready []*entry
+	for _, e := range r.messages {
+		if r.claimable(e, now) {
+			ready = append(ready, e)
+		}
+	}
+
+	slices.SortFunc(ready, func(a, b *entry) int {
+		if c := a.msg.NextRetryAt.Compare(b.msg.NextRetryAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.msg.ID, b.msg.ID)
+	})
+	if limit > 0 && len(ready) > limit {
+		ready = ready[:limit]
+	}
+
+	messages := make([]outbox.OutboxMessage, 0, len(ready))
+	for _, e := range ready {
+		e.lockedBy = workerID
+		e.lockedUntil = now.Add(lease)
+		messages = append(messages, e.msg)
+	}
+
+	return messages, nil
+}
+
+// MarkPublished marks messages leased by the worker as published and releases their lease.
+func (r *OutboxRepository) MarkPublished(_ context.Context, workerID string, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range r.messages {
+		if e.lockedBy == workerID && slices.Contains(ids, e.msg.ID) {
+			e.msg.PublishedAt = &now
+			e.msg.UpdatedAt = now
+			e.lockedBy = ""
+			e.lockedUntil = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// UpdateRetries records failed attempts of messages leased by the worker and releases them.
+func (r *OutboxRepository) UpdateRetries(_ context.Context, workerID string, updates []outbox.RetryUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, u := range updates {
+		for _, e := range r.messages {
+			if e.msg.ID != u.ID || e.lockedBy != workerID {
+				continue
+			}
+
+			e.msg.RetryCount = u.RetryCount
+			e.msg.LastError = u.LastError
+			e.msg.ErrorHistory = append(e.msg.ErrorHistory, outbox.ErrorRecord{
+				Attempt:    u.RetryCount,
+				Error:      u.LastError,
+				OccurredAt: now,
+			})
+			e.msg.NextRetryAt = u.NextRetryAt
+			e.msg.UpdatedAt = now
+			e.lockedBy = ""
+			e.lockedUntil = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// MoveExhaustedToDeadLetter moves unleased messages that exhausted their retries to the dead letters.
+func (r *OutboxRepository) MoveExhaustedToDeadLetter(_ context.Context, limit int) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	moved := make(map[string]int)
+	count := 0
+	r.messages = slices.DeleteFunc(r.messages, func(e *entry) bool {
+		switch {
+		case e.msg.PublishedAt != nil, e.msg.RetryCount < e.msg.MaxRetries:
+			return false
+		case e.lockedBy != "" && e.lockedUntil.After(now):
+			return false
+		case limit > 0 && count >= limit:
+			return false
+		}
+		moved[e.msg.QueueName]++
+		count++
+
+		r.nextDLID++
+		r.deadLetters = append(r.deadLetters, outbox.DeadLetter{
+			ID:             r.nextDLID,
+			OutboxID:       e.msg.ID,
+			QueueName:      e.msg.QueueName,
+			ExchangeName:   e.msg.ExchangeName,
+			RoutingKey:     e.msg.RoutingKey,
+			MessageID:      e.msg.MessageID,
+			MessageType:    e.msg.MessageType,
+			AggregateID:    e.msg.AggregateID,
+			Sequence:       e.msg.Sequence,
+			Payload:        e.msg.Payload,
+			ContentType:    e.msg.ContentType,
+			Headers:        e.msg.Headers,
+			RetryCount:     e.msg.RetryCount,
+			MaxRetries:     e.msg.MaxRetries,
+			LastError:      e.msg.LastError,
+			ErrorHistory:   e.msg.ErrorHistory,
+			CreatedAt:      e.msg.CreatedAt,
+			DeadLetteredAt: now,
+		})
+
+		return true
+	})
+
+	return moved, nil
+}
+
+// Messages returns a snapshot of all outbox messages, including published ones.
+func (r *OutboxRepository) Messages() []outbox.OutboxMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := make([]outbox.OutboxMessage, 0, len(r.messages))
+	for _, e := range r.messages {
+		messages = append(messages, e.msg)
+	}
+
+	return messages
+}
+
+// DeadLetters returns a snapshot of dead-lettered messages.
+func (r *OutboxRepository) DeadLetters() []outbox.DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.deadLetters)
+}
+
+// claimable reports whether the message can be leased. Must be called with the lock held.
+func (r *OutboxRepository) claimable(e *entry, now time.Time) bool {
+	switch {
+	case e.msg.PublishedAt != nil:
+		return false
+	case e.msg.NextRetryAt.After(now):
+		return false
+	case e.msg.RetryCount >= e.msg.MaxRetries:
+		return false
+	case e.lockedBy != "" && e.lockedUntil.After(now):
+		return false
+	case e.msg.AggregateID == "":
+		return true
+	}
+
+	for _, prev := range r.messages {
+		if prev.msg.AggregateID == e.msg.AggregateID &&
+			prev.msg.Sequence < e.msg.Sequence &&
+			prev.msg.PublishedAt == nil &&
+			prev.msg.RetryCount < prev.msg.MaxRetries {
+			return false
+		}
+	}
+
+	return true
+}
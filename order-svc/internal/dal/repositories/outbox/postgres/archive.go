@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// archivePartitionPrefix is the name prefix of monthly outbox archive partitions.
+const archivePartitionPrefix = "outbox_archive_"
+
+// ArchiveRepository implements the outbox archive repository for PostgreSQL.
+type ArchiveRepository struct {
+	client *postgres.Client
+}
+
+// NewArchiveRepository creates a new outbox archive repository.
+func NewArchiveRepository(client *postgres.Client) *ArchiveRepository {
+	return &ArchiveRepository{
+		client: client,
+	}
+}
+
+// PublishedMonths returns the months of published messages older than the given time.
+func (r *ArchiveRepository) PublishedMonths(ctx context.Context, before time.Time) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT date_trunc('month', published_at AT TIME ZONE 'UTC')
+		FROM outbox
+		WHERE published_at IS NOT NULL
+		  AND published_at < $1
+	`
+
+	rows, err := r.client.Pool().Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query published months: %w", err)
+	}
+	defer rows.Close()
+
+	var months []time.Time
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			return nil, fmt.Errorf("failed to scan published month: %w", err)
+		}
+		months = append(months, month)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating published months: %w", err)
+	}
+
+	return months, nil
+}
+
+// EnsurePartition creates the monthly archive partition that contains the given time.
+func (r *ArchiveRepository) EnsurePartition(ctx context.Context, month time.Time) error {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF outbox_archive FOR VALUES FROM ('%s') TO ('%s')",
+		pgx.Identifier{archivePartitionName(from)}.Sanitize(),
+		from.Format(time.RFC3339),
+		to.Format(time.RFC3339),
+	)
+
+	if _, err := r.client.Pool().Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to create archive partition: %w", err)
+	}
+
+	return nil
+}
+
+// ArchivePublished moves up to limit published messages older than the given time
+// to the archive and returns the number of archived messages.
+// Partitions for the archived months must exist, see EnsurePartition.
+func (r *ArchiveRepository) ArchivePublished(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		WITH archived AS (
+			DELETE FROM outbox
+			WHERE id IN (
+				SELECT id
+				FROM outbox
+				WHERE published_at IS NOT NULL
+				  AND published_at < $1
+				ORDER BY published_at ASC
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+			          sequence, payload, content_type, headers, retry_count, error_history, created_at, published_at
+		)
+		INSERT INTO outbox_archive (
+			id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+			sequence, payload, content_type, headers, retry_count, error_history, created_at, published_at, archived_at
+		)
+		SELECT id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+		       sequence, payload, content_type, headers, retry_count, error_history, created_at, published_at, now()
+		FROM archived
+	`
+
+	tag, err := r.client.Pool().Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive published outbox messages: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DropPartitionsBefore drops archive partitions that only contain messages
+// published before the given time and returns the names of dropped partitions.
+func (r *ArchiveRepository) DropPartitionsBefore(ctx context.Context, before time.Time) ([]string, error) {
+	query := `
+		SELECT child.relname
+		FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = 'outbox_archive'
+	`
+
+	rows, err := r.client.Pool().Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archive partitions: %w", err)
+	}
+
+	partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan archive partitions: %w", err)
+	}
+
+	var dropped []string
+	for _, partition := range partitions {
+		month, err := time.Parse("2006_01", strings.TrimPrefix(partition, archivePartitionPrefix))
+		if err != nil {
+			continue
+		}
+
+		if month.AddDate(0, 1, 0).After(before) {
+			continue
+		}
+
+		if _, err := r.client.Pool().Exec(ctx, "DROP TABLE IF EXISTS "+pgx.Identifier{partition}.Sanitize()); err != nil {
+			return dropped, fmt.Errorf("failed to drop archive partition %s: %w", partition, err)
+		}
+		dropped = append(dropped, partition)
+	}
+
+	return dropped, nil
+}
+
+// archivePartitionName returns the name of the archive partition for the month.
+func archivePartitionName(month time.Time) string {
+	return archivePartitionPrefix + month.Format("2006_01")
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/jackc/pgx/v5"
+)
+
+// DeadLetterRepository implements the outbox dead letter repository for PostgreSQL.
+type DeadLetterRepository struct {
+	client *postgres.Client
+}
+
+// NewDeadLetterRepository creates a new dead letter repository.
+func NewDeadLetterRepository(client *postgres.Client) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		client: client,
+	}
+}
+
+// deadLetterColumns are the columns selected for a dead letter.
+func deadLetterColumns() []string {
+	return []string{
+		"id",
+		"outbox_id",
+		"queue_name",
+		"exchange_name",
+		"routing_key",
+		"message_id",
+		"message_type",
+		"aggregate_id",
+		"sequence",
+		"payload",
+		"content_type",
+		"headers",
+		"retry_count",
+		"max_retries",
+		"last_error",
+		"error_history",
+		"created_at",
+		"dead_lettered_at",
+	}
+}
+
+// scanDeadLetter scans a row selected with deadLetterColumns.
+func scanDeadLetter(row pgx.Row) (outbox.DeadLetter, error) {
+	var dl outbox.DeadLetter
+	err := row.Scan(
+		&dl.ID,
+		&dl.OutboxID,
+		&dl.QueueName,
+		&dl.ExchangeName,
+		&dl.RoutingKey,
+		&dl.MessageID,
+		&dl.MessageType,
+		&dl.AggregateID,
+		&dl.Sequence,
+		&dl.Payload,
+		&dl.ContentType,
+		&dl.Headers,
+		&dl.RetryCount,
+		&dl.MaxRetries,
+		&dl.LastError,
+		&dl.ErrorHistory,
+		&dl.CreatedAt,
+		&dl.DeadLetteredAt,
+	)
+
+	return dl, err
+}
+
+// List retrieves dead letters, most recent first.
+func (r *DeadLetterRepository) List(
+	ctx context.Context,
+	limit int,
+	offset int,
+) ([]outbox.DeadLetter, error) {
+	builder := sq.Select(deadLetterColumns()...).
+		From("outbox_dead_letter").
+		OrderBy("dead_lettered_at DESC", "id DESC").
+		PlaceholderFormat(sq.Dollar)
+
+	if limit > 0 {
+		builder = builder.Limit(uint64(limit))
+	}
+
+	if offset > 0 {
+		builder = builder.Offset(uint64(offset))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.client.Pool().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var deadLetters []outbox.DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// Get retrieves a single dead letter by ID.
+func (r *DeadLetterRepository) Get(ctx context.Context, id int64) (outbox.DeadLetter, error) {
+	query, args, err := sq.Select(deadLetterColumns()...).
+		From("outbox_dead_letter").
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return outbox.DeadLetter{}, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	dl, err := scanDeadLetter(r.client.Pool().QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return outbox.DeadLetter{}, outbox.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return outbox.DeadLetter{}, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return dl, nil
+}
+
+// Requeue moves a dead letter back to the outbox with a reset retry count.
+// The error history is preserved. Returns the ID of the new outbox message.
+func (r *DeadLetterRepository) Requeue(ctx context.Context, id int64) (int64, error) {
+	query := `
+		WITH requeued AS (
+			DELETE FROM outbox_dead_letter
+			WHERE id = $1
+			RETURNING queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id, sequence,
+			          payload, content_type, headers, max_retries, last_error, error_history, created_at
+		)
+		INSERT INTO outbox (
+			queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id, sequence, payload,
+			content_type, headers, retry_count, max_retries, last_error, error_history, created_at, updated_at,
+			next_retry_at
+		)
+		SELECT queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id, sequence, payload,
+		       content_type, headers, 0, max_retries, last_error, error_history, created_at, now(), now()
+		FROM requeued
+		RETURNING id
+	`
+
+	var outboxID int64
+	err := r.client.Pool().QueryRow(ctx, query, id).Scan(&outboxID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, outbox.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead letter: %w", err)
+	}
+
+	return outboxID, nil
+}
+
+// Purge deletes dead letters matching the filter and returns the number of deleted rows.
+func (r *DeadLetterRepository) Purge(
+	ctx context.Context,
+	filter outbox.PurgeDeadLettersModel,
+) (int64, error) {
+	builder := sq.Delete("outbox_dead_letter").PlaceholderFormat(sq.Dollar)
+
+	if len(filter.Ids) > 0 {
+		builder = builder.Where(sq.Eq{"id": filter.Ids})
+	}
+
+	if !filter.DeadLetteredBefore.IsZero() {
+		builder = builder.Where(sq.Lt{"dead_lettered_at": filter.DeadLetteredBefore})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	tag, err := r.client.Pool().Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
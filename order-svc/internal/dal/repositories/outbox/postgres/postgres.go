@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -24,32 +27,56 @@ func NewOutboxRepository(client *postgres.Client) *OutboxRepository {
 
 // Insert adds a new message to the outbox.
 func (r *OutboxRepository) Insert(ctx context.Context, msg outbox.OutboxMessage) error {
+	errorHistory, err := marshalErrorHistory(msg.ErrorHistory)
+	if err != nil {
+		return err
+	}
+
+	headers, err := marshalHeaders(msg.Headers)
+	if err != nil {
+		return err
+	}
+
 	query, args, err := sq.Insert("outbox").
 		Columns(
 			"queue_name",
 			"exchange_name",
 			"routing_key",
+			"message_id",
+			"message_type",
+			"aggregate_id",
+			"sequence",
 			"payload",
 			"content_type",
+			"headers",
 			"retry_count",
 			"max_retries",
 			"last_error",
+			"error_history",
 			"created_at",
 			"updated_at",
 			"next_retry_at",
+			"published_at",
 		).
 		Values(
 			msg.QueueName,
 			msg.ExchangeName,
 			msg.RoutingKey,
+			msg.MessageID,
+			msg.MessageType,
+			msg.AggregateID,
+			msg.Sequence,
 			msg.Payload,
 			msg.ContentType,
+			headers,
 			msg.RetryCount,
 			msg.MaxRetries,
 			msg.LastError,
+			errorHistory,
 			msg.CreatedAt,
 			msg.UpdatedAt,
 			msg.NextRetryAt,
+			msg.PublishedAt,
 		).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -65,39 +92,88 @@ func (r *OutboxRepository) Insert(ctx context.Context, msg outbox.OutboxMessage)
 	return nil
 }
 
-// GetPendingMessages retrieves messages that are ready for retry.
-func (r *OutboxRepository) GetPendingMessages(
+// NextSequence allocates the next sequence number of the aggregate.
+func (r *OutboxRepository) NextSequence(ctx context.Context, aggregateID string) (int64, error) {
+	query := `
+		INSERT INTO outbox_aggregate_sequence (aggregate_id, last_sequence)
+		VALUES ($1, 1)
+		ON CONFLICT (aggregate_id)
+		DO UPDATE SET last_sequence = outbox_aggregate_sequence.last_sequence + 1
+		RETURNING last_sequence
+	`
+
+	var sequence int64
+	if err := r.client.Pool().QueryRow(ctx, query, aggregateID).Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to allocate aggregate sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// HasPending reports whether the aggregate has messages waiting in the outbox.
+// Messages that exhausted their retries are not waiting, they are bound for the dead letter table.
+func (r *OutboxRepository) HasPending(ctx context.Context, aggregateID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM outbox
+			WHERE aggregate_id = $1
+			  AND published_at IS NULL
+			  AND retry_count < max_retries
+		)
+	`
+
+	var pending bool
+	if err := r.client.Pool().QueryRow(ctx, query, aggregateID).Scan(&pending); err != nil {
+		return false, fmt.Errorf("failed to check pending outbox messages: %w", err)
+	}
+
+	return pending, nil
+}
+
+// ClaimPendingMessages leases a batch of messages that are ready for retry to the worker.
+// Rows locked by other transactions are skipped and expired leases are reclaimed,
+// so several workers can drain the outbox without picking the same messages.
+// A message is only claimed once all earlier messages of its aggregate are published or exhausted,
+// exhausted messages are dead-lettered and must not hold back the rest of the aggregate.
+func (r *OutboxRepository) ClaimPendingMessages(
 	ctx context.Context,
+	workerID string,
 	limit int,
+	lease time.Duration,
 ) ([]outbox.OutboxMessage, error) {
-	query, args, err := sq.Select(
-		"id",
-		"queue_name",
-		"exchange_name",
-		"routing_key",
-		"payload",
-		"content_type",
-		"retry_count",
-		"max_retries",
-		"last_error",
-		"created_at",
-		"updated_at",
-		"next_retry_at",
-	).
-		From("outbox").
-		Where(sq.LtOrEq{"next_retry_at": time.Now()}).
-		Where(sq.Expr("retry_count < max_retries")).
-		OrderBy("next_retry_at ASC").
-		Limit(uint64(limit)).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build select query: %w", err)
-	}
+	query := `
+		UPDATE outbox o
+		SET locked_by = $1,
+		    locked_until = now() + make_interval(secs => $2)
+		FROM (
+			SELECT id
+			FROM outbox
+			WHERE published_at IS NULL
+			  AND next_retry_at <= now()
+			  AND retry_count < max_retries
+			  AND (locked_until IS NULL OR locked_until < now())
+			  AND (aggregate_id = '' OR NOT EXISTS (
+			      SELECT 1
+			      FROM outbox prev
+			      WHERE prev.aggregate_id = outbox.aggregate_id
+			        AND prev.sequence < outbox.sequence
+			        AND prev.published_at IS NULL
+			        AND prev.retry_count < prev.max_retries
+			  ))
+			ORDER BY next_retry_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		) claimed
+		WHERE o.id = claimed.id
+		RETURNING o.id, o.queue_name, o.exchange_name, o.routing_key, o.message_id, o.message_type,
+		          o.aggregate_id, o.sequence, o.payload, o.content_type, o.headers, o.retry_count, o.max_retries, o.last_error,
+		          o.error_history, o.created_at, o.updated_at, o.next_retry_at
+	`
 
-	rows, err := r.client.Pool().Query(ctx, query, args...)
+	rows, err := r.client.Pool().Query(ctx, query, workerID, lease.Seconds(), limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query outbox messages: %w", err)
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
 	}
 	defer rows.Close()
 
@@ -109,11 +185,17 @@ func (r *OutboxRepository) GetPendingMessages(
 			&msg.QueueName,
 			&msg.ExchangeName,
 			&msg.RoutingKey,
+			&msg.MessageID,
+			&msg.MessageType,
+			&msg.AggregateID,
+			&msg.Sequence,
 			&msg.Payload,
 			&msg.ContentType,
+			&msg.Headers,
 			&msg.RetryCount,
 			&msg.MaxRetries,
 			&msg.LastError,
+			&msg.ErrorHistory,
 			&msg.CreatedAt,
 			&msg.UpdatedAt,
 			&msg.NextRetryAt,
@@ -128,51 +210,179 @@ func (r *OutboxRepository) GetPendingMessages(
 		return nil, fmt.Errorf("error iterating outbox messages: %w", err)
 	}
 
+	// RETURNING does not preserve the order of the claiming subquery
+	slices.SortFunc(messages, func(a, b outbox.OutboxMessage) int {
+		if c := a.NextRetryAt.Compare(b.NextRetryAt); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.ID, b.ID)
+	})
+
 	return messages, nil
 }
 
-// Delete removes a message from the outbox after successful delivery.
-func (r *OutboxRepository) Delete(ctx context.Context, id int64) error {
-	query, args, err := sq.Delete("outbox").
-		Where(sq.Eq{"id": id}).
+// MarkPublished marks successfully delivered messages leased by the worker as published
+// and releases their lease. Published messages are kept until archived by the retention worker.
+func (r *OutboxRepository) MarkPublished(ctx context.Context, workerID string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sq.Update("outbox").
+		Set("published_at", sq.Expr("now()")).
+		Set("updated_at", sq.Expr("now()")).
+		Set("locked_by", nil).
+		Set("locked_until", nil).
+		Where(sq.Eq{"id": ids}).
+		Where(sq.Eq{"locked_by": workerID}).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("failed to build delete query: %w", err)
+		return fmt.Errorf("failed to build update query: %w", err)
 	}
 
 	_, err = r.client.Pool().Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to delete outbox message: %w", err)
+		return fmt.Errorf("failed to mark outbox messages as published: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateRetry updates retry count and error information.
-func (r *OutboxRepository) UpdateRetry(
+// UpdateRetries updates retry count and error information of messages leased by the worker
+// and releases their lease. Each error is appended to the message error history.
+func (r *OutboxRepository) UpdateRetries(
 	ctx context.Context,
-	id int64,
-	retryCount int,
-	lastError string,
-	nextRetryAt time.Time,
+	workerID string,
+	updates []outbox.RetryUpdate,
 ) error {
-	query, args, err := sq.Update("outbox").
-		Set("retry_count", retryCount).
-		Set("last_error", lastError).
-		Set("next_retry_at", nextRetryAt).
-		Set("updated_at", time.Now()).
-		Where(sq.Eq{"id": id}).
-		PlaceholderFormat(sq.Dollar).
-		ToSql()
-	if err != nil {
-		return fmt.Errorf("failed to build update query: %w", err)
+	if len(updates) == 0 {
+		return nil
 	}
 
-	_, err = r.client.Pool().Exec(ctx, query, args...)
+	ids := make([]int64, len(updates))
+	retryCounts := make([]int32, len(updates))
+	lastErrors := make([]string, len(updates))
+	nextRetries := make([]time.Time, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ID
+		retryCounts[i] = int32(u.RetryCount)
+		lastErrors[i] = u.LastError
+		nextRetries[i] = u.NextRetryAt
+	}
+
+	query := `
+		UPDATE outbox o
+		SET retry_count = u.retry_count,
+		    last_error = u.last_error,
+		    error_history = o.error_history || jsonb_build_array(jsonb_build_object(
+		        'attempt', u.retry_count, 'error', u.last_error, 'occurred_at', now()
+		    )),
+		    next_retry_at = u.next_retry_at,
+		    updated_at = now(),
+		    locked_by = NULL,
+		    locked_until = NULL
+		FROM unnest($1::bigint[], $2::integer[], $3::text[], $4::timestamptz[])
+		     AS u(id, retry_count, last_error, next_retry_at)
+		WHERE o.id = u.id
+		  AND o.locked_by = $5
+	`
+
+	_, err := r.client.Pool().Exec(ctx, query, ids, retryCounts, lastErrors, nextRetries, workerID)
 	if err != nil {
-		return fmt.Errorf("failed to update outbox message: %w", err)
+		return fmt.Errorf("failed to update outbox messages: %w", err)
 	}
 
 	return nil
 }
+
+// MoveExhaustedToDeadLetter moves messages that exhausted their retries to the dead letter table.
+// Messages still leased by a worker are skipped until the lease is released or expires.
+// The move is a single statement, so a message is never lost between the tables, and
+// messages left behind by a failed move are picked up by the next call.
+func (r *OutboxRepository) MoveExhaustedToDeadLetter(ctx context.Context, limit int) (map[string]int, error) {
+	query := `
+		WITH moved AS (
+			DELETE FROM outbox
+			WHERE id IN (
+				SELECT id
+				FROM outbox
+				WHERE published_at IS NULL
+				  AND retry_count >= max_retries
+				  AND (locked_until IS NULL OR locked_until < now())
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+			          sequence, payload, content_type, headers, retry_count, max_retries, last_error, error_history, created_at
+		), inserted AS (
+			INSERT INTO outbox_dead_letter (
+				outbox_id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+				sequence, payload, content_type, headers, retry_count, max_retries, last_error, error_history, created_at,
+				dead_lettered_at
+			)
+			SELECT id, queue_name, exchange_name, routing_key, message_id, message_type, aggregate_id,
+			       sequence, payload, content_type, headers, retry_count, max_retries, coalesce(last_error, ''), error_history,
+			       created_at, now()
+			FROM moved
+			RETURNING queue_name
+		)
+		SELECT queue_name, count(*)
+		FROM inserted
+		GROUP BY queue_name
+	`
+
+	rows, err := r.client.Pool().Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move outbox messages to dead letter: %w", err)
+	}
+	defer rows.Close()
+
+	moved := make(map[string]int)
+	for rows.Next() {
+		var (
+			queue string
+			count int
+		)
+		if err := rows.Scan(&queue, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan dead-lettered queue: %w", err)
+		}
+		moved[queue] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead-lettered queues: %w", err)
+	}
+
+	return moved, nil
+}
+
+// marshalErrorHistory encodes error history for the jsonb column.
+func marshalErrorHistory(history []outbox.ErrorRecord) ([]byte, error) {
+	if history == nil {
+		history = []outbox.ErrorRecord{}
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal error history: %w", err)
+	}
+
+	return data, nil
+}
+
+// marshalHeaders encodes message headers for the jsonb column.
+func marshalHeaders(headers map[string]string) ([]byte, error) {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal headers: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package uow
+
+import (
+	"context"
+	"sync"
+
+	iorderitem "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderitemrepo"
+	iorder "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderrepo"
+	ordermemory "github.com/corray333/backend-labs/order/internal/dal/repositories/order/memory"
+	orderitemmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/orderitem/memory"
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+)
+
+// memoryUnitOfWork is a unit of work over in-memory repositories.
+// Inserts are applied immediately and removed again on rollback.
+type memoryUnitOfWork struct {
+	mu       sync.Mutex
+	orders   *ordermemory.OrderRepository
+	items    *orderitemmemory.OrderItemRepository
+	orderIDs []int64
+	itemIDs  []int64
+}
+
+// NewMemoryUnitOfWork creates a unit of work over in-memory repositories.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func NewMemoryUnitOfWork(
+	orders *ordermemory.OrderRepository,
+	items *orderitemmemory.OrderItemRepository,
+) *memoryUnitOfWork {
+	return &memoryUnitOfWork{
+		orders: orders,
+		items:  items,
+	}
+}
+
+// OrderRepository returns the order repository.
+func (u *memoryUnitOfWork) OrderRepository() iorder.IOrderRepository {
+	return memoryOrders{u}
+}
+
+// OrderItemRepository returns the order item repository.
+func (u *memoryUnitOfWork) OrderItemRepository() iorderitem.IOrderItemRepository {
+	return memoryOrderItems{u}
+}
+
+// Begin starts a new transaction.
+func (u *memoryUnitOfWork) Begin(_ context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.orderIDs = nil
+	u.itemIDs = nil
+
+	return nil
+}
+
+// Commit keeps the inserted rows.
+func (u *memoryUnitOfWork) Commit(_ context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.orderIDs = nil
+	u.itemIDs = nil
+
+	return nil
+}
+
+// Rollback removes rows inserted since Begin.
+func (u *memoryUnitOfWork) Rollback(_ context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.items.Delete(u.itemIDs)
+	u.orders.Delete(u.orderIDs)
+	u.orderIDs = nil
+	u.itemIDs = nil
+
+	return nil
+}
+
+// memoryOrders records inserted order IDs for rollback.
+type memoryOrders struct {
+	u *memoryUnitOfWork
+}
+
+func (r memoryOrders) BulkInsert(ctx context.Context, orders []order.Order) ([]order.Order, error) {
+	inserted, err := r.u.orders.BulkInsert(ctx, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	r.u.mu.Lock()
+	for _, o := range inserted {
+		r.u.orderIDs = append(r.u.orderIDs, o.ID)
+	}
+	r.u.mu.Unlock()
+
+	return inserted, nil
+}
+
+func (r memoryOrders) Query(ctx context.Context, filter *order.QueryOrdersModel) ([]order.Order, error) {
+	return r.u.orders.Query(ctx, filter)
+}
+
+// memoryOrderItems records inserted order item IDs for rollback.
+type memoryOrderItems struct {
+	u *memoryUnitOfWork
+}
+
+func (r memoryOrderItems) BulkInsert(
+	ctx context.Context,
+	orderItems []orderitem.OrderItem,
+) ([]orderitem.OrderItem, error) {
+	inserted, err := r.u.items.BulkInsert(ctx, orderItems)
+	if err != nil {
+		return nil, err
+	}
+
+	r.u.mu.Lock()
+	for _, item := range inserted {
+		r.u.itemIDs = append(r.u.itemIDs, item.ID)
+	}
+	r.u.mu.Unlock()
+
+	return inserted, nil
+}
+
+func (r memoryOrderItems) Query(
+	ctx context.Context,
+	filter *orderitem.QueryOrderItemsModel,
+) ([]orderitem.OrderItem, error) {
+	return r.u.items.Query(ctx, filter)
+}
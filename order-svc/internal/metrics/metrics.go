@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "order_svc"
+
+// OutboxDeadLetters counts outbox messages moved to the dead letter table.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var OutboxDeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "outbox",
+	Name:      "dead_letters_total",
+	Help:      "Number of outbox messages moved to the dead letter table after exhausting retries.",
+}, []string{"queue"})
+
+// CircuitBreakerState reports the state of circuit breakers: 0 closed, 1 half-open, 2 open.
+//
+//nolint:gochecknoglobals // metrics are registered once in the default registry
+var CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "circuit_breaker",
+	Name:      "state",
+	Help:      "State of the circuit breaker: 0 closed, 1 half-open, 2 open.",
+}, []string{"name"})
@@ -5,6 +5,7 @@ import (
 
 	"github.com/corray333/backend-labs/order/internal/jaeger"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
@@ -25,6 +26,11 @@ func MustInitOtel() *OtelController {
 	)
 
 	otel.SetTracerProvider(tp)
+	// W3C trace context is propagated through HTTP, gRPC and message headers
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
 
 	return &OtelController{
 		traceProvider: tp,
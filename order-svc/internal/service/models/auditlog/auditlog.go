@@ -1,14 +1,42 @@
 package auditlog
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // AuditLogOrder represents an audit log entry for order operations.
 type AuditLogOrder struct {
-	ID          int64     `json:"id"`
-	OrderID     int64     `json:"order_id"`
-	OrderItemID int64     `json:"order_item_id"`
-	CustomerID  int64     `json:"customer_id"`
-	OrderStatus string    `json:"order_status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-}
\ No newline at end of file
+	ID            int64                  `json:"id"`
+	OrderID       int64                  `json:"order_id"`
+	OrderItemID   int64                  `json:"order_item_id"`
+	CustomerID    int64                  `json:"customer_id"`
+	OrderStatus   string                 `json:"order_status"`
+	EventType     string                 `json:"event_type"`
+	ActorID       string                 `json:"actor_id"`
+	ActorType     string                 `json:"actor_type"`
+	SourceService string                 `json:"source_service"`
+	RequestID     string                 `json:"request_id"`
+	Changes       map[string]FieldChange `json:"changes"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	// MessageID is the broker message the audit log was built from, audit logs of a message are saved once
+	MessageID string `json:"message_id"`
+}
+
+// FieldChange is the value of a field before and after a change, nil for a field that was not set.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// SaveAuditLogsResult is the outcome of saving audit logs of several messages.
+type SaveAuditLogsResult struct {
+	// Saved are the newly saved audit logs
+	Saved []AuditLogOrder
+	// DuplicateMessageIDs are messages whose audit logs were saved before, their audit logs are skipped
+	DuplicateMessageIDs []string
+}
+
+// ErrMissingMessageID is returned for an audit log that cannot be deduplicated by its message.
+var ErrMissingMessageID = errors.New("audit log has no message id")
\ No newline at end of file
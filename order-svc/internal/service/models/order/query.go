@@ -1,9 +1,15 @@
 package order
 
+import "time"
+
 // QueryOrdersModel represents filter parameters for querying orders.
 type QueryOrdersModel struct {
-	Ids         []int64 `json:"ids,omitempty"`
-	CustomerIds []int64 `json:"customerIds,omitempty"`
-	Limit       int     `json:"limit,omitempty"`
-	Offset      int     `json:"offset,omitempty"`
+	Ids         []int64   `json:"ids,omitempty"`
+	CustomerIds []int64   `json:"customerIds,omitempty"`
+	FromID      int64     `json:"fromId,omitempty"`
+	ToID        int64     `json:"toId,omitempty"`
+	CreatedFrom time.Time `json:"createdFrom,omitzero"`
+	CreatedTo   time.Time `json:"createdTo,omitzero"`
+	Limit       int       `json:"limit,omitempty"`
+	Offset      int       `json:"offset,omitempty"`
 }
@@ -0,0 +1,106 @@
+package orderevent
+
+import (
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SourceService is the service name events emitted by this service carry.
+const SourceService = "order-svc"
+
+// OrderCreated builds the OrderCreated event of the order
+// caused by the request with the given metadata.
+func OrderCreated(
+	eventID string,
+	occurredAt time.Time,
+	o order.Order,
+	md requestmeta.Metadata,
+) (*pb.OrderCreated, error) {
+	changes, err := orderCreatedChanges(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.OrderCreated{
+		EventId:    eventID,
+		OccurredAt: timestamppb.New(occurredAt),
+		Order:      OrderSnapshot(o),
+		Context:    Context(md),
+		Changes:    changes,
+	}, nil
+}
+
+// Context builds the context of an event emitted by this service.
+func Context(md requestmeta.Metadata) *pb.EventContext {
+	return &pb.EventContext{
+		ActorId:       md.ActorID,
+		ActorType:     md.ActorType,
+		SourceService: SourceService,
+		RequestId:     md.RequestID,
+	}
+}
+
+// OrderSnapshot converts the order to its state carried by events.
+func OrderSnapshot(o order.Order) *pb.OrderSnapshot {
+	items := make([]*pb.OrderItemSnapshot, len(o.OrderItems))
+	for i, item := range o.OrderItems {
+		items[i] = orderItemSnapshot(item)
+	}
+
+	return &pb.OrderSnapshot{
+		Id:                 o.ID,
+		CustomerId:         o.CustomerID,
+		DeliveryAddress:    o.DeliveryAddress,
+		TotalPriceCents:    o.TotalPriceCents,
+		TotalPriceCurrency: o.TotalPriceCurrency.String(),
+		CreatedAt:          timestamppb.New(o.CreatedAt),
+		UpdatedAt:          timestamppb.New(o.UpdatedAt),
+		OrderItems:         items,
+	}
+}
+
+// orderItemSnapshot converts the order item to its state carried by events.
+func orderItemSnapshot(item orderitem.OrderItem) *pb.OrderItemSnapshot {
+	return &pb.OrderItemSnapshot{
+		Id:            item.ID,
+		OrderId:       item.OrderID,
+		ProductId:     item.ProductID,
+		Quantity:      int32(item.Quantity),
+		ProductTitle:  item.ProductTitle,
+		ProductUrl:    item.ProductUrl,
+		PriceCents:    item.PriceCents,
+		PriceCurrency: item.PriceCurrency.String(),
+		CreatedAt:     timestamppb.New(item.CreatedAt),
+		UpdatedAt:     timestamppb.New(item.UpdatedAt),
+	}
+}
+
+// orderCreatedChanges lists the order fields set by the creation of an order.
+func orderCreatedChanges(o order.Order) ([]*pb.FieldChange, error) {
+	fields := []struct {
+		name  string
+		value any
+	}{
+		{"customer_id", o.CustomerID},
+		{"delivery_address", o.DeliveryAddress},
+		{"total_price_cents", o.TotalPriceCents},
+		{"total_price_currency", o.TotalPriceCurrency.String()},
+	}
+
+	changes := make([]*pb.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		change, err := events.Change(field.name, nil, field.value)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
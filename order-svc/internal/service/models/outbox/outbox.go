@@ -1,21 +1,77 @@
 package outbox
 
 import (
+	"errors"
 	"time"
 )
 
-// OutboxMessage represents a message that failed to be published to RabbitMQ.
+// NotifyChannel is the Postgres channel notified on every insert into the outbox.
+const NotifyChannel = "outbox_inserted"
+
+// OutboxMessage represents a message that failed to be published to the broker.
 type OutboxMessage struct {
 	ID           int64
 	QueueName    string
 	ExchangeName string
 	RoutingKey   string
+	MessageID    string
+	MessageType  string
+	AggregateID  string
+	Sequence     int64
 	Payload      []byte
 	ContentType  string
+	Headers      map[string]string
 	RetryCount   int
 	MaxRetries   int
 	LastError    string
+	ErrorHistory []ErrorRecord
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	NextRetryAt  time.Time
+	PublishedAt  *time.Time
+}
+
+// RetryUpdate represents the outcome of a failed publish attempt.
+type RetryUpdate struct {
+	ID          int64
+	RetryCount  int
+	LastError   string
+	NextRetryAt time.Time
+}
+
+// ErrorRecord represents a single failed publish attempt.
+type ErrorRecord struct {
+	Attempt    int       `json:"attempt"`
+	Error      string    `json:"error"`
+	OccurredAt time.Time `json:"occurred_at"`
 }
+
+// DeadLetter represents an outbox message that exhausted its retries.
+type DeadLetter struct {
+	ID             int64
+	OutboxID       int64
+	QueueName      string
+	ExchangeName   string
+	RoutingKey     string
+	MessageID      string
+	MessageType    string
+	AggregateID    string
+	Sequence       int64
+	Payload        []byte
+	ContentType    string
+	Headers        map[string]string
+	RetryCount     int
+	MaxRetries     int
+	LastError      string
+	ErrorHistory   []ErrorRecord
+	CreatedAt      time.Time
+	DeadLetteredAt time.Time
+}
+
+// PurgeDeadLettersModel represents filter parameters for purging dead letters.
+type PurgeDeadLettersModel struct {
+	Ids                []int64
+	DeadLetteredBefore time.Time
+}
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
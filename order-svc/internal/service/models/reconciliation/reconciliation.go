@@ -0,0 +1,34 @@
+package reconciliation
+
+import (
+	"errors"
+	"time"
+)
+
+// ReconcileModel represents parameters of an order and audit log reconciliation.
+type ReconcileModel struct {
+	From      time.Time
+	To        time.Time
+	Republish bool
+}
+
+// OrderItemRef identifies an order item on both sides of the reconciliation.
+type OrderItemRef struct {
+	OrderID     int64 `json:"order_id"`
+	OrderItemID int64 `json:"order_item_id"`
+}
+
+// Report represents the outcome of a reconciliation.
+// Missing items exist in the order database without audit rows,
+// extra items have audit rows but no matching order item.
+type Report struct {
+	From              time.Time      `json:"from"`
+	To                time.Time      `json:"to"`
+	OrdersChecked     int64          `json:"orders_checked"`
+	ItemsChecked      int64          `json:"items_checked"`
+	Missing           []OrderItemRef `json:"missing"`
+	Extra             []OrderItemRef `json:"extra"`
+	RepublishedOrders int64          `json:"republished_orders"`
+}
+
+var ErrInvalidWindow = errors.New("reconciliation window must have from before to")
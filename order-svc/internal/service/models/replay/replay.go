@@ -0,0 +1,30 @@
+package replay
+
+import (
+	"errors"
+	"time"
+)
+
+// ReplayOrdersModel represents parameters of an order events replay.
+// At least one ID, ID bound or creation time bound must be set.
+type ReplayOrdersModel struct {
+	Ids           []int64
+	FromID        int64
+	ToID          int64
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	RoutingKey    string
+	RatePerSecond float64
+	BatchSize     int
+	DryRun        bool
+}
+
+// ReplayResult represents the outcome of an order events replay.
+type ReplayResult struct {
+	OrdersScanned  int64
+	EventsEnqueued int64
+	LastOrderID    int64
+	DryRun         bool
+}
+
+var ErrEmptyReplayRange = errors.New("replay range must contain ids or created time bounds")
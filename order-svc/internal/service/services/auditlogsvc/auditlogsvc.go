@@ -0,0 +1,67 @@
+package auditlogsvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/iauditlogrepo"
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
+	"go.opentelemetry.io/otel"
+)
+
+// AuditLogService is a service for audit logs sent by the audit consumer.
+type AuditLogService struct {
+	auditLogRepo iauditlogrepo.IAuditLogRepository
+}
+
+// option is a function that configures the AuditLogService.
+type option func(*AuditLogService)
+
+// MustNewAuditLogService creates a new AuditLogService.
+func MustNewAuditLogService(opts ...option) *AuditLogService {
+	s := &AuditLogService{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithAuditLogRepository sets the audit log repository for the AuditLogService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithAuditLogRepository(auditLogRepo iauditlogrepo.IAuditLogRepository) option {
+	return func(s *AuditLogService) {
+		s.auditLogRepo = auditLogRepo
+	}
+}
+
+// SaveAuditLogs saves audit logs of broker messages. Audit logs of a message saved before
+// are skipped, so a consumer may send the audit logs of a redelivered message again.
+// Returns auditlog.ErrMissingMessageID for an audit log without a message ID.
+func (s *AuditLogService) SaveAuditLogs(
+	ctx context.Context,
+	auditLogs []auditlog.AuditLogOrder,
+) (auditlog.SaveAuditLogsResult, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.SaveAuditLogs")
+	defer span.End()
+
+	for _, l := range auditLogs {
+		if l.MessageID == "" {
+			return auditlog.SaveAuditLogsResult{}, auditlog.ErrMissingMessageID
+		}
+	}
+
+	result, err := s.auditLogRepo.SaveAuditLogs(ctx, auditLogs)
+	if err != nil {
+		return auditlog.SaveAuditLogsResult{}, err
+	}
+
+	if len(result.DuplicateMessageIDs) > 0 {
+		slog.Info("Skipped audit logs of already processed messages",
+			"message_ids", result.DuplicateMessageIDs,
+		)
+	}
+
+	return result, nil
+}
@@ -17,15 +17,12 @@ import (
 
 // OrderService is a service for managing orders.
 type OrderService struct {
-	pgClient *postgres.Client
-	auditor  iauditrepo.IAuditorRepository
+	newUOW  func() UnitOfWork
+	auditor iauditrepo.IAuditorRepository
 }
 
-func (s *OrderService) newUOW() unitOfWork {
-	return uow.NewUnitOfWork(s.pgClient)
-}
-
-type unitOfWork interface {
+// UnitOfWork groups order repositories under a single transaction.
+type UnitOfWork interface {
 	Begin(ctx context.Context) error
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
@@ -56,12 +53,23 @@ func WithAuditor(auditor iauditrepo.IAuditorRepository) option {
 	}
 }
 
-// WithPostgresClient sets the Postgres client for the OrderService.
+// WithPostgresClient makes the OrderService use Postgres repositories.
 //
 //goland:noinspection GoExportedFuncWithUnexportedType
 func WithPostgresClient(pgClient *postgres.Client) option {
 	return func(s *OrderService) {
-		s.pgClient = pgClient
+		s.newUOW = func() UnitOfWork {
+			return uow.NewUnitOfWork(pgClient)
+		}
+	}
+}
+
+// WithUnitOfWork sets the unit of work factory for the OrderService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithUnitOfWork(newUOW func() UnitOfWork) option {
+	return func(s *OrderService) {
+		s.newUOW = newUOW
 	}
 }
 
@@ -107,7 +115,7 @@ func (s *OrderService) BatchInsert(
 		orders[i].OrderItems = orderItems[i*len(orders[i].OrderItems) : (i+1)*len(orders[i].OrderItems)]
 	}
 
-	// Try to send audit logs to RabbitMQ synchronously before committing
+	// Try to send audit logs to the broker synchronously before committing
 	err = s.auditor.LogBatchInsert(ctx, orders)
 	if err != nil {
 		// Rollback transaction if audit logging fails
@@ -0,0 +1,89 @@
+package outboxsvc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/ideadletterrepo"
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"go.opentelemetry.io/otel"
+)
+
+const defaultDeadLettersLimit = 100
+
+var ErrEmptyPurgeFilter = errors.New("purge filter must contain ids or dead lettered before time")
+
+// OutboxService is a service for administrating the outbox.
+type OutboxService struct {
+	deadLetterRepo ideadletterrepo.IDeadLetterRepository
+}
+
+// option is a function that configures the OutboxService.
+type option func(*OutboxService)
+
+// MustNewOutboxService creates a new OutboxService.
+func MustNewOutboxService(opts ...option) *OutboxService {
+	s := &OutboxService{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithDeadLetterRepository sets the dead letter repository for the OutboxService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithDeadLetterRepository(deadLetterRepo ideadletterrepo.IDeadLetterRepository) option {
+	return func(s *OutboxService) {
+		s.deadLetterRepo = deadLetterRepo
+	}
+}
+
+// ListDeadLetters retrieves dead letters, most recent first.
+func (s *OutboxService) ListDeadLetters(
+	ctx context.Context,
+	limit int,
+	offset int,
+) ([]outbox.DeadLetter, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ListDeadLetters")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = defaultDeadLettersLimit
+	}
+
+	return s.deadLetterRepo.List(ctx, limit, offset)
+}
+
+// GetDeadLetter retrieves a single dead letter with its error history.
+func (s *OutboxService) GetDeadLetter(ctx context.Context, id int64) (outbox.DeadLetter, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.GetDeadLetter")
+	defer span.End()
+
+	return s.deadLetterRepo.Get(ctx, id)
+}
+
+// RequeueDeadLetter moves a dead letter back to the outbox for another round of retries.
+func (s *OutboxService) RequeueDeadLetter(ctx context.Context, id int64) (int64, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.RequeueDeadLetter")
+	defer span.End()
+
+	return s.deadLetterRepo.Requeue(ctx, id)
+}
+
+// PurgeDeadLetters deletes dead letters matching the filter.
+// An empty filter is rejected to avoid purging everything by accident.
+func (s *OutboxService) PurgeDeadLetters(
+	ctx context.Context,
+	filter outbox.PurgeDeadLettersModel,
+) (int64, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.PurgeDeadLetters")
+	defer span.End()
+
+	if len(filter.Ids) == 0 && filter.DeadLetteredBefore.IsZero() {
+		return 0, ErrEmptyPurgeFilter
+	}
+
+	return s.deadLetterRepo.Purge(ctx, filter)
+}
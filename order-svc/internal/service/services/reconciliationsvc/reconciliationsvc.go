@@ -0,0 +1,261 @@
+package reconciliationsvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/iauditreaderrepo"
+	iorderitem "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderitemrepo"
+	iorder "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderrepo"
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/corray333/backend-labs/order/internal/dal/uow"
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+	"github.com/corray333/backend-labs/order/internal/service/models/replay"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+)
+
+// ReconciliationService compares orders with audit logs of the audit database.
+type ReconciliationService struct {
+	pgClient    *postgres.Client
+	auditReader iauditreaderrepo.IAuditReaderRepository
+	replayer    replayer
+	batchSize   int
+}
+
+type unitOfWork interface {
+	OrderRepository() iorder.IOrderRepository
+	OrderItemRepository() iorderitem.IOrderItemRepository
+}
+
+// replayer re-emits order events for missing audit logs.
+type replayer interface {
+	ReplayOrders(ctx context.Context, model replay.ReplayOrdersModel) (replay.ReplayResult, error)
+}
+
+// option is a function that configures the ReconciliationService.
+type option func(*ReconciliationService)
+
+// MustNewReconciliationService creates a new ReconciliationService.
+func MustNewReconciliationService(opts ...option) *ReconciliationService {
+	batchSize := viper.GetInt("reconciliation.batch_size")
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+
+	s := &ReconciliationService{
+		batchSize: batchSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithPostgresClient sets the Postgres client for the ReconciliationService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithPostgresClient(pgClient *postgres.Client) option {
+	return func(s *ReconciliationService) {
+		s.pgClient = pgClient
+	}
+}
+
+// WithAuditReader sets the audit reader repository for the ReconciliationService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithAuditReader(auditReader iauditreaderrepo.IAuditReaderRepository) option {
+	return func(s *ReconciliationService) {
+		s.auditReader = auditReader
+	}
+}
+
+// WithReplayer sets the service used to republish missing events.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithReplayer(r replayer) option {
+	return func(s *ReconciliationService) {
+		s.replayer = r
+	}
+}
+
+func (s *ReconciliationService) newUOW() unitOfWork {
+	return uow.NewUnitOfWork(s.pgClient)
+}
+
+// Reconcile compares order items of orders created in the window with audit logs.
+// Audit logs are matched by order ID, since audit rows are timestamped when consumed.
+// Missing events are republished through the replay routing key when requested.
+func (s *ReconciliationService) Reconcile(
+	ctx context.Context,
+	model reconciliation.ReconcileModel,
+) (reconciliation.Report, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.Reconcile")
+	defer span.End()
+
+	if model.From.IsZero() || model.To.IsZero() || !model.From.Before(model.To) {
+		return reconciliation.Report{}, reconciliation.ErrInvalidWindow
+	}
+
+	report := reconciliation.Report{
+		From:    model.From,
+		To:      model.To,
+		Missing: []reconciliation.OrderItemRef{},
+		Extra:   []reconciliation.OrderItemRef{},
+	}
+	work := s.newUOW()
+
+	var fromID int64
+	for {
+		orders, err := work.OrderRepository().Query(ctx, &order.QueryOrdersModel{
+			FromID:      fromID,
+			CreatedFrom: model.From,
+			CreatedTo:   model.To,
+			Limit:       s.batchSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to query orders: %w", err)
+		}
+
+		if len(orders) == 0 {
+			break
+		}
+
+		if err := s.reconcileBatch(ctx, work, orders, &report); err != nil {
+			return report, err
+		}
+
+		if len(orders) < s.batchSize {
+			break
+		}
+		fromID = orders[len(orders)-1].ID + 1
+	}
+
+	if model.Republish && len(report.Missing) > 0 {
+		// Republished events are caused by the reconciliation, not by the request that created the orders
+		ctx := requestmeta.NewContext(ctx, requestmeta.Metadata{
+			ActorID:   "reconciliation",
+			ActorType: requestmeta.ActorTypeSystem,
+		})
+		result, err := s.replayer.ReplayOrders(ctx, replay.ReplayOrdersModel{
+			Ids: missingOrderIDs(report.Missing),
+		})
+		report.RepublishedOrders = result.EventsEnqueued
+		if err != nil {
+			return report, fmt.Errorf("failed to republish missing events: %w", err)
+		}
+	}
+
+	slog.Info("Reconciliation finished",
+		"from", report.From,
+		"to", report.To,
+		"orders_checked", report.OrdersChecked,
+		"items_checked", report.ItemsChecked,
+		"missing", len(report.Missing),
+		"extra", len(report.Extra),
+		"republished_orders", report.RepublishedOrders,
+	)
+
+	return report, nil
+}
+
+// reconcileBatch compares a batch of orders, sorted by ID, with their audit logs.
+// Audit logs in the batch ID range without a matching order item are extra.
+func (s *ReconciliationService) reconcileBatch(
+	ctx context.Context,
+	work unitOfWork,
+	orders []order.Order,
+	report *reconciliation.Report,
+) error {
+	orderIDs := make([]int64, 0, len(orders))
+	for _, o := range orders {
+		orderIDs = append(orderIDs, o.ID)
+	}
+
+	items, err := work.OrderItemRepository().Query(ctx, &orderitem.QueryOrderItemsModel{OrderIds: orderIDs})
+	if err != nil {
+		return fmt.Errorf("failed to query order items: %w", err)
+	}
+
+	audited, err := s.auditReader.OrderItemRefs(ctx, orderIDs[0], orderIDs[len(orderIDs)-1])
+	if err != nil {
+		return err
+	}
+
+	auditedSet := make(map[reconciliation.OrderItemRef]struct{}, len(audited))
+	for _, ref := range audited {
+		auditedSet[ref] = struct{}{}
+	}
+
+	expected := make(map[reconciliation.OrderItemRef]struct{}, len(items))
+	for _, item := range items {
+		ref := reconciliation.OrderItemRef{OrderID: item.OrderID, OrderItemID: item.ID}
+		expected[ref] = struct{}{}
+
+		if _, ok := auditedSet[ref]; !ok {
+			report.Missing = append(report.Missing, ref)
+		}
+	}
+
+	inBatch := make(map[int64]struct{}, len(orderIDs))
+	for _, id := range orderIDs {
+		inBatch[id] = struct{}{}
+	}
+
+	var (
+		unexpected []reconciliation.OrderItemRef
+		outsideIDs []int64
+	)
+	for _, ref := range audited {
+		if _, ok := expected[ref]; ok {
+			continue
+		}
+		unexpected = append(unexpected, ref)
+		if _, ok := inBatch[ref.OrderID]; !ok {
+			outsideIDs = append(outsideIDs, ref.OrderID)
+		}
+	}
+
+	// Orders in the ID range but created outside the window are not extra
+	outside := make(map[int64]struct{})
+	if len(outsideIDs) > 0 {
+		existing, err := work.OrderRepository().Query(ctx, &order.QueryOrdersModel{Ids: outsideIDs})
+		if err != nil {
+			return fmt.Errorf("failed to query orders: %w", err)
+		}
+		for _, o := range existing {
+			outside[o.ID] = struct{}{}
+		}
+	}
+
+	for _, ref := range unexpected {
+		if _, ok := outside[ref.OrderID]; !ok {
+			report.Extra = append(report.Extra, ref)
+		}
+	}
+
+	report.OrdersChecked += int64(len(orders))
+	report.ItemsChecked += int64(len(items))
+
+	return nil
+}
+
+// missingOrderIDs returns distinct order IDs of the missing items.
+func missingOrderIDs(missing []reconciliation.OrderItemRef) []int64 {
+	seen := make(map[int64]struct{}, len(missing))
+	ids := make([]int64, 0, len(missing))
+	for _, ref := range missing {
+		if _, ok := seen[ref.OrderID]; ok {
+			continue
+		}
+		seen[ref.OrderID] = struct{}{}
+		ids = append(ids, ref.OrderID)
+	}
+
+	return ids
+}
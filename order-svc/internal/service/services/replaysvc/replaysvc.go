@@ -0,0 +1,275 @@
+package replaysvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	iorderitem "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderitemrepo"
+	iorder "github.com/corray333/backend-labs/order/internal/dal/interfaces/iorderrepo"
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/ioutboxrepo"
+	"github.com/corray333/backend-labs/order/internal/dal/postgres"
+	"github.com/corray333/backend-labs/order/internal/dal/uow"
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderevent"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/internal/service/models/replay"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/time/rate"
+)
+
+// ReplayService is a service for re-emitting historical order events.
+type ReplayService struct {
+	pgClient      *postgres.Client
+	outboxRepo    ioutboxrepo.IOutboxRepository
+	routingKey    string
+	ratePerSecond float64
+	batchSize     int
+	maxRetries    int
+}
+
+type unitOfWork interface {
+	OrderRepository() iorder.IOrderRepository
+	OrderItemRepository() iorderitem.IOrderItemRepository
+}
+
+// option is a function that configures the ReplayService.
+type option func(*ReplayService)
+
+// MustNewReplayService creates a new ReplayService.
+func MustNewReplayService(opts ...option) *ReplayService {
+	routingKey := viper.GetString("rabbitmq.replay.routing_key")
+	if routingKey == "" {
+		routingKey = "oms.order.replay"
+	}
+
+	ratePerSecond := viper.GetFloat64("rabbitmq.replay.rate_per_second")
+	if ratePerSecond == 0 {
+		ratePerSecond = 100
+	}
+
+	batchSize := viper.GetInt("rabbitmq.replay.batch_size")
+	if batchSize == 0 {
+		batchSize = 500
+	}
+
+	maxRetries := viper.GetInt("rabbitmq.outbox.max_retries")
+	if maxRetries == 0 {
+		maxRetries = 5
+	}
+
+	s := &ReplayService{
+		routingKey:    routingKey,
+		ratePerSecond: ratePerSecond,
+		batchSize:     batchSize,
+		maxRetries:    maxRetries,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithPostgresClient sets the Postgres client for the ReplayService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithPostgresClient(pgClient *postgres.Client) option {
+	return func(s *ReplayService) {
+		s.pgClient = pgClient
+	}
+}
+
+// WithOutboxRepository sets the outbox repository for the ReplayService.
+//
+//goland:noinspection GoExportedFuncWithUnexportedType
+func WithOutboxRepository(outboxRepo ioutboxrepo.IOutboxRepository) option {
+	return func(s *ReplayService) {
+		s.outboxRepo = outboxRepo
+	}
+}
+
+// RoutingKey returns the default routing key of replayed events.
+func (s *ReplayService) RoutingKey() string {
+	return s.routingKey
+}
+
+func (s *ReplayService) newUOW() unitOfWork {
+	return uow.NewUnitOfWork(s.pgClient)
+}
+
+// ReplayOrders scans orders in the requested range and writes an order.created event
+// for every order into the outbox under the replay routing key.
+// Replayed events are not part of per-aggregate ordering of live events.
+// In dry-run mode orders are only counted.
+func (s *ReplayService) ReplayOrders(
+	ctx context.Context,
+	model replay.ReplayOrdersModel,
+) (replay.ReplayResult, error) {
+	ctx, span := otel.Tracer("service").Start(ctx, "Service.ReplayOrders")
+	defer span.End()
+
+	if !hasRange(model) {
+		return replay.ReplayResult{}, replay.ErrEmptyReplayRange
+	}
+
+	if model.RoutingKey == "" {
+		model.RoutingKey = s.routingKey
+	}
+	if model.RatePerSecond <= 0 {
+		model.RatePerSecond = s.ratePerSecond
+	}
+	if model.BatchSize <= 0 {
+		model.BatchSize = s.batchSize
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(model.RatePerSecond), 1)
+	work := s.newUOW()
+	result := replay.ReplayResult{DryRun: model.DryRun}
+
+	slog.Info("Starting order events replay",
+		"ids", len(model.Ids),
+		"from_id", model.FromID,
+		"to_id", model.ToID,
+		"created_from", model.CreatedFrom,
+		"created_to", model.CreatedTo,
+		"routing_key", model.RoutingKey,
+		"rate_per_second", model.RatePerSecond,
+		"dry_run", model.DryRun,
+	)
+
+	fromID := model.FromID
+	for {
+		orders, err := work.OrderRepository().Query(ctx, &order.QueryOrdersModel{
+			Ids:         model.Ids,
+			FromID:      fromID,
+			ToID:        model.ToID,
+			CreatedFrom: model.CreatedFrom,
+			CreatedTo:   model.CreatedTo,
+			Limit:       model.BatchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to query orders: %w", err)
+		}
+
+		if len(orders) == 0 {
+			break
+		}
+
+		if err := s.loadOrderItems(ctx, work, orders); err != nil {
+			return result, err
+		}
+
+		for _, ord := range orders {
+			if !model.DryRun {
+				if err := limiter.Wait(ctx); err != nil {
+					return result, err
+				}
+
+				if err := s.enqueue(ctx, ord, model.RoutingKey); err != nil {
+					return result, err
+				}
+				result.EventsEnqueued++
+			}
+
+			result.OrdersScanned++
+			result.LastOrderID = ord.ID
+		}
+
+		slog.Info("Order events replay progress",
+			"orders_scanned", result.OrdersScanned,
+			"events_enqueued", result.EventsEnqueued,
+			"last_order_id", result.LastOrderID,
+		)
+
+		if len(orders) < model.BatchSize {
+			break
+		}
+		fromID = result.LastOrderID + 1
+	}
+
+	slog.Info("Order events replay finished",
+		"orders_scanned", result.OrdersScanned,
+		"events_enqueued", result.EventsEnqueued,
+		"dry_run", model.DryRun,
+	)
+
+	return result, nil
+}
+
+// hasRange reports whether the replay is limited by IDs or bounds.
+func hasRange(model replay.ReplayOrdersModel) bool {
+	return len(model.Ids) > 0 ||
+		model.FromID > 0 ||
+		model.ToID > 0 ||
+		!model.CreatedFrom.IsZero() ||
+		!model.CreatedTo.IsZero()
+}
+
+// loadOrderItems attaches order items to the orders.
+func (s *ReplayService) loadOrderItems(ctx context.Context, work unitOfWork, orders []order.Order) error {
+	orderItemQuery := &orderitem.QueryOrderItemsModel{}
+	for _, o := range orders {
+		orderItemQuery.OrderIds = append(orderItemQuery.OrderIds, o.ID)
+	}
+
+	orderItems, err := work.OrderItemRepository().Query(ctx, orderItemQuery)
+	if err != nil {
+		return fmt.Errorf("failed to query order items: %w", err)
+	}
+
+	itemsByOrder := make(map[int64][]orderitem.OrderItem, len(orders))
+	for _, item := range orderItems {
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], item)
+	}
+
+	for i := range orders {
+		orders[i].OrderItems = itemsByOrder[orders[i].ID]
+	}
+
+	return nil
+}
+
+// enqueue writes an order.created event for the order into the outbox.
+func (s *ReplayService) enqueue(ctx context.Context, ord order.Order, routingKey string) error {
+	now := time.Now()
+	eventID := uuid.NewString()
+
+	event, err := orderevent.OrderCreated(eventID, now, ord, requestmeta.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to build order event: %w", err)
+	}
+
+	payload, err := events.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	err = s.outboxRepo.Insert(ctx, outbox.OutboxMessage{
+		QueueName:    routingKey,
+		ExchangeName: "",
+		RoutingKey:   routingKey,
+		MessageID:    eventID,
+		MessageType:  events.TypeOrderCreated,
+		Payload:      payload,
+		ContentType:  events.ContentTypeProtobuf,
+		Headers:      broker.TraceHeaders(ctx),
+		MaxRetries:   s.maxRetries,
+		ErrorHistory: []outbox.ErrorRecord{},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		NextRetryAt:  now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue replayed event for order %d: %w", ord.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package admintransport
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+)
+
+// AdminTransport represents the HTTP admin server of the order service:
+// metrics and outbox administration. It has no authentication and listens on a port of its own,
+// apart from the public gateway, so the port can be kept on the internal network.
+type AdminTransport struct {
+	server      *http.Server
+	router      *chi.Mux
+	adminServer v1.AdminServiceServer
+	gatewayMux  *runtime.ServeMux
+}
+
+// Config configures the admin server.
+// Zero values are replaced with defaults.
+type Config struct {
+	Port string
+}
+
+// ConfigFromViper reads the admin server config from the server.admin section.
+func ConfigFromViper() Config {
+	return Config{
+		Port: viper.GetString("server.admin.port"),
+	}
+}
+
+// NewAdminTransport creates a new AdminTransport.
+func NewAdminTransport(adminServer v1.AdminServiceServer, cfg Config) *AdminTransport {
+	if cfg.Port == "" {
+		cfg.Port = "3101"
+	}
+
+	router := newRouter()
+
+	return &AdminTransport{
+		server:      newServer(cfg.Port, router),
+		router:      router,
+		adminServer: adminServer,
+		gatewayMux:  runtime.NewServeMux(),
+	}
+}
+
+// Run starts the admin server.
+func (a *AdminTransport) Run() error {
+	return a.server.ListenAndServe()
+}
+
+// Handler returns the HTTP handler with all registered routes.
+func (a *AdminTransport) Handler() http.Handler {
+	return a.router
+}
+
+// Shutdown gracefully shuts down the admin server.
+func (a *AdminTransport) Shutdown(ctx context.Context) error {
+	return a.server.Shutdown(ctx)
+}
+
+// RegisterRoutes registers the routes for the AdminTransport.
+func (a *AdminTransport) RegisterRoutes() {
+	if err := v1.RegisterAdminServiceHandlerServer(context.Background(), a.gatewayMux, a.adminServer); err != nil {
+		slog.Error("Failed to register admin grpc-gateway handler", "error", err)
+		panic(err)
+	}
+
+	// Scrapes are too frequent to be logged
+	a.router.Handle("/metrics", promhttp.Handler())
+
+	a.router.Group(func(r chi.Router) {
+		r.Use(logger.NewLoggerMiddleware(slog.Default()))
+
+		r.Mount("/", a.gatewayMux)
+	})
+}
+
+// newRouter creates a new router for the AdminTransport.
+func newRouter() *chi.Mux {
+	router := chi.NewMux()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Recoverer)
+
+	return router
+}
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+)
+
+// newServer creates a new HTTP server.
+func newServer(port string, router http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              "0.0.0.0:" + port,
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
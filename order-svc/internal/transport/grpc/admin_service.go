@@ -0,0 +1,151 @@
+package grpctransport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/internal/service/models/replay"
+	"github.com/corray333/backend-labs/order/internal/service/services/outboxsvc"
+	"github.com/corray333/backend-labs/order/internal/transport/http/v1/converters"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// outboxService is an interface for the outbox administration service layer.
+type outboxService interface {
+	ListDeadLetters(ctx context.Context, limit int, offset int) ([]outbox.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, id int64) (outbox.DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, id int64) (int64, error)
+	PurgeDeadLetters(ctx context.Context, filter outbox.PurgeDeadLettersModel) (int64, error)
+}
+
+// replayService is an interface for the order events replay service layer.
+type replayService interface {
+	ReplayOrders(ctx context.Context, model replay.ReplayOrdersModel) (replay.ReplayResult, error)
+}
+
+// AdminServer implements the gRPC AdminService.
+type AdminServer struct {
+	pb.UnimplementedAdminServiceServer
+
+	outboxService outboxService
+	replayService replayService
+}
+
+// NewAdminServer creates a new AdminServer.
+func NewAdminServer(outboxService outboxService, replayService replayService) *AdminServer {
+	return &AdminServer{
+		outboxService: outboxService,
+		replayService: replayService,
+	}
+}
+
+// ListDeadLetters handles the list dead letters gRPC request.
+func (s *AdminServer) ListDeadLetters(
+	ctx context.Context,
+	req *pb.ListDeadLettersRequest,
+) (*pb.ListDeadLettersResponse, error) {
+	deadLetters, err := s.outboxService.ListDeadLetters(ctx, int(req.Limit), int(req.Offset))
+	if err != nil {
+		slog.Error("Error listing dead letters", "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to list dead letters: %v", err)
+	}
+
+	return converters.ListDeadLettersResponseToProto(deadLetters), nil
+}
+
+// GetDeadLetter handles the get dead letter gRPC request.
+func (s *AdminServer) GetDeadLetter(
+	ctx context.Context,
+	req *pb.GetDeadLetterRequest,
+) (*pb.GetDeadLetterResponse, error) {
+	deadLetter, err := s.outboxService.GetDeadLetter(ctx, req.Id)
+	if errors.Is(err, outbox.ErrDeadLetterNotFound) {
+		return nil, status.Errorf(codes.NotFound, "dead letter %d not found", req.Id)
+	}
+	if err != nil {
+		slog.Error("Error getting dead letter", "id", req.Id, "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to get dead letter: %v", err)
+	}
+
+	return &pb.GetDeadLetterResponse{
+		DeadLetter: converters.DeadLetterToProto(deadLetter),
+	}, nil
+}
+
+// RequeueDeadLetter handles the requeue dead letter gRPC request.
+func (s *AdminServer) RequeueDeadLetter(
+	ctx context.Context,
+	req *pb.RequeueDeadLetterRequest,
+) (*pb.RequeueDeadLetterResponse, error) {
+	outboxID, err := s.outboxService.RequeueDeadLetter(ctx, req.Id)
+	if errors.Is(err, outbox.ErrDeadLetterNotFound) {
+		return nil, status.Errorf(codes.NotFound, "dead letter %d not found", req.Id)
+	}
+	if err != nil {
+		slog.Error("Error requeueing dead letter", "id", req.Id, "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to requeue dead letter: %v", err)
+	}
+
+	slog.Info("Dead letter requeued", "id", req.Id, "outbox_id", outboxID)
+
+	return &pb.RequeueDeadLetterResponse{
+		OutboxId: outboxID,
+	}, nil
+}
+
+// PurgeDeadLetters handles the purge dead letters gRPC request.
+func (s *AdminServer) PurgeDeadLetters(
+	ctx context.Context,
+	req *pb.PurgeDeadLettersRequest,
+) (*pb.PurgeDeadLettersResponse, error) {
+	purged, err := s.outboxService.PurgeDeadLetters(
+		ctx,
+		converters.PurgeDeadLettersRequestFromProto(req),
+	)
+	if errors.Is(err, outboxsvc.ErrEmptyPurgeFilter) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		slog.Error("Error purging dead letters", "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to purge dead letters: %v", err)
+	}
+
+	slog.Info("Dead letters purged", "purged_count", purged)
+
+	return &pb.PurgeDeadLettersResponse{
+		PurgedCount: purged,
+	}, nil
+}
+
+// ReplayOrderEvents handles the replay order events gRPC request.
+// The replay runs synchronously, the call returns once all events are enqueued.
+func (s *AdminServer) ReplayOrderEvents(
+	ctx context.Context,
+	req *pb.ReplayOrderEventsRequest,
+) (*pb.ReplayOrderEventsResponse, error) {
+	result, err := s.replayService.ReplayOrders(ctx, converters.ReplayOrderEventsRequestFromProto(req))
+	if errors.Is(err, replay.ErrEmptyReplayRange) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		slog.Error("Error replaying order events", "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to replay order events: %v", err)
+	}
+
+	slog.Info("Order events replayed",
+		"orders_scanned", result.OrdersScanned,
+		"events_enqueued", result.EventsEnqueued,
+		"dry_run", result.DryRun,
+	)
+
+	return converters.ReplayResultToProto(result), nil
+}
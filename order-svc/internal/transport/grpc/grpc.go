@@ -9,6 +9,7 @@ import (
 	"github.com/corray333/backend-labs/order/internal/service/models/order"
 	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
 	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
 	"github.com/spf13/viper"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -26,23 +27,31 @@ type GRPCTransport struct {
 	listener    net.Listener
 	service     service
 	orderServer *OrderServer
+	adminServer *AdminServer
 }
 
 // NewGRPCTransport creates a new GRPCTransport.
-func NewGRPCTransport(service service) *GRPCTransport {
+func NewGRPCTransport(
+	service service,
+	auditLogService auditLogService,
+	outboxService outboxService,
+	replayService replayService,
+) *GRPCTransport {
 	listener, err := net.Listen("tcp", ":"+viper.GetString("server.grpc.port"))
 	if err != nil {
 		panic(err)
 	}
 
 	server := newGRPCServer()
-	orderServer := NewOrderServer(service)
+	orderServer := NewOrderServer(service, auditLogService)
+	adminServer := NewAdminServer(outboxService, replayService)
 
 	return &GRPCTransport{
 		server:      server,
 		listener:    listener,
 		service:     service,
 		orderServer: orderServer,
+		adminServer: adminServer,
 	}
 }
 
@@ -72,7 +81,8 @@ func (g *GRPCTransport) Shutdown(ctx context.Context) error {
 	}
 }
 
-// RegisterServices registers the gRPC services.
+// RegisterServices registers the public gRPC services.
+// The AdminService is not registered, it is served by the admin transport on the internal port.
 func (g *GRPCTransport) RegisterServices() {
 	pb.RegisterOrderServiceServer(g.server, g.orderServer)
 }
@@ -82,6 +92,11 @@ func (g *GRPCTransport) GetOrderServer() *OrderServer {
 	return g.orderServer
 }
 
+// GetAdminServer returns the AdminServer instance.
+func (g *GRPCTransport) GetAdminServer() *AdminServer {
+	return g.adminServer
+}
+
 // newGRPCServer creates a new gRPC server with default settings.
 func newGRPCServer() *grpc.Server {
 	keepaliveParams := keepalive.ServerParameters{
@@ -112,6 +127,7 @@ func newGRPCServer() *grpc.Server {
 	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepaliveParams),
 		grpc.KeepaliveEnforcementPolicy(keepalivePolicy),
+		grpc.UnaryInterceptor(requestmeta.UnaryServerInterceptor),
 	}
 
 	return grpc.NewServer(opts...)
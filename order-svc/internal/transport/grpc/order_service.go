@@ -2,25 +2,36 @@ package grpctransport
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
 	"github.com/corray333/backend-labs/order/internal/transport/http/v1/converters"
 	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/events"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// auditLogService is an interface for the audit log service layer.
+type auditLogService interface {
+	SaveAuditLogs(ctx context.Context, auditLogs []auditlog.AuditLogOrder) (auditlog.SaveAuditLogsResult, error)
+}
+
 // OrderServer implements the gRPC OrderService.
 type OrderServer struct {
 	pb.UnimplementedOrderServiceServer
 
-	service service
+	service         service
+	auditLogService auditLogService
 }
 
 // NewOrderServer creates a new OrderServer.
-func NewOrderServer(service service) *OrderServer {
+func NewOrderServer(service service, auditLogService auditLogService) *OrderServer {
 	return &OrderServer{
-		service: service,
+		service:         service,
+		auditLogService: auditLogService,
 	}
 }
 
@@ -85,3 +96,40 @@ func (s *OrderServer) ListOrders(
 	return response, nil
 }
 
+// SaveAuditLog handles the save audit log gRPC request.
+// Audit logs without a message ID take the message ID of the call when it carries exactly one.
+func (s *OrderServer) SaveAuditLog(
+	ctx context.Context,
+	req *pb.SaveAuditLogRequest,
+) (*pb.SaveAuditLogResponse, error) {
+	slog.Info("Received SaveAuditLog gRPC request", "audit_logs_count", len(req.AuditLogs))
+
+	auditLogs := converters.SaveAuditLogRequestFromProto(req)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if messageIDs := md.Get(events.MetadataMessageID); len(messageIDs) == 1 {
+		for i := range auditLogs {
+			if auditLogs[i].MessageID == "" {
+				auditLogs[i].MessageID = messageIDs[0]
+			}
+		}
+	}
+
+	result, err := s.auditLogService.SaveAuditLogs(ctx, auditLogs)
+	if errors.Is(err, auditlog.ErrMissingMessageID) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		slog.Error("Error saving audit logs", "error", err)
+
+		return nil, status.Errorf(codes.Internal, "failed to save audit logs: %v", err)
+	}
+
+	slog.Info("SaveAuditLog completed successfully",
+		"saved_count", len(result.Saved),
+		"duplicate_messages", len(result.DuplicateMessageIDs),
+	)
+
+	return converters.SaveAuditLogResponseToProto(result), nil
+}
+
@@ -9,6 +9,7 @@ import (
 	v1 "github.com/corray333/backend-labs/order/pkg/api/v1"
 	"github.com/corray333/backend-labs/order/pkg/http/middleware/trace"
 	"github.com/corray333/backend-labs/order/pkg/logger"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -26,6 +27,7 @@ type HTTPTransport struct {
 }
 
 // NewHTTPTransport creates a new HTTPTransport.
+// It serves the public API only, metrics and administration are served by the admin transport.
 func NewHTTPTransport(grpcServer v1.OrderServiceServer) *HTTPTransport {
 	router := newRouter()
 	gatewayMux := runtime.NewServeMux()
@@ -44,6 +46,11 @@ func (h *HTTPTransport) Run() error {
 	return h.server.ListenAndServe()
 }
 
+// Handler returns the HTTP handler with all registered routes.
+func (h *HTTPTransport) Handler() http.Handler {
+	return h.router
+}
+
 // Shutdown gracefully shuts down the HTTP server.
 func (h *HTTPTransport) Shutdown(ctx context.Context) error {
 	return h.server.Shutdown(ctx)
@@ -74,6 +81,7 @@ func (h *HTTPTransport) RegisterRoutes() {
 func newRouter() *chi.Mux {
 	router := chi.NewMux()
 	router.Use(middleware.RequestID)
+	router.Use(requestmeta.NewMiddleware)
 	router.Use(logger.NewLoggerMiddleware(slog.Default()))
 	router.Use(trace.NewTraceMiddleware)
 
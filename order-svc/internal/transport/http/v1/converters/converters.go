@@ -2,12 +2,17 @@ package converters
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
 	"github.com/corray333/backend-labs/order/internal/service/models/currency"
 	"github.com/corray333/backend-labs/order/internal/service/models/order"
 	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+	"github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/internal/service/models/replay"
 	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -149,24 +154,38 @@ func ListOrdersResponseToProto(orders []order.Order) *pb.ListOrdersResponse {
 // AuditLogOrderToProto converts internal AuditLogOrder model to protobuf AuditLogOrder.
 func AuditLogOrderToProto(auditLog auditlog.AuditLogOrder) *pb.AuditLogOrder {
 	return &pb.AuditLogOrder{
-		Id:          auditLog.ID,
-		OrderId:     auditLog.OrderID,
-		OrderItemId: auditLog.OrderItemID,
-		CustomerId:  auditLog.CustomerID,
-		OrderStatus: auditLog.OrderStatus,
-		CreatedAt:   timestamppb.New(auditLog.CreatedAt),
-		UpdatedAt:   timestamppb.New(auditLog.UpdatedAt),
+		Id:            auditLog.ID,
+		OrderId:       auditLog.OrderID,
+		OrderItemId:   auditLog.OrderItemID,
+		CustomerId:    auditLog.CustomerID,
+		OrderStatus:   auditLog.OrderStatus,
+		CreatedAt:     timestamppb.New(auditLog.CreatedAt),
+		UpdatedAt:     timestamppb.New(auditLog.UpdatedAt),
+		EventType:     auditLog.EventType,
+		ActorId:       auditLog.ActorID,
+		ActorType:     auditLog.ActorType,
+		SourceService: auditLog.SourceService,
+		RequestId:     auditLog.RequestID,
+		Changes:       FieldChangesToProto(auditLog.Changes),
+		MessageId:     auditLog.MessageID,
 	}
 }
 
 // AuditLogOrderFromProto converts protobuf AuditLogOrder to internal AuditLogOrder model.
 func AuditLogOrderFromProto(pbAuditLog *pb.AuditLogOrder) auditlog.AuditLogOrder {
 	auditLog := auditlog.AuditLogOrder{
-		ID:          pbAuditLog.Id,
-		OrderID:     pbAuditLog.OrderId,
-		OrderItemID: pbAuditLog.OrderItemId,
-		CustomerID:  pbAuditLog.CustomerId,
-		OrderStatus: pbAuditLog.OrderStatus,
+		ID:            pbAuditLog.Id,
+		OrderID:       pbAuditLog.OrderId,
+		OrderItemID:   pbAuditLog.OrderItemId,
+		CustomerID:    pbAuditLog.CustomerId,
+		OrderStatus:   pbAuditLog.OrderStatus,
+		EventType:     pbAuditLog.EventType,
+		ActorID:       pbAuditLog.ActorId,
+		ActorType:     pbAuditLog.ActorType,
+		SourceService: pbAuditLog.SourceService,
+		RequestID:     pbAuditLog.RequestId,
+		Changes:       FieldChangesFromProto(pbAuditLog.Changes),
+		MessageID:     pbAuditLog.MessageId,
 	}
 
 	// Set timestamps if they exist in protobuf
@@ -190,14 +209,142 @@ func SaveAuditLogRequestFromProto(req *pb.SaveAuditLogRequest) []auditlog.AuditL
 	return auditLogs
 }
 
-// SaveAuditLogResponseToProto converts slice of internal AuditLogOrder models to protobuf SaveAuditLogResponse.
-func SaveAuditLogResponseToProto(auditLogs []auditlog.AuditLogOrder) *pb.SaveAuditLogResponse {
-	pbAuditLogs := make([]*pb.AuditLogOrder, len(auditLogs))
-	for i, auditLog := range auditLogs {
+// SaveAuditLogResponseToProto converts the result of saving audit logs to protobuf SaveAuditLogResponse.
+func SaveAuditLogResponseToProto(result auditlog.SaveAuditLogsResult) *pb.SaveAuditLogResponse {
+	pbAuditLogs := make([]*pb.AuditLogOrder, len(result.Saved))
+	for i, auditLog := range result.Saved {
 		pbAuditLogs[i] = AuditLogOrderToProto(auditLog)
 	}
 
 	return &pb.SaveAuditLogResponse{
-		AuditLogs: pbAuditLogs,
+		AuditLogs:           pbAuditLogs,
+		DuplicateMessageIds: result.DuplicateMessageIDs,
+	}
+}
+
+// FieldChangesToProto converts field changes to protobuf FieldChanges ordered by field.
+// Values without a JSON representation are converted to strings.
+func FieldChangesToProto(changes map[string]auditlog.FieldChange) []*pb.FieldChange {
+	fields := slices.Sorted(maps.Keys(changes))
+
+	pbChanges := make([]*pb.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		pbChanges = append(pbChanges, &pb.FieldChange{
+			Field:  field,
+			Before: valueToProto(changes[field].Before),
+			After:  valueToProto(changes[field].After),
+		})
+	}
+
+	return pbChanges
+}
+
+// FieldChangesFromProto converts protobuf FieldChanges to field changes keyed by field.
+func FieldChangesFromProto(pbChanges []*pb.FieldChange) map[string]auditlog.FieldChange {
+	changes := make(map[string]auditlog.FieldChange, len(pbChanges))
+	for _, change := range pbChanges {
+		changes[change.GetField()] = auditlog.FieldChange{
+			Before: change.GetBefore().AsInterface(),
+			After:  change.GetAfter().AsInterface(),
+		}
+	}
+
+	return changes
+}
+
+// valueToProto converts a changed value to protobuf Value.
+func valueToProto(v any) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewStringValue(fmt.Sprint(v))
+	}
+
+	return value
+}
+
+// DeadLetterToProto converts internal DeadLetter model to protobuf DeadLetter.
+func DeadLetterToProto(dl outbox.DeadLetter) *pb.DeadLetter {
+	history := make([]*pb.DeadLetterError, len(dl.ErrorHistory))
+	for i, record := range dl.ErrorHistory {
+		history[i] = &pb.DeadLetterError{
+			Attempt:    int32(record.Attempt),
+			Error:      record.Error,
+			OccurredAt: timestamppb.New(record.OccurredAt),
+		}
+	}
+
+	return &pb.DeadLetter{
+		Id:             dl.ID,
+		OutboxId:       dl.OutboxID,
+		QueueName:      dl.QueueName,
+		ExchangeName:   dl.ExchangeName,
+		RoutingKey:     dl.RoutingKey,
+		MessageId:      dl.MessageID,
+		MessageType:    dl.MessageType,
+		ContentType:    dl.ContentType,
+		Payload:        dl.Payload,
+		RetryCount:     int32(dl.RetryCount),
+		MaxRetries:     int32(dl.MaxRetries),
+		LastError:      dl.LastError,
+		ErrorHistory:   history,
+		CreatedAt:      timestamppb.New(dl.CreatedAt),
+		DeadLetteredAt: timestamppb.New(dl.DeadLetteredAt),
+	}
+}
+
+// ListDeadLettersResponseToProto converts slice of internal DeadLetter models to protobuf ListDeadLettersResponse.
+func ListDeadLettersResponseToProto(deadLetters []outbox.DeadLetter) *pb.ListDeadLettersResponse {
+	pbDeadLetters := make([]*pb.DeadLetter, len(deadLetters))
+	for i, dl := range deadLetters {
+		pbDeadLetters[i] = DeadLetterToProto(dl)
+	}
+
+	return &pb.ListDeadLettersResponse{
+		DeadLetters: pbDeadLetters,
+	}
+}
+
+// PurgeDeadLettersRequestFromProto converts protobuf PurgeDeadLettersRequest to internal PurgeDeadLettersModel.
+func PurgeDeadLettersRequestFromProto(req *pb.PurgeDeadLettersRequest) outbox.PurgeDeadLettersModel {
+	filter := outbox.PurgeDeadLettersModel{
+		Ids: req.Ids,
+	}
+
+	if req.DeadLetteredBefore != nil {
+		filter.DeadLetteredBefore = req.DeadLetteredBefore.AsTime()
+	}
+
+	return filter
+}
+
+// ReplayOrderEventsRequestFromProto converts protobuf ReplayOrderEventsRequest to internal ReplayOrdersModel.
+func ReplayOrderEventsRequestFromProto(req *pb.ReplayOrderEventsRequest) replay.ReplayOrdersModel {
+	model := replay.ReplayOrdersModel{
+		FromID:        req.FromId,
+		ToID:          req.ToId,
+		RoutingKey:    req.RoutingKey,
+		RatePerSecond: req.RatePerSecond,
+		BatchSize:     int(req.BatchSize),
+		DryRun:        req.DryRun,
+	}
+
+	if req.CreatedFrom != nil {
+		model.CreatedFrom = req.CreatedFrom.AsTime()
+	}
+
+	if req.CreatedTo != nil {
+		model.CreatedTo = req.CreatedTo.AsTime()
+	}
+
+	return model
+}
+
+// ReplayResultToProto converts internal ReplayResult to protobuf ReplayOrderEventsResponse.
+func ReplayResultToProto(result replay.ReplayResult) *pb.ReplayOrderEventsResponse {
+	return &pb.ReplayOrderEventsResponse{
+		OrdersScanned:  result.OrdersScanned,
+		EventsEnqueued: result.EventsEnqueued,
+		LastOrderId:    result.LastOrderID,
+		DryRun:         result.DryRun,
 	}
 }
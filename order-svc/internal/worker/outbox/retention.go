@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/iarchiverepo"
+	"github.com/spf13/viper"
+)
+
+// RetentionWorker moves published outbox messages to the archive and drops old archive partitions.
+type RetentionWorker struct {
+	archiveRepo  iarchiverepo.IArchiveRepository
+	enabled      bool
+	interval     time.Duration
+	archiveAfter time.Duration
+	dropAfter    time.Duration
+	batchSize    int
+}
+
+// NewRetentionWorker creates a new outbox retention worker.
+func NewRetentionWorker(archiveRepo iarchiverepo.IArchiveRepository) *RetentionWorker {
+	intervalMinutes := viper.GetInt("rabbitmq.outbox.retention.interval_minutes")
+	if intervalMinutes == 0 {
+		intervalMinutes = 60
+	}
+
+	archiveAfterDays := viper.GetInt("rabbitmq.outbox.retention.archive_after_days")
+	if archiveAfterDays == 0 {
+		archiveAfterDays = 7
+	}
+
+	batchSize := viper.GetInt("rabbitmq.outbox.retention.batch_size")
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+
+	return &RetentionWorker{
+		archiveRepo:  archiveRepo,
+		enabled:      viper.GetBool("rabbitmq.outbox.retention.enabled"),
+		interval:     time.Duration(intervalMinutes) * time.Minute,
+		archiveAfter: time.Duration(archiveAfterDays) * 24 * time.Hour,
+		// Zero keeps archive partitions forever
+		dropAfter: time.Duration(viper.GetInt("rabbitmq.outbox.retention.drop_after_days")) * 24 * time.Hour,
+		batchSize: batchSize,
+	}
+}
+
+// Start runs retention passes until the context is canceled.
+func (w *RetentionWorker) Start(ctx context.Context) {
+	if !w.enabled {
+		slog.Info("Outbox retention worker disabled")
+
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Outbox retention worker started",
+		"interval", w.interval,
+		"archive_after", w.archiveAfter,
+		"drop_after", w.dropAfter,
+	)
+
+	w.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Outbox retention worker shutting down")
+
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+// run performs a single retention pass.
+func (w *RetentionWorker) run(ctx context.Context) {
+	now := time.Now()
+
+	if err := w.archive(ctx, now.Add(-w.archiveAfter)); err != nil {
+		slog.Error("Failed to archive published outbox messages", "error", err)
+	}
+
+	if w.dropAfter <= 0 {
+		return
+	}
+
+	dropped, err := w.archiveRepo.DropPartitionsBefore(ctx, now.Add(-w.dropAfter))
+	if err != nil {
+		slog.Error("Failed to drop outbox archive partitions", "error", err)
+	}
+
+	if len(dropped) > 0 {
+		slog.Info("Dropped outbox archive partitions", "partitions", dropped)
+	}
+}
+
+// archive moves messages published before the cutoff to the archive in batches.
+func (w *RetentionWorker) archive(ctx context.Context, cutoff time.Time) error {
+	months, err := w.archiveRepo.PublishedMonths(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, month := range months {
+		if err := w.archiveRepo.EnsurePartition(ctx, month); err != nil {
+			return err
+		}
+	}
+
+	var total int64
+	for ctx.Err() == nil {
+		archived, err := w.archiveRepo.ArchivePublished(ctx, cutoff, w.batchSize)
+		if err != nil {
+			return err
+		}
+		total += archived
+
+		if archived < int64(w.batchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("Archived published outbox messages", "count", total, "cutoff", cutoff)
+	}
+
+	return nil
+}
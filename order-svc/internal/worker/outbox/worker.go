@@ -4,60 +4,113 @@ import (
 	"context"
 	"log/slog"
 	"math"
+	"os"
 	"time"
 
 	"github.com/corray333/backend-labs/order/internal/dal/interfaces/ioutboxrepo"
-	"github.com/corray333/backend-labs/order/internal/dal/rabbitmq"
+	"github.com/corray333/backend-labs/order/internal/metrics"
+	outboxmodel "github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
-	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Worker processes messages from the outbox table.
+// Several workers may run against the same table, each claims its own batches under a lease.
 type Worker struct {
 	outboxRepo    ioutboxrepo.IOutboxRepository
-	rabbitClient  *rabbitmq.Client
+	publisher     broker.Publisher
+	breaker       *circuitbreaker.Breaker
+	workerID      string
 	pollInterval  time.Duration
 	batchSize     int
+	lease         time.Duration
 	retryInterval time.Duration
+	wakeCh        chan struct{}
 	stopCh        chan struct{}
 }
 
+// Config configures the outbox worker.
+// Zero values are replaced with defaults.
+type Config struct {
+	PollInterval  time.Duration
+	BatchSize     int
+	Lease         time.Duration
+	RetryInterval time.Duration
+}
+
+// ConfigFromViper reads the outbox worker config from the rabbitmq.outbox section.
+func ConfigFromViper() Config {
+	return Config{
+		PollInterval:  time.Duration(viper.GetInt("rabbitmq.outbox.poll_interval_seconds")) * time.Second,
+		BatchSize:     viper.GetInt("rabbitmq.outbox.batch_size"),
+		Lease:         time.Duration(viper.GetInt("rabbitmq.outbox.lease_seconds")) * time.Second,
+		RetryInterval: time.Duration(viper.GetInt("rabbitmq.outbox.retry_interval_seconds")) * time.Second,
+	}
+}
+
 // NewWorker creates a new outbox worker.
 func NewWorker(
 	outboxRepo ioutboxrepo.IOutboxRepository,
-	rabbitClient *rabbitmq.Client,
+	publisher broker.Publisher,
+	breaker *circuitbreaker.Breaker,
+	cfg Config,
 ) *Worker {
-	pollIntervalSeconds := viper.GetInt("rabbitmq.outbox.poll_interval_seconds")
-	if pollIntervalSeconds == 0 {
-		pollIntervalSeconds = 10
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 10 * time.Second
 	}
-
-	batchSize := viper.GetInt("rabbitmq.outbox.batch_size")
-	if batchSize == 0 {
-		batchSize = 100
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
 	}
-
-	retryIntervalSeconds := viper.GetInt("rabbitmq.outbox.retry_interval_seconds")
-	if retryIntervalSeconds == 0 {
-		retryIntervalSeconds = 30
+	if cfg.Lease <= 0 {
+		cfg.Lease = 60 * time.Second
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
 	}
 
 	return &Worker{
 		outboxRepo:    outboxRepo,
-		rabbitClient:  rabbitClient,
-		pollInterval:  time.Duration(pollIntervalSeconds) * time.Second,
-		batchSize:     batchSize,
-		retryInterval: time.Duration(retryIntervalSeconds) * time.Second,
+		publisher:     publisher,
+		breaker:       breaker,
+		workerID:      newWorkerID(),
+		pollInterval:  cfg.PollInterval,
+		batchSize:     cfg.BatchSize,
+		lease:         cfg.Lease,
+		retryInterval: cfg.RetryInterval,
+		wakeCh:        make(chan struct{}, 1),
 		stopCh:        make(chan struct{}),
 	}
 }
 
+// newWorkerID builds a worker identifier that is unique across replicas.
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "outbox-worker"
+	}
+
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
 // Start begins processing messages from the outbox.
+// Messages are processed on every Wake call, polling is kept as a safety net.
 func (w *Worker) Start(ctx context.Context) {
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
-	slog.Info("Outbox worker started", "poll_interval", w.pollInterval, "batch_size", w.batchSize)
+	slog.Info("Outbox worker started",
+		"worker_id", w.workerID,
+		"poll_interval", w.pollInterval,
+		"batch_size", w.batchSize,
+		"lease", w.lease,
+	)
 
 	for {
 		select {
@@ -69,49 +122,90 @@ func (w *Worker) Start(ctx context.Context) {
 			slog.Info("Outbox worker stopped")
 
 			return
+		case <-w.wakeCh:
+			w.drain(ctx)
 		case <-ticker.C:
-			w.processMessages(ctx)
+			w.drain(ctx)
 		}
 	}
 }
 
+// Wake schedules an immediate outbox pass without blocking the caller.
+// Wakeups that arrive while a pass is pending are coalesced.
+func (w *Worker) Wake() {
+	select {
+	case w.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
 // Stop stops the worker.
 func (w *Worker) Stop() {
 	close(w.stopCh)
 }
 
-// processMessages retrieves and processes pending messages from the outbox.
-func (w *Worker) processMessages(ctx context.Context) {
-	messages, err := w.outboxRepo.GetPendingMessages(ctx, w.batchSize)
+// drain processes claimed batches until the outbox has no more ready messages.
+// Messages left exhausted by an earlier pass are dead-lettered first.
+func (w *Worker) drain(ctx context.Context) {
+	w.moveExhaustedToDeadLetter(ctx)
+
+	for ctx.Err() == nil {
+		// Claims only return the head of every aggregate, keep going while anything was claimed
+		if claimed := w.processBatch(ctx); claimed == 0 {
+			return
+		}
+	}
+}
+
+// processBatch claims a batch of pending messages, publishes them and
+// completes the batch. Returns the number of claimed messages.
+func (w *Worker) processBatch(ctx context.Context) int {
+	messages, err := w.outboxRepo.ClaimPendingMessages(ctx, w.workerID, w.batchSize, w.lease)
 	if err != nil {
-		slog.Error("Failed to get pending messages from outbox", "error", err)
+		slog.Error("Failed to claim pending messages from outbox", "worker_id", w.workerID, "error", err)
 
-		return
+		return 0
 	}
 
 	if len(messages) == 0 {
-		return
+		return 0
 	}
 
-	slog.Info("Processing outbox messages", "count", len(messages))
+	slog.Info("Processing outbox messages", "worker_id", w.workerID, "count", len(messages))
+
+	var (
+		published []int64
+		retries   []outboxmodel.RetryUpdate
+		exhausted int
+	)
 
 	for _, msg := range messages {
-		err := w.rabbitClient.Channel().Publish(
-			msg.ExchangeName,
-			msg.RoutingKey,
-			false,
-			false,
-			amqp.Publishing{
-				ContentType: msg.ContentType,
-				Body:        msg.Payload,
-			},
-		)
+		err := w.publish(ctx, msg)
+		// Publishing is not gated by the breaker, outcomes are reported as recovery probes
+		if err == nil {
+			w.breaker.Success()
+			published = append(published, msg.ID)
+
+			continue
+		}
+		w.breaker.Failure()
 
-		if err != nil {
-			// Update retry count and schedule next retry with exponential backoff
-			newRetryCount := msg.RetryCount + 1
-			backoffSeconds := math.Pow(2, float64(newRetryCount)) * 30 // 30s, 60s, 120s, 240s, etc.
-			nextRetryAt := time.Now().Add(time.Duration(backoffSeconds) * time.Second)
+		newRetryCount := msg.RetryCount + 1
+		nextRetryAt := time.Now()
+
+		if newRetryCount >= msg.MaxRetries {
+			slog.Error("Outbox message exhausted retries, moving to dead letter",
+				"outbox_id", msg.ID,
+				"message_id", msg.MessageID,
+				"queue", msg.QueueName,
+				"retry_count", newRetryCount,
+				"error", err,
+			)
+			exhausted++
+		} else {
+			// Schedule next retry with exponential backoff of the retry interval
+			backoff := math.Pow(2, float64(newRetryCount)) * float64(w.retryInterval)
+			nextRetryAt = nextRetryAt.Add(time.Duration(backoff))
 
 			slog.Warn("Failed to publish message from outbox, will retry",
 				"outbox_id", msg.ID,
@@ -119,20 +213,109 @@ func (w *Worker) processMessages(ctx context.Context) {
 				"next_retry", nextRetryAt,
 				"error", err,
 			)
-
-			if err := w.outboxRepo.UpdateRetry(ctx, msg.ID, newRetryCount, err.Error(), nextRetryAt); err != nil {
-				slog.Error("Failed to update retry information", "outbox_id", msg.ID, "error", err)
-			}
-		} else {
-			// Successfully published, delete from outbox
-			if err := w.outboxRepo.Delete(ctx, msg.ID); err != nil {
-				slog.Error("Failed to delete message from outbox after successful publish",
-					"outbox_id", msg.ID,
-					"error", err,
-				)
-			} else {
-				slog.Info("Message successfully published and removed from outbox", "outbox_id", msg.ID)
-			}
 		}
+
+		retries = append(retries, outboxmodel.RetryUpdate{
+			ID:          msg.ID,
+			RetryCount:  newRetryCount,
+			LastError:   err.Error(),
+			NextRetryAt: nextRetryAt,
+		})
+	}
+
+	w.completeBatch(ctx, published, retries, exhausted)
+
+	return len(messages)
+}
+
+// publish publishes the message under a producer span that continues the trace
+// of the request that created the message.
+func (w *Worker) publish(ctx context.Context, msg outboxmodel.OutboxMessage) error {
+	brokerMsg := broker.Message{
+		Topic:       msg.RoutingKey,
+		Key:         msg.AggregateID,
+		ID:          msg.MessageID,
+		Type:        msg.MessageType,
+		ContentType: msg.ContentType,
+		Timestamp:   msg.CreatedAt,
+		Body:        msg.Payload,
+	}
+
+	ctx, span := otel.Tracer("worker").Start(broker.ExtractTraceHeaders(ctx, msg.Headers), "publish "+msg.RoutingKey,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(broker.PublishAttributes(w.publisher.System(), brokerMsg)...),
+		trace.WithAttributes(attribute.Int("outbox.retry_count", msg.RetryCount)),
+	)
+	defer span.End()
+
+	brokerMsg.Headers = messageHeaders(ctx, msg)
+
+	if err := w.publisher.Publish(ctx, brokerMsg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// messageHeaders builds the trace context headers and the headers
+// consumers use to detect gaps in an aggregate.
+func messageHeaders(ctx context.Context, msg outboxmodel.OutboxMessage) map[string]any {
+	headers := make(map[string]any)
+	for k, v := range broker.TraceHeaders(ctx) {
+		headers[k] = v
+	}
+
+	if msg.AggregateID != "" {
+		headers[events.HeaderAggregateID] = msg.AggregateID
+		headers[events.HeaderSequence] = msg.Sequence
+	}
+
+	return headers
+}
+
+// completeBatch marks published messages, records failed attempts and moves
+// messages that exhausted their retries to the dead letter table.
+// The retry bump of an exhausted message is recorded before the move, so a failed move
+// leaves the message exhausted in the outbox for the next dead letter sweep.
+func (w *Worker) completeBatch(
+	ctx context.Context,
+	published []int64,
+	retries []outboxmodel.RetryUpdate,
+	exhausted int,
+) {
+	if err := w.outboxRepo.MarkPublished(ctx, w.workerID, published); err != nil {
+		slog.Error("Failed to mark outbox messages as published",
+			"count", len(published),
+			"error", err,
+		)
+	} else if len(published) > 0 {
+		slog.Info("Messages successfully published from outbox", "count", len(published))
+	}
+
+	if err := w.outboxRepo.UpdateRetries(ctx, w.workerID, retries); err != nil {
+		slog.Error("Failed to update retry information", "count", len(retries), "error", err)
+
+		return
+	}
+
+	if exhausted > 0 {
+		w.moveExhaustedToDeadLetter(ctx)
+	}
+}
+
+// moveExhaustedToDeadLetter moves messages that exhausted their retries to the dead letter table.
+func (w *Worker) moveExhaustedToDeadLetter(ctx context.Context) {
+	moved, err := w.outboxRepo.MoveExhaustedToDeadLetter(ctx, w.batchSize)
+	if err != nil {
+		slog.Error("Failed to move messages to dead letter", "error", err)
+
+		return
+	}
+
+	for queue, count := range moved {
+		metrics.OutboxDeadLetters.WithLabelValues(queue).Add(float64(count))
 	}
 }
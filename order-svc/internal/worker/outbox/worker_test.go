@@ -0,0 +1,274 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	outboxmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/outbox/memory"
+	outboxmodel "github.com/corray333/backend-labs/order/internal/service/models/outbox"
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/broker/memory"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+)
+
+const testQueue = "orders"
+
+var errBrokerDown = errors.New("broker is down")
+
+// failingPublisher fails publishing of the listed messages.
+type failingPublisher struct {
+	broker.Publisher
+
+	fail map[string]bool
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, msg broker.Message) error {
+	if p.fail[msg.ID] {
+		return errBrokerDown
+	}
+
+	return p.Publisher.Publish(ctx, msg)
+}
+
+// flakyOutbox fails moving messages to the dead letter table while failMoves is set.
+type flakyOutbox struct {
+	*outboxmemory.OutboxRepository
+
+	failMoves bool
+}
+
+func (r *flakyOutbox) MoveExhaustedToDeadLetter(ctx context.Context, limit int) (map[string]int, error) {
+	if r.failMoves {
+		return nil, errors.New("database is down")
+	}
+
+	return r.OutboxRepository.MoveExhaustedToDeadLetter(ctx, limit)
+}
+
+type testEnv struct {
+	worker    *Worker
+	outbox    *flakyOutbox
+	broker    *memory.Broker
+	publisher *failingPublisher
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	b := memory.New()
+	if err := b.Declare(context.Background(), testQueue); err != nil {
+		t.Fatalf("declare queue: %v", err)
+	}
+
+	repo := &flakyOutbox{OutboxRepository: outboxmemory.NewOutboxRepository()}
+	publisher := &failingPublisher{Publisher: b, fail: make(map[string]bool)}
+	breaker := circuitbreaker.New("test", circuitbreaker.Config{FailureThreshold: 100})
+
+	return &testEnv{
+		worker:    NewWorker(repo, publisher, breaker, Config{RetryInterval: time.Minute}),
+		outbox:    repo,
+		broker:    b,
+		publisher: publisher,
+	}
+}
+
+// insert adds a message of the aggregate to the outbox, ready for delivery unless changed by opts.
+func (e *testEnv) insert(t *testing.T, messageID string, aggregateID string, sequence int64, opts ...func(*outboxmodel.OutboxMessage)) {
+	t.Helper()
+
+	now := time.Now()
+	msg := outboxmodel.OutboxMessage{
+		QueueName:   testQueue,
+		RoutingKey:  testQueue,
+		MessageID:   messageID,
+		MessageType: events.TypeOrderCreated,
+		AggregateID: aggregateID,
+		Sequence:    sequence,
+		Payload:     []byte(messageID),
+		ContentType: events.ContentTypeProtobuf,
+		MaxRetries:  3,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		NextRetryAt: now.Add(-time.Second),
+	}
+	for _, opt := range opts {
+		opt(&msg)
+	}
+
+	if err := e.outbox.Insert(context.Background(), msg); err != nil {
+		t.Fatalf("insert outbox message: %v", err)
+	}
+}
+
+// publishedIDs returns the IDs of messages published to the test queue, in publish order.
+func (e *testEnv) publishedIDs() []string {
+	var ids []string
+	for _, msg := range e.broker.Published(testQueue) {
+		ids = append(ids, msg.ID)
+	}
+
+	return ids
+}
+
+// message returns the outbox message with the message ID.
+func (e *testEnv) message(t *testing.T, messageID string) outboxmodel.OutboxMessage {
+	t.Helper()
+
+	for _, msg := range e.outbox.Messages() {
+		if msg.MessageID == messageID {
+			return msg
+		}
+	}
+	t.Fatalf("outbox message %s not found", messageID)
+
+	return outboxmodel.OutboxMessage{}
+}
+
+func equalIDs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestDrainPublishesAggregateInSequence(t *testing.T) {
+	e := newTestEnv(t)
+	// Later messages are ready earlier, the claim must still follow the sequence
+	e.insert(t, "a-3", "a", 3, func(m *outboxmodel.OutboxMessage) { m.NextRetryAt = m.NextRetryAt.Add(-time.Hour) })
+	e.insert(t, "a-1", "a", 1)
+	e.insert(t, "a-2", "a", 2, func(m *outboxmodel.OutboxMessage) { m.NextRetryAt = m.NextRetryAt.Add(-time.Minute) })
+	e.insert(t, "b-1", "b", 1)
+
+	e.worker.drain(context.Background())
+
+	var aggregateA []string
+	for _, id := range e.publishedIDs() {
+		if id != "b-1" {
+			aggregateA = append(aggregateA, id)
+		}
+	}
+	if want := []string{"a-1", "a-2", "a-3"}; !equalIDs(aggregateA, want) {
+		t.Fatalf("expected aggregate published as %v, got %v", want, aggregateA)
+	}
+	if n := len(e.publishedIDs()); n != 4 {
+		t.Fatalf("expected 4 published messages, got %d", n)
+	}
+}
+
+func TestClaimWaitsForPendingPredecessor(t *testing.T) {
+	e := newTestEnv(t)
+	e.insert(t, "a-1", "a", 1, func(m *outboxmodel.OutboxMessage) {
+		m.RetryCount = 1
+		m.NextRetryAt = time.Now().Add(time.Hour)
+	})
+	e.insert(t, "a-2", "a", 2)
+
+	if claimed := e.worker.processBatch(context.Background()); claimed != 0 {
+		t.Fatalf("expected nothing claimed while the predecessor waits for retry, got %d", claimed)
+	}
+	if ids := e.publishedIDs(); len(ids) != 0 {
+		t.Fatalf("expected nothing published, got %v", ids)
+	}
+}
+
+func TestExhaustedMessageDoesNotBlockAggregate(t *testing.T) {
+	e := newTestEnv(t)
+	// Exhausted message left in the outbox by a failed dead letter move
+	e.insert(t, "a-1", "a", 1, func(m *outboxmodel.OutboxMessage) { m.RetryCount = m.MaxRetries })
+	e.insert(t, "a-2", "a", 2)
+	e.insert(t, "b-1", "b", 1, func(m *outboxmodel.OutboxMessage) { m.RetryCount = m.MaxRetries })
+
+	pending, err := e.outbox.HasPending(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("has pending: %v", err)
+	}
+	if pending {
+		t.Fatal("expected exhausted messages not to be pending")
+	}
+
+	if claimed := e.worker.processBatch(context.Background()); claimed != 1 {
+		t.Fatalf("expected a-2 claimed behind the exhausted message, got %d claimed", claimed)
+	}
+	if ids := e.publishedIDs(); !equalIDs(ids, []string{"a-2"}) {
+		t.Fatalf("expected only a-2 published, got %v", ids)
+	}
+
+	e.worker.drain(context.Background())
+
+	if dls := e.outbox.DeadLetters(); len(dls) != 2 {
+		t.Fatalf("expected exhausted messages dead-lettered, got %+v", dls)
+	}
+}
+
+func TestFailedPublishIsScheduledForRetry(t *testing.T) {
+	e := newTestEnv(t)
+	e.publisher.fail["a-1"] = true
+	e.insert(t, "a-1", "a", 1, func(m *outboxmodel.OutboxMessage) { m.RetryCount = 1 })
+
+	before := time.Now()
+	e.worker.processBatch(context.Background())
+
+	msg := e.message(t, "a-1")
+	if msg.RetryCount != 2 {
+		t.Fatalf("expected retry count 2, got %d", msg.RetryCount)
+	}
+	if msg.LastError != errBrokerDown.Error() || len(msg.ErrorHistory) != 1 {
+		t.Fatalf("expected the error recorded, got %q with history %+v", msg.LastError, msg.ErrorHistory)
+	}
+	// Backoff is 2^retry_count retry intervals
+	if wait := msg.NextRetryAt.Sub(before); wait < 4*time.Minute || wait > 4*time.Minute+time.Second {
+		t.Fatalf("expected retry in 4m, got %s", wait)
+	}
+	if dls := e.outbox.DeadLetters(); len(dls) != 0 {
+		t.Fatalf("expected no dead letters, got %+v", dls)
+	}
+}
+
+func TestLastFailedAttemptMovesToDeadLetter(t *testing.T) {
+	e := newTestEnv(t)
+	e.publisher.fail["a-1"] = true
+	e.insert(t, "a-1", "a", 1, func(m *outboxmodel.OutboxMessage) { m.RetryCount = m.MaxRetries - 1 })
+
+	e.worker.processBatch(context.Background())
+
+	if n := len(e.outbox.Messages()); n != 0 {
+		t.Fatalf("expected empty outbox, got %d messages", n)
+	}
+	dls := e.outbox.DeadLetters()
+	if len(dls) != 1 {
+		t.Fatalf("expected one dead letter, got %+v", dls)
+	}
+	if dls[0].RetryCount != dls[0].MaxRetries || dls[0].LastError != errBrokerDown.Error() {
+		t.Fatalf("expected the last attempt recorded on the dead letter, got %+v", dls[0])
+	}
+}
+
+func TestFailedDeadLetterMoveIsRetried(t *testing.T) {
+	e := newTestEnv(t)
+	e.publisher.fail["a-1"] = true
+	e.insert(t, "a-1", "a", 1, func(m *outboxmodel.OutboxMessage) { m.RetryCount = m.MaxRetries - 1 })
+
+	e.outbox.failMoves = true
+	e.worker.processBatch(context.Background())
+
+	msg := e.message(t, "a-1")
+	if msg.RetryCount != msg.MaxRetries {
+		t.Fatalf("expected the exhausting attempt recorded, got retry count %d", msg.RetryCount)
+	}
+
+	e.outbox.failMoves = false
+	e.worker.drain(context.Background())
+
+	if dls := e.outbox.DeadLetters(); len(dls) != 1 || dls[0].MessageID != "a-1" {
+		t.Fatalf("expected a-1 dead-lettered by the next pass, got %+v", dls)
+	}
+}
@@ -0,0 +1,95 @@
+package processed
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/interfaces/iauditlogrepo"
+	"github.com/spf13/viper"
+)
+
+// Worker deletes the messages of audit logs saved through the order service once they are older
+// than the retention, so audit_log_processed_message does not grow forever.
+// A message redelivered after it was deleted is saved again, the retention has to be longer
+// than the audit consumer and its broker can redeliver a message.
+type Worker struct {
+	auditLogRepo iauditlogrepo.IAuditLogRepository
+	interval     time.Duration
+	retention    time.Duration
+	batchSize    int
+}
+
+// NewWorker creates a new processed messages worker.
+func NewWorker(auditLogRepo iauditlogrepo.IAuditLogRepository) *Worker {
+	intervalMinutes := viper.GetInt("audit_log.processed_messages.interval_minutes")
+	if intervalMinutes == 0 {
+		intervalMinutes = 60
+	}
+
+	// Twice the default Kafka log retention
+	retentionDays := viper.GetInt("audit_log.processed_messages.retention_days")
+	if retentionDays == 0 {
+		retentionDays = 14
+	}
+
+	batchSize := viper.GetInt("audit_log.processed_messages.batch_size")
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+
+	return &Worker{
+		auditLogRepo: auditLogRepo,
+		interval:     time.Duration(intervalMinutes) * time.Minute,
+		retention:    time.Duration(retentionDays) * 24 * time.Hour,
+		batchSize:    batchSize,
+	}
+}
+
+// Start deletes expired processed messages until the context is canceled.
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Processed messages worker started",
+		"interval", w.interval,
+		"retention", w.retention,
+	)
+
+	w.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Processed messages worker shutting down")
+
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+// run deletes messages processed before the retention in batches.
+func (w *Worker) run(ctx context.Context) {
+	cutoff := time.Now().Add(-w.retention)
+
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := w.auditLogRepo.DeleteProcessedMessagesBefore(ctx, cutoff, w.batchSize)
+		if err != nil {
+			slog.Error("Failed to delete expired processed messages", "error", err)
+
+			return
+		}
+		total += deleted
+
+		if deleted < int64(w.batchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("Deleted expired processed messages", "count", total, "cutoff", cutoff)
+	}
+}
@@ -0,0 +1,53 @@
+package processed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	auditlogmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/auditlog/memory"
+	"github.com/corray333/backend-labs/order/internal/service/models/auditlog"
+)
+
+// saveMessage saves an audit log of the message and returns the number of saved audit logs.
+func saveMessage(t *testing.T, repo *auditlogmemory.AuditLogRepository, messageID string) int {
+	t.Helper()
+
+	result, err := repo.SaveAuditLogs(context.Background(), []auditlog.AuditLogOrder{{
+		OrderID:     1,
+		OrderItemID: 1,
+		OrderStatus: "created",
+		MessageID:   messageID,
+	}})
+	if err != nil {
+		t.Fatalf("save audit logs of %s: %v", messageID, err)
+	}
+
+	return len(result.Saved)
+}
+
+func TestRunDeletesMessagesProcessedBeforeRetention(t *testing.T) {
+	repo := auditlogmemory.NewAuditLogRepository()
+	for _, id := range []string{"old-1", "old-2", "old-3", "recent"} {
+		saveMessage(t, repo, id)
+	}
+	for _, id := range []string{"old-1", "old-2", "old-3"} {
+		repo.SetProcessedAt(id, time.Now().Add(-15*24*time.Hour))
+	}
+
+	worker := NewWorker(repo)
+	// Several batches are needed to delete all expired messages
+	worker.batchSize = 2
+	worker.run(context.Background())
+
+	// Expired messages are forgotten, a redelivery is saved again
+	for _, id := range []string{"old-1", "old-2", "old-3"} {
+		if saved := saveMessage(t, repo, id); saved != 1 {
+			t.Fatalf("expected %s to be forgotten, %d audit logs saved on redelivery", id, saved)
+		}
+	}
+
+	if saved := saveMessage(t, repo, "recent"); saved != 0 {
+		t.Fatalf("expected the recent message to stay processed, %d audit logs saved on redelivery", saved)
+	}
+}
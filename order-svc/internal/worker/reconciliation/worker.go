@@ -0,0 +1,123 @@
+package reconciliation
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+	"github.com/spf13/viper"
+)
+
+// lockKey is the advisory lock key of reconciliation passes.
+const lockKey int64 = 0x6f72646572726563
+
+// reconciler is an interface for the reconciliation service layer.
+type reconciler interface {
+	Reconcile(ctx context.Context, model reconciliation.ReconcileModel) (reconciliation.Report, error)
+}
+
+// locker runs a function while holding a lock shared by all replicas.
+type locker interface {
+	TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+// Worker periodically reconciles recent orders with the audit database.
+// Every replica runs the worker, a pass runs on the replica that takes the lock first
+// and the others skip it.
+type Worker struct {
+	reconciler reconciler
+	locker     locker
+	interval   time.Duration
+	window     time.Duration
+	lag        time.Duration
+	republish  bool
+}
+
+// NewWorker creates a new reconciliation worker.
+func NewWorker(reconciler reconciler, locker locker) *Worker {
+	intervalMinutes := viper.GetInt("reconciliation.interval_minutes")
+	if intervalMinutes == 0 {
+		intervalMinutes = 60
+	}
+
+	windowMinutes := viper.GetInt("reconciliation.window_minutes")
+	if windowMinutes == 0 {
+		windowMinutes = intervalMinutes
+	}
+
+	lagMinutes := viper.GetInt("reconciliation.lag_minutes")
+	if lagMinutes == 0 {
+		lagMinutes = 15
+	}
+
+	return &Worker{
+		reconciler: reconciler,
+		locker:     locker,
+		interval:   time.Duration(intervalMinutes) * time.Minute,
+		window:     time.Duration(windowMinutes) * time.Minute,
+		lag:        time.Duration(lagMinutes) * time.Minute,
+		republish:  viper.GetBool("reconciliation.republish"),
+	}
+}
+
+// Start runs reconciliation passes until the context is canceled.
+// Every pass checks a window that ended lag ago, so events still in flight are not reported.
+func (w *Worker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	slog.Info("Reconciliation worker started",
+		"interval", w.interval,
+		"window", w.window,
+		"lag", w.lag,
+		"republish", w.republish,
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Reconciliation worker shutting down")
+
+			return
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+// run performs a single reconciliation pass unless another replica is running one.
+func (w *Worker) run(ctx context.Context) {
+	to := time.Now().Add(-w.lag)
+
+	var report reconciliation.Report
+	ran, err := w.locker.TryWithLock(ctx, lockKey, func(ctx context.Context) error {
+		var err error
+		report, err = w.reconciler.Reconcile(ctx, reconciliation.ReconcileModel{
+			From:      to.Add(-w.window),
+			To:        to,
+			Republish: w.republish,
+		})
+
+		return err
+	})
+	if err != nil {
+		slog.Error("Reconciliation failed", "error", err)
+
+		return
+	}
+	if !ran {
+		slog.Info("Reconciliation pass skipped, another replica is running one")
+
+		return
+	}
+
+	if len(report.Missing) > 0 || len(report.Extra) > 0 {
+		slog.Warn("Reconciliation found mismatches",
+			"from", report.From,
+			"to", report.To,
+			"missing", report.Missing,
+			"extra", report.Extra,
+		)
+	}
+}
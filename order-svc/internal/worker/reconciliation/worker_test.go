@@ -0,0 +1,73 @@
+package reconciliation
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/reconciliation"
+)
+
+// sharedLock is an advisory lock shared by the workers of several replicas.
+type sharedLock struct {
+	mu   sync.Mutex
+	held map[int64]bool
+}
+
+// TryWithLock runs fn unless the key is held.
+func (l *sharedLock) TryWithLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error) {
+	l.mu.Lock()
+	if l.held[key] {
+		l.mu.Unlock()
+
+		return false, nil
+	}
+	l.held[key] = true
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.held, key)
+		l.mu.Unlock()
+	}()
+
+	return true, fn(ctx)
+}
+
+// countingReconciler counts passes and runs during while a pass is in progress.
+type countingReconciler struct {
+	passes int
+	during func()
+}
+
+// Reconcile records the pass.
+func (r *countingReconciler) Reconcile(context.Context, reconciliation.ReconcileModel) (reconciliation.Report, error) {
+	r.passes++
+	if r.during != nil {
+		r.during()
+	}
+
+	return reconciliation.Report{}, nil
+}
+
+func TestPassRunsOnOneReplicaAtATime(t *testing.T) {
+	lock := &sharedLock{held: make(map[int64]bool)}
+	first := &countingReconciler{}
+	second := &countingReconciler{}
+	firstWorker := NewWorker(first, lock)
+	secondWorker := NewWorker(second, lock)
+
+	// The second replica ticks while the first one is reconciling
+	first.during = func() { secondWorker.run(context.Background()) }
+	firstWorker.run(context.Background())
+
+	if first.passes != 1 || second.passes != 0 {
+		t.Fatalf("expected only the first replica to reconcile, got %d and %d passes", first.passes, second.passes)
+	}
+
+	// The lock is released with the pass
+	secondWorker.run(context.Background())
+	if second.passes != 1 {
+		t.Fatalf("expected the second replica to reconcile once the lock is free, got %d passes", second.passes)
+	}
+}
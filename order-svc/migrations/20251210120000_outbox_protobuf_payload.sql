@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+    alter column payload type bytea using convert_to(payload::text, 'UTF8'),
+    alter column content_type set default 'application/x-protobuf',
+    add column if not exists message_id text not null default '',
+    add column if not exists message_type text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table outbox
+    drop column if exists message_type,
+    drop column if exists message_id,
+    alter column content_type set default 'application/json',
+    alter column payload type jsonb using convert_from(payload, 'UTF8')::jsonb;
+-- +goose StatementEnd
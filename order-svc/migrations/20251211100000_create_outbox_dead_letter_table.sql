@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+    add column if not exists error_history jsonb not null default '[]'::jsonb;
+
+create table if not exists outbox_dead_letter (
+    id bigserial not null primary key,
+    outbox_id bigint not null,
+    queue_name text not null,
+    exchange_name text not null default '',
+    routing_key text not null,
+    message_id text not null default '',
+    message_type text not null default '',
+    payload bytea not null,
+    content_type text not null,
+    retry_count integer not null,
+    max_retries integer not null,
+    last_error text not null default '',
+    error_history jsonb not null default '[]'::jsonb,
+    created_at timestamp with time zone not null,
+    dead_lettered_at timestamp with time zone not null
+);
+
+create index if not exists idx_outbox_dead_letter_dead_lettered_at on outbox_dead_letter (dead_lettered_at);
+
+-- Messages that already exhausted their retries were never selected again, move them out
+with moved as (
+    delete from outbox
+    where retry_count >= max_retries
+    returning *
+)
+insert into outbox_dead_letter (
+    outbox_id, queue_name, exchange_name, routing_key, message_id, message_type, payload,
+    content_type, retry_count, max_retries, last_error, error_history, created_at, dead_lettered_at
+)
+select id, queue_name, exchange_name, routing_key, message_id, message_type, payload,
+       content_type, retry_count, max_retries, coalesce(last_error, ''), error_history, created_at, now()
+from moved;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Dead letters go back to the outbox as messages with exhausted retries, as they were kept before
+insert into outbox (
+    queue_name, exchange_name, routing_key, message_id, message_type, payload, content_type,
+    retry_count, max_retries, last_error, created_at, updated_at, next_retry_at
+)
+select queue_name, exchange_name, routing_key, message_id, message_type, payload, content_type,
+       greatest(retry_count, max_retries), max_retries, nullif(last_error, ''), created_at, dead_lettered_at, dead_lettered_at
+from outbox_dead_letter
+order by id;
+
+drop table if exists outbox_dead_letter;
+alter table outbox
+    drop column if exists error_history;
+-- +goose StatementEnd
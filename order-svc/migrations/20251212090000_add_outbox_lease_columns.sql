@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+    add column if not exists locked_by text,
+    add column if not exists locked_until timestamp with time zone;
+
+create index if not exists idx_outbox_locked_by on outbox (locked_by) where locked_by is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_outbox_locked_by;
+alter table outbox
+    drop column if exists locked_until,
+    drop column if exists locked_by;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+create or replace function notify_outbox_insert() returns trigger as $$
+begin
+    perform pg_notify('outbox_inserted', '');
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger trg_outbox_notify_insert
+    after insert on outbox
+    for each statement
+    execute function notify_outbox_insert();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists trg_outbox_notify_insert on outbox;
+drop function if exists notify_outbox_insert();
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+    add column if not exists aggregate_id text not null default '',
+    add column if not exists sequence bigint not null default 0;
+
+create index if not exists idx_outbox_aggregate_sequence on outbox (aggregate_id, sequence) where aggregate_id <> '';
+
+alter table outbox_dead_letter
+    add column if not exists aggregate_id text not null default '',
+    add column if not exists sequence bigint not null default 0;
+
+create table if not exists outbox_aggregate_sequence (
+    aggregate_id text not null primary key,
+    last_sequence bigint not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists outbox_aggregate_sequence;
+alter table outbox_dead_letter
+    drop column if exists sequence,
+    drop column if exists aggregate_id;
+drop index if exists idx_outbox_aggregate_sequence;
+alter table outbox
+    drop column if exists sequence,
+    drop column if exists aggregate_id;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+    add column if not exists published_at timestamp with time zone;
+
+drop index if exists idx_outbox_next_retry;
+create index if not exists idx_outbox_next_retry on outbox (next_retry_at)
+    where published_at is null and retry_count < max_retries;
+
+drop index if exists idx_outbox_aggregate_sequence;
+create index if not exists idx_outbox_aggregate_sequence on outbox (aggregate_id, sequence)
+    where published_at is null and aggregate_id <> '';
+
+create index if not exists idx_outbox_published_at on outbox (published_at) where published_at is not null;
+
+-- Published rows are kept for history, only pending inserts need to wake the worker
+drop trigger if exists trg_outbox_notify_insert on outbox;
+create trigger trg_outbox_notify_insert
+    after insert on outbox
+    for each row
+    when (new.published_at is null)
+    execute function notify_outbox_insert();
+
+-- Partitions are created monthly by the retention worker
+create table if not exists outbox_archive (
+    id bigint not null,
+    queue_name text not null,
+    exchange_name text not null,
+    routing_key text not null,
+    message_id text not null,
+    message_type text not null,
+    aggregate_id text not null,
+    sequence bigint not null,
+    payload bytea not null,
+    content_type text not null,
+    retry_count integer not null,
+    error_history jsonb not null,
+    created_at timestamp with time zone not null,
+    published_at timestamp with time zone not null,
+    archived_at timestamp with time zone not null,
+    primary key (id, published_at)
+) partition by range (published_at);
+
+create index if not exists idx_outbox_archive_aggregate on outbox_archive (aggregate_id, sequence);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists outbox_archive;
+
+drop trigger if exists trg_outbox_notify_insert on outbox;
+create trigger trg_outbox_notify_insert
+    after insert on outbox
+    for each statement
+    execute function notify_outbox_insert();
+
+drop index if exists idx_outbox_published_at;
+drop index if exists idx_outbox_aggregate_sequence;
+create index if not exists idx_outbox_aggregate_sequence on outbox (aggregate_id, sequence) where aggregate_id <> '';
+drop index if exists idx_outbox_next_retry;
+create index if not exists idx_outbox_next_retry on outbox (next_retry_at) where retry_count < max_retries;
+
+delete from outbox where published_at is not null;
+alter table outbox
+    drop column if exists published_at;
+-- +goose StatementEnd
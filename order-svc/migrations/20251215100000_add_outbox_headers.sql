@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Message headers such as the W3C trace context, published together with the payload
+alter table outbox
+    add column if not exists headers jsonb not null default '{}'::jsonb;
+
+alter table outbox_dead_letter
+    add column if not exists headers jsonb not null default '{}'::jsonb;
+
+alter table outbox_archive
+    add column if not exists headers jsonb not null default '{}'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table outbox_archive
+    drop column if exists headers;
+
+alter table outbox_dead_letter
+    drop column if exists headers;
+
+alter table outbox
+    drop column if exists headers;
+-- +goose StatementEnd
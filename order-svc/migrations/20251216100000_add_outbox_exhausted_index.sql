@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Exhausted rows waiting to be moved to the dead letter table by the outbox worker
+create index if not exists idx_outbox_exhausted on outbox (id)
+    where published_at is null and retry_count >= max_retries;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_outbox_exhausted;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Audit logs sent by the audit consumer when it uses the order service as an audit sink.
+-- With the grpc sink this is the audit trail, without the hash chains, partitions and archives
+-- of the audit database. With the fanout sink it is a copy of the audit database
+create table if not exists audit_log_order
+(
+    id             bigserial                not null primary key,
+    order_id       bigint                   not null,
+    order_item_id  bigint                   not null,
+    customer_id    bigint                   not null,
+    order_status   text                     not null,
+    event_type     text                     not null default '',
+    actor_id       text                     not null default '',
+    actor_type     text                     not null default '',
+    source_service text                     not null default '',
+    request_id     text                     not null default '',
+    changes        jsonb                    not null default '{}'::jsonb,
+    message_id     text                     not null,
+    created_at     timestamp with time zone not null,
+    updated_at     timestamp with time zone not null
+);
+
+create index if not exists idx_audit_log_order_order_id on audit_log_order (order_id);
+
+-- Broker messages whose audit logs were saved, redelivered messages are dropped
+create table if not exists audit_log_processed_message
+(
+    message_id   text                     not null primary key,
+    processed_at timestamp with time zone not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists audit_log_processed_message;
+drop table if exists audit_log_order;
+-- +goose StatementEnd
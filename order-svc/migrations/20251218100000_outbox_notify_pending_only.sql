@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Notifies once per insert statement and only when it inserted a pending row,
+-- rows written already published for history never wake the worker
+create or replace function notify_outbox_insert() returns trigger as $$
+begin
+    if exists (select 1 from inserted_outbox where published_at is null) then
+        perform pg_notify('outbox_inserted', '');
+    end if;
+    return null;
+end;
+$$ language plpgsql;
+
+drop trigger if exists trg_outbox_notify_insert on outbox;
+create trigger trg_outbox_notify_insert
+    after insert on outbox
+    referencing new table as inserted_outbox
+    for each statement
+    execute function notify_outbox_insert();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+create or replace function notify_outbox_insert() returns trigger as $$
+begin
+    perform pg_notify('outbox_inserted', '');
+    return null;
+end;
+$$ language plpgsql;
+
+drop trigger if exists trg_outbox_notify_insert on outbox;
+create trigger trg_outbox_notify_insert
+    after insert on outbox
+    for each row
+    when (new.published_at is null)
+    execute function notify_outbox_insert();
+-- +goose StatementEnd
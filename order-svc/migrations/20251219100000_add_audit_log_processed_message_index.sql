@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Processed messages older than the retention are deleted by the processed messages worker
+create index if not exists idx_audit_log_processed_message_processed_at on audit_log_processed_message (processed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_audit_log_processed_message_processed_at;
+-- +goose StatementEnd
@@ -0,0 +1,942 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: v1/admin.proto
+
+package v1
+
+import (
+	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Messages
+type DeadLetterError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attempt       int32                  `protobuf:"varint,1,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetterError) Reset() {
+	*x = DeadLetterError{}
+	mi := &file_v1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetterError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetterError) ProtoMessage() {}
+
+func (x *DeadLetterError) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetterError.ProtoReflect.Descriptor instead.
+func (*DeadLetterError) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DeadLetterError) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *DeadLetterError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DeadLetterError) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type DeadLetter struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OutboxId       int64                  `protobuf:"varint,2,opt,name=outbox_id,json=outboxId,proto3" json:"outbox_id,omitempty"`
+	QueueName      string                 `protobuf:"bytes,3,opt,name=queue_name,json=queueName,proto3" json:"queue_name,omitempty"`
+	ExchangeName   string                 `protobuf:"bytes,4,opt,name=exchange_name,json=exchangeName,proto3" json:"exchange_name,omitempty"`
+	RoutingKey     string                 `protobuf:"bytes,5,opt,name=routing_key,json=routingKey,proto3" json:"routing_key,omitempty"`
+	MessageId      string                 `protobuf:"bytes,6,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	MessageType    string                 `protobuf:"bytes,7,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	ContentType    string                 `protobuf:"bytes,8,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Payload        []byte                 `protobuf:"bytes,9,opt,name=payload,proto3" json:"payload,omitempty"`
+	RetryCount     int32                  `protobuf:"varint,10,opt,name=retry_count,json=retryCount,proto3" json:"retry_count,omitempty"`
+	MaxRetries     int32                  `protobuf:"varint,11,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	LastError      string                 `protobuf:"bytes,12,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	ErrorHistory   []*DeadLetterError     `protobuf:"bytes,13,rep,name=error_history,json=errorHistory,proto3" json:"error_history,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	DeadLetteredAt *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=dead_lettered_at,json=deadLetteredAt,proto3" json:"dead_lettered_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_v1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeadLetter) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetOutboxId() int64 {
+	if x != nil {
+		return x.OutboxId
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetQueueName() string {
+	if x != nil {
+		return x.QueueName
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetExchangeName() string {
+	if x != nil {
+		return x.ExchangeName
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetRoutingKey() string {
+	if x != nil {
+		return x.RoutingKey
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetRetryCount() int32 {
+	if x != nil {
+		return x.RetryCount
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetMaxRetries() int32 {
+	if x != nil {
+		return x.MaxRetries
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetErrorHistory() []*DeadLetterError {
+	if x != nil {
+		return x.ErrorHistory
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetDeadLetteredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeadLetteredAt
+	}
+	return nil
+}
+
+type ListDeadLettersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersRequest) Reset() {
+	*x = ListDeadLettersRequest{}
+	mi := &file_v1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersRequest) ProtoMessage() {}
+
+func (x *ListDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListDeadLettersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListDeadLettersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListDeadLettersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetters   []*DeadLetter          `protobuf:"bytes,1,rep,name=dead_letters,json=deadLetters,proto3" json:"dead_letters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersResponse) Reset() {
+	*x = ListDeadLettersResponse{}
+	mi := &file_v1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersResponse) ProtoMessage() {}
+
+func (x *ListDeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListDeadLettersResponse) GetDeadLetters() []*DeadLetter {
+	if x != nil {
+		return x.DeadLetters
+	}
+	return nil
+}
+
+type GetDeadLetterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterRequest) Reset() {
+	*x = GetDeadLetterRequest{}
+	mi := &file_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterRequest) ProtoMessage() {}
+
+func (x *GetDeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetDeadLetterRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetDeadLetterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeadLetter    *DeadLetter            `protobuf:"bytes,1,opt,name=dead_letter,json=deadLetter,proto3" json:"dead_letter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDeadLetterResponse) Reset() {
+	*x = GetDeadLetterResponse{}
+	mi := &file_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeadLetterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeadLetterResponse) ProtoMessage() {}
+
+func (x *GetDeadLetterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeadLetterResponse.ProtoReflect.Descriptor instead.
+func (*GetDeadLetterResponse) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetDeadLetterResponse) GetDeadLetter() *DeadLetter {
+	if x != nil {
+		return x.DeadLetter
+	}
+	return nil
+}
+
+type RequeueDeadLetterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequeueDeadLetterRequest) Reset() {
+	*x = RequeueDeadLetterRequest{}
+	mi := &file_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequeueDeadLetterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequeueDeadLetterRequest) ProtoMessage() {}
+
+func (x *RequeueDeadLetterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequeueDeadLetterRequest.ProtoReflect.Descriptor instead.
+func (*RequeueDeadLetterRequest) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RequeueDeadLetterRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type RequeueDeadLetterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OutboxId      int64                  `protobuf:"varint,1,opt,name=outbox_id,json=outboxId,proto3" json:"outbox_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequeueDeadLetterResponse) Reset() {
+	*x = RequeueDeadLetterResponse{}
+	mi := &file_v1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequeueDeadLetterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequeueDeadLetterResponse) ProtoMessage() {}
+
+func (x *RequeueDeadLetterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequeueDeadLetterResponse.ProtoReflect.Descriptor instead.
+func (*RequeueDeadLetterResponse) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RequeueDeadLetterResponse) GetOutboxId() int64 {
+	if x != nil {
+		return x.OutboxId
+	}
+	return 0
+}
+
+type PurgeDeadLettersRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Ids                []int64                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	DeadLetteredBefore *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=dead_lettered_before,json=deadLetteredBefore,proto3" json:"dead_lettered_before,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PurgeDeadLettersRequest) Reset() {
+	*x = PurgeDeadLettersRequest{}
+	mi := &file_v1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeadLettersRequest) ProtoMessage() {}
+
+func (x *PurgeDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*PurgeDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PurgeDeadLettersRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *PurgeDeadLettersRequest) GetDeadLetteredBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeadLetteredBefore
+	}
+	return nil
+}
+
+type PurgeDeadLettersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PurgedCount   int64                  `protobuf:"varint,1,opt,name=purged_count,json=purgedCount,proto3" json:"purged_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeDeadLettersResponse) Reset() {
+	*x = PurgeDeadLettersResponse{}
+	mi := &file_v1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeadLettersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeadLettersResponse) ProtoMessage() {}
+
+func (x *PurgeDeadLettersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeadLettersResponse.ProtoReflect.Descriptor instead.
+func (*PurgeDeadLettersResponse) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PurgeDeadLettersResponse) GetPurgedCount() int64 {
+	if x != nil {
+		return x.PurgedCount
+	}
+	return 0
+}
+
+type ReplayOrderEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromId        int64                  `protobuf:"varint,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId          int64                  `protobuf:"varint,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	RoutingKey    string                 `protobuf:"bytes,5,opt,name=routing_key,json=routingKey,proto3" json:"routing_key,omitempty"`
+	RatePerSecond float64                `protobuf:"fixed64,6,opt,name=rate_per_second,json=ratePerSecond,proto3" json:"rate_per_second,omitempty"`
+	BatchSize     int32                  `protobuf:"varint,7,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	DryRun        bool                   `protobuf:"varint,8,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayOrderEventsRequest) Reset() {
+	*x = ReplayOrderEventsRequest{}
+	mi := &file_v1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayOrderEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayOrderEventsRequest) ProtoMessage() {}
+
+func (x *ReplayOrderEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayOrderEventsRequest.ProtoReflect.Descriptor instead.
+func (*ReplayOrderEventsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReplayOrderEventsRequest) GetFromId() int64 {
+	if x != nil {
+		return x.FromId
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsRequest) GetToId() int64 {
+	if x != nil {
+		return x.ToId
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ReplayOrderEventsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ReplayOrderEventsRequest) GetRoutingKey() string {
+	if x != nil {
+		return x.RoutingKey
+	}
+	return ""
+}
+
+func (x *ReplayOrderEventsRequest) GetRatePerSecond() float64 {
+	if x != nil {
+		return x.RatePerSecond
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsRequest) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ReplayOrderEventsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrdersScanned  int64                  `protobuf:"varint,1,opt,name=orders_scanned,json=ordersScanned,proto3" json:"orders_scanned,omitempty"`
+	EventsEnqueued int64                  `protobuf:"varint,2,opt,name=events_enqueued,json=eventsEnqueued,proto3" json:"events_enqueued,omitempty"`
+	LastOrderId    int64                  `protobuf:"varint,3,opt,name=last_order_id,json=lastOrderId,proto3" json:"last_order_id,omitempty"`
+	DryRun         bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReplayOrderEventsResponse) Reset() {
+	*x = ReplayOrderEventsResponse{}
+	mi := &file_v1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayOrderEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayOrderEventsResponse) ProtoMessage() {}
+
+func (x *ReplayOrderEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayOrderEventsResponse.ProtoReflect.Descriptor instead.
+func (*ReplayOrderEventsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReplayOrderEventsResponse) GetOrdersScanned() int64 {
+	if x != nil {
+		return x.OrdersScanned
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsResponse) GetEventsEnqueued() int64 {
+	if x != nil {
+		return x.EventsEnqueued
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsResponse) GetLastOrderId() int64 {
+	if x != nil {
+		return x.LastOrderId
+	}
+	return 0
+}
+
+func (x *ReplayOrderEventsResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+var File_v1_admin_proto protoreflect.FileDescriptor
+
+const file_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x0ev1/admin.proto\x12\x06api.v1\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"~\n" +
+	"\x0fDeadLetterError\x12\x18\n" +
+	"\aattempt\x18\x01 \x01(\x05R\aattempt\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12;\n" +
+	"\voccurred_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"\xbd\x04\n" +
+	"\n" +
+	"DeadLetter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\toutbox_id\x18\x02 \x01(\x03R\boutboxId\x12\x1d\n" +
+	"\n" +
+	"queue_name\x18\x03 \x01(\tR\tqueueName\x12#\n" +
+	"\rexchange_name\x18\x04 \x01(\tR\fexchangeName\x12\x1f\n" +
+	"\vrouting_key\x18\x05 \x01(\tR\n" +
+	"routingKey\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x06 \x01(\tR\tmessageId\x12!\n" +
+	"\fmessage_type\x18\a \x01(\tR\vmessageType\x12!\n" +
+	"\fcontent_type\x18\b \x01(\tR\vcontentType\x12\x18\n" +
+	"\apayload\x18\t \x01(\fR\apayload\x12\x1f\n" +
+	"\vretry_count\x18\n" +
+	" \x01(\x05R\n" +
+	"retryCount\x12\x1f\n" +
+	"\vmax_retries\x18\v \x01(\x05R\n" +
+	"maxRetries\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\f \x01(\tR\tlastError\x12<\n" +
+	"\rerror_history\x18\r \x03(\v2\x17.api.v1.DeadLetterErrorR\ferrorHistory\x129\n" +
+	"\n" +
+	"created_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12D\n" +
+	"\x10dead_lettered_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\x0edeadLetteredAt\"F\n" +
+	"\x16ListDeadLettersRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"P\n" +
+	"\x17ListDeadLettersResponse\x125\n" +
+	"\fdead_letters\x18\x01 \x03(\v2\x12.api.v1.DeadLetterR\vdeadLetters\"&\n" +
+	"\x14GetDeadLetterRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"L\n" +
+	"\x15GetDeadLetterResponse\x123\n" +
+	"\vdead_letter\x18\x01 \x01(\v2\x12.api.v1.DeadLetterR\n" +
+	"deadLetter\"*\n" +
+	"\x18RequeueDeadLetterRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"8\n" +
+	"\x19RequeueDeadLetterResponse\x12\x1b\n" +
+	"\toutbox_id\x18\x01 \x01(\x03R\boutboxId\"y\n" +
+	"\x17PurgeDeadLettersRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\x12L\n" +
+	"\x14dead_lettered_before\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x12deadLetteredBefore\"=\n" +
+	"\x18PurgeDeadLettersResponse\x12!\n" +
+	"\fpurged_count\x18\x01 \x01(\x03R\vpurgedCount\"\xc3\x02\n" +
+	"\x18ReplayOrderEventsRequest\x12\x17\n" +
+	"\afrom_id\x18\x01 \x01(\x03R\x06fromId\x12\x13\n" +
+	"\x05to_id\x18\x02 \x01(\x03R\x04toId\x12=\n" +
+	"\fcreated_from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x1f\n" +
+	"\vrouting_key\x18\x05 \x01(\tR\n" +
+	"routingKey\x12&\n" +
+	"\x0frate_per_second\x18\x06 \x01(\x01R\rratePerSecond\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\a \x01(\x05R\tbatchSize\x12\x17\n" +
+	"\adry_run\x18\b \x01(\bR\x06dryRun\"\xa8\x01\n" +
+	"\x19ReplayOrderEventsResponse\x12%\n" +
+	"\x0eorders_scanned\x18\x01 \x01(\x03R\rordersScanned\x12'\n" +
+	"\x0fevents_enqueued\x18\x02 \x01(\x03R\x0eeventsEnqueued\x12\"\n" +
+	"\rlast_order_id\x18\x03 \x01(\x03R\vlastOrderId\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun2\xe6\t\n" +
+	"\fAdminService\x12\xe1\x01\n" +
+	"\x0fListDeadLetters\x12\x1e.api.v1.ListDeadLettersRequest\x1a\x1f.api.v1.ListDeadLettersResponse\"\x8c\x01\x92AR\n" +
+	"\x05Admin\x12\x11List dead letters\x1a6Retrieves outbox messages that exhausted their retries\x82\xd3\xe4\x93\x021\x12//api/order-service/v1/admin/outbox/dead-letters\x12\xe7\x01\n" +
+	"\rGetDeadLetter\x12\x1c.api.v1.GetDeadLetterRequest\x1a\x1d.api.v1.GetDeadLetterResponse\"\x98\x01\x92AY\n" +
+	"\x05Admin\x12\x0fGet dead letter\x1a?Retrieves a dead letter with its payload and full error history\x82\xd3\xe4\x93\x026\x124/api/order-service/v1/admin/outbox/dead-letters/{id}\x12\x83\x02\n" +
+	"\x11RequeueDeadLetter\x12 .api.v1.RequeueDeadLetterRequest\x1a!.api.v1.RequeueDeadLetterResponse\"\xa8\x01\x92A^\n" +
+	"\x05Admin\x12\x13Requeue dead letter\x1a@Moves a dead letter back to the outbox with a fresh retry budget\x82\xd3\xe4\x93\x02A:\x01*\"</api/order-service/v1/admin/outbox/dead-letters/{id}/requeue\x12\xf5\x01\n" +
+	"\x10PurgeDeadLetters\x12\x1f.api.v1.PurgeDeadLettersRequest\x1a .api.v1.PurgeDeadLettersResponse\"\x9d\x01\x92Ac\n" +
+	"\x05Admin\x12\x12Purge dead letters\x1aFDeletes dead letters by IDs and/or dead-lettered before the given time\x82\xd3\xe4\x93\x021*//api/order-service/v1/admin/outbox/dead-letters\x12\x89\x02\n" +
+	"\x11ReplayOrderEvents\x12 .api.v1.ReplayOrderEventsRequest\x1a!.api.v1.ReplayOrderEventsResponse\"\xae\x01\x92A~\n" +
+	"\x05Admin\x12\x13Replay order events\x1a`Regenerates order.created events for orders in the ID and/or creation time range into the outbox\x82\xd3\xe4\x93\x02':\x01*\"\"/api/order-service/v1/admin/replayB\xc7\x01\x92A\x9c\x01\x12b\n" +
+	"\x0fOrder Admin API\x12 Order Service administrative API\"(\n" +
+	"\vMark Anikin\x1a\x19mark.corray.off@gmail.com2\x031.0\x1a\x0elocalhost:3001*\x02\x01\x022\x10application/json:\x10application/jsonZ%github.com/yourorg/yourproject/api/v1b\x06proto3"
+
+var (
+	file_v1_admin_proto_rawDescOnce sync.Once
+	file_v1_admin_proto_rawDescData []byte
+)
+
+func file_v1_admin_proto_rawDescGZIP() []byte {
+	file_v1_admin_proto_rawDescOnce.Do(func() {
+		file_v1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_v1_admin_proto_rawDesc), len(file_v1_admin_proto_rawDesc)))
+	})
+	return file_v1_admin_proto_rawDescData
+}
+
+var file_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_v1_admin_proto_goTypes = []any{
+	(*DeadLetterError)(nil),           // 0: api.v1.DeadLetterError
+	(*DeadLetter)(nil),                // 1: api.v1.DeadLetter
+	(*ListDeadLettersRequest)(nil),    // 2: api.v1.ListDeadLettersRequest
+	(*ListDeadLettersResponse)(nil),   // 3: api.v1.ListDeadLettersResponse
+	(*GetDeadLetterRequest)(nil),      // 4: api.v1.GetDeadLetterRequest
+	(*GetDeadLetterResponse)(nil),     // 5: api.v1.GetDeadLetterResponse
+	(*RequeueDeadLetterRequest)(nil),  // 6: api.v1.RequeueDeadLetterRequest
+	(*RequeueDeadLetterResponse)(nil), // 7: api.v1.RequeueDeadLetterResponse
+	(*PurgeDeadLettersRequest)(nil),   // 8: api.v1.PurgeDeadLettersRequest
+	(*PurgeDeadLettersResponse)(nil),  // 9: api.v1.PurgeDeadLettersResponse
+	(*ReplayOrderEventsRequest)(nil),  // 10: api.v1.ReplayOrderEventsRequest
+	(*ReplayOrderEventsResponse)(nil), // 11: api.v1.ReplayOrderEventsResponse
+	(*timestamppb.Timestamp)(nil),     // 12: google.protobuf.Timestamp
+}
+var file_v1_admin_proto_depIdxs = []int32{
+	12, // 0: api.v1.DeadLetterError.occurred_at:type_name -> google.protobuf.Timestamp
+	0,  // 1: api.v1.DeadLetter.error_history:type_name -> api.v1.DeadLetterError
+	12, // 2: api.v1.DeadLetter.created_at:type_name -> google.protobuf.Timestamp
+	12, // 3: api.v1.DeadLetter.dead_lettered_at:type_name -> google.protobuf.Timestamp
+	1,  // 4: api.v1.ListDeadLettersResponse.dead_letters:type_name -> api.v1.DeadLetter
+	1,  // 5: api.v1.GetDeadLetterResponse.dead_letter:type_name -> api.v1.DeadLetter
+	12, // 6: api.v1.PurgeDeadLettersRequest.dead_lettered_before:type_name -> google.protobuf.Timestamp
+	12, // 7: api.v1.ReplayOrderEventsRequest.created_from:type_name -> google.protobuf.Timestamp
+	12, // 8: api.v1.ReplayOrderEventsRequest.created_to:type_name -> google.protobuf.Timestamp
+	2,  // 9: api.v1.AdminService.ListDeadLetters:input_type -> api.v1.ListDeadLettersRequest
+	4,  // 10: api.v1.AdminService.GetDeadLetter:input_type -> api.v1.GetDeadLetterRequest
+	6,  // 11: api.v1.AdminService.RequeueDeadLetter:input_type -> api.v1.RequeueDeadLetterRequest
+	8,  // 12: api.v1.AdminService.PurgeDeadLetters:input_type -> api.v1.PurgeDeadLettersRequest
+	10, // 13: api.v1.AdminService.ReplayOrderEvents:input_type -> api.v1.ReplayOrderEventsRequest
+	3,  // 14: api.v1.AdminService.ListDeadLetters:output_type -> api.v1.ListDeadLettersResponse
+	5,  // 15: api.v1.AdminService.GetDeadLetter:output_type -> api.v1.GetDeadLetterResponse
+	7,  // 16: api.v1.AdminService.RequeueDeadLetter:output_type -> api.v1.RequeueDeadLetterResponse
+	9,  // 17: api.v1.AdminService.PurgeDeadLetters:output_type -> api.v1.PurgeDeadLettersResponse
+	11, // 18: api.v1.AdminService.ReplayOrderEvents:output_type -> api.v1.ReplayOrderEventsResponse
+	14, // [14:19] is the sub-list for method output_type
+	9,  // [9:14] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_v1_admin_proto_init() }
+func file_v1_admin_proto_init() {
+	if File_v1_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_admin_proto_rawDesc), len(file_v1_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_v1_admin_proto_goTypes,
+		DependencyIndexes: file_v1_admin_proto_depIdxs,
+		MessageInfos:      file_v1_admin_proto_msgTypes,
+	}.Build()
+	File_v1_admin_proto = out.File
+	file_v1_admin_proto_goTypes = nil
+	file_v1_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,1123 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: v1/audit.proto
+
+package v1
+
+import (
+	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Messages
+type AuditLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId       int64                  `protobuf:"varint,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	OrderItemId   int64                  `protobuf:"varint,3,opt,name=order_item_id,json=orderItemId,proto3" json:"order_item_id,omitempty"`
+	CustomerId    int64                  `protobuf:"varint,4,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	OrderStatus   string                 `protobuf:"bytes,5,opt,name=order_status,json=orderStatus,proto3" json:"order_status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	EventType     string                 `protobuf:"bytes,8,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	ActorId       string                 `protobuf:"bytes,9,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	ActorType     string                 `protobuf:"bytes,10,opt,name=actor_type,json=actorType,proto3" json:"actor_type,omitempty"`
+	SourceService string                 `protobuf:"bytes,11,opt,name=source_service,json=sourceService,proto3" json:"source_service,omitempty"`
+	RequestId     string                 `protobuf:"bytes,12,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,13,rep,name=changes,proto3" json:"changes,omitempty"`
+	// Hash chain the audit log is linked into, empty for audit logs saved before chaining
+	ChainId       string `protobuf:"bytes,14,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	PrevHash      string `protobuf:"bytes,15,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Hash          string `protobuf:"bytes,16,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditLog) Reset() {
+	*x = AuditLog{}
+	mi := &file_v1_audit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditLog) ProtoMessage() {}
+
+func (x *AuditLog) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditLog.ProtoReflect.Descriptor instead.
+func (*AuditLog) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AuditLog) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditLog) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *AuditLog) GetOrderItemId() int64 {
+	if x != nil {
+		return x.OrderItemId
+	}
+	return 0
+}
+
+func (x *AuditLog) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *AuditLog) GetOrderStatus() string {
+	if x != nil {
+		return x.OrderStatus
+	}
+	return ""
+}
+
+func (x *AuditLog) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *AuditLog) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *AuditLog) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *AuditLog) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *AuditLog) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *AuditLog) GetSourceService() string {
+	if x != nil {
+		return x.SourceService
+	}
+	return ""
+}
+
+func (x *AuditLog) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *AuditLog) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *AuditLog) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *AuditLog) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *AuditLog) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type ListAuditLogsRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	OrderId     int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId  int64                  `protobuf:"varint,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	OrderStatus string                 `protobuf:"bytes,3,opt,name=order_status,json=orderStatus,proto3" json:"order_status,omitempty"`
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	PageSize    int32                  `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token of the next page returned by the previous call, empty for the first page
+	PageToken     string `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditLogsRequest) Reset() {
+	*x = ListAuditLogsRequest{}
+	mi := &file_v1_audit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditLogsRequest) ProtoMessage() {}
+
+func (x *ListAuditLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditLogsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditLogsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListAuditLogsRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *ListAuditLogsRequest) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *ListAuditLogsRequest) GetOrderStatus() string {
+	if x != nil {
+		return x.OrderStatus
+	}
+	return ""
+}
+
+func (x *ListAuditLogsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListAuditLogsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListAuditLogsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuditLogsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAuditLogsResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AuditLogs []*AuditLog            `protobuf:"bytes,1,rep,name=audit_logs,json=auditLogs,proto3" json:"audit_logs,omitempty"`
+	// Empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditLogsResponse) Reset() {
+	*x = ListAuditLogsResponse{}
+	mi := &file_v1_audit_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditLogsResponse) ProtoMessage() {}
+
+func (x *ListAuditLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditLogsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditLogsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListAuditLogsResponse) GetAuditLogs() []*AuditLog {
+	if x != nil {
+		return x.AuditLogs
+	}
+	return nil
+}
+
+func (x *ListAuditLogsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetOrderTimelineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderTimelineRequest) Reset() {
+	*x = GetOrderTimelineRequest{}
+	mi := &file_v1_audit_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderTimelineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderTimelineRequest) ProtoMessage() {}
+
+func (x *GetOrderTimelineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderTimelineRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderTimelineRequest) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetOrderTimelineRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+type OrderTimelineEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderStatus   string                 `protobuf:"bytes,1,opt,name=order_status,json=orderStatus,proto3" json:"order_status,omitempty"`
+	OrderItemIds  []int64                `protobuf:"varint,2,rep,packed,name=order_item_ids,json=orderItemIds,proto3" json:"order_item_ids,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderTimelineEntry) Reset() {
+	*x = OrderTimelineEntry{}
+	mi := &file_v1_audit_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderTimelineEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderTimelineEntry) ProtoMessage() {}
+
+func (x *OrderTimelineEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderTimelineEntry.ProtoReflect.Descriptor instead.
+func (*OrderTimelineEntry) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderTimelineEntry) GetOrderStatus() string {
+	if x != nil {
+		return x.OrderStatus
+	}
+	return ""
+}
+
+func (x *OrderTimelineEntry) GetOrderItemIds() []int64 {
+	if x != nil {
+		return x.OrderItemIds
+	}
+	return nil
+}
+
+func (x *OrderTimelineEntry) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type GetOrderTimelineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId    int64                  `protobuf:"varint,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	Entries       []*OrderTimelineEntry  `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderTimelineResponse) Reset() {
+	*x = GetOrderTimelineResponse{}
+	mi := &file_v1_audit_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderTimelineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderTimelineResponse) ProtoMessage() {}
+
+func (x *GetOrderTimelineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderTimelineResponse.ProtoReflect.Descriptor instead.
+func (*GetOrderTimelineResponse) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetOrderTimelineResponse) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *GetOrderTimelineResponse) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *GetOrderTimelineResponse) GetEntries() []*OrderTimelineEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type VerifyAuditChainRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chain to verify, "global" or "customer:<id>", empty verifies all chains
+	ChainId       string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyAuditChainRequest) Reset() {
+	*x = VerifyAuditChainRequest{}
+	mi := &file_v1_audit_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyAuditChainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyAuditChainRequest) ProtoMessage() {}
+
+func (x *VerifyAuditChainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyAuditChainRequest.ProtoReflect.Descriptor instead.
+func (*VerifyAuditChainRequest) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VerifyAuditChainRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+type AuditChainBrokenLink struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AuditLogId int64                  `protobuf:"varint,1,opt,name=audit_log_id,json=auditLogId,proto3" json:"audit_log_id,omitempty"`
+	// prev_hash_mismatch, hash_mismatch, head_mismatch, checkpoint_mismatch or checkpoint_signature
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Expected      string `protobuf:"bytes,3,opt,name=expected,proto3" json:"expected,omitempty"`
+	Actual        string `protobuf:"bytes,4,opt,name=actual,proto3" json:"actual,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditChainBrokenLink) Reset() {
+	*x = AuditChainBrokenLink{}
+	mi := &file_v1_audit_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditChainBrokenLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditChainBrokenLink) ProtoMessage() {}
+
+func (x *AuditChainBrokenLink) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditChainBrokenLink.ProtoReflect.Descriptor instead.
+func (*AuditChainBrokenLink) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AuditChainBrokenLink) GetAuditLogId() int64 {
+	if x != nil {
+		return x.AuditLogId
+	}
+	return 0
+}
+
+func (x *AuditChainBrokenLink) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AuditChainBrokenLink) GetExpected() string {
+	if x != nil {
+		return x.Expected
+	}
+	return ""
+}
+
+func (x *AuditChainBrokenLink) GetActual() string {
+	if x != nil {
+		return x.Actual
+	}
+	return ""
+}
+
+type AuditChainVerification struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ChainId     string                 `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	AuditLogs   int64                  `protobuf:"varint,2,opt,name=audit_logs,json=auditLogs,proto3" json:"audit_logs,omitempty"`
+	Checkpoints int64                  `protobuf:"varint,3,opt,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	// Set to the first link that does not verify
+	BrokenLink *AuditChainBrokenLink `protobuf:"bytes,4,opt,name=broken_link,json=brokenLink,proto3" json:"broken_link,omitempty"`
+	// Audit logs removed by the retention, verified by the links kept for them
+	PrunedAuditLogs int64 `protobuf:"varint,5,opt,name=pruned_audit_logs,json=prunedAuditLogs,proto3" json:"pruned_audit_logs,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AuditChainVerification) Reset() {
+	*x = AuditChainVerification{}
+	mi := &file_v1_audit_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditChainVerification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditChainVerification) ProtoMessage() {}
+
+func (x *AuditChainVerification) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditChainVerification.ProtoReflect.Descriptor instead.
+func (*AuditChainVerification) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AuditChainVerification) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *AuditChainVerification) GetAuditLogs() int64 {
+	if x != nil {
+		return x.AuditLogs
+	}
+	return 0
+}
+
+func (x *AuditChainVerification) GetCheckpoints() int64 {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return 0
+}
+
+func (x *AuditChainVerification) GetBrokenLink() *AuditChainBrokenLink {
+	if x != nil {
+		return x.BrokenLink
+	}
+	return nil
+}
+
+func (x *AuditChainVerification) GetPrunedAuditLogs() int64 {
+	if x != nil {
+		return x.PrunedAuditLogs
+	}
+	return 0
+}
+
+type VerifyAuditChainResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// True when every chain verified
+	Verified      bool                      `protobuf:"varint,1,opt,name=verified,proto3" json:"verified,omitempty"`
+	Verifications []*AuditChainVerification `protobuf:"bytes,2,rep,name=verifications,proto3" json:"verifications,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyAuditChainResponse) Reset() {
+	*x = VerifyAuditChainResponse{}
+	mi := &file_v1_audit_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyAuditChainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyAuditChainResponse) ProtoMessage() {}
+
+func (x *VerifyAuditChainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyAuditChainResponse.ProtoReflect.Descriptor instead.
+func (*VerifyAuditChainResponse) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *VerifyAuditChainResponse) GetVerified() bool {
+	if x != nil {
+		return x.Verified
+	}
+	return false
+}
+
+func (x *VerifyAuditChainResponse) GetVerifications() []*AuditChainVerification {
+	if x != nil {
+		return x.Verifications
+	}
+	return nil
+}
+
+// AuditCheckpoint is a chain head signed with ed25519 over
+// "<chain_id>\n<last_audit_log_id>\n<hash>\n<created_at RFC 3339 UTC>"
+type AuditCheckpoint struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ChainId        string                 `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	LastAuditLogId int64                  `protobuf:"varint,3,opt,name=last_audit_log_id,json=lastAuditLogId,proto3" json:"last_audit_log_id,omitempty"`
+	Hash           string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	KeyId          string                 `protobuf:"bytes,5,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	Signature      []byte                 `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AuditCheckpoint) Reset() {
+	*x = AuditCheckpoint{}
+	mi := &file_v1_audit_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditCheckpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditCheckpoint) ProtoMessage() {}
+
+func (x *AuditCheckpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditCheckpoint.ProtoReflect.Descriptor instead.
+func (*AuditCheckpoint) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AuditCheckpoint) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditCheckpoint) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *AuditCheckpoint) GetLastAuditLogId() int64 {
+	if x != nil {
+		return x.LastAuditLogId
+	}
+	return 0
+}
+
+func (x *AuditCheckpoint) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *AuditCheckpoint) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *AuditCheckpoint) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *AuditCheckpoint) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListAuditCheckpointsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty lists the checkpoints of all chains
+	ChainId       string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditCheckpointsRequest) Reset() {
+	*x = ListAuditCheckpointsRequest{}
+	mi := &file_v1_audit_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditCheckpointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditCheckpointsRequest) ProtoMessage() {}
+
+func (x *ListAuditCheckpointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditCheckpointsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditCheckpointsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListAuditCheckpointsRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+type ListAuditCheckpointsResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Checkpoints []*AuditCheckpoint     `protobuf:"bytes,1,rep,name=checkpoints,proto3" json:"checkpoints,omitempty"`
+	// ed25519 public key the checkpoints are verified with
+	PublicKey     []byte `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditCheckpointsResponse) Reset() {
+	*x = ListAuditCheckpointsResponse{}
+	mi := &file_v1_audit_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditCheckpointsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditCheckpointsResponse) ProtoMessage() {}
+
+func (x *ListAuditCheckpointsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_audit_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditCheckpointsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditCheckpointsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_audit_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListAuditCheckpointsResponse) GetCheckpoints() []*AuditCheckpoint {
+	if x != nil {
+		return x.Checkpoints
+	}
+	return nil
+}
+
+func (x *ListAuditCheckpointsResponse) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+var File_v1_audit_proto protoreflect.FileDescriptor
+
+const file_v1_audit_proto_rawDesc = "" +
+	"\n" +
+	"\x0ev1/audit.proto\x12\x06api.v1\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x0fv1/events.proto\"\xad\x04\n" +
+	"\bAuditLog\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\x03R\aorderId\x12\"\n" +
+	"\rorder_item_id\x18\x03 \x01(\x03R\vorderItemId\x12\x1f\n" +
+	"\vcustomer_id\x18\x04 \x01(\x03R\n" +
+	"customerId\x12!\n" +
+	"\forder_status\x18\x05 \x01(\tR\vorderStatus\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\b \x01(\tR\teventType\x12\x19\n" +
+	"\bactor_id\x18\t \x01(\tR\aactorId\x12\x1d\n" +
+	"\n" +
+	"actor_type\x18\n" +
+	" \x01(\tR\tactorType\x12%\n" +
+	"\x0esource_service\x18\v \x01(\tR\rsourceService\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\f \x01(\tR\trequestId\x12-\n" +
+	"\achanges\x18\r \x03(\v2\x13.api.v1.FieldChangeR\achanges\x12\x19\n" +
+	"\bchain_id\x18\x0e \x01(\tR\achainId\x12\x1b\n" +
+	"\tprev_hash\x18\x0f \x01(\tR\bprevHash\x12\x12\n" +
+	"\x04hash\x18\x10 \x01(\tR\x04hash\"\xab\x02\n" +
+	"\x14ListAuditLogsRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\x03R\n" +
+	"customerId\x12!\n" +
+	"\forder_status\x18\x03 \x01(\tR\vorderStatus\x12=\n" +
+	"\fcreated_from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x1b\n" +
+	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\a \x01(\tR\tpageToken\"p\n" +
+	"\x15ListAuditLogsResponse\x12/\n" +
+	"\n" +
+	"audit_logs\x18\x01 \x03(\v2\x10.api.v1.AuditLogR\tauditLogs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"4\n" +
+	"\x17GetOrderTimelineRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\"\x9a\x01\n" +
+	"\x12OrderTimelineEntry\x12!\n" +
+	"\forder_status\x18\x01 \x01(\tR\vorderStatus\x12$\n" +
+	"\x0eorder_item_ids\x18\x02 \x03(\x03R\forderItemIds\x12;\n" +
+	"\voccurred_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"\x8c\x01\n" +
+	"\x18GetOrderTimelineResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\x03R\n" +
+	"customerId\x124\n" +
+	"\aentries\x18\x03 \x03(\v2\x1a.api.v1.OrderTimelineEntryR\aentries\"4\n" +
+	"\x17VerifyAuditChainRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\"\x84\x01\n" +
+	"\x14AuditChainBrokenLink\x12 \n" +
+	"\faudit_log_id\x18\x01 \x01(\x03R\n" +
+	"auditLogId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1a\n" +
+	"\bexpected\x18\x03 \x01(\tR\bexpected\x12\x16\n" +
+	"\x06actual\x18\x04 \x01(\tR\x06actual\"\xdf\x01\n" +
+	"\x16AuditChainVerification\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\x12\x1d\n" +
+	"\n" +
+	"audit_logs\x18\x02 \x01(\x03R\tauditLogs\x12 \n" +
+	"\vcheckpoints\x18\x03 \x01(\x03R\vcheckpoints\x12=\n" +
+	"\vbroken_link\x18\x04 \x01(\v2\x1c.api.v1.AuditChainBrokenLinkR\n" +
+	"brokenLink\x12*\n" +
+	"\x11pruned_audit_logs\x18\x05 \x01(\x03R\x0fprunedAuditLogs\"|\n" +
+	"\x18VerifyAuditChainResponse\x12\x1a\n" +
+	"\bverified\x18\x01 \x01(\bR\bverified\x12D\n" +
+	"\rverifications\x18\x02 \x03(\v2\x1e.api.v1.AuditChainVerificationR\rverifications\"\xeb\x01\n" +
+	"\x0fAuditCheckpoint\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\bchain_id\x18\x02 \x01(\tR\achainId\x12)\n" +
+	"\x11last_audit_log_id\x18\x03 \x01(\x03R\x0elastAuditLogId\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x15\n" +
+	"\x06key_id\x18\x05 \x01(\tR\x05keyId\x12\x1c\n" +
+	"\tsignature\x18\x06 \x01(\fR\tsignature\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"8\n" +
+	"\x1bListAuditCheckpointsRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\"x\n" +
+	"\x1cListAuditCheckpointsResponse\x129\n" +
+	"\vcheckpoints\x18\x01 \x03(\v2\x17.api.v1.AuditCheckpointR\vcheckpoints\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey2\xfc\a\n" +
+	"\fAuditService\x12\xec\x01\n" +
+	"\rListAuditLogs\x12\x1c.api.v1.ListAuditLogsRequest\x1a\x1d.api.v1.ListAuditLogsResponse\"\x9d\x01\x92Ar\n" +
+	"\x05Audit\x12\x0fList audit logs\x1aXRetrieves audit logs newest first, filtered by order, customer, status and creation time\x82\xd3\xe4\x93\x02\"\x12 /api/audit-service/v1/audit-logs\x12\xf1\x01\n" +
+	"\x10GetOrderTimeline\x12\x1f.api.v1.GetOrderTimelineRequest\x1a .api.v1.GetOrderTimelineResponse\"\x99\x01\x92A^\n" +
+	"\x05Audit\x12\x12Get order timeline\x1aARetrieves the status history of an order in the order it happened\x82\xd3\xe4\x93\x022\x120/api/audit-service/v1/orders/{order_id}/timeline\x12\x82\x02\n" +
+	"\x10VerifyAuditChain\x12\x1f.api.v1.VerifyAuditChainRequest\x1a .api.v1.VerifyAuditChainResponse\"\xaa\x01\x92Av\n" +
+	"\x05Audit\x12\x13Verify audit chains\x1aXRecomputes the hash chains of audit logs and reports the first broken link of each chain\x82\xd3\xe4\x93\x02+\x12)/api/audit-service/v1/audit-chains/verify\x12\x83\x02\n" +
+	"\x14ListAuditCheckpoints\x12#.api.v1.ListAuditCheckpointsRequest\x1a$.api.v1.ListAuditCheckpointsResponse\"\x9f\x01\x92Am\n" +
+	"\x05Audit\x12\x18Export audit checkpoints\x1aJExports the signed checkpoints of audit chains with the key to verify them\x82\xd3\xe4\x93\x02)\x12'/api/audit-service/v1/audit-checkpointsB\xc4\x01\x92A\x99\x01\x12_\n" +
+	"\x0fOrder Audit API\x12\x1dOrder Audit Service query API\"(\n" +
+	"\vMark Anikin\x1a\x19mark.corray.off@gmail.com2\x031.0\x1a\x0elocalhost:3002*\x02\x01\x022\x10application/json:\x10application/jsonZ%github.com/yourorg/yourproject/api/v1b\x06proto3"
+
+var (
+	file_v1_audit_proto_rawDescOnce sync.Once
+	file_v1_audit_proto_rawDescData []byte
+)
+
+func file_v1_audit_proto_rawDescGZIP() []byte {
+	file_v1_audit_proto_rawDescOnce.Do(func() {
+		file_v1_audit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_v1_audit_proto_rawDesc), len(file_v1_audit_proto_rawDesc)))
+	})
+	return file_v1_audit_proto_rawDescData
+}
+
+var file_v1_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_v1_audit_proto_goTypes = []any{
+	(*AuditLog)(nil),                     // 0: api.v1.AuditLog
+	(*ListAuditLogsRequest)(nil),         // 1: api.v1.ListAuditLogsRequest
+	(*ListAuditLogsResponse)(nil),        // 2: api.v1.ListAuditLogsResponse
+	(*GetOrderTimelineRequest)(nil),      // 3: api.v1.GetOrderTimelineRequest
+	(*OrderTimelineEntry)(nil),           // 4: api.v1.OrderTimelineEntry
+	(*GetOrderTimelineResponse)(nil),     // 5: api.v1.GetOrderTimelineResponse
+	(*VerifyAuditChainRequest)(nil),      // 6: api.v1.VerifyAuditChainRequest
+	(*AuditChainBrokenLink)(nil),         // 7: api.v1.AuditChainBrokenLink
+	(*AuditChainVerification)(nil),       // 8: api.v1.AuditChainVerification
+	(*VerifyAuditChainResponse)(nil),     // 9: api.v1.VerifyAuditChainResponse
+	(*AuditCheckpoint)(nil),              // 10: api.v1.AuditCheckpoint
+	(*ListAuditCheckpointsRequest)(nil),  // 11: api.v1.ListAuditCheckpointsRequest
+	(*ListAuditCheckpointsResponse)(nil), // 12: api.v1.ListAuditCheckpointsResponse
+	(*timestamppb.Timestamp)(nil),        // 13: google.protobuf.Timestamp
+	(*FieldChange)(nil),                  // 14: api.v1.FieldChange
+}
+var file_v1_audit_proto_depIdxs = []int32{
+	13, // 0: api.v1.AuditLog.created_at:type_name -> google.protobuf.Timestamp
+	13, // 1: api.v1.AuditLog.updated_at:type_name -> google.protobuf.Timestamp
+	14, // 2: api.v1.AuditLog.changes:type_name -> api.v1.FieldChange
+	13, // 3: api.v1.ListAuditLogsRequest.created_from:type_name -> google.protobuf.Timestamp
+	13, // 4: api.v1.ListAuditLogsRequest.created_to:type_name -> google.protobuf.Timestamp
+	0,  // 5: api.v1.ListAuditLogsResponse.audit_logs:type_name -> api.v1.AuditLog
+	13, // 6: api.v1.OrderTimelineEntry.occurred_at:type_name -> google.protobuf.Timestamp
+	4,  // 7: api.v1.GetOrderTimelineResponse.entries:type_name -> api.v1.OrderTimelineEntry
+	7,  // 8: api.v1.AuditChainVerification.broken_link:type_name -> api.v1.AuditChainBrokenLink
+	8,  // 9: api.v1.VerifyAuditChainResponse.verifications:type_name -> api.v1.AuditChainVerification
+	13, // 10: api.v1.AuditCheckpoint.created_at:type_name -> google.protobuf.Timestamp
+	10, // 11: api.v1.ListAuditCheckpointsResponse.checkpoints:type_name -> api.v1.AuditCheckpoint
+	1,  // 12: api.v1.AuditService.ListAuditLogs:input_type -> api.v1.ListAuditLogsRequest
+	3,  // 13: api.v1.AuditService.GetOrderTimeline:input_type -> api.v1.GetOrderTimelineRequest
+	6,  // 14: api.v1.AuditService.VerifyAuditChain:input_type -> api.v1.VerifyAuditChainRequest
+	11, // 15: api.v1.AuditService.ListAuditCheckpoints:input_type -> api.v1.ListAuditCheckpointsRequest
+	2,  // 16: api.v1.AuditService.ListAuditLogs:output_type -> api.v1.ListAuditLogsResponse
+	5,  // 17: api.v1.AuditService.GetOrderTimeline:output_type -> api.v1.GetOrderTimelineResponse
+	9,  // 18: api.v1.AuditService.VerifyAuditChain:output_type -> api.v1.VerifyAuditChainResponse
+	12, // 19: api.v1.AuditService.ListAuditCheckpoints:output_type -> api.v1.ListAuditCheckpointsResponse
+	16, // [16:20] is the sub-list for method output_type
+	12, // [12:16] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_v1_audit_proto_init() }
+func file_v1_audit_proto_init() {
+	if File_v1_audit_proto != nil {
+		return
+	}
+	file_v1_events_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_audit_proto_rawDesc), len(file_v1_audit_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_v1_audit_proto_goTypes,
+		DependencyIndexes: file_v1_audit_proto_depIdxs,
+		MessageInfos:      file_v1_audit_proto_msgTypes,
+	}.Build()
+	File_v1_audit_proto = out.File
+	file_v1_audit_proto_goTypes = nil
+	file_v1_audit_proto_depIdxs = nil
+}
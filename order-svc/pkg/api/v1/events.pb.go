@@ -0,0 +1,922 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: v1/events.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Who caused an event and where it came from.
+type EventContext struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ActorId string                 `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	// e.g. "system", or "claimed:user" for an unauthenticated actor of request headers
+	ActorType     string `protobuf:"bytes,2,opt,name=actor_type,json=actorType,proto3" json:"actor_type,omitempty"`
+	SourceService string `protobuf:"bytes,3,opt,name=source_service,json=sourceService,proto3" json:"source_service,omitempty"`
+	// ID of the request the event was emitted by
+	RequestId     string `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventContext) Reset() {
+	*x = EventContext{}
+	mi := &file_v1_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventContext) ProtoMessage() {}
+
+func (x *EventContext) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventContext.ProtoReflect.Descriptor instead.
+func (*EventContext) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventContext) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *EventContext) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *EventContext) GetSourceService() string {
+	if x != nil {
+		return x.SourceService
+	}
+	return ""
+}
+
+func (x *EventContext) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// A field changed by an event, before is null for fields that were not set.
+type FieldChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Before        *structpb.Value        `protobuf:"bytes,2,opt,name=before,proto3" json:"before,omitempty"`
+	After         *structpb.Value        `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldChange) Reset() {
+	*x = FieldChange{}
+	mi := &file_v1_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldChange) ProtoMessage() {}
+
+func (x *FieldChange) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldChange.ProtoReflect.Descriptor instead.
+func (*FieldChange) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FieldChange) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldChange) GetBefore() *structpb.Value {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *FieldChange) GetAfter() *structpb.Value {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+// Snapshot of an order item at the moment the event was emitted.
+type OrderItemSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	OrderId       int64                  `protobuf:"varint,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ProductId     int64                  `protobuf:"varint,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ProductTitle  string                 `protobuf:"bytes,5,opt,name=product_title,json=productTitle,proto3" json:"product_title,omitempty"`
+	ProductUrl    string                 `protobuf:"bytes,6,opt,name=product_url,json=productUrl,proto3" json:"product_url,omitempty"`
+	PriceCents    int64                  `protobuf:"varint,7,opt,name=price_cents,json=priceCents,proto3" json:"price_cents,omitempty"`
+	PriceCurrency string                 `protobuf:"bytes,8,opt,name=price_currency,json=priceCurrency,proto3" json:"price_currency,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItemSnapshot) Reset() {
+	*x = OrderItemSnapshot{}
+	mi := &file_v1_events_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItemSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItemSnapshot) ProtoMessage() {}
+
+func (x *OrderItemSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItemSnapshot.ProtoReflect.Descriptor instead.
+func (*OrderItemSnapshot) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderItemSnapshot) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OrderItemSnapshot) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderItemSnapshot) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *OrderItemSnapshot) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *OrderItemSnapshot) GetProductTitle() string {
+	if x != nil {
+		return x.ProductTitle
+	}
+	return ""
+}
+
+func (x *OrderItemSnapshot) GetProductUrl() string {
+	if x != nil {
+		return x.ProductUrl
+	}
+	return ""
+}
+
+func (x *OrderItemSnapshot) GetPriceCents() int64 {
+	if x != nil {
+		return x.PriceCents
+	}
+	return 0
+}
+
+func (x *OrderItemSnapshot) GetPriceCurrency() string {
+	if x != nil {
+		return x.PriceCurrency
+	}
+	return ""
+}
+
+func (x *OrderItemSnapshot) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *OrderItemSnapshot) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// Snapshot of an order at the moment the event was emitted.
+type OrderSnapshot struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CustomerId         int64                  `protobuf:"varint,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	DeliveryAddress    string                 `protobuf:"bytes,3,opt,name=delivery_address,json=deliveryAddress,proto3" json:"delivery_address,omitempty"`
+	TotalPriceCents    int64                  `protobuf:"varint,4,opt,name=total_price_cents,json=totalPriceCents,proto3" json:"total_price_cents,omitempty"`
+	TotalPriceCurrency string                 `protobuf:"bytes,5,opt,name=total_price_currency,json=totalPriceCurrency,proto3" json:"total_price_currency,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt          *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	OrderItems         []*OrderItemSnapshot   `protobuf:"bytes,8,rep,name=order_items,json=orderItems,proto3" json:"order_items,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *OrderSnapshot) Reset() {
+	*x = OrderSnapshot{}
+	mi := &file_v1_events_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderSnapshot) ProtoMessage() {}
+
+func (x *OrderSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderSnapshot.ProtoReflect.Descriptor instead.
+func (*OrderSnapshot) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OrderSnapshot) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OrderSnapshot) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *OrderSnapshot) GetDeliveryAddress() string {
+	if x != nil {
+		return x.DeliveryAddress
+	}
+	return ""
+}
+
+func (x *OrderSnapshot) GetTotalPriceCents() int64 {
+	if x != nil {
+		return x.TotalPriceCents
+	}
+	return 0
+}
+
+func (x *OrderSnapshot) GetTotalPriceCurrency() string {
+	if x != nil {
+		return x.TotalPriceCurrency
+	}
+	return ""
+}
+
+func (x *OrderSnapshot) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *OrderSnapshot) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *OrderSnapshot) GetOrderItems() []*OrderItemSnapshot {
+	if x != nil {
+		return x.OrderItems
+	}
+	return nil
+}
+
+// Emitted once an order with its items has been created.
+type OrderCreated struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	EventId    string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	Order      *OrderSnapshot         `protobuf:"bytes,3,opt,name=order,proto3" json:"order,omitempty"`
+	Context    *EventContext          `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"`
+	// Order level fields set by the creation
+	Changes       []*FieldChange `protobuf:"bytes,5,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderCreated) Reset() {
+	*x = OrderCreated{}
+	mi := &file_v1_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderCreated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderCreated) ProtoMessage() {}
+
+func (x *OrderCreated) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderCreated.ProtoReflect.Descriptor instead.
+func (*OrderCreated) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderCreated) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *OrderCreated) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *OrderCreated) GetOrder() *OrderSnapshot {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *OrderCreated) GetContext() *EventContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *OrderCreated) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// Emitted when an order moves to another status.
+type OrderStatusChanged struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	OrderId       int64                  `protobuf:"varint,3,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId    int64                  `protobuf:"varint,4,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	OrderItemIds  []int64                `protobuf:"varint,5,rep,packed,name=order_item_ids,json=orderItemIds,proto3" json:"order_item_ids,omitempty"`
+	OldStatus     string                 `protobuf:"bytes,6,opt,name=old_status,json=oldStatus,proto3" json:"old_status,omitempty"`
+	NewStatus     string                 `protobuf:"bytes,7,opt,name=new_status,json=newStatus,proto3" json:"new_status,omitempty"`
+	Context       *EventContext          `protobuf:"bytes,8,opt,name=context,proto3" json:"context,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,9,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderStatusChanged) Reset() {
+	*x = OrderStatusChanged{}
+	mi := &file_v1_events_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderStatusChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderStatusChanged) ProtoMessage() {}
+
+func (x *OrderStatusChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderStatusChanged.ProtoReflect.Descriptor instead.
+func (*OrderStatusChanged) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OrderStatusChanged) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *OrderStatusChanged) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *OrderStatusChanged) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderStatusChanged) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *OrderStatusChanged) GetOrderItemIds() []int64 {
+	if x != nil {
+		return x.OrderItemIds
+	}
+	return nil
+}
+
+func (x *OrderStatusChanged) GetOldStatus() string {
+	if x != nil {
+		return x.OldStatus
+	}
+	return ""
+}
+
+func (x *OrderStatusChanged) GetNewStatus() string {
+	if x != nil {
+		return x.NewStatus
+	}
+	return ""
+}
+
+func (x *OrderStatusChanged) GetContext() *EventContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *OrderStatusChanged) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// Emitted when an order is cancelled.
+type OrderCancelled struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	OrderId       int64                  `protobuf:"varint,3,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId    int64                  `protobuf:"varint,4,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	OrderItemIds  []int64                `protobuf:"varint,5,rep,packed,name=order_item_ids,json=orderItemIds,proto3" json:"order_item_ids,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Context       *EventContext          `protobuf:"bytes,7,opt,name=context,proto3" json:"context,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,8,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderCancelled) Reset() {
+	*x = OrderCancelled{}
+	mi := &file_v1_events_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderCancelled) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderCancelled) ProtoMessage() {}
+
+func (x *OrderCancelled) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderCancelled.ProtoReflect.Descriptor instead.
+func (*OrderCancelled) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OrderCancelled) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *OrderCancelled) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *OrderCancelled) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderCancelled) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *OrderCancelled) GetOrderItemIds() []int64 {
+	if x != nil {
+		return x.OrderItemIds
+	}
+	return nil
+}
+
+func (x *OrderCancelled) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *OrderCancelled) GetContext() *EventContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *OrderCancelled) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// Emitted when a single order item is changed.
+type OrderItemUpdated struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	OrderId       int64                  `protobuf:"varint,3,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId    int64                  `protobuf:"varint,4,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	Item          *OrderItemSnapshot     `protobuf:"bytes,5,opt,name=item,proto3" json:"item,omitempty"`
+	Context       *EventContext          `protobuf:"bytes,6,opt,name=context,proto3" json:"context,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,7,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItemUpdated) Reset() {
+	*x = OrderItemUpdated{}
+	mi := &file_v1_events_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItemUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItemUpdated) ProtoMessage() {}
+
+func (x *OrderItemUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_events_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItemUpdated.ProtoReflect.Descriptor instead.
+func (*OrderItemUpdated) Descriptor() ([]byte, []int) {
+	return file_v1_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OrderItemUpdated) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *OrderItemUpdated) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *OrderItemUpdated) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *OrderItemUpdated) GetCustomerId() int64 {
+	if x != nil {
+		return x.CustomerId
+	}
+	return 0
+}
+
+func (x *OrderItemUpdated) GetItem() *OrderItemSnapshot {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *OrderItemUpdated) GetContext() *EventContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *OrderItemUpdated) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+var File_v1_events_proto protoreflect.FileDescriptor
+
+const file_v1_events_proto_rawDesc = "" +
+	"\n" +
+	"\x0fv1/events.proto\x12\x06api.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8e\x01\n" +
+	"\fEventContext\x12\x19\n" +
+	"\bactor_id\x18\x01 \x01(\tR\aactorId\x12\x1d\n" +
+	"\n" +
+	"actor_type\x18\x02 \x01(\tR\tactorType\x12%\n" +
+	"\x0esource_service\x18\x03 \x01(\tR\rsourceService\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\"\x81\x01\n" +
+	"\vFieldChange\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12.\n" +
+	"\x06before\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x06before\x12,\n" +
+	"\x05after\x18\x03 \x01(\v2\x16.google.protobuf.ValueR\x05after\"\xfd\x02\n" +
+	"\x11OrderItemSnapshot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\x03R\aorderId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\x03R\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12#\n" +
+	"\rproduct_title\x18\x05 \x01(\tR\fproductTitle\x12\x1f\n" +
+	"\vproduct_url\x18\x06 \x01(\tR\n" +
+	"productUrl\x12\x1f\n" +
+	"\vprice_cents\x18\a \x01(\x03R\n" +
+	"priceCents\x12%\n" +
+	"\x0eprice_currency\x18\b \x01(\tR\rpriceCurrency\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xfb\x02\n" +
+	"\rOrderSnapshot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\x03R\n" +
+	"customerId\x12)\n" +
+	"\x10delivery_address\x18\x03 \x01(\tR\x0fdeliveryAddress\x12*\n" +
+	"\x11total_price_cents\x18\x04 \x01(\x03R\x0ftotalPriceCents\x120\n" +
+	"\x14total_price_currency\x18\x05 \x01(\tR\x12totalPriceCurrency\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12:\n" +
+	"\vorder_items\x18\b \x03(\v2\x19.api.v1.OrderItemSnapshotR\n" +
+	"orderItems\"\xf2\x01\n" +
+	"\fOrderCreated\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12;\n" +
+	"\voccurred_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12+\n" +
+	"\x05order\x18\x03 \x01(\v2\x15.api.v1.OrderSnapshotR\x05order\x12.\n" +
+	"\acontext\x18\x04 \x01(\v2\x14.api.v1.EventContextR\acontext\x12-\n" +
+	"\achanges\x18\x05 \x03(\v2\x13.api.v1.FieldChangeR\achanges\"\xeb\x02\n" +
+	"\x12OrderStatusChanged\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12;\n" +
+	"\voccurred_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x19\n" +
+	"\border_id\x18\x03 \x01(\x03R\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x04 \x01(\x03R\n" +
+	"customerId\x12$\n" +
+	"\x0eorder_item_ids\x18\x05 \x03(\x03R\forderItemIds\x12\x1d\n" +
+	"\n" +
+	"old_status\x18\x06 \x01(\tR\toldStatus\x12\x1d\n" +
+	"\n" +
+	"new_status\x18\a \x01(\tR\tnewStatus\x12.\n" +
+	"\acontext\x18\b \x01(\v2\x14.api.v1.EventContextR\acontext\x12-\n" +
+	"\achanges\x18\t \x03(\v2\x13.api.v1.FieldChangeR\achanges\"\xc1\x02\n" +
+	"\x0eOrderCancelled\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12;\n" +
+	"\voccurred_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x19\n" +
+	"\border_id\x18\x03 \x01(\x03R\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x04 \x01(\x03R\n" +
+	"customerId\x12$\n" +
+	"\x0eorder_item_ids\x18\x05 \x03(\x03R\forderItemIds\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12.\n" +
+	"\acontext\x18\a \x01(\v2\x14.api.v1.EventContextR\acontext\x12-\n" +
+	"\achanges\x18\b \x03(\v2\x13.api.v1.FieldChangeR\achanges\"\xb4\x02\n" +
+	"\x10OrderItemUpdated\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12;\n" +
+	"\voccurred_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x19\n" +
+	"\border_id\x18\x03 \x01(\x03R\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x04 \x01(\x03R\n" +
+	"customerId\x12-\n" +
+	"\x04item\x18\x05 \x01(\v2\x19.api.v1.OrderItemSnapshotR\x04item\x12.\n" +
+	"\acontext\x18\x06 \x01(\v2\x14.api.v1.EventContextR\acontext\x12-\n" +
+	"\achanges\x18\a \x03(\v2\x13.api.v1.FieldChangeR\achangesB'Z%github.com/yourorg/yourproject/api/v1b\x06proto3"
+
+var (
+	file_v1_events_proto_rawDescOnce sync.Once
+	file_v1_events_proto_rawDescData []byte
+)
+
+func file_v1_events_proto_rawDescGZIP() []byte {
+	file_v1_events_proto_rawDescOnce.Do(func() {
+		file_v1_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_v1_events_proto_rawDesc), len(file_v1_events_proto_rawDesc)))
+	})
+	return file_v1_events_proto_rawDescData
+}
+
+var file_v1_events_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_v1_events_proto_goTypes = []any{
+	(*EventContext)(nil),          // 0: api.v1.EventContext
+	(*FieldChange)(nil),           // 1: api.v1.FieldChange
+	(*OrderItemSnapshot)(nil),     // 2: api.v1.OrderItemSnapshot
+	(*OrderSnapshot)(nil),         // 3: api.v1.OrderSnapshot
+	(*OrderCreated)(nil),          // 4: api.v1.OrderCreated
+	(*OrderStatusChanged)(nil),    // 5: api.v1.OrderStatusChanged
+	(*OrderCancelled)(nil),        // 6: api.v1.OrderCancelled
+	(*OrderItemUpdated)(nil),      // 7: api.v1.OrderItemUpdated
+	(*structpb.Value)(nil),        // 8: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+}
+var file_v1_events_proto_depIdxs = []int32{
+	8,  // 0: api.v1.FieldChange.before:type_name -> google.protobuf.Value
+	8,  // 1: api.v1.FieldChange.after:type_name -> google.protobuf.Value
+	9,  // 2: api.v1.OrderItemSnapshot.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 3: api.v1.OrderItemSnapshot.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 4: api.v1.OrderSnapshot.created_at:type_name -> google.protobuf.Timestamp
+	9,  // 5: api.v1.OrderSnapshot.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 6: api.v1.OrderSnapshot.order_items:type_name -> api.v1.OrderItemSnapshot
+	9,  // 7: api.v1.OrderCreated.occurred_at:type_name -> google.protobuf.Timestamp
+	3,  // 8: api.v1.OrderCreated.order:type_name -> api.v1.OrderSnapshot
+	0,  // 9: api.v1.OrderCreated.context:type_name -> api.v1.EventContext
+	1,  // 10: api.v1.OrderCreated.changes:type_name -> api.v1.FieldChange
+	9,  // 11: api.v1.OrderStatusChanged.occurred_at:type_name -> google.protobuf.Timestamp
+	0,  // 12: api.v1.OrderStatusChanged.context:type_name -> api.v1.EventContext
+	1,  // 13: api.v1.OrderStatusChanged.changes:type_name -> api.v1.FieldChange
+	9,  // 14: api.v1.OrderCancelled.occurred_at:type_name -> google.protobuf.Timestamp
+	0,  // 15: api.v1.OrderCancelled.context:type_name -> api.v1.EventContext
+	1,  // 16: api.v1.OrderCancelled.changes:type_name -> api.v1.FieldChange
+	9,  // 17: api.v1.OrderItemUpdated.occurred_at:type_name -> google.protobuf.Timestamp
+	2,  // 18: api.v1.OrderItemUpdated.item:type_name -> api.v1.OrderItemSnapshot
+	0,  // 19: api.v1.OrderItemUpdated.context:type_name -> api.v1.EventContext
+	1,  // 20: api.v1.OrderItemUpdated.changes:type_name -> api.v1.FieldChange
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_v1_events_proto_init() }
+func file_v1_events_proto_init() {
+	if File_v1_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_events_proto_rawDesc), len(file_v1_events_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_v1_events_proto_goTypes,
+		DependencyIndexes: file_v1_events_proto_depIdxs,
+		MessageInfos:      file_v1_events_proto_msgTypes,
+	}.Build()
+	File_v1_events_proto = out.File
+	file_v1_events_proto_goTypes = nil
+	file_v1_events_proto_depIdxs = nil
+}
@@ -24,7 +24,7 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// Сообщения
+// Messages
 type OrderItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     int64                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
@@ -418,6 +418,15 @@ type AuditLogOrder struct {
 	OrderStatus   string                 `protobuf:"bytes,5,opt,name=order_status,json=orderStatus,proto3" json:"order_status,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	EventType     string                 `protobuf:"bytes,8,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	ActorId       string                 `protobuf:"bytes,9,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	ActorType     string                 `protobuf:"bytes,10,opt,name=actor_type,json=actorType,proto3" json:"actor_type,omitempty"`
+	SourceService string                 `protobuf:"bytes,11,opt,name=source_service,json=sourceService,proto3" json:"source_service,omitempty"`
+	RequestId     string                 `protobuf:"bytes,12,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,13,rep,name=changes,proto3" json:"changes,omitempty"`
+	// ID of the broker message the audit log was built from, audit logs of a message are saved once.
+	// When empty, the only x-message-id metadata value of the call is used.
+	MessageId     string `protobuf:"bytes,14,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -501,6 +510,55 @@ func (x *AuditLogOrder) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *AuditLogOrder) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *AuditLogOrder) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *AuditLogOrder) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *AuditLogOrder) GetSourceService() string {
+	if x != nil {
+		return x.SourceService
+	}
+	return ""
+}
+
+func (x *AuditLogOrder) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *AuditLogOrder) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *AuditLogOrder) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
 type SaveAuditLogRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AuditLogs     []*AuditLogOrder       `protobuf:"bytes,1,rep,name=audit_logs,json=auditLogs,proto3" json:"audit_logs,omitempty"`
@@ -546,10 +604,13 @@ func (x *SaveAuditLogRequest) GetAuditLogs() []*AuditLogOrder {
 }
 
 type SaveAuditLogResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AuditLogs     []*AuditLogOrder       `protobuf:"bytes,1,rep,name=audit_logs,json=auditLogs,proto3" json:"audit_logs,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Newly saved audit logs
+	AuditLogs []*AuditLogOrder `protobuf:"bytes,1,rep,name=audit_logs,json=auditLogs,proto3" json:"audit_logs,omitempty"`
+	// Messages whose audit logs were saved by an earlier call, their audit logs are not saved again
+	DuplicateMessageIds []string `protobuf:"bytes,2,rep,name=duplicate_message_ids,json=duplicateMessageIds,proto3" json:"duplicate_message_ids,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *SaveAuditLogResponse) Reset() {
@@ -589,11 +650,18 @@ func (x *SaveAuditLogResponse) GetAuditLogs() []*AuditLogOrder {
 	return nil
 }
 
+func (x *SaveAuditLogResponse) GetDuplicateMessageIds() []string {
+	if x != nil {
+		return x.DuplicateMessageIds
+	}
+	return nil
+}
+
 var File_v1_order_proto protoreflect.FileDescriptor
 
 const file_v1_order_proto_rawDesc = "" +
 	"\n" +
-	"\x0ev1/order.proto\x12\x06api.v1\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd4\x01\n" +
+	"\x0ev1/order.proto\x12\x06api.v1\x1a\x1cgoogle/api/annotations.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x0fv1/events.proto\"\xd4\x01\n" +
 	"\tOrderItem\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\x03R\tproductId\x12\x1a\n" +
@@ -627,7 +695,7 @@ const file_v1_order_proto_rawDesc = "" +
 	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
 	"\x06offset\x18\x04 \x01(\x05R\x06offset\";\n" +
 	"\x12ListOrdersResponse\x12%\n" +
-	"\x06orders\x18\x01 \x03(\v2\r.api.v1.OrderR\x06orders\"\x98\x02\n" +
+	"\x06orders\x18\x01 \x03(\v2\r.api.v1.OrderR\x06orders\"\x85\x04\n" +
 	"\rAuditLogOrder\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
 	"\border_id\x18\x02 \x01(\x03R\aorderId\x12\"\n" +
@@ -638,21 +706,34 @@ const file_v1_order_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"K\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\b \x01(\tR\teventType\x12\x19\n" +
+	"\bactor_id\x18\t \x01(\tR\aactorId\x12\x1d\n" +
+	"\n" +
+	"actor_type\x18\n" +
+	" \x01(\tR\tactorType\x12%\n" +
+	"\x0esource_service\x18\v \x01(\tR\rsourceService\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\f \x01(\tR\trequestId\x12-\n" +
+	"\achanges\x18\r \x03(\v2\x13.api.v1.FieldChangeR\achanges\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x0e \x01(\tR\tmessageId\"K\n" +
 	"\x13SaveAuditLogRequest\x124\n" +
 	"\n" +
-	"audit_logs\x18\x01 \x03(\v2\x15.api.v1.AuditLogOrderR\tauditLogs\"L\n" +
+	"audit_logs\x18\x01 \x03(\v2\x15.api.v1.AuditLogOrderR\tauditLogs\"\x80\x01\n" +
 	"\x14SaveAuditLogResponse\x124\n" +
 	"\n" +
-	"audit_logs\x18\x01 \x03(\v2\x15.api.v1.AuditLogOrderR\tauditLogs2\xa0\x06\n" +
-	"\fOrderService\x12\xe1\x01\n" +
-	"\vBatchInsert\x12\x1a.api.v1.BatchInsertRequest\x1a\x1b.api.v1.BatchInsertResponse\"\x98\x01\x92An\n" +
-	"\x06Orders\x12\x1bСоздать заказы\x1aGСоздает новые заказы в системе батчами\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/api/order-service/v1/orders\x12\xaf\x02\n" +
+	"audit_logs\x18\x01 \x03(\v2\x15.api.v1.AuditLogOrderR\tauditLogs\x122\n" +
+	"\x15duplicate_message_ids\x18\x02 \x03(\tR\x13duplicateMessageIds2\x85\x05\n" +
+	"\fOrderService\x12\xb6\x01\n" +
+	"\vBatchInsert\x12\x1a.api.v1.BatchInsertRequest\x1a\x1b.api.v1.BatchInsertResponse\"n\x92AD\n" +
+	"\x06Orders\x12\rCreate orders\x1a+Creates new orders in the system in batches\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/api/order-service/v1/orders\x12\xcb\x01\n" +
 	"\n" +
-	"ListOrders\x12\x19.api.v1.ListOrdersRequest\x1a\x1a.api.v1.ListOrdersResponse\"\xe9\x01\x92A\xc1\x01\n" +
-	"\x06Orders\x12,Получить список заказов\x1a\x88\x01Получает список заказов с возможностью фильтрации по ID заказов и клиентов\x82\xd3\xe4\x93\x02\x1e\x12\x1c/api/order-service/v1/orders\x12\xf9\x01\n" +
-	"\fSaveAuditLog\x12\x1b.api.v1.SaveAuditLogRequest\x1a\x1c.api.v1.SaveAuditLogResponse\"\xad\x01\x92A\x7f\n" +
-	"\x05Audit\x12&Сохранить аудит логи\x1aNСохраняет аудит логи заказов в базу данных\x82\xd3\xe4\x93\x02%:\x01*\" /api/order-service/v1/audit-logsB\xb2\x01\x92A\x87\x01\x12M\n" +
+	"ListOrders\x12\x19.api.v1.ListOrdersRequest\x1a\x1a.api.v1.ListOrdersResponse\"\x85\x01\x92A^\n" +
+	"\x06Orders\x12\vList orders\x1aGRetrieves a list of orders with filtering by order IDs and customer IDs\x82\xd3\xe4\x93\x02\x1e\x12\x1c/api/order-service/v1/orders\x12\xed\x01\n" +
+	"\fSaveAuditLog\x12\x1b.api.v1.SaveAuditLogRequest\x1a\x1c.api.v1.SaveAuditLogResponse\"\xa1\x01\x92As\n" +
+	"\x05Audit\x12\x0fSave audit logs\x1aYSaves order audit logs to the database, audit logs of a message already saved are skipped\x82\xd3\xe4\x93\x02%:\x01*\" /api/order-service/v1/audit-logsB\xb2\x01\x92A\x87\x01\x12M\n" +
 	"\tOrder API\x12\x11Order Service API\"(\n" +
 	"\vMark Anikin\x1a\x19mark.corray.off@gmail.com2\x031.0\x1a\x0elocalhost:3001*\x02\x01\x022\x10application/json:\x10application/jsonZ%github.com/yourorg/yourproject/api/v1b\x06proto3"
 
@@ -680,6 +761,7 @@ var file_v1_order_proto_goTypes = []any{
 	(*SaveAuditLogRequest)(nil),   // 7: api.v1.SaveAuditLogRequest
 	(*SaveAuditLogResponse)(nil),  // 8: api.v1.SaveAuditLogResponse
 	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
+	(*FieldChange)(nil),           // 10: api.v1.FieldChange
 }
 var file_v1_order_proto_depIdxs = []int32{
 	9,  // 0: api.v1.Order.created_at:type_name -> google.protobuf.Timestamp
@@ -690,19 +772,20 @@ var file_v1_order_proto_depIdxs = []int32{
 	1,  // 5: api.v1.ListOrdersResponse.orders:type_name -> api.v1.Order
 	9,  // 6: api.v1.AuditLogOrder.created_at:type_name -> google.protobuf.Timestamp
 	9,  // 7: api.v1.AuditLogOrder.updated_at:type_name -> google.protobuf.Timestamp
-	6,  // 8: api.v1.SaveAuditLogRequest.audit_logs:type_name -> api.v1.AuditLogOrder
-	6,  // 9: api.v1.SaveAuditLogResponse.audit_logs:type_name -> api.v1.AuditLogOrder
-	2,  // 10: api.v1.OrderService.BatchInsert:input_type -> api.v1.BatchInsertRequest
-	4,  // 11: api.v1.OrderService.ListOrders:input_type -> api.v1.ListOrdersRequest
-	7,  // 12: api.v1.OrderService.SaveAuditLog:input_type -> api.v1.SaveAuditLogRequest
-	3,  // 13: api.v1.OrderService.BatchInsert:output_type -> api.v1.BatchInsertResponse
-	5,  // 14: api.v1.OrderService.ListOrders:output_type -> api.v1.ListOrdersResponse
-	8,  // 15: api.v1.OrderService.SaveAuditLog:output_type -> api.v1.SaveAuditLogResponse
-	13, // [13:16] is the sub-list for method output_type
-	10, // [10:13] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	10, // 8: api.v1.AuditLogOrder.changes:type_name -> api.v1.FieldChange
+	6,  // 9: api.v1.SaveAuditLogRequest.audit_logs:type_name -> api.v1.AuditLogOrder
+	6,  // 10: api.v1.SaveAuditLogResponse.audit_logs:type_name -> api.v1.AuditLogOrder
+	2,  // 11: api.v1.OrderService.BatchInsert:input_type -> api.v1.BatchInsertRequest
+	4,  // 12: api.v1.OrderService.ListOrders:input_type -> api.v1.ListOrdersRequest
+	7,  // 13: api.v1.OrderService.SaveAuditLog:input_type -> api.v1.SaveAuditLogRequest
+	3,  // 14: api.v1.OrderService.BatchInsert:output_type -> api.v1.BatchInsertResponse
+	5,  // 15: api.v1.OrderService.ListOrders:output_type -> api.v1.ListOrdersResponse
+	8,  // 16: api.v1.OrderService.SaveAuditLog:output_type -> api.v1.SaveAuditLogResponse
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_v1_order_proto_init() }
@@ -710,6 +793,7 @@ func file_v1_order_proto_init() {
 	if File_v1_order_proto != nil {
 		return
 	}
+	file_v1_events_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
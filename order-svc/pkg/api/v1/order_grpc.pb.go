@@ -27,14 +27,9 @@ const (
 // OrderServiceClient is the client API for OrderService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
-//
-// Сервис с HTTP аннотациями
 type OrderServiceClient interface {
-	// Создать заказы пакетно
 	BatchInsert(ctx context.Context, in *BatchInsertRequest, opts ...grpc.CallOption) (*BatchInsertResponse, error)
-	// Получить список заказов
 	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
-	// Сохранить аудит логи
 	SaveAuditLog(ctx context.Context, in *SaveAuditLogRequest, opts ...grpc.CallOption) (*SaveAuditLogResponse, error)
 }
 
@@ -79,14 +74,9 @@ func (c *orderServiceClient) SaveAuditLog(ctx context.Context, in *SaveAuditLogR
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility.
-//
-// Сервис с HTTP аннотациями
 type OrderServiceServer interface {
-	// Создать заказы пакетно
 	BatchInsert(context.Context, *BatchInsertRequest) (*BatchInsertResponse, error)
-	// Получить список заказов
 	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
-	// Сохранить аудит логи
 	SaveAuditLog(context.Context, *SaveAuditLogRequest) (*SaveAuditLogResponse, error)
 	mustEmbedUnimplementedOrderServiceServer()
 }
@@ -0,0 +1,257 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Broker types selectable in config.
+const (
+	TypeRabbitMQ = "rabbitmq"
+	TypeKafka    = "kafka"
+	TypeNATS     = "nats"
+)
+
+// Header names carrying message properties on brokers that have no native fields for them.
+const (
+	HeaderMessageID   = "message-id"
+	HeaderMessageType = "message-type"
+	HeaderContentType = "content-type"
+	HeaderTimestamp   = "timestamp"
+)
+
+var (
+	ErrUnknownBroker  = errors.New("unknown broker type")
+	ErrNotImplemented = errors.New("not implemented by the broker")
+	ErrUnhealthy      = errors.New("broker connection is unhealthy")
+)
+
+// Message is a broker independent message.
+// Topic is the queue, topic or subject name, Key is used for partitioning where supported.
+type Message struct {
+	Topic       string
+	Key         string
+	ID          string
+	Type        string
+	ContentType string
+	Timestamp   time.Time
+	Headers     map[string]any
+	Body        []byte
+}
+
+// Delivery is a received message that has to be acknowledged.
+type Delivery struct {
+	Message
+
+	Redelivered bool
+
+	ack         func() error
+	nack        func(requeue bool) error
+	ackMultiple func() error
+	// settled is called once the delivery is acknowledged or rejected
+	settled func()
+}
+
+// NewDelivery creates a delivery with broker specific acknowledgement functions.
+func NewDelivery(msg Message, redelivered bool, ack func() error, nack func(requeue bool) error) Delivery {
+	return Delivery{
+		Message:     msg,
+		Redelivered: redelivered,
+		ack:         ack,
+		nack:        nack,
+	}
+}
+
+// WithAckMultiple returns the delivery with a cumulative acknowledgement function,
+// which acknowledges the delivery and all earlier ones of its subscription.
+func (d Delivery) WithAckMultiple(ackMultiple func() error) Delivery {
+	d.ackMultiple = ackMultiple
+
+	return d
+}
+
+// WithSettleHook returns the delivery calling the hook once, when it is acknowledged or rejected.
+// The hook is called even when settling fails, the delivery is redelivered by the broker then.
+func (d Delivery) WithSettleHook(hook func()) Delivery {
+	var once sync.Once
+	d.settled = func() { once.Do(hook) }
+
+	return d
+}
+
+// Ack acknowledges successful processing of the delivery.
+func (d Delivery) Ack() error {
+	defer d.settle()
+
+	return d.ack()
+}
+
+// Nack rejects the delivery. With requeue the message is delivered again later,
+// otherwise it is dropped or dead-lettered by the broker.
+func (d Delivery) Nack(requeue bool) error {
+	defer d.settle()
+
+	return d.nack(requeue)
+}
+
+// settle calls the settle hook of the delivery, if any.
+func (d Delivery) settle() {
+	if d.settled != nil {
+		d.settled()
+	}
+}
+
+// AckBatch acknowledges deliveries of one subscription given in delivery order.
+// Every earlier delivery of the subscription must already be settled or be part of the batch:
+// brokers supporting cumulative acknowledgement acknowledge the batch with its last delivery,
+// other brokers acknowledge deliveries one by one.
+func AckBatch(deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if last := deliveries[len(deliveries)-1]; last.ackMultiple != nil {
+		defer func() {
+			for _, d := range deliveries {
+				d.settle()
+			}
+		}()
+
+		return last.ackMultiple()
+	}
+
+	var errs []error
+	for _, d := range deliveries {
+		if err := d.Ack(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Publisher publishes messages.
+type Publisher interface {
+	// System returns the messaging system name reported in telemetry
+	System() string
+	// Declare makes sure the topic exists
+	Declare(ctx context.Context, topic string) error
+	// Publish sends the message to its topic
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Subscriber receives messages.
+type Subscriber interface {
+	// System returns the messaging system name reported in telemetry
+	System() string
+	// Declare makes sure the topic exists
+	Declare(ctx context.Context, topic string) error
+	// Subscribe starts receiving messages of the topic as a member of the group.
+	// The channel is closed when the context is canceled or the subscription ends.
+	Subscribe(ctx context.Context, topic string, group string) (<-chan Delivery, error)
+}
+
+// DelayDeclarer is implemented by brokers able to delay messages.
+type DelayDeclarer interface {
+	// DeclareDelayed makes sure the topic exists and moves every message
+	// published to it to the target topic once the delay has passed
+	DeclareDelayed(ctx context.Context, topic string, delay time.Duration, target string) error
+}
+
+// HealthChecker is implemented by brokers able to report the state of their connection.
+type HealthChecker interface {
+	// CheckHealth returns an error when the connection, or a channel of it, is down
+	CheckHealth(ctx context.Context) error
+}
+
+// QueueInspector is implemented by brokers able to report how many messages wait in a queue.
+type QueueInspector interface {
+	// QueueDepth returns the number of messages of the topic not yet delivered to the group
+	QueueDepth(ctx context.Context, topic string, group string) (int, error)
+}
+
+// Broker is a message broker connection.
+type Broker interface {
+	Publisher
+	Subscriber
+
+	// Close closes the connection
+	Close() error
+}
+
+// StringHeaders flattens message properties and headers into string headers.
+func StringHeaders(msg Message) map[string]string {
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for k, v := range msg.Headers {
+		headers[k] = fmt.Sprint(v)
+	}
+
+	if msg.ID != "" {
+		headers[HeaderMessageID] = msg.ID
+	}
+	if msg.Type != "" {
+		headers[HeaderMessageType] = msg.Type
+	}
+	if msg.ContentType != "" {
+		headers[HeaderContentType] = msg.ContentType
+	}
+	if !msg.Timestamp.IsZero() {
+		headers[HeaderTimestamp] = msg.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	return headers
+}
+
+// MessageFromStringHeaders restores a message from string headers built by StringHeaders.
+func MessageFromStringHeaders(topic string, key string, headers map[string]string, body []byte) Message {
+	msg := Message{
+		Topic:       topic,
+		Key:         key,
+		ID:          headers[HeaderMessageID],
+		Type:        headers[HeaderMessageType],
+		ContentType: headers[HeaderContentType],
+		Headers:     make(map[string]any, len(headers)),
+		Body:        body,
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, headers[HeaderTimestamp]); err == nil {
+		msg.Timestamp = ts
+	}
+
+	for k, v := range headers {
+		switch k {
+		case HeaderMessageID, HeaderMessageType, HeaderContentType, HeaderTimestamp:
+		default:
+			msg.Headers[k] = v
+		}
+	}
+
+	return msg
+}
+
+// HeaderInt64 converts a header value to int64.
+// String values are parsed, since some brokers only carry string headers.
+func HeaderInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
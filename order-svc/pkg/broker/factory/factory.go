@@ -0,0 +1,101 @@
+package factory
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/broker/kafka"
+	"github.com/corray333/backend-labs/order/pkg/broker/nats"
+	"github.com/corray333/backend-labs/order/pkg/broker/rabbitmq"
+	"github.com/spf13/viper"
+)
+
+// Config selects and configures the message broker.
+type Config struct {
+	// Type is one of the broker types, RabbitMQ is used when it is empty
+	Type     string
+	RabbitMQ rabbitmq.Config
+	Kafka    kafka.Config
+	NATS     nats.Config
+	// Prefetch of consumers applies to every broker type, zero means the broker default
+	Prefetch int
+}
+
+// ConfigFromViper reads the broker config from the broker and rabbitmq sections.
+// RabbitMQ credentials are read from the RABBITMQ_DEFAULT_USER and RABBITMQ_DEFAULT_PASS variables.
+func ConfigFromViper() Config {
+	host := viper.GetString("rabbitmq.host")
+	port := viper.GetInt("rabbitmq.port")
+	user := os.Getenv("RABBITMQ_DEFAULT_USER")
+	password := os.Getenv("RABBITMQ_DEFAULT_PASS")
+
+	if host == "" {
+		host = "rabbitmq"
+	}
+	if port == 0 {
+		port = 5672
+	}
+
+	return Config{
+		Type: viper.GetString("broker.type"),
+		RabbitMQ: rabbitmq.Config{
+			URL: fmt.Sprintf("amqp://%s:%s@%s:%d/", user, password, host, port),
+			Queue: rabbitmq.QueueConfig{
+				Durable:    viper.GetBool("rabbitmq.queue.durable"),
+				AutoDelete: viper.GetBool("rabbitmq.queue.auto_delete"),
+				Exclusive:  viper.GetBool("rabbitmq.queue.exclusive"),
+				NoWait:     viper.GetBool("rabbitmq.queue.no_wait"),
+			},
+		},
+		Kafka: kafka.Config{
+			Brokers:           viper.GetStringSlice("broker.kafka.brokers"),
+			Partitions:        viper.GetInt("broker.kafka.partitions"),
+			ReplicationFactor: viper.GetInt("broker.kafka.replication_factor"),
+		},
+		NATS: nats.Config{
+			URL:      viper.GetString("broker.nats.url"),
+			Replicas: viper.GetInt("broker.nats.replicas"),
+		},
+		Prefetch: viper.GetInt("rabbitmq.consumer.prefetch_count"),
+	}
+}
+
+// New creates the message broker selected by the config.
+func New(cfg Config) (broker.Broker, error) {
+	switch cfg.Type {
+	case broker.TypeRabbitMQ, "":
+		cfg.RabbitMQ.Prefetch = cfg.Prefetch
+
+		return rabbitmq.New(cfg.RabbitMQ)
+	case broker.TypeKafka:
+		cfg.Kafka.Prefetch = cfg.Prefetch
+
+		return kafka.New(cfg.Kafka)
+	case broker.TypeNATS:
+		cfg.NATS.Prefetch = cfg.Prefetch
+
+		return nats.New(cfg.NATS)
+	default:
+		return nil, fmt.Errorf("%w: %s", broker.ErrUnknownBroker, cfg.Type)
+	}
+}
+
+// MustNew creates the message broker selected by the broker.type config.
+func MustNew() broker.Broker {
+	cfg := ConfigFromViper()
+
+	b, err := New(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create message broker: %v", err))
+	}
+
+	brokerType := cfg.Type
+	if brokerType == "" {
+		brokerType = broker.TypeRabbitMQ
+	}
+	slog.Info("Message broker connected", "type", brokerType)
+
+	return b
+}
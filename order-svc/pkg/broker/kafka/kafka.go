@@ -0,0 +1,267 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/segmentio/kafka-go"
+)
+
+// Config configures the Kafka broker.
+type Config struct {
+	Brokers           []string
+	Partitions        int
+	ReplicationFactor int
+	// Prefetch is the number of messages fetched ahead per reader, zero means the client default
+	Prefetch int
+}
+
+// writeBatchTimeout bounds how long a write waits for other messages to batch with.
+// Publish writes synchronously, the client default of a second would delay every publish.
+const writeBatchTimeout = 10 * time.Millisecond
+
+// DeadLetterSuffix is appended to a topic name to get the topic of its rejected messages.
+const DeadLetterSuffix = ".dlq"
+
+// Broker implements broker.Broker on top of Kafka.
+// Messages are partitioned by key, groups are consumer groups.
+//
+// Kafka commits offsets, not single messages: the offset of a partition is committed
+// past a message once it and every earlier message of the partition are settled.
+// A message rejected with requeue is left unsettled, so the committed offset stays before it
+// and the partition is read again from it after a restart or rebalance, keeping the order of
+// the partition. Publishing it again would put it behind later events of its key.
+// A message rejected without requeue is published to the dead letter topic of its topic,
+// a message whose dead lettering failed is left unsettled as well.
+type Broker struct {
+	cfg    Config
+	writer *kafka.Writer
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+}
+
+// New creates a Kafka broker. Connections are established lazily.
+func New(cfg Config) (*Broker, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka brokers are not set")
+	}
+	if cfg.Partitions <= 0 {
+		cfg.Partitions = 1
+	}
+	if cfg.ReplicationFactor <= 0 {
+		cfg.ReplicationFactor = 1
+	}
+
+	return &Broker{
+		cfg: cfg,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           writeBatchTimeout,
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+// System returns the messaging system name.
+func (b *Broker) System() string {
+	return broker.TypeKafka
+}
+
+// DeadLetterTopic returns the topic of the messages of the topic rejected without requeue.
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// Declare creates the topic and its dead letter topic if they do not exist.
+// Topics are created on the controller of the cluster, other brokers reject the request.
+func (b *Broker) Declare(ctx context.Context, topic string) error {
+	conn, err := b.dialController(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, name := range []string{topic, DeadLetterTopic(topic)} {
+		err = conn.CreateTopics(kafka.TopicConfig{
+			Topic:             name,
+			NumPartitions:     b.cfg.Partitions,
+			ReplicationFactor: b.cfg.ReplicationFactor,
+		})
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create topic %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// dialController connects to the controller of the cluster through the first broker.
+func (b *Broker) dialController(ctx context.Context) (*kafka.Conn, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", b.cfg.Brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kafka controller: %w", err)
+	}
+
+	addr := net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port))
+	controllerConn, err := kafka.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka controller %s: %w", addr, err)
+	}
+
+	return controllerConn, nil
+}
+
+// Publish writes the message to its topic.
+func (b *Broker) Publish(ctx context.Context, msg broker.Message) error {
+	key := msg.Key
+	if key == "" {
+		key = msg.ID
+	}
+
+	headers := broker.StringHeaders(msg)
+	kafkaHeaders := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	err := b.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     []byte(key),
+		Value:   msg.Body,
+		Headers: kafkaHeaders,
+		Time:    msg.Timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write kafka message: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe reads the topic as a member of the consumer group.
+func (b *Broker) Subscribe(ctx context.Context, topic string, group string) (<-chan broker.Delivery, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:       b.cfg.Brokers,
+		GroupID:       group,
+		Topic:         topic,
+		QueueCapacity: b.cfg.Prefetch,
+	})
+
+	offsets := newOffsetTracker(func(m kafka.Message) error {
+		return reader.CommitMessages(context.Background(), m)
+	})
+
+	b.mu.Lock()
+	b.readers = append(b.readers, reader)
+	b.mu.Unlock()
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+
+		for {
+			m, err := reader.FetchMessage(ctx)
+			if err != nil {
+				return
+			}
+
+			redelivered := offsets.fetched(m)
+
+			select {
+			case out <- b.toDelivery(offsets, m, redelivered):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// CheckHealth connects to one of the brokers, the broker connections are lazy and short lived.
+func (b *Broker) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, addr := range b.cfg.Brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	return fmt.Errorf("%w: %w", broker.ErrUnhealthy, errors.Join(errs...))
+}
+
+// Close closes the writer and all readers.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errs := []error{b.writer.Close()}
+	for _, reader := range b.readers {
+		errs = append(errs, reader.Close())
+	}
+	b.readers = nil
+
+	return errors.Join(errs...)
+}
+
+// toDelivery converts a Kafka message.
+func (b *Broker) toDelivery(offsets *offsetTracker, m kafka.Message, redelivered bool) broker.Delivery {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	msg := broker.MessageFromStringHeaders(m.Topic, string(m.Key), headers, m.Value)
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = m.Time
+	}
+
+	return broker.NewDelivery(
+		msg,
+		redelivered,
+		func() error {
+			return offsets.settle(m)
+		},
+		func(requeue bool) error {
+			if requeue {
+				offsets.requeue(m)
+
+				return nil
+			}
+			if err := b.deadLetter(msg); err != nil {
+				return err
+			}
+
+			return offsets.settle(m)
+		},
+	)
+}
+
+// deadLetter publishes a message rejected without requeue to the dead letter topic of its topic.
+func (b *Broker) deadLetter(msg broker.Message) error {
+	msg.Topic = DeadLetterTopic(msg.Topic)
+	if err := b.Publish(context.Background(), msg); err != nil {
+		return fmt.Errorf("failed to dead letter rejected message to %s: %w", msg.Topic, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker commits the offsets of a reader in order.
+// Messages of a partition may be settled in any order, the committed offset of a partition
+// moves past a message only once the message and every message fetched before it are settled.
+type offsetTracker struct {
+	commit func(kafka.Message) error
+
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+	// requeued are the offsets of the messages rejected with requeue by partition,
+	// they are reported redelivered when the partition is read again
+	requeued map[int]map[int64]bool
+}
+
+// partitionOffsets is the state of the uncommitted messages of a partition.
+type partitionOffsets struct {
+	// fetched are the uncommitted messages in offset order
+	fetched []kafka.Message
+	settled map[int64]bool
+}
+
+// newOffsetTracker creates an offset tracker committing with commit.
+func newOffsetTracker(commit func(kafka.Message) error) *offsetTracker {
+	return &offsetTracker{
+		commit:     commit,
+		partitions: make(map[int]*partitionOffsets),
+		requeued:   make(map[int]map[int64]bool),
+	}
+}
+
+// fetched registers a message handed out to the subscriber
+// and reports whether the message was rejected with requeue before.
+func (t *offsetTracker) fetched(m kafka.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partitions[m.Partition]
+	if p == nil || (len(p.fetched) > 0 && m.Offset <= p.fetched[len(p.fetched)-1].Offset) {
+		// The partition is read again from the committed offset after a rebalance,
+		// messages fetched before are fetched again
+		p = &partitionOffsets{settled: make(map[int64]bool)}
+		t.partitions[m.Partition] = p
+	}
+
+	p.fetched = append(p.fetched, m)
+
+	redelivered := t.requeued[m.Partition][m.Offset]
+	delete(t.requeued[m.Partition], m.Offset)
+
+	return redelivered
+}
+
+// requeue leaves a message unsettled, the committed offset of its partition does not move past it
+// until the partition is read again from the committed offset.
+func (t *offsetTracker) requeue(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.requeued[m.Partition] == nil {
+		t.requeued[m.Partition] = make(map[int64]bool)
+	}
+	t.requeued[m.Partition][m.Offset] = true
+}
+
+// settle marks a message settled and commits the settled messages at the head of its partition.
+// Commits are made under the lock, so the committed offset of a partition never goes back.
+func (t *offsetTracker) settle(m kafka.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partitions[m.Partition]
+	if p == nil || len(p.fetched) == 0 || m.Offset < p.fetched[0].Offset {
+		// Committed already
+		return nil
+	}
+	p.settled[m.Offset] = true
+
+	committable := 0
+	for committable < len(p.fetched) && p.settled[p.fetched[committable].Offset] {
+		delete(p.settled, p.fetched[committable].Offset)
+		committable++
+	}
+	if committable == 0 {
+		return nil
+	}
+
+	last := p.fetched[committable-1]
+	p.fetched = p.fetched[committable:]
+
+	// A failed commit is covered by the next one of the partition
+	return t.commit(last)
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// recordedCommits returns a tracker recording the committed partitions and offsets.
+func recordedCommits(fail func(kafka.Message) error) (*offsetTracker, *[][2]int64) {
+	var commits [][2]int64
+	tracker := newOffsetTracker(func(m kafka.Message) error {
+		if fail != nil {
+			if err := fail(m); err != nil {
+				return err
+			}
+		}
+		commits = append(commits, [2]int64{int64(m.Partition), m.Offset})
+
+		return nil
+	})
+
+	return tracker, &commits
+}
+
+func msgAt(partition int, offset int64) kafka.Message {
+	return kafka.Message{Partition: partition, Offset: offset}
+}
+
+func TestOffsetTrackerCommitsContiguousOffsets(t *testing.T) {
+	tracker, commits := recordedCommits(nil)
+	for offset := range int64(4) {
+		tracker.fetched(msgAt(0, offset))
+	}
+
+	// Later messages settled first wait for the earlier ones
+	for _, offset := range []int64{2, 1} {
+		if err := tracker.settle(msgAt(0, offset)); err != nil {
+			t.Fatalf("settle %d: %v", offset, err)
+		}
+	}
+	if len(*commits) != 0 {
+		t.Fatalf("expected no commit before the first message is settled, got %v", *commits)
+	}
+
+	if err := tracker.settle(msgAt(0, 0)); err != nil {
+		t.Fatalf("settle 0: %v", err)
+	}
+	if err := tracker.settle(msgAt(0, 3)); err != nil {
+		t.Fatalf("settle 3: %v", err)
+	}
+
+	want := [][2]int64{{0, 2}, {0, 3}}
+	if !slices.Equal(*commits, want) {
+		t.Fatalf("expected commits %v, got %v", want, *commits)
+	}
+
+	// Settling a committed message again commits nothing
+	if err := tracker.settle(msgAt(0, 1)); err != nil {
+		t.Fatalf("settle committed message: %v", err)
+	}
+	if len(*commits) != 2 {
+		t.Fatalf("expected no commit of a committed message, got %v", *commits)
+	}
+}
+
+func TestOffsetTrackerTracksPartitionsIndependently(t *testing.T) {
+	tracker, commits := recordedCommits(nil)
+	tracker.fetched(msgAt(0, 10))
+	tracker.fetched(msgAt(1, 5))
+	tracker.fetched(msgAt(0, 11))
+
+	for _, m := range []kafka.Message{msgAt(0, 11), msgAt(1, 5), msgAt(0, 10)} {
+		if err := tracker.settle(m); err != nil {
+			t.Fatalf("settle %+v: %v", m, err)
+		}
+	}
+
+	want := [][2]int64{{1, 5}, {0, 11}}
+	if !slices.Equal(*commits, want) {
+		t.Fatalf("expected commits %v, got %v", want, *commits)
+	}
+}
+
+func TestOffsetTrackerRestartsPartitionFetchedAgain(t *testing.T) {
+	tracker, commits := recordedCommits(nil)
+	tracker.fetched(msgAt(0, 0))
+	tracker.fetched(msgAt(0, 1))
+
+	// A rebalance hands out the partition again from the committed offset
+	tracker.fetched(msgAt(0, 0))
+	if err := tracker.settle(msgAt(0, 0)); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+
+	want := [][2]int64{{0, 0}}
+	if !slices.Equal(*commits, want) {
+		t.Fatalf("expected commits %v, got %v", want, *commits)
+	}
+}
+
+func TestOffsetTrackerFailedCommitIsCoveredByNextOne(t *testing.T) {
+	failing := true
+	tracker, commits := recordedCommits(func(kafka.Message) error {
+		if failing {
+			return errors.New("coordinator not available")
+		}
+
+		return nil
+	})
+	tracker.fetched(msgAt(0, 0))
+	tracker.fetched(msgAt(0, 1))
+
+	if err := tracker.settle(msgAt(0, 0)); err == nil {
+		t.Fatal("expected the failed commit to be returned")
+	}
+
+	failing = false
+	if err := tracker.settle(msgAt(0, 1)); err != nil {
+		t.Fatalf("settle: %v", err)
+	}
+
+	want := [][2]int64{{0, 1}}
+	if !slices.Equal(*commits, want) {
+		t.Fatalf("expected commits %v, got %v", want, *commits)
+	}
+}
+
+func TestOffsetTrackerRequeuedMessageIsReadAgainInOrder(t *testing.T) {
+	tracker, commits := recordedCommits(nil)
+	for offset := range int64(3) {
+		if tracker.fetched(msgAt(0, offset)) {
+			t.Fatalf("expected message %d delivered for the first time", offset)
+		}
+	}
+
+	// A requeued message holds back the committed offset of the later ones
+	tracker.requeue(msgAt(0, 1))
+	for _, offset := range []int64{0, 2} {
+		if err := tracker.settle(msgAt(0, offset)); err != nil {
+			t.Fatalf("settle %d: %v", offset, err)
+		}
+	}
+
+	want := [][2]int64{{0, 0}}
+	if !slices.Equal(*commits, want) {
+		t.Fatalf("expected commits %v, got %v", want, *commits)
+	}
+
+	// The partition is read again from the requeued message
+	if !tracker.fetched(msgAt(0, 1)) {
+		t.Fatal("expected the requeued message reported redelivered")
+	}
+	if tracker.fetched(msgAt(0, 2)) {
+		t.Fatal("expected the message after the requeued one not reported redelivered")
+	}
+}
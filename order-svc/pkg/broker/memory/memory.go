@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+)
+
+var ErrClosed = errors.New("broker is closed")
+
+// Broker implements broker.Broker in memory.
+// Topics behave like queues: subscribers of a topic compete for its messages,
+// unacknowledged messages are delivered again when rejected with requeue.
+// It is meant for tests and keeps every published message for assertions.
+type Broker struct {
+	mu         sync.Mutex
+	topics     map[string]*topic
+	published  []broker.Message
+	publishErr error
+	closed     bool
+}
+
+// topic is a queue of messages waiting for delivery.
+type topic struct {
+	queue  []queued
+	notify chan struct{}
+	// delay and target are set for delayed topics, messages are moved to the target instead of queued
+	delay  time.Duration
+	target string
+}
+
+// queued is a message waiting in a topic.
+type queued struct {
+	msg         broker.Message
+	redelivered bool
+}
+
+// New creates an in-memory broker.
+func New() *Broker {
+	return &Broker{
+		topics: make(map[string]*topic),
+	}
+}
+
+// System returns the messaging system name.
+func (b *Broker) System() string {
+	return "memory"
+}
+
+// Declare creates the topic if it does not exist.
+func (b *Broker) Declare(_ context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	b.topic(name)
+
+	return nil
+}
+
+// DeclareDelayed creates a topic whose messages are moved to the target topic after the delay.
+func (b *Broker) DeclareDelayed(_ context.Context, name string, delay time.Duration, target string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	t := b.topic(name)
+	t.delay = delay
+	t.target = target
+
+	return nil
+}
+
+// Publish enqueues the message to its topic.
+func (b *Broker) Publish(_ context.Context, msg broker.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+
+	b.published = append(b.published, msg)
+	b.enqueue(msg.Topic, queued{msg: msg})
+
+	return nil
+}
+
+// Subscribe delivers messages of the topic until the context is canceled.
+// The group is ignored, all subscribers of a topic compete for messages.
+func (b *Broker) Subscribe(ctx context.Context, name string, _ string) (<-chan broker.Delivery, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+
+		return nil, ErrClosed
+	}
+	t := b.topic(name)
+	b.mu.Unlock()
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+
+		for {
+			b.mu.Lock()
+			if b.closed {
+				b.mu.Unlock()
+
+				return
+			}
+			if len(t.queue) == 0 {
+				notify := t.notify
+				b.mu.Unlock()
+
+				select {
+				case <-notify:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			next := t.queue[0]
+			t.queue = t.queue[1:]
+			b.mu.Unlock()
+
+			select {
+			case out <- b.toDelivery(next):
+			case <-ctx.Done():
+				b.mu.Lock()
+				t.queue = append([]queued{next}, t.queue...)
+				b.mu.Unlock()
+
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// QueueDepth returns the number of messages waiting in the topic, the group is not used.
+func (b *Broker) QueueDepth(_ context.Context, topic string, _ string) (int, error) {
+	return b.Pending(topic), nil
+}
+
+// CheckHealth returns broker.ErrUnhealthy once the broker is closed.
+func (b *Broker) CheckHealth(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("%w: %w", broker.ErrUnhealthy, ErrClosed)
+	}
+
+	return nil
+}
+
+// Close stops all subscriptions.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, t := range b.topics {
+		close(t.notify)
+	}
+
+	return nil
+}
+
+// SetPublishError makes every following Publish fail with err, nil restores publishing.
+func (b *Broker) SetPublishError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.publishErr = err
+}
+
+// Published returns messages successfully published to the topic, in publish order.
+func (b *Broker) Published(name string) []broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []broker.Message
+	for _, msg := range b.published {
+		if msg.Topic == name {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages
+}
+
+// Pending returns the number of messages waiting for delivery in the topic.
+func (b *Broker) Pending(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.topic(name).queue)
+}
+
+// topic returns the topic, creating it if needed. Must be called with the lock held.
+func (b *Broker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{notify: make(chan struct{})}
+		b.topics[name] = t
+	}
+
+	return t
+}
+
+// enqueue appends the message and wakes subscribers. Must be called with the lock held.
+// Messages of a delayed topic are moved to its target once the delay has passed.
+func (b *Broker) enqueue(name string, q queued) {
+	t := b.topic(name)
+	if t.target != "" {
+		time.AfterFunc(t.delay, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			if !b.closed {
+				msg := q.msg
+				msg.Topic = t.target
+				b.enqueue(t.target, queued{msg: msg})
+			}
+		})
+
+		return
+	}
+
+	t.queue = append(t.queue, q)
+
+	if !b.closed {
+		close(t.notify)
+		t.notify = make(chan struct{})
+	}
+}
+
+// toDelivery wraps a queued message, a requeued rejection puts it back to the topic.
+func (b *Broker) toDelivery(q queued) broker.Delivery {
+	var once sync.Once
+	settle := func(requeue bool) error {
+		once.Do(func() {
+			if !requeue {
+				return
+			}
+
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.enqueue(q.msg.Topic, queued{msg: q.msg, redelivered: true})
+		})
+
+		return nil
+	}
+
+	return broker.NewDelivery(
+		q.msg,
+		q.redelivered,
+		func() error { return settle(false) },
+		settle,
+	)
+}
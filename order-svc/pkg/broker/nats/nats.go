@@ -0,0 +1,207 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Config configures the NATS JetStream broker.
+type Config struct {
+	URL      string
+	Replicas int
+	// Prefetch limits unacknowledged messages per consumer, zero means the server default
+	Prefetch int
+}
+
+// Broker implements broker.Broker on top of NATS JetStream.
+// Every topic is a subject with its own stream, groups are durable consumers.
+type Broker struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+	cfg  Config
+}
+
+// New connects to NATS.
+func New(cfg Config) (*Broker, error) {
+	if cfg.Replicas <= 0 {
+		cfg.Replicas = 1
+	}
+
+	conn, err := nats.Connect(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	return &Broker{
+		conn: conn,
+		js:   js,
+		cfg:  cfg,
+	}, nil
+}
+
+// System returns the messaging system name.
+func (b *Broker) System() string {
+	return broker.TypeNATS
+}
+
+// Declare creates or updates the stream of the subject.
+func (b *Broker) Declare(ctx context.Context, topic string) error {
+	_, err := b.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName(topic),
+		Subjects: []string{topic},
+		Replicas: b.cfg.Replicas,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare stream for %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// Publish publishes the message to its subject. The message ID is used for deduplication.
+func (b *Broker) Publish(ctx context.Context, msg broker.Message) error {
+	natsMsg := nats.NewMsg(msg.Topic)
+	natsMsg.Data = msg.Body
+	for k, v := range broker.StringHeaders(msg) {
+		natsMsg.Header.Set(k, v)
+	}
+
+	var opts []jetstream.PublishOpt
+	if msg.ID != "" {
+		opts = append(opts, jetstream.WithMsgID(msg.ID))
+	}
+
+	if _, err := b.js.PublishMsg(ctx, natsMsg, opts...); err != nil {
+		return fmt.Errorf("failed to publish nats message: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe consumes the subject with a durable consumer named after the group.
+func (b *Broker) Subscribe(ctx context.Context, topic string, group string) (<-chan broker.Delivery, error) {
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, streamName(topic), jetstream.ConsumerConfig{
+		Durable:       consumerName(group),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: topic,
+		MaxAckPending: b.cfg.Prefetch,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer for %s: %w", topic, err)
+	}
+
+	messages, err := consumer.Messages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s: %w", topic, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		messages.Stop()
+	}()
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+
+		for {
+			m, err := messages.Next()
+			if err != nil {
+				if !errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+					messages.Stop()
+				}
+
+				return
+			}
+
+			select {
+			case out <- toDelivery(topic, m):
+			case <-ctx.Done():
+				_ = m.Nak()
+
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// QueueDepth returns the number of messages of the subject not yet delivered to the durable consumer of the group.
+func (b *Broker) QueueDepth(ctx context.Context, topic string, group string) (int, error) {
+	consumer, err := b.js.Consumer(ctx, streamName(topic), consumerName(group))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get consumer of %s: %w", topic, err)
+	}
+
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get consumer info of %s: %w", topic, err)
+	}
+
+	return int(info.NumPending), nil
+}
+
+// CheckHealth returns broker.ErrUnhealthy when the connection is not connected,
+// including while it reconnects.
+func (b *Broker) CheckHealth(context.Context) error {
+	if !b.conn.IsConnected() {
+		return fmt.Errorf("%w: connection is %s", broker.ErrUnhealthy, b.conn.Status())
+	}
+
+	return nil
+}
+
+// Close drains and closes the connection.
+func (b *Broker) Close() error {
+	return b.conn.Drain()
+}
+
+// toDelivery converts a JetStream message.
+func toDelivery(topic string, m jetstream.Msg) broker.Delivery {
+	headers := make(map[string]string, len(m.Headers()))
+	for k := range m.Headers() {
+		headers[k] = m.Headers().Get(k)
+	}
+
+	redelivered := false
+	if meta, err := m.Metadata(); err == nil {
+		redelivered = meta.NumDelivered > 1
+	}
+
+	return broker.NewDelivery(
+		broker.MessageFromStringHeaders(topic, "", headers, m.Data()),
+		redelivered,
+		m.Ack,
+		func(requeue bool) error {
+			if requeue {
+				return m.Nak()
+			}
+
+			return m.Term()
+		},
+	)
+}
+
+// streamName derives a stream name from the subject, stream names cannot contain dots.
+func streamName(topic string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "*", "ALL", ">", "REST").Replace(topic))
+}
+
+// consumerName derives a durable consumer name from the group.
+func consumerName(group string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(group)
+}
@@ -0,0 +1,341 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/streadway/amqp"
+)
+
+// QueueConfig configures declared queues.
+type QueueConfig struct {
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	NoWait     bool
+}
+
+// Config configures the RabbitMQ broker.
+type Config struct {
+	URL string
+	// Exchange messages are published to, the default exchange routes by queue name
+	Exchange string
+	Queue    QueueConfig
+	// Prefetch limits unacknowledged deliveries per subscription, zero means unlimited
+	Prefetch int
+}
+
+// ErrNotConfirmed is returned for a published message the server did not take responsibility for.
+var ErrNotConfirmed = errors.New("message was not confirmed by RabbitMQ")
+
+// Broker implements broker.Broker on top of RabbitMQ.
+// Topics are queues, groups are consumer tags.
+//
+// The publishing channel is in confirm mode and messages are published as mandatory:
+// Publish returns once the server confirmed the message and fails for a message
+// rejected by the server or not routed to any queue.
+type Broker struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	cfg     Config
+
+	// publishMu serializes publishes, a message is confirmed before the next one is published
+	publishMu sync.Mutex
+	// published is the delivery tag of the last published message
+	published uint64
+	confirms  chan amqp.Confirmation
+	returns   chan amqp.Return
+
+	mu sync.Mutex
+	// closedChannels holds the close errors of the channels closed by the server, by channel name
+	closedChannels map[string]error
+}
+
+// New connects to RabbitMQ.
+func New(cfg Config) (*Broker, error) {
+	conn, err := amqp.Dial(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = conn.Close()
+
+		return nil, fmt.Errorf("failed to put the channel in confirm mode: %w", err)
+	}
+
+	b := &Broker{
+		conn:    conn,
+		channel: channel,
+		cfg:     cfg,
+		// Confirms and returns of messages whose publisher stopped waiting are read by the next publish
+		confirms:       channel.NotifyPublish(make(chan amqp.Confirmation, 16)),
+		returns:        channel.NotifyReturn(make(chan amqp.Return, 16)),
+		closedChannels: make(map[string]error),
+	}
+	b.watch("publish", channel)
+
+	return b, nil
+}
+
+// System returns the messaging system name.
+func (b *Broker) System() string {
+	return broker.TypeRabbitMQ
+}
+
+// Declare declares the queue.
+func (b *Broker) Declare(_ context.Context, topic string) error {
+	_, err := b.channel.QueueDeclare(
+		topic,
+		b.cfg.Queue.Durable,
+		b.cfg.Queue.AutoDelete,
+		b.cfg.Queue.Exclusive,
+		b.cfg.Queue.NoWait,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// DeclareDelayed declares a queue without consumers whose messages expire after the delay
+// and are dead-lettered through the default exchange to the target queue.
+func (b *Broker) DeclareDelayed(_ context.Context, topic string, delay time.Duration, target string) error {
+	_, err := b.channel.QueueDeclare(
+		topic,
+		b.cfg.Queue.Durable,
+		b.cfg.Queue.AutoDelete,
+		b.cfg.Queue.Exclusive,
+		b.cfg.Queue.NoWait,
+		amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": target,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare delayed queue %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// Publish publishes the message with the topic as routing key and waits for its confirmation.
+// Returns ErrNotConfirmed when the server rejected the message or could not route it to a queue.
+func (b *Broker) Publish(ctx context.Context, msg broker.Message) error {
+	b.publishMu.Lock()
+	defer b.publishMu.Unlock()
+
+	err := b.channel.Publish(
+		b.cfg.Exchange,
+		msg.Topic,
+		true,
+		false,
+		amqp.Publishing{
+			Headers:     amqp.Table(msg.Headers),
+			ContentType: msg.ContentType,
+			Type:        msg.Type,
+			MessageId:   msg.ID,
+			Timestamp:   msg.Timestamp,
+			Body:        msg.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to %s: %w", msg.Topic, err)
+	}
+	b.published++
+
+	return b.waitConfirm(ctx, msg.Topic, b.published)
+}
+
+// waitConfirm waits for the confirmation of the message with the delivery tag.
+// The server returns an unroutable mandatory message before it confirms it.
+func (b *Broker) waitConfirm(ctx context.Context, topic string, tag uint64) error {
+	var returned *amqp.Return
+	for {
+		select {
+		case r := <-b.returns:
+			returned = &r
+		case c, ok := <-b.confirms:
+			if !ok {
+				return fmt.Errorf("%w: channel closed before message to %s was confirmed", ErrNotConfirmed, topic)
+			}
+			if c.DeliveryTag < tag {
+				// A message whose publisher stopped waiting, a return read before its confirmation was its own
+				returned = nil
+
+				continue
+			}
+			if !c.Ack {
+				return fmt.Errorf("%w: message to %s was rejected", ErrNotConfirmed, topic)
+			}
+			if returned != nil {
+				return fmt.Errorf("%w: message to %s was returned: %s", ErrNotConfirmed, topic, returned.ReplyText)
+			}
+
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrNotConfirmed, ctx.Err())
+		}
+	}
+}
+
+// Subscribe consumes the queue with manual acknowledgements on a channel of its own,
+// so prefetch and cumulative acknowledgements apply to the subscription only.
+// When the context is canceled the consumer is canceled and deliveries
+// that were prefetched but not handed out are rejected with requeue.
+func (b *Broker) Subscribe(ctx context.Context, topic string, group string) (<-chan broker.Delivery, error) {
+	channel, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if b.cfg.Prefetch > 0 {
+		if err := channel.Qos(b.cfg.Prefetch, 0, false); err != nil {
+			_ = channel.Close()
+
+			return nil, fmt.Errorf("failed to set prefetch count: %w", err)
+		}
+	}
+
+	// Consumer tags are unique per channel, the queue keeps them apart on a shared connection
+	tag := group + "." + topic
+	msgs, err := channel.Consume(topic, tag, false, false, false, false, nil)
+	if err != nil {
+		_ = channel.Close()
+
+		return nil, fmt.Errorf("failed to consume queue %s: %w", topic, err)
+	}
+	b.watch(tag, channel)
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				cancel(channel, tag, msgs)
+
+				return
+			case d, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				select {
+				case out <- toDelivery(topic, d):
+				case <-ctx.Done():
+					_ = d.Nack(false, true)
+					cancel(channel, tag, msgs)
+
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// cancel stops the consumer and requeues the deliveries buffered for it.
+// The channel stays open until the connection is closed, so handed out deliveries can still be settled.
+func cancel(channel *amqp.Channel, tag string, msgs <-chan amqp.Delivery) {
+	if err := channel.Cancel(tag, false); err != nil {
+		return
+	}
+
+	for d := range msgs {
+		_ = d.Nack(false, true)
+	}
+}
+
+// QueueDepth returns the number of ready messages of the queue, the group is not used.
+// The queue is inspected on a channel of its own, inspecting a missing queue closes the channel.
+func (b *Broker) QueueDepth(_ context.Context, topic string, _ string) (int, error) {
+	channel, err := b.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer channel.Close()
+
+	queue, err := channel.QueueInspect(topic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue %s: %w", topic, err)
+	}
+
+	return queue.Messages, nil
+}
+
+// CheckHealth returns broker.ErrUnhealthy when the connection is closed
+// or the server closed the publishing channel or a subscription channel.
+func (b *Broker) CheckHealth(context.Context) error {
+	if b.conn.IsClosed() {
+		return fmt.Errorf("%w: connection is closed", broker.ErrUnhealthy)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for name, err := range b.closedChannels {
+		return fmt.Errorf("%w: channel %s is closed: %v", broker.ErrUnhealthy, name, err)
+	}
+
+	return nil
+}
+
+// watch records the error the channel is closed with.
+// Channels closed by the client close the notification without an error and are not recorded.
+func (b *Broker) watch(name string, channel *amqp.Channel) {
+	closed := channel.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		err, ok := <-closed
+		if !ok || err == nil {
+			return
+		}
+
+		b.mu.Lock()
+		b.closedChannels[name] = err
+		b.mu.Unlock()
+	}()
+}
+
+// Close closes the channel and the connection.
+func (b *Broker) Close() error {
+	if err := b.channel.Close(); err != nil {
+		return err
+	}
+
+	return b.conn.Close()
+}
+
+// toDelivery converts an AMQP delivery.
+func toDelivery(topic string, d amqp.Delivery) broker.Delivery {
+	return broker.NewDelivery(
+		broker.Message{
+			Topic:       topic,
+			ID:          d.MessageId,
+			Type:        d.Type,
+			ContentType: d.ContentType,
+			Timestamp:   d.Timestamp,
+			Headers:     d.Headers,
+			Body:        d.Body,
+		},
+		d.Redelivered,
+		func() error { return d.Ack(false) },
+		func(requeue bool) error { return d.Nack(false, requeue) },
+	).WithAckMultiple(func() error { return d.Ack(true) })
+}
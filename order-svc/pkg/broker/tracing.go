@@ -0,0 +1,92 @@
+package broker
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// HeaderCarrier adapts message headers to the OpenTelemetry text map carrier.
+type HeaderCarrier map[string]any
+
+// Get returns the header value as a string.
+func (c HeaderCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+// Set sets the header value.
+func (c HeaderCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+// Keys returns the header names.
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// TraceHeaders returns the trace context of ctx as headers, e.g. the W3C traceparent.
+// They are kept as strings so they can be stored until the message is published.
+func TraceHeaders(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	return carrier
+}
+
+// ExtractTraceHeaders returns ctx with the trace context stored by TraceHeaders.
+func ExtractTraceHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// ExtractTraceContext returns ctx with the trace context carried in the message headers.
+func ExtractTraceContext(ctx context.Context, headers map[string]any) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, HeaderCarrier(headers))
+}
+
+// PublishAttributes returns messaging semantic convention attributes of a publish span.
+func PublishAttributes(system string, msg Message) []attribute.KeyValue {
+	return append(messageAttributes(system, msg),
+		semconv.MessagingOperationTypePublish,
+		semconv.MessagingOperationName("publish"),
+	)
+}
+
+// ProcessAttributes returns messaging semantic convention attributes of a process span.
+func ProcessAttributes(system string, group string, msg Message) []attribute.KeyValue {
+	return append(messageAttributes(system, msg),
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingConsumerGroupName(group),
+	)
+}
+
+// messageAttributes returns attributes common to all messaging spans.
+func messageAttributes(system string, msg Message) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(system),
+		semconv.MessagingDestinationName(msg.Topic),
+	}
+	if len(msg.Body) > 0 {
+		attrs = append(attrs, semconv.MessagingMessageBodySize(len(msg.Body)))
+	}
+	if msg.ID != "" {
+		attrs = append(attrs, semconv.MessagingMessageID(msg.ID))
+	}
+
+	return attrs
+}
@@ -0,0 +1,244 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+// Circuit breaker states.
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Config configures a circuit breaker.
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker
+	FailureThreshold int
+	// SuccessThreshold is the number of successes in half-open state that closes the breaker,
+	// it also caps the trial calls let through at once in half-open state
+	SuccessThreshold int
+	// OpenTimeout is how long the breaker stays open before letting trial calls through
+	OpenTimeout time.Duration
+	// OnStateChange is called on every state transition, outside of the breaker lock
+	OnStateChange func(name string, from State, to State)
+	// OnState is called with the initial state and on every state transition under the breaker lock,
+	// so concurrent transitions are observed in order. It is meant for metrics and must not call the breaker.
+	OnState func(name string, state State)
+}
+
+// Breaker is a circuit breaker with closed, open and half-open states.
+//
+// Calls are allowed while closed. Consecutive failures open the breaker and calls
+// are rejected with ErrOpen until OpenTimeout elapses, then the breaker is half-open
+// and lets at most SuccessThreshold calls through as trials, further calls are rejected
+// with ErrOpen until a trial reports its result. Trials that never report are released
+// after OpenTimeout. Successful trials close the breaker, a failed trial opens it again.
+// Results of calls made outside of Allow, e.g. by a background retry worker,
+// can be reported too and count as trials.
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	openedAt  time.Time
+	// trials is the number of half-open trial calls let through and not reported yet
+	trials int
+	// trialsAt is when the first of the unreported trials was let through
+	trialsAt time.Time
+}
+
+// New creates a new closed circuit breaker.
+func New(name string, cfg Config) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = 1
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+
+	b := &Breaker{
+		name:  name,
+		cfg:   cfg,
+		now:   time.Now,
+		state: StateClosed,
+	}
+	if cfg.OnState != nil {
+		cfg.OnState(name, b.state)
+	}
+
+	return b
+}
+
+// Name returns the name of the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Allow reports whether a call may proceed. It returns ErrOpen while the breaker is open
+// and while it is half-open with all trial calls in flight.
+// A call let through must report its result with Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	from := b.state
+	now := b.now()
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+
+	allowed := true
+	switch b.state {
+	case StateOpen:
+		allowed = false
+	case StateHalfOpen:
+		// Trials that never reported, e.g. canceled calls, do not block probing forever
+		if b.trials > 0 && now.Sub(b.trialsAt) >= b.cfg.OpenTimeout {
+			b.trials = 0
+		}
+		if b.trials >= b.cfg.SuccessThreshold {
+			allowed = false
+
+			break
+		}
+		if b.trials == 0 {
+			b.trialsAt = now
+		}
+		b.trials++
+	case StateClosed:
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+
+	if !allowed {
+		return ErrOpen
+	}
+
+	return nil
+}
+
+// Success records a successful call.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case StateClosed:
+		b.failures = 0
+	case StateOpen:
+		// A call made outside of the breaker succeeded, start probing
+		b.setState(StateHalfOpen)
+		b.successes = 1
+	case StateHalfOpen:
+		b.successes++
+		b.releaseTrial()
+	}
+	if b.state == StateHalfOpen && b.successes >= b.cfg.SuccessThreshold {
+		b.setState(StateClosed)
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// Failure records a failed call.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	from := b.state
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.setState(StateOpen)
+	case StateOpen:
+		b.openedAt = b.now()
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// Execute runs fn if the breaker allows it and records the result.
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		b.Failure()
+
+		return err
+	}
+	b.Success()
+
+	return nil
+}
+
+// setState switches the state and resets counters. Must be called with the lock held.
+func (b *Breaker) setState(state State) {
+	if state != b.state && b.cfg.OnState != nil {
+		b.cfg.OnState(b.name, state)
+	}
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	b.trials = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// releaseTrial frees the slot of a reported trial. Must be called with the lock held.
+func (b *Breaker) releaseTrial() {
+	if b.trials > 0 {
+		b.trials--
+	}
+}
+
+// notify calls the state change callback if the state changed.
+func (b *Breaker) notify(from State, to State) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, to)
+	}
+}
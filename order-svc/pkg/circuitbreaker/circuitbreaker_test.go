@@ -0,0 +1,260 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type op int
+
+const (
+	opAllow op = iota
+	opSuccess
+	opFailure
+	opAdvance
+)
+
+type step struct {
+	op op
+	// wantErr is the expected result of opAllow
+	wantErr error
+	// advance is the time passed by opAdvance
+	advance time.Duration
+	// wantState is the state after the step
+	wantState State
+}
+
+func allow(wantErr error, wantState State) step {
+	return step{op: opAllow, wantErr: wantErr, wantState: wantState}
+}
+
+func success(wantState State) step {
+	return step{op: opSuccess, wantState: wantState}
+}
+
+func failure(wantState State) step {
+	return step{op: opFailure, wantState: wantState}
+}
+
+func advance(d time.Duration, wantState State) step {
+	return step{op: opAdvance, advance: d, wantState: wantState}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	const timeout = 10 * time.Second
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		steps []step
+	}{
+		{
+			name: "closed stays closed below the failure threshold",
+			cfg:  Config{FailureThreshold: 3},
+			steps: []step{
+				failure(StateClosed),
+				failure(StateClosed),
+				success(StateClosed),
+				failure(StateClosed),
+				failure(StateClosed),
+				allow(nil, StateClosed),
+			},
+		},
+		{
+			name: "consecutive failures open the breaker",
+			cfg:  Config{FailureThreshold: 2},
+			steps: []step{
+				failure(StateClosed),
+				failure(StateOpen),
+				allow(ErrOpen, StateOpen),
+				advance(timeout-time.Second, StateOpen),
+				allow(ErrOpen, StateOpen),
+			},
+		},
+		{
+			name: "successful trials close the breaker",
+			cfg:  Config{FailureThreshold: 1, SuccessThreshold: 2},
+			steps: []step{
+				failure(StateOpen),
+				advance(timeout, StateOpen),
+				allow(nil, StateHalfOpen),
+				success(StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				success(StateClosed),
+				allow(nil, StateClosed),
+			},
+		},
+		{
+			name: "failed trial opens the breaker again",
+			cfg:  Config{FailureThreshold: 1, SuccessThreshold: 2},
+			steps: []step{
+				failure(StateOpen),
+				advance(timeout, StateOpen),
+				allow(nil, StateHalfOpen),
+				success(StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				failure(StateOpen),
+				allow(ErrOpen, StateOpen),
+				advance(timeout, StateOpen),
+				allow(nil, StateHalfOpen),
+			},
+		},
+		{
+			name: "half-open trials are capped at the success threshold",
+			cfg:  Config{FailureThreshold: 1, SuccessThreshold: 2},
+			steps: []step{
+				failure(StateOpen),
+				advance(timeout, StateOpen),
+				allow(nil, StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				allow(ErrOpen, StateHalfOpen),
+				success(StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				allow(ErrOpen, StateHalfOpen),
+			},
+		},
+		{
+			name: "unreported trials are released after the open timeout",
+			cfg:  Config{FailureThreshold: 1},
+			steps: []step{
+				failure(StateOpen),
+				advance(timeout, StateOpen),
+				allow(nil, StateHalfOpen),
+				allow(ErrOpen, StateHalfOpen),
+				advance(timeout, StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				success(StateClosed),
+			},
+		},
+		{
+			name: "success reported while open starts probing",
+			cfg:  Config{FailureThreshold: 1, SuccessThreshold: 2},
+			steps: []step{
+				failure(StateOpen),
+				success(StateHalfOpen),
+				allow(nil, StateHalfOpen),
+				success(StateClosed),
+			},
+		},
+		{
+			name: "failure reported while open restarts the timeout",
+			cfg:  Config{FailureThreshold: 1},
+			steps: []step{
+				failure(StateOpen),
+				advance(timeout-time.Second, StateOpen),
+				failure(StateOpen),
+				advance(timeout-time.Second, StateOpen),
+				allow(ErrOpen, StateOpen),
+				advance(time.Second, StateOpen),
+				allow(nil, StateHalfOpen),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			cfg := tt.cfg
+			cfg.OpenTimeout = timeout
+			b := New("test", cfg)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case opAllow:
+					if err := b.Allow(); !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d: expected allow error %v, got %v", i, s.wantErr, err)
+					}
+				case opSuccess:
+					b.Success()
+				case opFailure:
+					b.Failure()
+				case opAdvance:
+					now = now.Add(s.advance)
+				}
+
+				if state := b.State(); state != s.wantState {
+					t.Fatalf("step %d: expected state %s, got %s", i, s.wantState, state)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerNotifiesStateChanges(t *testing.T) {
+	var transitions []string
+	now := time.Unix(0, 0)
+	b := New("test", Config{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Second,
+		OnStateChange: func(name string, from State, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	now = now.Add(time.Second)
+	if err := b.Execute(func() error { return errors.New("boom") }); err == nil {
+		t.Fatal("expected the trial error")
+	}
+	now = now.Add(time.Second)
+	if err := b.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+	}
+}
+
+func TestBreakerObservedStateFollowsConcurrentTransitions(t *testing.T) {
+	// The observed state is only written by OnState, a stale write would leave it behind the breaker
+	var observed State
+	b := New("test", Config{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Nanosecond,
+		OnState: func(name string, state State) {
+			observed = state
+		},
+	})
+	if observed != StateClosed {
+		t.Fatalf("expected the initial state observed, got %s", observed)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 200 {
+				if b.Allow() != nil {
+					continue
+				}
+				if i%2 == 0 {
+					b.Failure()
+				} else {
+					b.Success()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	b.mu.Lock()
+	state := b.state
+	got := observed
+	b.mu.Unlock()
+	if got != state {
+		t.Fatalf("expected observed state %s, got %s", state, got)
+	}
+}
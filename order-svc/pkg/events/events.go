@@ -0,0 +1,146 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Content types of published events.
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// Event types, sent in the AMQP type property.
+const (
+	TypeOrderCreated       = "order.created"
+	TypeOrderStatusChanged = "order.status_changed"
+	TypeOrderCancelled     = "order.cancelled"
+	TypeOrderItemUpdated   = "order.item_updated"
+)
+
+// Message headers used to order events of a single aggregate.
+const (
+	HeaderAggregateID = "x-aggregate-id"
+	HeaderSequence    = "x-sequence"
+)
+
+// MetadataMessageID is the gRPC metadata key carrying the IDs of the broker messages
+// whose audit logs are saved through the order service, redelivered messages are dropped by it.
+const MetadataMessageID = "x-message-id"
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Marshal encodes an event as protobuf.
+func Marshal(event proto.Message) ([]byte, error) {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return data, nil
+}
+
+// Change builds a field change, a nil before marks a field that was not set.
+// Values have to be JSON compatible: nil, bool, numbers, strings, slices and maps of them.
+func Change(field string, before any, after any) (*pb.FieldChange, error) {
+	beforeValue, err := structpb.NewValue(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s before value: %w", field, err)
+	}
+
+	afterValue, err := structpb.NewValue(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s after value: %w", field, err)
+	}
+
+	return &pb.FieldChange{Field: field, Before: beforeValue, After: afterValue}, nil
+}
+
+// DecodeOrderCreated decodes an OrderCreated event according to the content type.
+// JSON payloads are legacy messages that contain a bare order instead of an event.
+func DecodeOrderCreated(contentType string, body []byte) (*pb.OrderCreated, error) {
+	switch mediaType(contentType) {
+	case ContentTypeProtobuf:
+		var event pb.OrderCreated
+		if err := proto.Unmarshal(body, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal protobuf event: %w", err)
+		}
+
+		return &event, nil
+	case ContentTypeJSON, "":
+		var snapshot pb.OrderSnapshot
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal legacy json order: %w", err)
+		}
+
+		return &pb.OrderCreated{Order: &snapshot}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// DecodeOrderStatusChanged decodes an OrderStatusChanged event according to the content type.
+func DecodeOrderStatusChanged(contentType string, body []byte) (*pb.OrderStatusChanged, error) {
+	var event pb.OrderStatusChanged
+	if err := decode(contentType, body, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// DecodeOrderCancelled decodes an OrderCancelled event according to the content type.
+func DecodeOrderCancelled(contentType string, body []byte) (*pb.OrderCancelled, error) {
+	var event pb.OrderCancelled
+	if err := decode(contentType, body, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// DecodeOrderItemUpdated decodes an OrderItemUpdated event according to the content type.
+func DecodeOrderItemUpdated(contentType string, body []byte) (*pb.OrderItemUpdated, error) {
+	var event pb.OrderItemUpdated
+	if err := decode(contentType, body, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// decode unmarshals an event encoded as protobuf or protobuf JSON.
+func decode(contentType string, body []byte, event proto.Message) error {
+	switch mediaType(contentType) {
+	case ContentTypeProtobuf:
+		if err := proto.Unmarshal(body, event); err != nil {
+			return fmt.Errorf("failed to unmarshal protobuf event: %w", err)
+		}
+
+		return nil
+	case ContentTypeJSON:
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, event); err != nil {
+			return fmt.Errorf("failed to unmarshal json event: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// mediaType strips parameters such as charset from the content type.
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,193 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/service/models/currency"
+	"github.com/corray333/backend-labs/order/internal/service/models/order"
+	"github.com/corray333/backend-labs/order/internal/service/models/orderitem"
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecodeOrderCreated(t *testing.T) {
+	event := &pb.OrderCreated{
+		EventId: "event-1",
+		Order:   &pb.OrderSnapshot{Id: 42, CustomerId: 7, DeliveryAddress: "Main st. 1"},
+	}
+	protobufBody, err := proto.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal protobuf: %v", err)
+	}
+	legacyBody, err := protojson.Marshal(event.GetOrder())
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantEventID string
+		wantErr     error
+	}{
+		{
+			name:        "protobuf",
+			contentType: ContentTypeProtobuf,
+			body:        protobufBody,
+			wantEventID: "event-1",
+		},
+		{
+			name:        "protobuf with parameters and mixed case",
+			contentType: "Application/X-Protobuf; charset=binary",
+			body:        protobufBody,
+			wantEventID: "event-1",
+		},
+		{
+			name:        "legacy json order",
+			contentType: "application/json; charset=utf-8",
+			body:        legacyBody,
+		},
+		{
+			name: "legacy message without content type",
+			body: legacyBody,
+		},
+		{
+			name:        "legacy json with unknown fields",
+			contentType: ContentTypeJSON,
+			body:        []byte(`{"id": "42", "customerId": "7", "deliveryAddress": "Main st. 1", "comment": "new"}`),
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        []byte("42"),
+			wantErr:     ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeOrderCreated(tt.contentType, tt.body)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+
+			if got.GetEventId() != tt.wantEventID {
+				t.Errorf("expected event id %q, got %q", tt.wantEventID, got.GetEventId())
+			}
+			if order := got.GetOrder(); order.GetId() != 42 || order.GetCustomerId() != 7 ||
+				order.GetDeliveryAddress() != "Main st. 1" {
+				t.Errorf("unexpected order %v", order)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderCreatedLegacyOrder(t *testing.T) {
+	// The legacy producer published the order model encoded with encoding/json
+	createdAt := time.Date(2025, time.November, 3, 14, 5, 6, 123456789, time.FixedZone("MSK", 3*60*60))
+	legacyBody, err := json.Marshal(order.Order{
+		ID:                 42,
+		CustomerID:         7,
+		DeliveryAddress:    "Main st. 1",
+		TotalPriceCents:    1500,
+		TotalPriceCurrency: currency.CurrencyRUB,
+		CreatedAt:          createdAt,
+		UpdatedAt:          createdAt,
+		OrderItems: []orderitem.OrderItem{{
+			ID:            100,
+			OrderID:       42,
+			ProductID:     5,
+			Quantity:      3,
+			ProductTitle:  "Tea",
+			ProductUrl:    "https://example.com/tea",
+			PriceCents:    500,
+			PriceCurrency: currency.CurrencyRUB,
+			CreatedAt:     createdAt,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal legacy order: %v", err)
+	}
+
+	got, err := DecodeOrderCreated(ContentTypeJSON, legacyBody)
+	if err != nil {
+		t.Fatalf("decode legacy order %s: %v", legacyBody, err)
+	}
+
+	snapshot := got.GetOrder()
+	if snapshot.GetId() != 42 || snapshot.GetCustomerId() != 7 || snapshot.GetDeliveryAddress() != "Main st. 1" ||
+		snapshot.GetTotalPriceCents() != 1500 || snapshot.GetTotalPriceCurrency() != "RUB" {
+		t.Errorf("unexpected order %v", snapshot)
+	}
+	if !snapshot.GetCreatedAt().AsTime().Equal(createdAt) || !snapshot.GetUpdatedAt().AsTime().Equal(createdAt) {
+		t.Errorf("expected order times %s, got %v and %v", createdAt, snapshot.GetCreatedAt(), snapshot.GetUpdatedAt())
+	}
+
+	if len(snapshot.GetOrderItems()) != 1 {
+		t.Fatalf("expected 1 order item, got %v", snapshot.GetOrderItems())
+	}
+	item := snapshot.GetOrderItems()[0]
+	if item.GetId() != 100 || item.GetOrderId() != 42 || item.GetProductId() != 5 || item.GetQuantity() != 3 ||
+		item.GetProductTitle() != "Tea" || item.GetProductUrl() != "https://example.com/tea" ||
+		item.GetPriceCents() != 500 || item.GetPriceCurrency() != "RUB" {
+		t.Errorf("unexpected order item %v", item)
+	}
+	if !item.GetCreatedAt().AsTime().Equal(createdAt) {
+		t.Errorf("expected item creation time %s, got %v", createdAt, item.GetCreatedAt())
+	}
+	// The zero time of an unset field is kept as the zero time, not rejected
+	if !item.GetUpdatedAt().AsTime().Equal(time.Time{}) {
+		t.Errorf("expected zero item update time, got %v", item.GetUpdatedAt())
+	}
+}
+
+func TestDecodeOrderCreatedRejectsMalformedBody(t *testing.T) {
+	if _, err := DecodeOrderCreated(ContentTypeProtobuf, []byte{0xff, 0xff}); err == nil {
+		t.Error("expected an error for a malformed protobuf body")
+	}
+	if _, err := DecodeOrderCreated(ContentTypeJSON, []byte("{")); err == nil {
+		t.Error("expected an error for a malformed json body")
+	}
+}
+
+func TestDecodeEventNegotiatesContentType(t *testing.T) {
+	event := &pb.OrderCancelled{EventId: "event-2"}
+	protobufBody, err := proto.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal protobuf: %v", err)
+	}
+	jsonBody, err := protojson.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+
+	for contentType, body := range map[string][]byte{
+		ContentTypeProtobuf: protobufBody,
+		ContentTypeJSON:     jsonBody,
+	} {
+		got, err := DecodeOrderCancelled(contentType, body)
+		if err != nil {
+			t.Fatalf("decode %s: %v", contentType, err)
+		}
+		if got.GetEventId() != "event-2" {
+			t.Errorf("decode %s: unexpected event %v", contentType, got)
+		}
+	}
+
+	// Only OrderCreated has legacy messages without a content type
+	if _, err := DecodeOrderCancelled("", jsonBody); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("expected unsupported content type, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package requestmeta
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Headers and gRPC metadata keys the request metadata is read from.
+const (
+	HeaderActorID   = "X-Actor-Id"
+	HeaderActorType = "X-Actor-Type"
+	HeaderRequestID = "X-Request-Id"
+)
+
+// Actor types.
+const (
+	ActorTypeAnonymous = "anonymous"
+	ActorTypeSystem    = "system"
+)
+
+// ClaimedActorTypePrefix prefixes the actor type of actors read from request headers.
+// Requests are not authenticated, so a header actor is only what the client claims to be,
+// e.g. "claimed:user". The prefix also keeps clients from passing for the system actor.
+const ClaimedActorTypePrefix = "claimed:"
+
+// Metadata identifies who made a request.
+type Metadata struct {
+	ActorID   string
+	ActorType string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the request metadata.
+func NewContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, md)
+}
+
+// FromContext returns the request metadata of ctx.
+// Requests without an actor are made by an anonymous actor.
+func FromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(contextKey{}).(Metadata)
+	if md.ActorType == "" {
+		md.ActorType = ActorTypeAnonymous
+	}
+
+	return md
+}
+
+// NewMiddleware stores the claimed actor of the headers and the request ID set by middleware.RequestID
+// in the request context. The grpc-gateway passes the request context on to the gRPC servers.
+func NewMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actorID, actorType := claimedActor(r.Header.Get(HeaderActorID), r.Header.Get(HeaderActorType))
+		ctx := NewContext(r.Context(), Metadata{
+			ActorID:   actorID,
+			ActorType: actorType,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor stores the claimed actor and request ID of incoming gRPC metadata in the context.
+// Requests already carrying request metadata, such as the ones of the HTTP gateway, are left as is.
+func UnaryServerInterceptor(
+	ctx context.Context,
+	req any,
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if _, ok := ctx.Value(contextKey{}).(Metadata); ok {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	actorID, actorType := claimedActor(first(md.Get(HeaderActorID)), first(md.Get(HeaderActorType)))
+	ctx = NewContext(ctx, Metadata{
+		ActorID:   actorID,
+		ActorType: actorType,
+		RequestID: first(md.Get(HeaderRequestID)),
+	})
+
+	return handler(ctx, req)
+}
+
+// claimedActor returns the actor of request headers marked as claimed.
+// Requests without actor headers are left to the anonymous actor.
+func claimedActor(actorID, actorType string) (string, string) {
+	if actorID == "" && actorType == "" {
+		return "", ""
+	}
+	if actorType == "" {
+		actorType = ActorTypeAnonymous
+	}
+
+	return actorID, ClaimedActorTypePrefix + actorType
+}
+
+// first returns the first metadata value or an empty string.
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
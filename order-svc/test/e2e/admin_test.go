@@ -0,0 +1,41 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAdministrationIsServedOnTheAdminPortOnly(t *testing.T) {
+	e := newEnv(t, 5)
+
+	// A malformed ID is rejected by the gateway, the request does not reach the outbox
+	paths := []string{"/metrics", "/api/order-service/v1/admin/outbox/dead-letters/not-a-number"}
+	for _, path := range paths {
+		resp, err := http.Get(e.server.URL + path)
+		if err != nil {
+			t.Fatalf("get %s from public server: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected %s not found on the public server, got %d", path, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(e.adminServer.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("get metrics: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected metrics on the admin server, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(e.adminServer.URL + paths[1])
+	if err != nil {
+		t.Fatalf("get dead letter: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected the admin route served by the admin server, got %d", resp.StatusCode)
+	}
+}
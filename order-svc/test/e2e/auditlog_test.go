@@ -0,0 +1,152 @@
+package e2e
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/corray333/backend-labs/order/pkg/api/v1"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// orderServiceClient serves the order service of the env over gRPC and returns a client of it.
+func (e *env) orderServiceClient(t *testing.T) pb.OrderServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterOrderServiceServer(server, e.orderServer)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewOrderServiceClient(conn)
+}
+
+// withMessageIDs returns ctx sending the message IDs as metadata, the way the audit consumer does.
+func withMessageIDs(ctx context.Context, messageIDs ...string) context.Context {
+	for _, id := range messageIDs {
+		ctx = metadata.AppendToOutgoingContext(ctx, events.MetadataMessageID, id)
+	}
+
+	return ctx
+}
+
+// auditLogProto returns an audit log of an order item built from the message.
+func auditLogProto(t *testing.T, messageID string, orderItemID int64) *pb.AuditLogOrder {
+	t.Helper()
+
+	change, err := events.Change("order_status", nil, "created")
+	if err != nil {
+		t.Fatalf("build change: %v", err)
+	}
+	createdAt := timestamppb.New(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	return &pb.AuditLogOrder{
+		OrderId:       1,
+		OrderItemId:   orderItemID,
+		CustomerId:    10,
+		OrderStatus:   "created",
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+		EventType:     events.TypeOrderCreated,
+		ActorId:       "user-1",
+		ActorType:     "user",
+		SourceService: "order-svc",
+		RequestId:     "req-1",
+		Changes:       []*pb.FieldChange{change},
+		MessageId:     messageID,
+	}
+}
+
+func TestSaveAuditLogDropsRedeliveredMessages(t *testing.T) {
+	e := newEnv(t, 5)
+	client := e.orderServiceClient(t)
+
+	// A single message identified only by the call metadata
+	req := &pb.SaveAuditLogRequest{AuditLogs: []*pb.AuditLogOrder{auditLogProto(t, "", 100)}}
+	resp, err := client.SaveAuditLog(withMessageIDs(context.Background(), "m1"), req)
+	if err != nil {
+		t.Fatalf("save audit log: %v", err)
+	}
+	if len(resp.GetAuditLogs()) != 1 || resp.GetAuditLogs()[0].GetId() == 0 || len(resp.GetDuplicateMessageIds()) != 0 {
+		t.Fatalf("expected the audit log saved, got %v", resp)
+	}
+
+	// The redelivery of the message, e.g. a retry after a lost response
+	resp, err = client.SaveAuditLog(withMessageIDs(context.Background(), "m1"), req)
+	if err != nil {
+		t.Fatalf("save redelivered audit log: %v", err)
+	}
+	if len(resp.GetAuditLogs()) != 0 || len(resp.GetDuplicateMessageIds()) != 1 || resp.GetDuplicateMessageIds()[0] != "m1" {
+		t.Fatalf("expected the redelivery reported as duplicate, got %v", resp)
+	}
+
+	// A batch mixing a redelivered and a new message
+	batch := &pb.SaveAuditLogRequest{AuditLogs: []*pb.AuditLogOrder{
+		auditLogProto(t, "m1", 100),
+		auditLogProto(t, "m2", 101),
+		auditLogProto(t, "m2", 102),
+	}}
+	resp, err = client.SaveAuditLog(withMessageIDs(context.Background(), "m1", "m2"), batch)
+	if err != nil {
+		t.Fatalf("save audit log batch: %v", err)
+	}
+	if len(resp.GetAuditLogs()) != 2 || len(resp.GetDuplicateMessageIds()) != 1 || resp.GetDuplicateMessageIds()[0] != "m1" {
+		t.Fatalf("expected only the new message saved, got %v", resp)
+	}
+
+	saved := e.auditLogs.AuditLogs()
+	if len(saved) != 3 {
+		t.Fatalf("expected 3 audit logs saved once each, got %+v", saved)
+	}
+	first := saved[0]
+	if first.MessageID != "m1" || first.EventType != events.TypeOrderCreated || first.ActorID != "user-1" ||
+		first.ActorType != "user" || first.SourceService != "order-svc" || first.RequestID != "req-1" {
+		t.Errorf("unexpected audit log context %+v", first)
+	}
+	if change, ok := first.Changes["order_status"]; !ok || change.Before != nil || change.After != "created" {
+		t.Errorf("unexpected audit log changes %+v", first.Changes)
+	}
+	if after := resp.GetAuditLogs()[0].GetChanges()[0].GetAfter(); after.GetStringValue() != "created" {
+		t.Errorf("expected changes returned, got %v", resp.GetAuditLogs()[0].GetChanges())
+	}
+}
+
+func TestSaveAuditLogRequiresMessageID(t *testing.T) {
+	e := newEnv(t, 5)
+	client := e.orderServiceClient(t)
+
+	req := &pb.SaveAuditLogRequest{AuditLogs: []*pb.AuditLogOrder{auditLogProto(t, "", 100)}}
+
+	// Without a message ID the call could not be retried safely
+	_, err := client.SaveAuditLog(context.Background(), req)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without a message ID, got %v", err)
+	}
+
+	// Several message IDs do not tell which audit log belongs to which message
+	_, err = client.SaveAuditLog(withMessageIDs(context.Background(), "m1", "m2"), req)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an ambiguous message ID, got %v", err)
+	}
+
+	if saved := e.auditLogs.AuditLogs(); len(saved) != 0 {
+		t.Fatalf("expected nothing saved, got %+v", saved)
+	}
+}
@@ -0,0 +1,165 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/corray333/backend-labs/order/internal/dal/repositories/audit"
+	auditlogmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/auditlog/memory"
+	ordermemory "github.com/corray333/backend-labs/order/internal/dal/repositories/order/memory"
+	orderitemmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/orderitem/memory"
+	outboxmemory "github.com/corray333/backend-labs/order/internal/dal/repositories/outbox/memory"
+	"github.com/corray333/backend-labs/order/internal/dal/uow"
+	"github.com/corray333/backend-labs/order/internal/service/services/auditlogsvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/ordersvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/outboxsvc"
+	"github.com/corray333/backend-labs/order/internal/service/services/replaysvc"
+	admintransport "github.com/corray333/backend-labs/order/internal/transport/admin"
+	grpctransport "github.com/corray333/backend-labs/order/internal/transport/grpc"
+	httptransport "github.com/corray333/backend-labs/order/internal/transport/http"
+	"github.com/corray333/backend-labs/order/internal/worker/outbox"
+	"github.com/corray333/backend-labs/order/pkg/broker/memory"
+	"github.com/corray333/backend-labs/order/pkg/circuitbreaker"
+)
+
+const queueName = "oms.order.created"
+
+var errBrokerDown = errors.New("broker is down")
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	os.Exit(m.Run())
+}
+
+// env is the order service wired with in-memory dependencies.
+type env struct {
+	broker *memory.Broker
+	orders *ordermemory.OrderRepository
+	items  *orderitemmemory.OrderItemRepository
+	outbox *outboxmemory.OutboxRepository
+	worker *outbox.Worker
+	server *httptest.Server
+	// adminServer serves metrics and the AdminService on the internal port
+	adminServer *httptest.Server
+	// auditLogs holds audit logs saved through SaveAuditLog
+	auditLogs *auditlogmemory.AuditLogRepository
+	// orderServer is the gRPC OrderService behind the HTTP gateway
+	orderServer *grpctransport.OrderServer
+}
+
+// newEnv wires the service and starts the HTTP gateway and the outbox worker.
+func newEnv(t *testing.T, maxRetries int) *env {
+	t.Helper()
+
+	e := &env{
+		broker: memory.New(),
+		orders: ordermemory.NewOrderRepository(),
+		items:  orderitemmemory.NewOrderItemRepository(),
+		outbox: outboxmemory.NewOutboxRepository(),
+
+		auditLogs: auditlogmemory.NewAuditLogRepository(),
+	}
+
+	breaker := circuitbreaker.New("test", circuitbreaker.Config{FailureThreshold: 100})
+
+	auditRepo := audit.NewAuditBrokerRepository(e.broker, e.outbox, breaker, audit.Config{
+		QueueName:     queueName,
+		MaxRetries:    maxRetries,
+		RetryInterval: time.Millisecond,
+	})
+
+	orderSvc := ordersvc.MustNewOrderService(
+		ordersvc.WithUnitOfWork(func() ordersvc.UnitOfWork {
+			return uow.NewMemoryUnitOfWork(e.orders, e.items)
+		}),
+		ordersvc.WithAuditor(auditRepo),
+	)
+
+	grpcTransport := grpctransport.NewGRPCTransport(
+		orderSvc,
+		auditlogsvc.MustNewAuditLogService(auditlogsvc.WithAuditLogRepository(e.auditLogs)),
+		outboxsvc.MustNewOutboxService(),
+		replaysvc.MustNewReplayService(replaysvc.WithOutboxRepository(e.outbox)),
+	)
+	e.orderServer = grpcTransport.GetOrderServer()
+	transport := httptransport.NewHTTPTransport(e.orderServer)
+	transport.RegisterRoutes()
+
+	e.server = httptest.NewServer(transport.Handler())
+	t.Cleanup(e.server.Close)
+
+	adminTransp := admintransport.NewAdminTransport(grpcTransport.GetAdminServer(), admintransport.Config{})
+	adminTransp.RegisterRoutes()
+
+	e.adminServer = httptest.NewServer(adminTransp.Handler())
+	t.Cleanup(e.adminServer.Close)
+
+	e.worker = outbox.NewWorker(e.outbox, e.broker, breaker, outbox.Config{
+		PollInterval:  10 * time.Millisecond,
+		RetryInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go e.worker.Start(ctx)
+	t.Cleanup(cancel)
+
+	return e
+}
+
+// createOrders posts orders through the HTTP gateway and returns the decoded response.
+func (e *env) createOrders(t *testing.T, body string) map[string]any {
+	t.Helper()
+
+	resp, err := http.Post(e.server.URL+"/api/order-service/v1/orders", "application/json", bytesReader(body))
+	if err != nil {
+		t.Fatalf("post orders: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("post orders: status %d: %s", resp.StatusCode, data)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	return result
+}
+
+// eventually polls cond until it holds or the timeout expires.
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+const twoOrders = `{"orders": [
+	{"customerId": 1, "deliveryAddress": "Moscow", "totalPriceCents": 1000, "totalPriceCurrency": "RUB",
+	 "orderItems": [{"productId": 10, "quantity": 1, "productTitle": "Book", "priceCents": 1000, "priceCurrency": "RUB"}]},
+	{"customerId": 2, "deliveryAddress": "Kazan", "totalPriceCents": 500, "totalPriceCurrency": "RUB",
+	 "orderItems": [{"productId": 11, "quantity": 2, "productTitle": "Pen", "priceCents": 250, "priceCurrency": "RUB"}]}
+]}`
+
+// bytesReader returns a request body reader.
+func bytesReader(body string) io.Reader {
+	return bytes.NewBufferString(body)
+}
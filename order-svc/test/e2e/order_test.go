@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/corray333/backend-labs/order/pkg/broker"
+	"github.com/corray333/backend-labs/order/pkg/events"
+	"github.com/corray333/backend-labs/order/pkg/requestmeta"
+)
+
+func TestCreateOrdersPublishesEvents(t *testing.T) {
+	e := newEnv(t, 5)
+
+	result := e.createOrders(t, twoOrders)
+	if orders, _ := result["orders"].([]any); len(orders) != 2 {
+		t.Fatalf("expected 2 created orders, got %v", result["orders"])
+	}
+
+	published := e.broker.Published(queueName)
+	if len(published) != 2 {
+		t.Fatalf("expected 2 published events, got %d", len(published))
+	}
+
+	for i, msg := range published {
+		event, err := events.DecodeOrderCreated(msg.ContentType, msg.Body)
+		if err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+
+		ord := event.GetOrder()
+		if ord.GetId() != int64(i+1) || len(ord.GetOrderItems()) != 1 {
+			t.Errorf("unexpected order in event %d: %v", i, ord)
+		}
+		if msg.ID != event.GetEventId() || msg.Type != events.TypeOrderCreated {
+			t.Errorf("unexpected message properties: id %q type %q", msg.ID, msg.Type)
+		}
+		if seq, ok := broker.HeaderInt64(msg.Headers[events.HeaderSequence]); !ok || seq != 1 {
+			t.Errorf("expected sequence 1, got %v", msg.Headers[events.HeaderSequence])
+		}
+	}
+
+	for _, msg := range e.outbox.Messages() {
+		if msg.PublishedAt == nil {
+			t.Errorf("outbox message %d is not marked as published", msg.ID)
+		}
+	}
+
+	resp, err := http.Get(e.server.URL + "/api/order-service/v1/orders?customerIds=2")
+	if err != nil {
+		t.Fatalf("list orders: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Orders []struct {
+			ID         string `json:"id"`
+			OrderItems []any  `json:"orderItems"`
+		} `json:"orders"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Orders) != 1 || list.Orders[0].ID != "2" || len(list.Orders[0].OrderItems) != 1 {
+		t.Fatalf("unexpected orders: %+v", list.Orders)
+	}
+}
+
+func TestBrokerOutageFallsBackToOutbox(t *testing.T) {
+	e := newEnv(t, 100)
+	e.broker.SetPublishError(errBrokerDown)
+
+	e.createOrders(t, twoOrders)
+
+	if n := len(e.broker.Published(queueName)); n != 0 {
+		t.Fatalf("expected no published events during the outage, got %d", n)
+	}
+	if n := len(e.outbox.Messages()); n != 2 {
+		t.Fatalf("expected 2 outbox messages, got %d", n)
+	}
+
+	e.broker.SetPublishError(nil)
+	e.worker.Wake()
+
+	eventually(t, func() bool {
+		return len(e.broker.Published(queueName)) == 2
+	}, "outbox worker did not publish pending events")
+
+	eventually(t, func() bool {
+		for _, msg := range e.outbox.Messages() {
+			if msg.PublishedAt == nil {
+				return false
+			}
+		}
+
+		return true
+	}, "outbox messages were not marked as published")
+}
+
+func TestExhaustedRetriesMoveToDeadLetter(t *testing.T) {
+	e := newEnv(t, 1)
+	e.broker.SetPublishError(errBrokerDown)
+
+	e.createOrders(t, twoOrders)
+
+	eventually(t, func() bool {
+		return len(e.outbox.DeadLetters()) == 2
+	}, "messages were not dead-lettered")
+
+	if n := len(e.outbox.Messages()); n != 0 {
+		t.Fatalf("expected empty outbox, got %d messages", n)
+	}
+	for _, dl := range e.outbox.DeadLetters() {
+		if dl.LastError != errBrokerDown.Error() {
+			t.Errorf("unexpected last error %q", dl.LastError)
+		}
+	}
+}
+
+func TestInvalidOrderIsRejected(t *testing.T) {
+	e := newEnv(t, 5)
+
+	body := `{"orders": [{"customerId": 1, "totalPriceCurrency": "USD", "orderItems": []}]}`
+	resp, err := http.Post(e.server.URL+"/api/order-service/v1/orders", "application/json", bytesReader(body))
+	if err != nil {
+		t.Fatalf("post orders: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+	if n := len(e.broker.Published(queueName)); n != 0 {
+		t.Fatalf("expected no published events, got %d", n)
+	}
+}
+
+func TestOrderEventsCarryTheRequestActor(t *testing.T) {
+	e := newEnv(t, 5)
+
+	req, err := http.NewRequest(http.MethodPost, e.server.URL+"/api/order-service/v1/orders", bytesReader(twoOrders))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestmeta.HeaderActorID, "42")
+	req.Header.Set(requestmeta.HeaderActorType, "user")
+	req.Header.Set(requestmeta.HeaderRequestID, "req-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post orders: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("post orders: status %d", resp.StatusCode)
+	}
+
+	published := e.broker.Published(queueName)
+	if len(published) != 2 {
+		t.Fatalf("expected 2 published events, got %d", len(published))
+	}
+
+	event, err := events.DecodeOrderCreated(published[0].ContentType, published[0].Body)
+	if err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+
+	ctx := event.GetContext()
+	if ctx.GetActorId() != "42" || ctx.GetActorType() != requestmeta.ClaimedActorTypePrefix+"user" ||
+		ctx.GetRequestId() != "req-1" || ctx.GetSourceService() != "order-svc" {
+		t.Fatalf("unexpected event context: %v", ctx)
+	}
+
+	changes := make(map[string]any)
+	for _, change := range event.GetChanges() {
+		if change.GetBefore().AsInterface() != nil {
+			t.Errorf("expected no value before creation of %s, got %v", change.GetField(), change.GetBefore())
+		}
+		changes[change.GetField()] = change.GetAfter().AsInterface()
+	}
+	if changes["delivery_address"] != "Moscow" || changes["total_price_cents"] != float64(1000) {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestOrderEventsDoNotTrustAClaimedSystemActor(t *testing.T) {
+	e := newEnv(t, 5)
+
+	req, err := http.NewRequest(http.MethodPost, e.server.URL+"/api/order-service/v1/orders", bytesReader(twoOrders))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestmeta.HeaderActorType, requestmeta.ActorTypeSystem)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post orders: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("post orders: status %d", resp.StatusCode)
+	}
+
+	published := e.broker.Published(queueName)
+	if len(published) == 0 {
+		t.Fatal("expected published events")
+	}
+
+	event, err := events.DecodeOrderCreated(published[0].ContentType, published[0].Body)
+	if err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if actorType := event.GetContext().GetActorType(); actorType != requestmeta.ClaimedActorTypePrefix+requestmeta.ActorTypeSystem {
+		t.Fatalf("expected the system actor recorded as claimed, got %q", actorType)
+	}
+}